@@ -0,0 +1,90 @@
+// cmd/migrate/main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"debt-tracker-backend/configs"
+	"debt-tracker-backend/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  status      list applied and pending migrations
+  up          apply all pending migrations
+  down [n]    revert the last n applied migrations (default 1)
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	config := configs.Load()
+
+	db, err := database.Initialize(config.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	migrator := database.NewMigrator(db)
+
+	switch flag.Arg(0) {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Modified {
+				state += " (modified)"
+			}
+			fmt.Printf("%04d  %-32s %s\n", status.Version, status.Name, state)
+		}
+
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps < 1 {
+				log.Fatal("down expects a positive number of steps")
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
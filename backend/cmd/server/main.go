@@ -0,0 +1,331 @@
+// cmd/server/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	"debt-tracker-backend/configs"
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/database"
+	"debt-tracker-backend/internal/handlers"
+	"debt-tracker-backend/internal/jobs"
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/middleware"
+	"debt-tracker-backend/internal/notify"
+	"debt-tracker-backend/internal/rates"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	// Load configuration
+	config := configs.Load()
+	if err := config.Validate(); err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
+
+	// Initialize database
+	db, err := database.Initialize(config.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	// Run migrations
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	// Load token signing keys
+	keys, err := auth.LoadKeys(auth.KeyOptions{
+		Algorithm:      config.JWTAlgorithm,
+		Secret:         config.JWTSecret,
+		PrivateKeyFile: config.JWTPrivateKeyFile,
+		PublicKeyFiles: config.JWTPublicKeyFiles,
+		Issuer:         config.JWTIssuer,
+		Audience:       config.JWTAudience,
+	})
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+
+	mailer, err := mail.New(mail.Options{
+		Driver:       config.MailDriver,
+		From:         config.MailFrom,
+		SMTPHost:     config.SMTPHost,
+		SMTPPort:     config.SMTPPort,
+		SMTPUsername: config.SMTPUsername,
+		SMTPPassword: config.SMTPPassword,
+		Dir:          config.MailDir,
+	})
+	if err != nil {
+		log.Fatal("Failed to set up mailer:", err)
+	}
+
+	notifier, err := notify.New(notify.Options{
+		Driver:        config.Notifier,
+		Mailer:        mailer,
+		WebhookURL:    config.NotifierWebhookURL,
+		WebhookSecret: config.NotifierWebhookSecret,
+	})
+	if err != nil {
+		log.Fatal("Failed to set up notifier:", err)
+	}
+
+	// Initialize Gin router
+	if config.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.Default()
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// Middleware
+	router.Use(middleware.CORS())
+	router.Use(middleware.Logger())
+
+	// Health check endpoint
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "healthy",
+			"service": "debt-tracker-api",
+		})
+	})
+
+	st := store.NewSQL(db)
+
+	// Background jobs
+	if config.RemovedDebtRetention > 0 {
+		go jobs.Every(context.Background(), "purge-removed-debts", config.PurgeInterval,
+			jobs.PurgeRemovedDebts(st, config.RemovedDebtRetention))
+	}
+	go jobs.Every(context.Background(), "purge-expired-tokens", config.PurgeInterval,
+		jobs.PurgeExpiredTokens(st))
+	go jobs.Every(context.Background(), "purge-login-attempts", config.PurgeInterval,
+		jobs.PurgeLoginAttempts(st, handlers.LoginFailureMemory))
+	if config.ExchangeRatesFile != "" {
+		go jobs.Every(context.Background(), "load-exchange-rates", config.ExchangeRatesInterval,
+			jobs.LoadExchangeRates(st, rates.NewFile(config.ExchangeRatesFile)))
+	}
+	go jobs.Every(context.Background(), "run-schedules", config.ScheduleInterval,
+		handlers.RunSchedules(st))
+	go jobs.Every(context.Background(), "post-interest", config.InterestInterval,
+		handlers.PostInterest(st))
+	go jobs.Every(context.Background(), "queue-reminders", config.ReminderInterval,
+		jobs.QueueReminders(st, config.ReminderDaysBefore, config.ReminderDaysAfter))
+	go jobs.Every(context.Background(), "send-notifications", config.ReminderInterval,
+		jobs.SendNotifications(st, notifier))
+
+	// Initialize handlers
+	authHandler := handlers.NewAuthHandler(st, handlers.AuthConfig{
+		Keys:         keys,
+		AccessTTL:    config.AccessTokenTTL,
+		RefreshTTL:   config.RefreshTokenTTL,
+		ActionTokens: auth.NewActionTokens(config.ActionTokenSecret),
+		Mailer:       mailer,
+		AppURL:       config.AppURL,
+		Lockout: handlers.LockoutConfig{
+			Threshold: config.LoginLockoutThreshold,
+			BaseDelay: config.LoginLockoutBase,
+			MaxDelay:  config.LoginLockoutMax,
+		},
+		TOTPIssuer: config.TOTPIssuer,
+	})
+	contactHandler := handlers.NewContactHandler(st)
+	debtHandler := handlers.NewDebtHandler(st)
+	transactionHandler := handlers.NewTransactionHandler(st)
+	apiKeyHandler := handlers.NewAPIKeyHandler(st)
+	inviteHandler := handlers.NewInviteHandler(st, mailer, config.AppURL)
+	groupHandler := handlers.NewGroupHandler(st)
+	scheduleHandler := handlers.NewScheduleHandler(st)
+	exportHandler := handlers.NewExportHandler(st)
+	importHandler := handlers.NewImportHandler(st)
+
+	authRequired := middleware.AuthRequired(keys, st.Tokens())
+	apiAuthRequired := middleware.AuthOrAPIKeyRequired(keys, st.Tokens(), st.APIKeys())
+
+	// Rate limits
+	globalLimit := middleware.RateLimit(
+		middleware.NewRateLimiter(config.RateLimit.Requests, config.RateLimit.Period), middleware.ByIP)
+	authLimit := middleware.RateLimit(
+		middleware.NewRateLimiter(config.AuthRateLimit.Requests, config.AuthRateLimit.Period), middleware.ByIP)
+	apiLimit := middleware.RateLimit(
+		middleware.NewRateLimiter(config.APIRateLimit.Requests, config.APIRateLimit.Period), middleware.ByUser)
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// API routes
+	api := router.Group("/api/v1")
+	api.Use(globalLimit)
+	{
+		// Auth routes
+		auth := api.Group("/auth")
+		auth.Use(authLimit)
+		{
+			auth.POST("/register", authHandler.Register)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginTwoFactor)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authRequired, authHandler.Logout)
+			auth.POST("/logout-all", authRequired, authHandler.LogoutAll)
+			auth.GET("/me", authRequired, authHandler.GetProfile)
+			auth.PUT("/me", authRequired, authHandler.UpdateProfile)
+			auth.PATCH("/me", authRequired, authHandler.UpdateProfile)
+			auth.DELETE("/me", authRequired, authHandler.DeleteAccount)
+			auth.GET("/me/export", authRequired, authHandler.ExportAccount)
+			auth.POST("/confirm-email", authHandler.ConfirmEmail)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/verify-email/send", authRequired, authHandler.SendVerificationEmail)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/change-password", authRequired, authHandler.ChangePassword)
+			auth.GET("/2fa", authRequired, authHandler.GetTwoFactor)
+			auth.POST("/2fa/setup", authRequired, authHandler.SetupTwoFactor)
+			auth.POST("/2fa/enable", authRequired, authHandler.EnableTwoFactor)
+			auth.POST("/2fa/disable", authRequired, authHandler.DisableTwoFactor)
+			auth.POST("/2fa/recovery-codes", authRequired, authHandler.RegenerateRecoveryCodes)
+			auth.GET("/api-keys", authRequired, apiKeyHandler.GetAPIKeys)
+			auth.POST("/api-keys", authRequired, apiKeyHandler.CreateAPIKey)
+			auth.DELETE("/api-keys/:id", authRequired, apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Protected routes
+		protected := api.Group("/")
+		protected.Use(apiAuthRequired, apiLimit)
+		{
+			// Contact routes
+			contacts := protected.Group("/contacts", middleware.RequireScope("contacts"))
+			{
+				contacts.GET("", contactHandler.GetContacts)
+				contacts.POST("", contactHandler.CreateContact)
+				contacts.GET("/:id", contactHandler.GetContact)
+				contacts.PUT("/:id", contactHandler.UpdateContact)
+				contacts.DELETE("/:id", contactHandler.DeleteContact)
+				contacts.POST("/:id/invite", inviteHandler.InviteContact)
+			}
+
+			// Invites to link contacts to accounts
+			invites := protected.Group("/invites", middleware.RequireScope("contacts"))
+			{
+				invites.GET("", inviteHandler.GetInvites)
+				invites.POST("/:id/accept", inviteHandler.AcceptInvite)
+				invites.POST("/:id/decline", inviteHandler.DeclineInvite)
+				invites.DELETE("/:id", inviteHandler.CancelInvite)
+			}
+
+			// Debt routes
+			debts := protected.Group("/debts", middleware.RequireScope("debts"))
+			{
+				debts.GET("", debtHandler.GetDebts)
+				debts.POST("", debtHandler.CreateDebt)
+				debts.GET("/summary", debtHandler.GetDebtSummary)
+				debts.GET("/settlement-plan", debtHandler.GetSettlementPlan)
+				debts.POST("/settle", middleware.RequireScope("transactions"), debtHandler.Settle)
+				debts.GET("/:id", debtHandler.GetDebt)
+				debts.PUT("/:id", debtHandler.UpdateDebt)
+				debts.PUT("/:id/due-date", debtHandler.SetDueDate)
+				debts.GET("/:id/interest", debtHandler.GetInterest)
+				debts.PUT("/:id/interest", debtHandler.SetInterest)
+				debts.POST("/:id/amortization", debtHandler.PreviewAmortization)
+				debts.DELETE("/:id", debtHandler.DeleteDebt)
+				debts.POST("/:id/restore", debtHandler.RestoreDebt)
+				debts.POST("/:id/confirm", debtHandler.ConfirmDebt)
+				debts.POST("/:id/dispute", debtHandler.DisputeDebt)
+				// Changes to shared debts waiting for approval
+				debts.GET("/:id/changes", debtHandler.GetDebtChanges)
+				debts.POST("/:id/changes/:change_id/accept", debtHandler.AcceptDebtChange)
+				debts.POST("/:id/changes/:change_id/reject", debtHandler.RejectDebtChange)
+				// Debt-specific transactions
+				debts.GET("/:id/transactions", middleware.RequireScope("transactions"), transactionHandler.GetDebtTransactions)
+			}
+
+			// Groups and their shared expenses, which produce debts
+			groups := protected.Group("/groups", middleware.RequireScope("debts"))
+			{
+				groups.GET("", groupHandler.GetGroups)
+				groups.POST("", groupHandler.CreateGroup)
+				groups.GET("/:id", groupHandler.GetGroup)
+				groups.PUT("/:id", groupHandler.UpdateGroup)
+				groups.DELETE("/:id", groupHandler.DeleteGroup)
+				groups.POST("/:id/members", groupHandler.AddGroupMember)
+				groups.DELETE("/:id/members/:contact_id", groupHandler.RemoveGroupMember)
+				groups.GET("/:id/expenses", groupHandler.GetExpenses)
+				groups.POST("/:id/expenses", groupHandler.CreateExpense)
+				groups.GET("/:id/expenses/:expense_id", groupHandler.GetExpense)
+				groups.PUT("/:id/expenses/:expense_id", groupHandler.UpdateExpense)
+				groups.DELETE("/:id/expenses/:expense_id", groupHandler.DeleteExpense)
+			}
+
+			// Recurring debts and transactions
+			schedules := protected.Group("/schedules", middleware.RequireScope("debts"), middleware.RequireScope("transactions"))
+			{
+				schedules.GET("", scheduleHandler.GetSchedules)
+				schedules.POST("", scheduleHandler.CreateSchedule)
+				schedules.GET("/:id", scheduleHandler.GetSchedule)
+				schedules.PUT("/:id", scheduleHandler.UpdateSchedule)
+				schedules.DELETE("/:id", scheduleHandler.DeleteSchedule)
+				schedules.POST("/:id/pause", scheduleHandler.PauseSchedule)
+				schedules.POST("/:id/resume", scheduleHandler.ResumeSchedule)
+				schedules.GET("/:id/occurrences", scheduleHandler.GetOccurrences)
+				schedules.PUT("/:id/occurrences/:date", scheduleHandler.UpdateOccurrence)
+				schedules.POST("/:id/occurrences/:date/skip", scheduleHandler.SkipOccurrence)
+				schedules.DELETE("/:id/occurrences/:date", scheduleHandler.ResetOccurrence)
+			}
+
+			// Transaction routes
+			transactions := protected.Group("/transactions", middleware.RequireScope("transactions"))
+			{
+				transactions.GET("", transactionHandler.GetTransactions)
+				transactions.POST("", transactionHandler.CreateTransaction)
+				transactions.GET("/:id", transactionHandler.GetTransaction)
+				transactions.DELETE("/:id", transactionHandler.DeleteTransaction)
+				transactions.POST("/:id/confirm", transactionHandler.ConfirmTransaction)
+				transactions.POST("/:id/dispute", transactionHandler.DisputeTransaction)
+			}
+
+			// CSV and XLSX downloads of the lists
+			exports := protected.Group("/export")
+			{
+				exports.GET("/debts", middleware.RequireScope("debts"), exportHandler.ExportDebts)
+				exports.GET("/transactions", middleware.RequireScope("transactions"), exportHandler.ExportTransactions)
+				exports.GET("/contacts", middleware.RequireScope("contacts"), exportHandler.ExportContacts)
+			}
+
+			// Imports of CSV, OFX/QFX and CAMT.053 files, which may add
+			// contacts, debts and transactions
+			imports := protected.Group("/imports",
+				middleware.RequireScope("contacts"), middleware.RequireScope("debts"), middleware.RequireScope("transactions"))
+			{
+				imports.GET("/profiles", importHandler.GetImportProfiles)
+				imports.POST("/profiles", importHandler.CreateImportProfile)
+				imports.GET("/profiles/:id", importHandler.GetImportProfile)
+				imports.PUT("/profiles/:id", importHandler.UpdateImportProfile)
+				imports.DELETE("/profiles/:id", importHandler.DeleteImportProfile)
+				imports.POST("/preview", importHandler.PreviewImport)
+				imports.POST("", importHandler.Import)
+			}
+		}
+	}
+
+	// Start server
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	log.Printf("Server starting on port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, router))
+}
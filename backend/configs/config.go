@@ -1,23 +1,205 @@
 package configs
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	Environment string
-	Port        string      
+	Port        string
+
+	// JWTAlgorithm is HS256, which signs with JWTSecret, or RS256 or EdDSA,
+	// which sign with the PEM key in JWTPrivateKeyFile. JWTPublicKeyFiles
+	// keep tokens signed by retired keys valid during a rotation.
+	JWTAlgorithm      string
+	JWTPrivateKeyFile string
+	JWTPublicKeyFiles []string
+	JWTIssuer         string
+	JWTAudience       string
+
+	// ActionTokenSecret signs the links in verification and password reset
+	// emails. It must be set outside development; in development a random
+	// secret is used if it is not, so mailed links stop working when the
+	// server restarts.
+	ActionTokenSecret string
+	// AppURL is the frontend address those links point at.
+	AppURL string
+
+	// MailDriver is "smtp", "file" (writes to MailDir) or "log".
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// AccessTokenTTL is the lifetime of the JWTs sent with each request.
+	// RefreshTokenTTL bounds how long a session lasts without logging in
+	// again.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// RemovedDebtRetention is how long removed debts are kept before the
+	// purge job deletes them for good. Zero keeps them forever.
+	RemovedDebtRetention time.Duration
+	PurgeInterval        time.Duration
+
+	// RateLimit applies to every API request per client address,
+	// AuthRateLimit to the /auth routes per client address and APIRateLimit
+	// to the authenticated routes per user.
+	RateLimit     RateLimit
+	AuthRateLimit RateLimit
+	APIRateLimit  RateLimit
+	// TrustedProxies may set X-Forwarded-For, which then decides the client
+	// address the limits are keyed by.
+	TrustedProxies []string
+
+	// After LoginLockoutThreshold failed logins in a row an account is
+	// locked for LoginLockoutBase, doubling with every further failure up
+	// to LoginLockoutMax. A zero threshold disables the lockout.
+	LoginLockoutThreshold int
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+
+	// TOTPIssuer is the name authenticator apps show for this service.
+	TOTPIssuer string
+
+	// ExchangeRatesFile is a JSON file of exchange rate snapshots, loaded
+	// every ExchangeRatesInterval. Without one, only amounts already in a
+	// user's default currency are converted.
+	ExchangeRatesFile     string
+	ExchangeRatesInterval time.Duration
+
+	// ScheduleInterval is how often recurring schedules are checked for
+	// occurrences that have fallen due.
+	ScheduleInterval time.Duration
+	// InterestInterval is how often interest is posted to the debts that
+	// post it automatically.
+	InterestInterval time.Duration
+
+	// Payment reminders are queued ReminderDaysBefore a payment is due and
+	// once it is ReminderDaysAfter days overdue, checked every
+	// ReminderInterval; zero days turns either off. They are delivered by
+	// the Notifier driver: "email", "webhook" (to NotifierWebhookURL,
+	// signed with NotifierWebhookSecret) or "log".
+	ReminderDaysBefore    int
+	ReminderDaysAfter     int
+	ReminderInterval      time.Duration
+	Notifier              string
+	NotifierWebhookURL    string
+	NotifierWebhookSecret string
 }
 
+// RateLimit allows Requests per Period, read from values such as "60/1m".
+// Zero requests means no limit.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", r.Requests, r.Period)
+}
+
+// defaultJWTSecret is the JWT_SECRET used when none is configured. It is
+// public, so nothing may be signed with it outside development.
+const defaultJWTSecret = "your-secret-key-change-this-in-production"
+
 func Load() *Config {
+	environment := getEnv("ENVIRONMENT", "development")
+
+	actionTokenSecret := getEnv("ACTION_TOKEN_SECRET", "")
+	if actionTokenSecret == "" && environment == "development" {
+		actionTokenSecret = randomSecret()
+		log.Println("ACTION_TOKEN_SECRET is not set, using a random secret until the server restarts")
+	}
+
 	return &Config{
 		DatabaseURL: getEnv("DATABASE_URL", "debt_tracker.db"),
-		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		JWTSecret:   getEnv("JWT_SECRET", defaultJWTSecret),
+		Environment: environment,
 		Port:        getEnv("PORT", "8080"),
+
+		JWTAlgorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+		JWTPrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		JWTPublicKeyFiles: getEnvList("JWT_PUBLIC_KEY_FILES"),
+		JWTIssuer:         getEnv("JWT_ISSUER", "debt-tracker-api"),
+		JWTAudience:       getEnv("JWT_AUDIENCE", "debt-tracker"),
+
+		ActionTokenSecret: actionTokenSecret,
+		AppURL:            getEnv("APP_URL", "http://localhost:3000"),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		RemovedDebtRetention: time.Duration(getEnvInt("REMOVED_DEBT_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		PurgeInterval:        getEnvDuration("PURGE_INTERVAL", time.Hour),
+
+		RateLimit:      getEnvRateLimit("RATE_LIMIT", RateLimit{600, time.Minute}),
+		AuthRateLimit:  getEnvRateLimit("RATE_LIMIT_AUTH", RateLimit{30, time.Minute}),
+		APIRateLimit:   getEnvRateLimit("RATE_LIMIT_API", RateLimit{300, time.Minute}),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutBase:      getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:       getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+
+		TOTPIssuer: getEnv("TOTP_ISSUER", "Debt Tracker"),
+
+		ExchangeRatesFile:     getEnv("EXCHANGE_RATES_FILE", ""),
+		ExchangeRatesInterval: getEnvDuration("EXCHANGE_RATES_INTERVAL", time.Hour),
+
+		ScheduleInterval: getEnvDuration("SCHEDULE_INTERVAL", time.Hour),
+		InterestInterval: getEnvDuration("INTEREST_INTERVAL", time.Hour),
+
+		ReminderDaysBefore:    getEnvInt("REMINDER_DAYS_BEFORE", 3),
+		ReminderDaysAfter:     getEnvInt("REMINDER_DAYS_AFTER", 1),
+		ReminderInterval:      getEnvDuration("REMINDER_INTERVAL", time.Hour),
+		Notifier:              getEnv("NOTIFIER", "log"),
+		NotifierWebhookURL:    getEnv("NOTIFIER_WEBHOOK_URL", ""),
+		NotifierWebhookSecret: getEnv("NOTIFIER_WEBHOOK_SECRET", ""),
+	}
+}
+
+// Validate reports settings the server must not start with. Action tokens
+// must be signed with a secret of their own: anyone who knows the secret
+// can forge email verification links.
+func (c *Config) Validate() error {
+	switch c.ActionTokenSecret {
+	case "":
+		return errors.New("ACTION_TOKEN_SECRET must be set outside development")
+	case defaultJWTSecret, c.JWTSecret:
+		return errors.New("ACTION_TOKEN_SECRET must not be the default secret or JWT_SECRET")
+	}
+	return nil
+}
+
+// randomSecret returns a secret that lasts as long as the process.
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal("Failed to generate a secret:", err)
 	}
+	return hex.EncodeToString(b)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -25,4 +207,66 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvList splits a comma-separated variable, dropping empty items.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvRateLimit reads "<requests>/<period>", e.g. "100/1m". "0" or "off"
+// disables the limit.
+func getEnvRateLimit(key string, defaultValue RateLimit) RateLimit {
+	value := strings.TrimSpace(os.Getenv(key))
+	switch value {
+	case "":
+		return defaultValue
+	case "0", "off":
+		return RateLimit{}
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if !ok || err != nil || n < 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return RateLimit{Requests: n, Period: d}
+}
@@ -0,0 +1,71 @@
+package configs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"own secret", Config{JWTSecret: "jwt", ActionTokenSecret: "action"}, true},
+		{"missing secret", Config{JWTSecret: "jwt"}, false},
+		{"JWT secret", Config{JWTSecret: "jwt", ActionTokenSecret: "jwt"}, false},
+		{"default secret", Config{JWTSecret: "jwt", ActionTokenSecret: defaultJWTSecret}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.config.Validate(); (err == nil) != tc.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestLoadActionTokenSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("ACTION_TOKEN_SECRET", "")
+
+	t.Setenv("ENVIRONMENT", "production")
+	if err := Load().Validate(); err == nil {
+		t.Error("production starts without ACTION_TOKEN_SECRET")
+	}
+
+	t.Setenv("ENVIRONMENT", "development")
+	first, second := Load(), Load()
+	if err := first.Validate(); err != nil {
+		t.Errorf("development does not start without ACTION_TOKEN_SECRET: %v", err)
+	}
+	if first.ActionTokenSecret == second.ActionTokenSecret {
+		t.Error("development uses a fixed ACTION_TOKEN_SECRET")
+	}
+}
+
+func TestGetEnvRateLimit(t *testing.T) {
+	defaultLimit := RateLimit{60, time.Minute}
+	cases := []struct {
+		value string
+		want  RateLimit
+	}{
+		{"", defaultLimit},
+		{"100/1m", RateLimit{100, time.Minute}},
+		{" 5 / 10s ", RateLimit{5, 10 * time.Second}},
+		{"off", RateLimit{}},
+		{"0", RateLimit{}},
+		{"100", defaultLimit},
+		{"-1/1m", defaultLimit},
+		{"100/soon", defaultLimit},
+		{"100/0s", defaultLimit},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT", tc.value)
+			if got := getEnvRateLimit("RATE_LIMIT", defaultLimit); got != tc.want {
+				t.Errorf("getEnvRateLimit(%q) = %s, want %s", tc.value, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// internal/auth/apikeys_test.go
+package auth
+
+import "testing"
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIKey(key) || len(key) != len(APIKeyPrefix)+40 {
+		t.Errorf("key %q is not %s and 40 characters", key, APIKeyPrefix)
+	}
+	if prefix != key[:len(APIKeyPrefix)+8] {
+		t.Errorf("prefix %q does not start the key %q", prefix, key)
+	}
+	if other, _, _ := NewAPIKey(); other == key {
+		t.Error("two keys are the same")
+	}
+	if IsAPIKey("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Error("a JWT is taken for an API key")
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	cases := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{ScopeDebtsRead}, ScopeDebtsRead, true},
+		{[]string{ScopeDebtsWrite}, ScopeDebtsRead, true},
+		{[]string{ScopeDebtsRead}, ScopeDebtsWrite, false},
+		{[]string{ScopeContactsWrite}, ScopeDebtsRead, false},
+		{nil, ScopeTransactionsRead, false},
+	}
+	for _, tc := range cases {
+		if got := HasScope(tc.granted, tc.required); got != tc.want {
+			t.Errorf("HasScope(%v, %s) = %v, want %v", tc.granted, tc.required, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// internal/auth/keys.go
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeyOptions configures how access tokens are signed and verified.
+type KeyOptions struct {
+	// Algorithm is HS256, RS256 or EdDSA. Tokens signed with any other
+	// algorithm are rejected.
+	Algorithm string
+	// Secret is the HMAC key used with HS256.
+	Secret string
+	// PrivateKeyFile is the PEM file of the key that signs new tokens with
+	// RS256 or EdDSA.
+	PrivateKeyFile string
+	// PublicKeyFiles are PEM files of retired keys whose tokens are still
+	// accepted while keys are being rotated.
+	PublicKeyFiles []string
+	Issuer         string
+	Audience       string
+}
+
+// Keys signs and verifies the access tokens of the API.
+type Keys struct {
+	method   jwt.SigningMethod
+	signing  key
+	verify   map[string]key
+	issuer   string
+	audience string
+}
+
+type key struct {
+	id      string
+	private interface{}
+	public  interface{}
+}
+
+// LoadKeys reads the keys described by opts.
+func LoadKeys(opts KeyOptions) (*Keys, error) {
+	keys := &Keys{
+		verify:   make(map[string]key),
+		issuer:   opts.Issuer,
+		audience: opts.Audience,
+	}
+
+	switch opts.Algorithm {
+	case "HS256":
+		if opts.Secret == "" {
+			return nil, errors.New("HS256 requires a secret")
+		}
+		keys.method = jwt.SigningMethodHS256
+		keys.signing = key{private: []byte(opts.Secret), public: []byte(opts.Secret)}
+		keys.verify[""] = keys.signing
+		return keys, nil
+	case "RS256":
+		keys.method = jwt.SigningMethodRS256
+	case "EdDSA":
+		keys.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", opts.Algorithm)
+	}
+
+	if opts.PrivateKeyFile == "" {
+		return nil, fmt.Errorf("%s requires a private key file", opts.Algorithm)
+	}
+	signing, err := loadKey(opts.Algorithm, opts.PrivateKeyFile, true)
+	if err != nil {
+		return nil, err
+	}
+	keys.signing = signing
+	keys.verify[signing.id] = signing
+
+	for _, path := range opts.PublicKeyFiles {
+		k, err := loadKey(opts.Algorithm, path, false)
+		if err != nil {
+			return nil, err
+		}
+		keys.verify[k.id] = k
+	}
+	return keys, nil
+}
+
+// loadKey reads a PEM key for alg. Public key files may also hold a
+// private key, in which case only its public half is used.
+func loadKey(alg, path string, private bool) (key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return key{}, err
+	}
+
+	var k key
+	switch alg {
+	case "RS256":
+		if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			k.private, k.public = privateKey, &privateKey.PublicKey
+		} else if !private {
+			k.public, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		}
+	case "EdDSA":
+		if privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			k.private, k.public = privateKey, privateKey.(crypto.Signer).Public()
+		} else if !private {
+			k.public, err = jwt.ParseEdPublicKeyFromPEM(data)
+		}
+	}
+	if k.public == nil || (private && k.private == nil) {
+		return key{}, fmt.Errorf("%s: not a valid %s key", path, alg)
+	}
+
+	k.id, err = keyID(k.public)
+	if err != nil {
+		return key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// keyID derives a stable key ID from the public key, so rotating keys
+// needs no separate naming scheme.
+func keyID(public interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys that verify tokens issued by the API. It is
+// empty with HS256, whose secret cannot be published.
+func (k *Keys) JWKS() []JWK {
+	jwks := []JWK{}
+	for _, verify := range k.verify {
+		jwk := JWK{KeyID: verify.id, Use: "sig", Alg: k.method.Alg()}
+		switch public := verify.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(bigEndian(public.E))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// bigEndian encodes n in as few bytes as possible.
+func bigEndian(n int) []byte {
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return b
+}
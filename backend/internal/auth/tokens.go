@@ -0,0 +1,88 @@
+// internal/auth/tokens.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// leeway allows for clock skew between this API and the services that
+// verify its tokens.
+const leeway = time.Minute
+
+// Claims are the claims of an access token.
+type Claims struct {
+	UserID    int    `json:"user_id"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// NewClaims returns the claims of an access token for the user's session,
+// valid from issuedAt until expiresAt.
+func NewClaims(userID int, sessionID, jti string, issuedAt, expiresAt time.Time) Claims {
+	return Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+}
+
+// Sign signs the claims with the current signing key, adding the issuer
+// and audience.
+func (k *Keys) Sign(claims Claims) (string, error) {
+	claims.Issuer = k.issuer
+	claims.Audience = jwt.ClaimStrings{k.audience}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signing.id != "" {
+		token.Header["kid"] = k.signing.id
+	}
+	return token.SignedString(k.signing.private)
+}
+
+// Verify parses a token and checks its algorithm, signature, issuer,
+// audience and validity period, and that it names a user and a session.
+func (k *Keys) Verify(tokenString string) (*Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{k.method.Alg()}),
+		jwt.WithoutClaimsValidation(),
+	)
+
+	var claims Claims
+	_, err := parser.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		verify, ok := k.verify[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return verify.public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case !claims.VerifyExpiresAt(now.Add(-leeway), true):
+		return nil, errors.New("token is expired")
+	case !claims.VerifyNotBefore(now.Add(leeway), true):
+		return nil, errors.New("token is not valid yet")
+	case !claims.VerifyIssuer(k.issuer, true):
+		return nil, errors.New("token has the wrong issuer")
+	case !claims.VerifyAudience(k.audience, true):
+		return nil, errors.New("token has the wrong audience")
+	case claims.UserID <= 0 || claims.ID == "" || claims.SessionID == "":
+		return nil, errors.New("token is missing claims")
+	}
+	return &claims, nil
+}
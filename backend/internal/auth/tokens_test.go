@@ -0,0 +1,197 @@
+// internal/auth/tokens_test.go
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writeKey writes the private key to a PEM file and returns its path.
+func writeKey(t *testing.T, name string, private interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadKeys loads the keys, with a default issuer and audience.
+func loadKeys(t *testing.T, opts KeyOptions) *Keys {
+	t.Helper()
+	if opts.Issuer == "" {
+		opts.Issuer = "debt-tracker"
+	}
+	if opts.Audience == "" {
+		opts.Audience = "debt-tracker-api"
+	}
+	keys, err := LoadKeys(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts KeyOptions
+	}{
+		{"HS256", KeyOptions{Algorithm: "HS256", Secret: "secret"}},
+		{"RS256", KeyOptions{Algorithm: "RS256", PrivateKeyFile: writeKey(t, "rsa.pem", rsaKey)}},
+		{"EdDSA", KeyOptions{Algorithm: "EdDSA", PrivateKeyFile: writeKey(t, "ed25519.pem", edKey)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys := loadKeys(t, tc.opts)
+			now := time.Now()
+			token, err := keys.Sign(NewClaims(7, "session", "jti", now, now.Add(time.Minute)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := keys.Verify(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserID != 7 || claims.SessionID != "session" || claims.ID != "jti" {
+				t.Errorf("verified claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	keys := loadKeys(t, KeyOptions{Algorithm: "HS256", Secret: "secret"})
+	other := loadKeys(t, KeyOptions{Algorithm: "HS256", Secret: "other"})
+	now := time.Now()
+	valid := NewClaims(7, "session", "jti", now, now.Add(time.Minute))
+
+	sign := func(k *Keys, claims Claims) string {
+		t.Helper()
+		token, err := k.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	// unsigned signs the claims with the algorithm "none", bypassing Sign.
+	unsigned := func(claims Claims) string {
+		t.Helper()
+		claims.Issuer, claims.Audience = keys.issuer, jwt.ClaimStrings{keys.audience}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * leeway))
+	early := valid
+	early.NotBefore = jwt.NewNumericDate(now.Add(2 * leeway))
+	noSession := valid
+	noSession.SessionID = ""
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"another secret", sign(other, valid)},
+		{"algorithm none", unsigned(valid)},
+		{"expired", sign(keys, expired)},
+		{"not valid yet", sign(keys, early)},
+		{"no session", sign(keys, noSession)},
+		{"no expiry", sign(keys, noExpiry)},
+		{"wrong issuer", sign(loadKeys(t, KeyOptions{Algorithm: "HS256", Secret: "secret", Issuer: "someone-else"}), valid)},
+		{"wrong audience", sign(loadKeys(t, KeyOptions{Algorithm: "HS256", Secret: "secret", Audience: "another-api"}), valid)},
+		{"garbage", "not.a.token"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if claims, err := keys.Verify(tc.token); err == nil {
+				t.Errorf("verified %+v", claims)
+			}
+		})
+	}
+
+	// Clock skew within the leeway is tolerated.
+	skewed := valid
+	skewed.ExpiresAt = jwt.NewNumericDate(now.Add(-leeway / 2))
+	if _, err := keys.Verify(sign(keys, skewed)); err != nil {
+		t.Errorf("token expired within the leeway was rejected: %v", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldFile, newFile := writeKey(t, "old.pem", oldKey), writeKey(t, "new.pem", newKey)
+
+	now := time.Now()
+	token, err := loadKeys(t, KeyOptions{Algorithm: "EdDSA", PrivateKeyFile: oldFile}).
+		Sign(NewClaims(7, "session", "jti", now, now.Add(time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := loadKeys(t, KeyOptions{Algorithm: "EdDSA", PrivateKeyFile: newFile, PublicKeyFiles: []string{oldFile}})
+	if _, err := rotated.Verify(token); err != nil {
+		t.Errorf("token of the retired key was rejected: %v", err)
+	}
+	if jwks := rotated.JWKS(); len(jwks) != 2 {
+		t.Errorf("JWKS has %d keys during the rotation, want 2", len(jwks))
+	}
+
+	if _, err := loadKeys(t, KeyOptions{Algorithm: "EdDSA", PrivateKeyFile: newFile}).Verify(token); err == nil {
+		t.Error("token of a key that is no longer listed was accepted")
+	}
+}
+
+func TestLoadKeysErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts KeyOptions
+	}{
+		{"unknown algorithm", KeyOptions{Algorithm: "HS512", Secret: "secret"}},
+		{"HS256 without a secret", KeyOptions{Algorithm: "HS256"}},
+		{"RS256 without a key", KeyOptions{Algorithm: "RS256"}},
+		{"missing key file", KeyOptions{Algorithm: "EdDSA", PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := LoadKeys(tc.opts); err == nil {
+				t.Error("LoadKeys succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// internal/auth/totp_test.go
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTP(t *testing.T) {
+	// The RFC 6238 SHA-1 vectors, truncated to 6 digits.
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tc := range cases {
+		code, err := GenerateTOTP(rfcSecret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tc.code {
+			t.Errorf("code at %d = %s, want %s", tc.unix, code, tc.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	step := at.Unix() / 30
+
+	cases := []struct {
+		name string
+		at   time.Time
+		ok   bool
+	}{
+		{"same step", at, true},
+		{"one step late", at.Add(30 * time.Second), true},
+		{"one step early", at.Add(-30 * time.Second), true},
+		{"two steps late", at.Add(60 * time.Second), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ValidateTOTP(rfcSecret, "050471", tc.at)
+			if ok != tc.ok {
+				t.Fatalf("ValidateTOTP accepted %v, want %v", ok, tc.ok)
+			}
+			if ok && got != step {
+				t.Errorf("step %d, want %d", got, step)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP(strings.ToLower(rfcSecret), "050471", at); !ok {
+		t.Error("lower case secret was rejected")
+	}
+	for _, code := range []string{"050472", "05047", "0504711", "abcdef"} {
+		if _, ok := ValidateTOTP(rfcSecret, code, at); ok {
+			t.Errorf("code %q was accepted", code)
+		}
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := totpEncoding.DecodeString(secret); err != nil || len(key) != 20 {
+		t.Errorf("secret %q decodes to %d bytes, %v", secret, len(key), err)
+	}
+}
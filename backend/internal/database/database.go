@@ -4,12 +4,31 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"log"
+	"net/url"
+	"strings"
 
+	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
 )
 
-func Initialize(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", databaseURL)
+// Initialize opens the database named by databaseURL. The URL scheme
+// selects the storage backend, see ParseURL.
+func Initialize(databaseURL string) (*DB, error) {
+	dialect, dsn, err := ParseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := "postgres"
+	if dialect == SQLite {
+		driver = "sqlite"
+		dsn = sqliteDSN(dsn)
+	} else if dsn, err = postgresDSN(dsn); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -18,88 +37,56 @@ func Initialize(databaseURL string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return db, nil
+	return &DB{DB: db, Dialect: dialect}, nil
 }
 
-func RunMigrations(databaseURL string) error {
-	db, err := sql.Open("sqlite", databaseURL)
-	if err != nil {
-		return fmt.Errorf("failed to open database for migrations: %w", err)
-	}
-	defer db.Close()
-
-	// Create tables
-	migrations := []string{
-		createUsersTable,
-		createContactsTable,
-		createDebtsTable,
-		createTransactionsTable,
-		createIndexes,
-	}
+// sqliteDSN adds the pragmas the application relies on, unless the URL
+// already sets them: a busy timeout so that writers queue behind a held
+// write lock instead of failing immediately with SQLITE_BUSY, and foreign
+// key enforcement, which SQLite leaves off by default, so that deletes
+// cascade as declared in the schema.
+func sqliteDSN(databaseURL string) string {
+	for _, pragma := range []string{"busy_timeout(5000)", "foreign_keys(1)"} {
+		name := pragma[:strings.Index(pragma, "(")]
+		if strings.Contains(databaseURL, name) {
+			continue
+		}
 
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("failed to execute migration: %w", err)
+		separator := "?"
+		if strings.Contains(databaseURL, "?") {
+			separator = "&"
 		}
+		databaseURL += separator + "_pragma=" + pragma
 	}
-
-	return nil
+	return databaseURL
 }
 
-const createUsersTable = `
-CREATE TABLE IF NOT EXISTS users (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    email TEXT UNIQUE NOT NULL,
-    password_hash TEXT NOT NULL,
-    name TEXT NOT NULL,
-    phone TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);`
+// postgresDSN pins every session to UTC. Timestamps are written and
+// compared as UTC strings, while CURRENT_TIMESTAMP defaults follow the
+// session time zone.
+func postgresDSN(databaseURL string) (string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
 
-const createContactsTable = `
-CREATE TABLE IF NOT EXISTS contacts (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    user_id INTEGER NOT NULL,
-    name TEXT NOT NULL,
-    phone TEXT,
-    email TEXT,
-    is_active BOOLEAN DEFAULT 1,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    UNIQUE(user_id, phone)
-);`
+	query := u.Query()
+	query.Set("timezone", "UTC")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
 
-const createDebtsTable = `
-CREATE TABLE IF NOT EXISTS debts (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    user_id INTEGER NOT NULL,
-    contact_id INTEGER NOT NULL,
-    amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
-    direction TEXT NOT NULL CHECK (direction IN ('owe_to', 'owe_from')),
-    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'settled', 'removed')),
-    description TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
-);`
+// RunMigrations brings the schema up to date by applying every pending
+// migration.
+func RunMigrations(db *DB) error {
+	applied, err := NewMigrator(db).Up()
+	if err != nil {
+		return err
+	}
 
-const createTransactionsTable = `
-CREATE TABLE IF NOT EXISTS transactions (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    debt_id INTEGER NOT NULL,
-    amount DECIMAL(10,2) NOT NULL,
-    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('lent', 'borrowed', 'paid_back', 'received_back')),
-    description TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
-);`
+	if applied > 0 {
+		log.Printf("Applied %d database migration(s)", applied)
+	}
 
-const createIndexes = `
-CREATE INDEX IF NOT EXISTS idx_debts_user_id ON debts(user_id);
-CREATE INDEX IF NOT EXISTS idx_debts_status ON debts(status);
-CREATE INDEX IF NOT EXISTS idx_transactions_debt_id ON transactions(debt_id);
-CREATE INDEX IF NOT EXISTS idx_contacts_user_id ON contacts(user_id);
-`
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,942 @@
+// internal/database/migrations.go
+package database
+
+// migrations is the ordered schema history. Never edit a migration once it
+// has been released; add a new one instead.
+var migrations = []Migration{
+	// The baseline keeps IF NOT EXISTS so databases created before the
+	// migration engine existed adopt it without losing data.
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		SQLite: Script{
+			Up:   createUsersTable + createContactsTable + createDebtsTable + createTransactionsTable + createIndexes,
+			Down: dropInitialSchema,
+		},
+		Postgres: Script{
+			Up:   postgresInitialSchema + createIndexes,
+			Down: dropInitialSchema,
+		},
+	},
+	// Amounts move from DECIMAL (a REAL in SQLite) to integer minor units.
+	// Existing values are rounded to the nearest cent, which is exact for
+	// anything that was entered with two decimal places.
+	{
+		Version: 2,
+		Name:    "money_minor_units",
+		SQLite: Script{
+			Up: `
+ALTER TABLE debts ADD COLUMN amount_minor INTEGER NOT NULL DEFAULT 0;
+UPDATE debts SET amount_minor = CAST(ROUND(amount * 100) AS INTEGER);
+ALTER TABLE debts DROP COLUMN amount;
+ALTER TABLE debts RENAME COLUMN amount_minor TO amount;
+ALTER TABLE debts ADD COLUMN currency TEXT NOT NULL DEFAULT 'ZAR';
+
+ALTER TABLE transactions ADD COLUMN amount_minor INTEGER NOT NULL DEFAULT 0;
+UPDATE transactions SET amount_minor = CAST(ROUND(amount * 100) AS INTEGER);
+ALTER TABLE transactions DROP COLUMN amount;
+ALTER TABLE transactions RENAME COLUMN amount_minor TO amount;`,
+			Down: `
+ALTER TABLE transactions ADD COLUMN amount_decimal DECIMAL(10,2) NOT NULL DEFAULT 0.00;
+UPDATE transactions SET amount_decimal = amount / 100.0;
+ALTER TABLE transactions DROP COLUMN amount;
+ALTER TABLE transactions RENAME COLUMN amount_decimal TO amount;
+
+ALTER TABLE debts DROP COLUMN currency;
+ALTER TABLE debts ADD COLUMN amount_decimal DECIMAL(10,2) NOT NULL DEFAULT 0.00;
+UPDATE debts SET amount_decimal = amount / 100.0;
+ALTER TABLE debts DROP COLUMN amount;
+ALTER TABLE debts RENAME COLUMN amount_decimal TO amount;`,
+		},
+		Postgres: Script{
+			Up: `
+ALTER TABLE debts ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE debts ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE debts ALTER COLUMN amount SET DEFAULT 0;
+ALTER TABLE debts ADD COLUMN currency TEXT NOT NULL DEFAULT 'ZAR';
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;`,
+			Down: `
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(10,2) USING amount / 100.0;
+
+ALTER TABLE debts DROP COLUMN currency;
+ALTER TABLE debts ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE debts ALTER COLUMN amount TYPE NUMERIC(10,2) USING amount / 100.0;
+ALTER TABLE debts ALTER COLUMN amount SET DEFAULT 0.00;`,
+		},
+	},
+	// Composite indexes matching the default orderings of the paginated
+	// list endpoints.
+	{
+		Version:  3,
+		Name:     "list_indexes",
+		SQLite:   Script{Up: createListIndexes, Down: dropListIndexes},
+		Postgres: Script{Up: createListIndexes, Down: dropListIndexes},
+	},
+	// removed_at dates soft deletes so removed debts can be purged once the
+	// retention period has passed. Debts removed before this migration are
+	// dated by their last update.
+	{
+		Version: 4,
+		Name:    "debt_removed_at",
+		SQLite: Script{
+			Up: `
+ALTER TABLE debts ADD COLUMN removed_at DATETIME;
+UPDATE debts SET removed_at = updated_at WHERE status = 'removed';`,
+			Down: `
+ALTER TABLE debts DROP COLUMN removed_at;`,
+		},
+		Postgres: Script{
+			Up: `
+ALTER TABLE debts ADD COLUMN removed_at TIMESTAMP;
+UPDATE debts SET removed_at = updated_at WHERE status = 'removed';`,
+			Down: `
+ALTER TABLE debts DROP COLUMN removed_at;`,
+		},
+	},
+	// Refresh tokens are stored as hashes. Each one records the access token
+	// issued with it, so revoking a session can put that access token on the
+	// revocation list as well.
+	{
+		Version: 5,
+		Name:    "refresh_tokens",
+		SQLite: Script{
+			Up: `
+CREATE TABLE refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    family_id TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    access_jti TEXT NOT NULL,
+    access_expires_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+` + createTokenIndexes,
+			Down: dropTokenTables,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    access_jti TEXT NOT NULL,
+    access_expires_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+` + createTokenIndexes,
+			Down: dropTokenTables,
+		},
+	},
+	// Existing accounts start out unverified.
+	{
+		Version: 6,
+		Name:    "email_verification",
+		SQLite: Script{
+			Up:   `ALTER TABLE users ADD COLUMN email_verified_at DATETIME;`,
+			Down: `ALTER TABLE users DROP COLUMN email_verified_at;`,
+		},
+		Postgres: Script{
+			Up:   `ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;`,
+			Down: `ALTER TABLE users DROP COLUMN email_verified_at;`,
+		},
+	},
+	// A new email address is held here until it is verified.
+	{
+		Version:  7,
+		Name:     "user_pending_email",
+		SQLite:   Script{Up: addPendingEmail, Down: dropPendingEmail},
+		Postgres: Script{Up: addPendingEmail, Down: dropPendingEmail},
+	},
+	// Failed logins are counted per email address rather than per user, so
+	// that unknown addresses are throttled the same way as real accounts.
+	{
+		Version: 8,
+		Name:    "login_attempts",
+		SQLite: Script{
+			Up: `
+CREATE TABLE login_attempts (
+    email TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until DATETIME
+);`,
+			Down: `DROP TABLE login_attempts;`,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE login_attempts (
+    email TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);`,
+			Down: `DROP TABLE login_attempts;`,
+		},
+	},
+	// TOTP secrets stay pending until a first code is verified. Recovery
+	// codes are stored hashed.
+	{
+		Version: 9,
+		Name:    "two_factor",
+		SQLite: Script{
+			Up: `
+CREATE TABLE two_factor (
+    user_id INTEGER PRIMARY KEY,
+    secret TEXT NOT NULL,
+    enabled_at DATETIME,
+    last_step INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, code_hash)
+);`,
+			Down: dropTwoFactorTables,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE two_factor (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, code_hash)
+);`,
+			Down: dropTwoFactorTables,
+		},
+	},
+	// API keys are looked up by the hash of the full key; the prefix only
+	// identifies them to their owner.
+	{
+		Version: 10,
+		Name:    "api_keys",
+		SQLite: Script{
+			Up: `
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);` + createAPIKeyIndexes,
+			Down: `DROP TABLE api_keys;`,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);` + createAPIKeyIndexes,
+			Down: `DROP TABLE api_keys;`,
+		},
+	},
+	// A contact can be linked to the account of the person it stands for.
+	// Debts and transactions between linked users are recorded on both
+	// sides, pointing at each other through counterpart_id. Those columns
+	// have no foreign keys, so SQLite can still drop them; code treats a
+	// counterpart that no longer exists as unshared.
+	{
+		Version: 11,
+		Name:    "shared_debts",
+		SQLite: Script{
+			Up: addCounterpartColumns + `
+CREATE TABLE contact_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    inviter_id INTEGER NOT NULL,
+    contact_id INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (inviter_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE debt_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    debt_id INTEGER NOT NULL,
+    proposed_by INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('update', 'delete_transaction')),
+    amount INTEGER,
+    description TEXT,
+    status TEXT,
+    transaction_id INTEGER,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'accepted', 'rejected', 'cancelled')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    resolved_at DATETIME,
+    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
+);` + createSharingIndexes,
+			Down: dropSharing,
+		},
+		Postgres: Script{
+			Up: addCounterpartColumns + `
+CREATE TABLE contact_invites (
+    id BIGSERIAL PRIMARY KEY,
+    inviter_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP
+);
+
+CREATE TABLE debt_changes (
+    id BIGSERIAL PRIMARY KEY,
+    debt_id BIGINT NOT NULL REFERENCES debts(id) ON DELETE CASCADE,
+    proposed_by BIGINT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('update', 'delete_transaction')),
+    amount BIGINT,
+    description TEXT,
+    status TEXT,
+    transaction_id BIGINT,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'accepted', 'rejected', 'cancelled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);` + createSharingIndexes,
+			Down: dropSharing,
+		},
+	},
+	// Shared debts and transactions wait for the other user to confirm
+	// them. created_by records which side entered them; existing rows
+	// count as confirmed.
+	{
+		Version: 12,
+		Name:    "confirmations",
+		SQLite: Script{
+			Up:   addConfirmationColumns,
+			Down: dropConfirmationColumns,
+		},
+		Postgres: Script{
+			Up:   addConfirmationColumns,
+			Down: dropConfirmationColumns,
+		},
+	},
+	// Groups of contacts share expenses. Each expense is split between
+	// members and produces the debts between the user and the other
+	// members, which point back at it through debts.expense_id. A NULL
+	// contact_id in expenses and expense_splits stands for the user.
+	// Deleting an expense clears debts.expense_id, since a debt can outlive
+	// its expense; SQLite cannot drop a column that is part of a foreign
+	// key, so a trigger does it there.
+	{
+		Version: 13,
+		Name:    "groups",
+		SQLite: Script{
+			Up: `
+CREATE TABLE expense_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE group_members (
+    group_id INTEGER NOT NULL,
+    contact_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, contact_id),
+    FOREIGN KEY (group_id) REFERENCES expense_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE expenses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    description TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT 'ZAR',
+    paid_by INTEGER,
+    split_type TEXT NOT NULL CHECK (split_type IN ('equal', 'exact', 'percentage', 'shares')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES expense_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (paid_by) REFERENCES contacts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE expense_splits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    expense_id INTEGER NOT NULL,
+    contact_id INTEGER,
+    amount INTEGER NOT NULL,
+    weight INTEGER,
+    FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+);
+
+ALTER TABLE debts ADD COLUMN expense_id INTEGER;
+
+CREATE TRIGGER trg_expenses_clear_debts AFTER DELETE ON expenses
+BEGIN
+    UPDATE debts SET expense_id = NULL WHERE expense_id = OLD.id;
+END;
+` + createGroupIndexes,
+			Down: `
+DROP TRIGGER trg_expenses_clear_debts;` + dropGroups,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE expense_groups (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE group_members (
+    group_id BIGINT NOT NULL REFERENCES expense_groups(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, contact_id)
+);
+
+CREATE TABLE expenses (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT NOT NULL REFERENCES expense_groups(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT 'ZAR',
+    paid_by BIGINT REFERENCES contacts(id) ON DELETE CASCADE,
+    split_type TEXT NOT NULL CHECK (split_type IN ('equal', 'exact', 'percentage', 'shares')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE expense_splits (
+    id BIGSERIAL PRIMARY KEY,
+    expense_id BIGINT NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    contact_id BIGINT REFERENCES contacts(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    weight BIGINT
+);
+
+ALTER TABLE debts ADD COLUMN expense_id BIGINT REFERENCES expenses(id) ON DELETE SET NULL;
+` + createGroupIndexes,
+			Down: dropGroups,
+		},
+	},
+	// Users pick the currency their summaries are converted into. Exchange
+	// rates are kept as daily snapshots, quoted against a common base
+	// currency, so that every amount can be converted at the rate of the
+	// day it was recorded. Days are ISO dates and rates decimal strings, to
+	// compare and convert exactly in both databases.
+	{
+		Version: 14,
+		Name:    "currencies",
+		SQLite: Script{
+			Up:   createCurrencies,
+			Down: dropCurrencies,
+		},
+		Postgres: Script{
+			Up:   createCurrencies,
+			Down: dropCurrencies,
+		},
+	},
+	// Schedules repeat a debt (contact_id and direction) or a transaction
+	// on a debt (debt_id and transaction_type) by an RRULE. next_date is
+	// the next occurrence still to be created and NULL once the rule has
+	// ended. schedule_occurrences holds the occurrences that were edited
+	// or skipped ahead of time and those already created; its key keeps an
+	// occurrence from being created twice.
+	{
+		Version: 15,
+		Name:    "schedules",
+		SQLite: Script{
+			Up: `
+CREATE TABLE schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('debt', 'transaction')),
+    contact_id INTEGER,
+    direction TEXT CHECK (direction IN ('owe_to', 'owe_from')),
+    debt_id INTEGER,
+    transaction_type TEXT CHECK (transaction_type IN ('lent', 'borrowed', 'paid_back', 'received_back')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT 'ZAR',
+    description TEXT,
+    rrule TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    next_date TEXT,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'finished')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE,
+    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE schedule_occurrences (
+    schedule_id INTEGER NOT NULL,
+    occurrence_date TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('edited', 'skipped', 'created')),
+    amount INTEGER NOT NULL DEFAULT 0,
+    description TEXT,
+    debt_id INTEGER,
+    transaction_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, occurrence_date),
+    FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+);
+` + createScheduleIndexes,
+			Down: dropSchedules,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE schedules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('debt', 'transaction')),
+    contact_id BIGINT REFERENCES contacts(id) ON DELETE CASCADE,
+    direction TEXT CHECK (direction IN ('owe_to', 'owe_from')),
+    debt_id BIGINT REFERENCES debts(id) ON DELETE CASCADE,
+    transaction_type TEXT CHECK (transaction_type IN ('lent', 'borrowed', 'paid_back', 'received_back')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT 'ZAR',
+    description TEXT,
+    rrule TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    next_date TEXT,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'finished')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE schedule_occurrences (
+    schedule_id BIGINT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    occurrence_date TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('edited', 'skipped', 'created')),
+    amount BIGINT NOT NULL DEFAULT 0,
+    description TEXT,
+    debt_id BIGINT,
+    transaction_id BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, occurrence_date)
+);
+` + createScheduleIndexes,
+			Down: dropSchedules,
+		},
+	},
+	// A debt is due on due_date, or in the instalments of debt_instalments,
+	// in which case due_date is the day of the last one. notifications is
+	// the queue of reminders about them; dedupe_key keeps a reminder from
+	// being queued twice.
+	{
+		Version: 16,
+		Name:    "due_dates",
+		SQLite: Script{
+			Up: `
+ALTER TABLE debts ADD COLUMN due_date TEXT;
+
+CREATE TABLE debt_instalments (
+    debt_id INTEGER NOT NULL,
+    due_date TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (debt_id, due_date),
+    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    debt_id INTEGER,
+    kind TEXT NOT NULL,
+    dedupe_key TEXT NOT NULL UNIQUE,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    sent_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
+);
+` + createDueDateIndexes,
+			Down: `
+DROP TABLE notifications;
+DROP TABLE debt_instalments;
+DROP INDEX idx_debts_due_date;
+ALTER TABLE debts DROP COLUMN due_date;`,
+		},
+		Postgres: Script{
+			Up: `
+ALTER TABLE debts ADD COLUMN due_date TEXT;
+
+CREATE TABLE debt_instalments (
+    debt_id BIGINT NOT NULL REFERENCES debts(id) ON DELETE CASCADE,
+    due_date TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (debt_id, due_date)
+);
+
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    debt_id BIGINT REFERENCES debts(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    dedupe_key TEXT NOT NULL UNIQUE,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+` + createDueDateIndexes,
+			Down: `
+DROP TABLE notifications;
+DROP TABLE debt_instalments;
+ALTER TABLE debts DROP COLUMN due_date;`,
+		},
+	},
+	// Interest terms of a debt. interest_rate is the annual rate in percent
+	// as a decimal string, and interest_start the day interest accrues from.
+	// Interest posted to the balance is recorded as transactions marked
+	// interest.
+	{
+		Version: 17,
+		Name:    "interest",
+		SQLite: Script{
+			Up: addInterestColumns + `
+ALTER TABLE debts ADD COLUMN interest_auto_post BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN interest BOOLEAN NOT NULL DEFAULT 0;`,
+			Down: dropInterestColumns,
+		},
+		Postgres: Script{
+			Up: addInterestColumns + `
+ALTER TABLE debts ADD COLUMN interest_auto_post BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE transactions ADD COLUMN interest BOOLEAN NOT NULL DEFAULT FALSE;`,
+			Down: dropInterestColumns,
+		},
+	},
+	// Saved mapping profiles of imports. mapping is the JSON of a
+	// models.ImportMapping: how the columns of a file map to contacts,
+	// debts and transactions.
+	{
+		Version: 18,
+		Name:    "import_profiles",
+		SQLite: Script{
+			Up: `
+CREATE TABLE import_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'ofx', 'qfx', 'camt053')),
+    mapping TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+` + createImportProfileIndexes,
+			Down: `
+DROP TABLE import_profiles;`,
+		},
+		Postgres: Script{
+			Up: `
+CREATE TABLE import_profiles (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'ofx', 'qfx', 'camt053')),
+    mapping TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+` + createImportProfileIndexes,
+			Down: `
+DROP TABLE import_profiles;`,
+		},
+	},
+}
+
+const addCounterpartColumns = `
+ALTER TABLE contacts ADD COLUMN linked_user_id INTEGER;
+ALTER TABLE debts ADD COLUMN counterpart_id INTEGER;
+ALTER TABLE transactions ADD COLUMN counterpart_id INTEGER;
+`
+
+const createSharingIndexes = `
+CREATE UNIQUE INDEX idx_contacts_linked_user ON contacts(user_id, linked_user_id);
+CREATE INDEX idx_contact_invites_email ON contact_invites(email, status);
+CREATE INDEX idx_contact_invites_inviter ON contact_invites(inviter_id, status);
+CREATE INDEX idx_debt_changes_debt ON debt_changes(debt_id);`
+
+const dropSharing = `
+DROP TABLE debt_changes;
+DROP TABLE contact_invites;
+DROP INDEX idx_contacts_linked_user;
+ALTER TABLE transactions DROP COLUMN counterpart_id;
+ALTER TABLE debts DROP COLUMN counterpart_id;
+ALTER TABLE contacts DROP COLUMN linked_user_id;`
+
+const createAPIKeyIndexes = `
+CREATE INDEX idx_api_keys_user ON api_keys(user_id);`
+
+const dropTwoFactorTables = `
+DROP TABLE recovery_codes;
+DROP TABLE two_factor;`
+
+const addPendingEmail = `ALTER TABLE users ADD COLUMN pending_email TEXT;`
+
+const dropPendingEmail = `ALTER TABLE users DROP COLUMN pending_email;`
+
+const createTokenIndexes = `
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_expires ON refresh_tokens(expires_at);
+CREATE INDEX idx_revoked_tokens_expires ON revoked_tokens(expires_at);`
+
+const dropTokenTables = `
+DROP TABLE revoked_tokens;
+DROP TABLE refresh_tokens;`
+
+const dropInitialSchema = `
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS debts;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS users;`
+
+const createUsersTable = `
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    name TEXT NOT NULL,
+    phone TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createContactsTable = `
+CREATE TABLE IF NOT EXISTS contacts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    phone TEXT,
+    email TEXT,
+    is_active BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, phone)
+);`
+
+const createDebtsTable = `
+CREATE TABLE IF NOT EXISTS debts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    contact_id INTEGER NOT NULL,
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    direction TEXT NOT NULL CHECK (direction IN ('owe_to', 'owe_from')),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'settled', 'removed')),
+    description TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+);`
+
+const createTransactionsTable = `
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    debt_id INTEGER NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('lent', 'borrowed', 'paid_back', 'received_back')),
+    description TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
+);`
+
+const createListIndexes = `
+CREATE INDEX idx_debts_user_status_created ON debts(user_id, status, created_at);
+CREATE INDEX idx_transactions_debt_created ON transactions(debt_id, created_at);
+CREATE INDEX idx_contacts_user_name ON contacts(user_id, name);`
+
+const dropListIndexes = `
+DROP INDEX idx_contacts_user_name;
+DROP INDEX idx_transactions_debt_created;
+DROP INDEX idx_debts_user_status_created;`
+
+const createIndexes = `
+CREATE INDEX IF NOT EXISTS idx_debts_user_id ON debts(user_id);
+CREATE INDEX IF NOT EXISTS idx_debts_status ON debts(status);
+CREATE INDEX IF NOT EXISTS idx_transactions_debt_id ON transactions(debt_id);
+CREATE INDEX IF NOT EXISTS idx_contacts_user_id ON contacts(user_id);
+`
+
+const postgresInitialSchema = `
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    name TEXT NOT NULL,
+    phone TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS contacts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    phone TEXT,
+    email TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, phone)
+);
+
+CREATE TABLE IF NOT EXISTS debts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    amount NUMERIC(10,2) NOT NULL DEFAULT 0.00,
+    direction TEXT NOT NULL CHECK (direction IN ('owe_to', 'owe_from')),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'settled', 'removed')),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    debt_id BIGINT NOT NULL REFERENCES debts(id) ON DELETE CASCADE,
+    amount NUMERIC(10,2) NOT NULL,
+    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('lent', 'borrowed', 'paid_back', 'received_back')),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+const addConfirmationColumns = `
+ALTER TABLE debts ADD COLUMN confirmation TEXT NOT NULL DEFAULT 'confirmed';
+ALTER TABLE debts ADD COLUMN confirmation_comment TEXT;
+ALTER TABLE debts ADD COLUMN created_by INTEGER;
+ALTER TABLE transactions ADD COLUMN confirmation TEXT NOT NULL DEFAULT 'confirmed';
+ALTER TABLE transactions ADD COLUMN confirmation_comment TEXT;
+ALTER TABLE transactions ADD COLUMN created_by INTEGER;`
+
+const dropConfirmationColumns = `
+ALTER TABLE transactions DROP COLUMN created_by;
+ALTER TABLE transactions DROP COLUMN confirmation_comment;
+ALTER TABLE transactions DROP COLUMN confirmation;
+ALTER TABLE debts DROP COLUMN created_by;
+ALTER TABLE debts DROP COLUMN confirmation_comment;
+ALTER TABLE debts DROP COLUMN confirmation;`
+
+const createGroupIndexes = `
+CREATE INDEX idx_expense_groups_user_id ON expense_groups(user_id);
+CREATE INDEX idx_expenses_group_id ON expenses(group_id);
+CREATE INDEX idx_expense_splits_expense_id ON expense_splits(expense_id);
+CREATE INDEX idx_debts_expense_id ON debts(expense_id);`
+
+const dropGroups = `
+DROP INDEX idx_debts_expense_id;
+ALTER TABLE debts DROP COLUMN expense_id;
+DROP TABLE expense_splits;
+DROP TABLE expenses;
+DROP TABLE group_members;
+DROP TABLE expense_groups;`
+
+const createCurrencies = `
+ALTER TABLE users ADD COLUMN default_currency TEXT NOT NULL DEFAULT 'ZAR';
+
+CREATE TABLE exchange_rates (
+    currency TEXT NOT NULL,
+    rate_date TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    PRIMARY KEY (currency, rate_date)
+);`
+
+const dropCurrencies = `
+DROP TABLE exchange_rates;
+ALTER TABLE users DROP COLUMN default_currency;`
+
+const createScheduleIndexes = `
+CREATE INDEX idx_schedules_user_id ON schedules(user_id);
+CREATE INDEX idx_schedules_due ON schedules(status, next_date);`
+
+const dropSchedules = `
+DROP TABLE schedule_occurrences;
+DROP TABLE schedules;`
+
+const createDueDateIndexes = `
+CREATE INDEX idx_debts_due_date ON debts(due_date);
+CREATE INDEX idx_notifications_status ON notifications(status, id);`
+
+const addInterestColumns = `
+ALTER TABLE debts ADD COLUMN interest_rate TEXT;
+ALTER TABLE debts ADD COLUMN interest_method TEXT CHECK (interest_method IN ('simple', 'compound'));
+ALTER TABLE debts ADD COLUMN interest_compounding TEXT CHECK (interest_compounding IN ('daily', 'monthly', 'quarterly', 'annually'));
+ALTER TABLE debts ADD COLUMN interest_day_count TEXT CHECK (interest_day_count IN ('actual/365', 'actual/360', '30/360', 'actual/actual'));
+ALTER TABLE debts ADD COLUMN interest_start TEXT;`
+
+const dropInterestColumns = `
+ALTER TABLE transactions DROP COLUMN interest;
+ALTER TABLE debts DROP COLUMN interest_auto_post;
+ALTER TABLE debts DROP COLUMN interest_start;
+ALTER TABLE debts DROP COLUMN interest_day_count;
+ALTER TABLE debts DROP COLUMN interest_compounding;
+ALTER TABLE debts DROP COLUMN interest_method;
+ALTER TABLE debts DROP COLUMN interest_rate;`
+
+const createImportProfileIndexes = `
+CREATE UNIQUE INDEX idx_import_profiles_name ON import_profiles(user_id, name);`
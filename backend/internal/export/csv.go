@@ -0,0 +1,103 @@
+// internal/export/csv.go
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// CSVOptions sets the number format of a CSV export, whether amounts group
+// their thousands, and the delimiter between fields.
+type CSVOptions struct {
+	Numbers   NumberFormat
+	Grouping  bool
+	Delimiter rune
+}
+
+// CSV writes an export as CSV (RFC 4180) with a header row. Times are
+// written in RFC 3339 in UTC. Text that a spreadsheet would read as a
+// formula is prefixed with a single quote.
+type CSV struct {
+	w       *csv.Writer
+	columns []Column
+	opts    CSVOptions
+}
+
+// NewCSV writes the header row and returns the writer of the rows.
+func NewCSV(w io.Writer, columns []Column, opts CSVOptions) (*CSV, error) {
+	if opts.Numbers.Decimal == "" {
+		opts.Numbers.Decimal = "."
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.Delimiter
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &CSV{w: writer, columns: columns, opts: opts}, nil
+}
+
+func (c *CSV) Write(row []interface{}) error {
+	if len(row) != len(c.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(c.columns))
+	}
+
+	record := make([]string, len(row))
+	for i, v := range row {
+		field, err := c.field(c.columns[i], v)
+		if err != nil {
+			return err
+		}
+		record[i] = field
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// Flushing every row keeps the buffer small and sends rows as they
+	// are written.
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSV) field(column Column, v interface{}) (string, error) {
+	v, ok := value(v)
+	if !ok {
+		return "", nil
+	}
+
+	switch v := v.(type) {
+	case string:
+		if column.Kind == Text && strings.ContainsAny(v[:min(len(v), 1)], "=+-@\t\r") {
+			return "'" + v, nil
+		}
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case models.Money:
+		return c.opts.Numbers.FormatAmount(v, c.opts.Grouping), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("column %s: unsupported value %T", column.Name, v)
+	}
+}
+
+func (c *CSV) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,58 @@
+// internal/export/csv_test.go
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestCSV(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Kind: Integer},
+		{Name: "description", Kind: Text},
+		{Name: "amount", Kind: Amount},
+		{Name: "shared", Kind: Bool},
+		{Name: "due_date", Kind: Date},
+		{Name: "created_at", Kind: Timestamp},
+	}
+	var out strings.Builder
+	w, err := NewCSV(&out, columns, CSVOptions{Numbers: NumberFormat{Decimal: ",", Group: "."}, Grouping: true, Delimiter: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	description, dueDate := "=SUM(A1:A9)", "2025-07-01"
+	var noDescription *string
+	created := time.Date(2025, 7, 1, 12, 30, 0, 0, time.FixedZone("SAST", 2*60*60))
+	rows := [][]interface{}{
+		{1, &description, models.NewMoney(123456, "ZAR"), true, &dueDate, created},
+		{2, noDescription, models.NewMoney(-5, "ZAR"), false, (*string)(nil), created},
+		{3, "Lunch; drinks", models.NewMoney(0, "ZAR"), false, "-", created},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id;description;amount;shared;due_date;created_at\n" +
+		"1;'=SUM(A1:A9);1.234,56;true;2025-07-01;2025-07-01T10:30:00Z\n" +
+		"2;;-0,05;false;;2025-07-01T10:30:00Z\n" +
+		"3;\"Lunch; drinks\";0,00;false;-;2025-07-01T10:30:00Z\n"
+	if out.String() != want {
+		t.Errorf("CSV is\n%s\nwant\n%s", out.String(), want)
+	}
+
+	if err := w.Write([]interface{}{1}); err == nil {
+		t.Error("a short row was written")
+	}
+	if err := w.Write([]interface{}{1, 2.5, nil, nil, nil, nil}); err == nil {
+		t.Error("a float was written")
+	}
+}
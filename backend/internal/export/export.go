@@ -0,0 +1,146 @@
+// internal/export/export.go
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// Kind is the type of the values of a column, which decides how they are
+// written.
+type Kind int
+
+const (
+	Text Kind = iota
+	Integer
+	Amount
+	Bool
+	// Timestamp columns hold times and Date columns days such as
+	// "2025-07-01".
+	Timestamp
+	Date
+)
+
+// Column is one column of an export. Its name is the header of the column.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Writer writes the rows of an export one at a time, so an export of any
+// size is never held in memory. Each row has a value for every column, in
+// order: a string, int, bool, models.Money, time.Time, or a pointer to
+// one, where nil leaves the cell empty. Close must be called once all rows
+// are written.
+type Writer interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// NumberFormat sets how amounts are written in a CSV file: with Decimal
+// between the units and the cents, and Group between the thousands if
+// grouping is wanted.
+type NumberFormat struct {
+	Decimal string
+	Group   string
+}
+
+// localeFormats are the separators of the locales that exports support,
+// by language and, where it differs, by language and region.
+var localeFormats = map[string]NumberFormat{
+	"en":    {Decimal: ".", Group: ","},
+	"de":    {Decimal: ",", Group: "."},
+	"es":    {Decimal: ",", Group: "."},
+	"it":    {Decimal: ",", Group: "."},
+	"nl":    {Decimal: ",", Group: "."},
+	"pt":    {Decimal: ",", Group: "."},
+	"da":    {Decimal: ",", Group: "."},
+	"fr":    {Decimal: ",", Group: "\u202f"},
+	"sv":    {Decimal: ",", Group: "\u00a0"},
+	"nb":    {Decimal: ",", Group: "\u00a0"},
+	"fi":    {Decimal: ",", Group: "\u00a0"},
+	"pl":    {Decimal: ",", Group: "\u00a0"},
+	"cs":    {Decimal: ",", Group: "\u00a0"},
+	"ru":    {Decimal: ",", Group: "\u00a0"},
+	"af":    {Decimal: ",", Group: "\u00a0"},
+	"en-za": {Decimal: ",", Group: "\u00a0"},
+	"de-ch": {Decimal: ".", Group: "\u2019"},
+	"fr-ch": {Decimal: ".", Group: "\u2019"},
+	"it-ch": {Decimal: ".", Group: "\u2019"},
+}
+
+// LocaleFormat returns the number format of a locale such as "de" or
+// "de-CH". A locale with an unknown region falls back to its language.
+func LocaleFormat(locale string) (NumberFormat, error) {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if format, ok := localeFormats[tag]; ok {
+		return format, nil
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	if format, ok := localeFormats[language]; ok {
+		return format, nil
+	}
+	return NumberFormat{}, fmt.Errorf("unsupported locale %q", locale)
+}
+
+// FormatAmount writes an amount with the separators of the format. Group
+// is only used if group is true. Like Money.String, which the XLSX export
+// writes, it gives two decimals, the only minor unit Money supports.
+func (f NumberFormat) FormatAmount(amount models.Money, group bool) string {
+	sign := ""
+	text, negative := strings.CutPrefix(amount.String(), "-")
+	if negative {
+		sign = "-"
+	}
+	units, cents, _ := strings.Cut(text, ".")
+
+	if group && f.Group != "" {
+		var grouped strings.Builder
+		for i, digit := range units {
+			if i > 0 && (len(units)-i)%3 == 0 {
+				grouped.WriteString(f.Group)
+			}
+			grouped.WriteRune(digit)
+		}
+		units = grouped.String()
+	}
+	return sign + units + f.Decimal + cents
+}
+
+// value dereferences a pointer value of a row; it reports false for nil.
+func value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case *string:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	case *int:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	case *bool:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	case *models.Money:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	case *time.Time:
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	default:
+		return v, true
+	}
+}
@@ -0,0 +1,57 @@
+// internal/export/export_test.go
+package export
+
+import (
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestFormatAmount(t *testing.T) {
+	english := NumberFormat{Decimal: ".", Group: ","}
+	swiss := NumberFormat{Decimal: ".", Group: "’"}
+	german := NumberFormat{Decimal: ",", Group: "."}
+	cases := []struct {
+		format NumberFormat
+		minor  int64
+		group  bool
+		want   string
+	}{
+		{english, 0, true, "0.00"},
+		{english, 5, true, "0.05"},
+		{english, -5, true, "-0.05"},
+		{english, 123456789, false, "1234567.89"},
+		{english, 123456789, true, "1,234,567.89"},
+		{english, -100000, true, "-1,000.00"},
+		{english, 99999, true, "999.99"},
+		{german, 123456789, true, "1.234.567,89"},
+		{german, 123456789, false, "1234567,89"},
+		{swiss, 1234567, true, "12’345.67"},
+		{NumberFormat{Decimal: ","}, 123456789, true, "1234567,89"},
+	}
+	for _, tc := range cases {
+		if got := tc.format.FormatAmount(models.NewMoney(tc.minor, "ZAR"), tc.group); got != tc.want {
+			t.Errorf("%+v.FormatAmount(%d, %v) = %q, want %q", tc.format, tc.minor, tc.group, got, tc.want)
+		}
+	}
+}
+
+func TestLocaleFormat(t *testing.T) {
+	cases := map[string]NumberFormat{
+		"en":    {Decimal: ".", Group: ","},
+		"de":    {Decimal: ",", Group: "."},
+		"de-CH": {Decimal: ".", Group: "’"},
+		"de_ch": {Decimal: ".", Group: "’"},
+		"de-AT": {Decimal: ",", Group: "."},
+	}
+	for locale, want := range cases {
+		if got, err := LocaleFormat(locale); err != nil || got != want {
+			t.Errorf("LocaleFormat(%q) = %+v, %v; want %+v", locale, got, err, want)
+		}
+	}
+	for _, invalid := range []string{"", "xx", "xx-DE"} {
+		if _, err := LocaleFormat(invalid); err == nil {
+			t.Errorf("LocaleFormat(%q) succeeded, want an error", invalid)
+		}
+	}
+}
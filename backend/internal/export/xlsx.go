@@ -0,0 +1,194 @@
+// internal/export/xlsx.go
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// Cell styles of styles.xml, by index into its cellXfs.
+const (
+	styleHeader    = 1
+	styleAmount    = 2
+	styleTimestamp = 3
+	styleDate      = 4
+)
+
+// excelEpoch is day zero of the serial dates of a workbook.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSX writes an export as an Office Open XML workbook with one sheet.
+// The workbook is a zip archive written straight through to w, the sheet
+// last, so rows go out as they are written. Amounts are numbers shown
+// with thousands and two decimals in the reader's own locale, and times
+// and days are dates in UTC.
+type XLSX struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []Column
+	rows    int
+}
+
+// NewXLSX writes the parts of the workbook ahead of the sheet, and the
+// header row, and returns the writer of the rows.
+func NewXLSX(w io.Writer, sheetName string, columns []Column) (*XLSX, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &XLSX{zip: archive, sheet: bufio.NewWriter(f), columns: columns}
+	x.sheet.WriteString(xml.Header)
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	x.rows++
+	x.sheet.WriteString(`<row r="1">`)
+	for i, column := range columns {
+		fmt.Fprintf(x.sheet, `<c r="%s1" s="%d" t="inlineStr"><is><t>%s</t></is></c>`,
+			columnName(i), styleHeader, escape(column.Name))
+	}
+	x.sheet.WriteString(`</row>`)
+	return x, nil
+}
+
+func (x *XLSX) Write(row []interface{}) error {
+	if len(row) != len(x.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(x.columns))
+	}
+
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, v := range row {
+		v, ok := value(v)
+		if !ok {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(x.rows)
+
+		switch v := v.(type) {
+		case string:
+			if x.columns[i].Kind == Date {
+				if day, err := time.Parse(time.DateOnly, v); err == nil {
+					fmt.Fprintf(x.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, serial(day))
+					continue
+				}
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(v))
+		case int:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case bool:
+			fmt.Fprintf(x.sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, map[bool]int{false: 0, true: 1}[v])
+		case models.Money:
+			fmt.Fprintf(x.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleAmount, v.String())
+		case time.Time:
+			fmt.Fprintf(x.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleTimestamp, serial(v))
+		default:
+			return fmt.Errorf("column %s: unsupported value %T", x.columns[i].Name, v)
+		}
+	}
+	x.sheet.WriteString(`</row>`)
+
+	// Flush whole rows so that the buffer stays small.
+	if x.sheet.Buffered() > 32*1024 {
+		return x.sheet.Flush()
+	}
+	return nil
+}
+
+func (x *XLSX) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// columnName is the letter name of a column, counted from zero: A, B, ...,
+// Z, AA, AB and so on.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// serial is the serial date of a time in UTC: days since excelEpoch, with
+// the time of day as a fraction.
+func serial(t time.Time) string {
+	days := t.UTC().Sub(excelEpoch).Seconds() / 86400
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles holds the cell styles: the default, a bold header, amounts in
+// the built-in format 4 (#,##0.00), timestamps and days.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2">` +
+	`<numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/>` +
+	`<numFmt numFmtId="165" formatCode="yyyy-mm-dd"/>` +
+	`</numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
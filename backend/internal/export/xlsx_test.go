@@ -0,0 +1,108 @@
+// internal/export/xlsx_test.go
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// sheetCells reads the cells of the workbook's sheet as reference, style
+// and value.
+func sheetCells(t *testing.T, workbook []byte) map[string][2]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := archive.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Style  string `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(content, &sheet); err != nil {
+		t.Fatal(err)
+	}
+	cells := make(map[string][2]string)
+	for _, row := range sheet.Rows {
+		for _, cell := range row.Cells {
+			cells[cell.Ref] = [2]string{cell.Style, cell.Value + cell.Inline}
+		}
+	}
+	return cells
+}
+
+func TestXLSX(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Kind: Integer},
+		{Name: "description", Kind: Text},
+		{Name: "amount", Kind: Amount},
+		{Name: "due_date", Kind: Date},
+		{Name: "created_at", Kind: Timestamp},
+	}
+	var out bytes.Buffer
+	w, err := NewXLSX(&out, "debts", columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dueDate := "2025-07-01"
+	created := time.Date(2025, 7, 1, 18, 0, 0, 0, time.UTC)
+	if err := w.Write([]interface{}{7, "Fish & chips", models.NewMoney(-123456, "ZAR"), &dueDate, created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]interface{}{8, nil, models.NewMoney(5, "ZAR"), "someday", created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cells := sheetCells(t, out.Bytes())
+	want := map[string][2]string{
+		"A1": {"1", "id"},
+		"E1": {"1", "created_at"},
+		"A2": {"", "7"},
+		"B2": {"", "Fish & chips"},
+		"C2": {"2", "-1234.56"},
+		"D2": {"4", "45839"},
+		"E2": {"3", "45839.75"},
+		"C3": {"2", "0.05"},
+		"D3": {"", "someday"},
+	}
+	for ref, cell := range want {
+		if cells[ref] != cell {
+			t.Errorf("cell %s is %q, want %q", ref, cells[ref], cell)
+		}
+	}
+	if _, ok := cells["B3"]; ok {
+		t.Error("an empty value has a cell")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
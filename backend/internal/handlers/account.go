@@ -0,0 +1,239 @@
+// internal/handlers/account.go
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// SendVerificationEmail mails a new verification link to the user, or a
+// new confirmation link if they are changing their email address.
+func (h *AuthHandler) SendVerificationEmail(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	user, err := h.store.Users().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.PendingEmail != nil {
+		err = h.sendEmailChangeConfirmation(user)
+	} else if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already verified"})
+		return
+	} else {
+		err = h.sendVerificationEmail(user)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.store.WithinTx(func(s store.Store) error {
+		userID, err := h.ActionTokens.Verify(req.Token, auth.PurposeVerifyEmail, fingerprint(s, emailFingerprint))
+		if err == auth.ErrInvalidActionToken {
+			return newRequestError(http.StatusBadRequest, "Invalid or expired token")
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.Users().MarkEmailVerified(userID); err == store.ErrNotFound {
+			return newRequestError(http.StatusBadRequest, "Invalid or expired token")
+		} else if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to verify email address")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// ForgotPassword mails a password reset link. It answers the same way
+// whether or not the address belongs to an account, so it cannot be used
+// to find out who has one.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Users().GetByEmail(req.Email)
+	if err == nil {
+		err = h.sendMail(user, auth.PurposeResetPassword, passwordFingerprint(user), resetPasswordTTL, "reset_token", mail.ResetPassword)
+	}
+	if err != nil && err != store.ErrNotFound {
+		log.Printf("Failed to send password reset email: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for that email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword and
+// logs the user out everywhere.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = h.store.WithinTx(func(s store.Store) error {
+		userID, err := h.ActionTokens.Verify(req.Token, auth.PurposeResetPassword, fingerprint(s, passwordFingerprint))
+		if err == auth.ErrInvalidActionToken {
+			return newRequestError(http.StatusBadRequest, "Invalid or expired token")
+		} else if err != nil {
+			return err
+		}
+
+		return h.setPassword(s, userID, string(hashedPassword))
+	})
+	if err != nil {
+		respondError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// ChangePassword replaces the password of a logged in user who knows the
+// current one. All sessions are ended, and a new one is started for the
+// caller.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Users().GetWithPassword(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = h.store.WithinTx(func(s store.Store) error {
+		return h.setPassword(s, userID, string(hashedPassword))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	user.PasswordHash = ""
+	response, err := h.startSession(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// setPassword saves a new password hash and revokes all of the user's
+// sessions, since any of them may belong to whoever knew the old one.
+func (h *AuthHandler) setPassword(s store.Store, userID int, passwordHash string) error {
+	if err := s.Users().SetPassword(userID, passwordHash); err != nil {
+		return err
+	}
+	_, err := s.Tokens().RevokeUser(userID)
+	return err
+}
+
+// sendVerificationEmail mails the user a link to verify their address.
+func (h *AuthHandler) sendVerificationEmail(user models.User) error {
+	err := h.sendMail(user, auth.PurposeVerifyEmail, emailFingerprint(user), verifyEmailTTL, "verify_token", mail.VerifyEmail)
+	if err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+	return err
+}
+
+// sendMail signs an action token and mails it to the user as a link to
+// the frontend, which passes it back to the API.
+func (h *AuthHandler) sendMail(user models.User, purpose, fingerprint string, ttl time.Duration, param string, message func(to, name, link string) mail.Message) error {
+	token, err := h.ActionTokens.Sign(purpose, user.ID, fingerprint, ttl)
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimRight(h.AppURL, "/") + "/?" + url.Values{param: {token}}.Encode()
+	return h.Mailer.Send(message(user.Email, user.Name, link))
+}
+
+// emailFingerprint changes once the address is verified or replaced.
+func emailFingerprint(user models.User) string {
+	if user.EmailVerifiedAt != nil {
+		return "verified"
+	}
+	return "unverified:" + user.Email
+}
+
+// passwordFingerprint changes whenever the password does.
+func passwordFingerprint(user models.User) string {
+	return user.PasswordHash
+}
+
+// fingerprint looks up a user and applies of. Unknown users get an empty
+// fingerprint, which no token matches.
+func fingerprint(s store.Store, of func(models.User) string) func(int) (string, error) {
+	return func(userID int) (string, error) {
+		user, err := s.Users().GetWithPassword(userID)
+		if err == store.ErrNotFound {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		return of(user), nil
+	}
+}
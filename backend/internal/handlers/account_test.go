@@ -0,0 +1,93 @@
+// internal/handlers/account_test.go
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com")
+		token := ts.outbox.token(t, "alice@example.com", "verify_token")
+
+		ts.expect(ts.do(0, "POST", "/auth/verify-email", models.TokenRequest{Token: token}), http.StatusOK, nil)
+
+		var profile models.User
+		ts.expect(ts.do(alice.User.ID, "GET", "/auth/me", nil), http.StatusOK, &profile)
+		if profile.EmailVerifiedAt == nil {
+			t.Error("email address is not verified")
+		}
+
+		// The link is spent once the address is verified.
+		ts.expect(ts.do(0, "POST", "/auth/verify-email", models.TokenRequest{Token: token}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(alice.User.ID, "POST", "/auth/verify-email/send", nil), http.StatusConflict, nil)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com")
+
+		ts.expect(ts.do(0, "POST", "/auth/forgot-password", models.ForgotPasswordRequest{Email: "alice@example.com"}), http.StatusAccepted, nil)
+		token := ts.outbox.token(t, "alice@example.com", "reset_token")
+
+		reset := models.ResetPasswordRequest{Token: token, Password: "secret2"}
+		ts.expect(ts.do(0, "POST", "/auth/reset-password", reset), http.StatusOK, nil)
+
+		ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "secret1"}), http.StatusUnauthorized, nil)
+		ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "secret2"}), http.StatusOK, nil)
+
+		// The old session is over, and the token cannot be used twice.
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: alice.RefreshToken}), http.StatusUnauthorized, nil)
+		reset.Password = "secret3"
+		ts.expect(ts.do(0, "POST", "/auth/reset-password", reset), http.StatusBadRequest, nil)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com")
+
+		change := models.ChangePasswordRequest{OldPassword: "wrong1", NewPassword: "secret2"}
+		ts.expect(ts.doWithToken(alice.Token, "POST", "/auth/change-password", change), http.StatusForbidden, nil)
+
+		change.OldPassword = "secret1"
+		var session models.LoginResponse
+		ts.expect(ts.doWithToken(alice.Token, "POST", "/auth/change-password", change), http.StatusOK, &session)
+
+		ts.expect(ts.doWithToken(alice.Token, "GET", "/auth/me", nil), http.StatusUnauthorized, nil)
+		ts.expect(ts.doWithToken(session.Token, "GET", "/auth/me", nil), http.StatusOK, nil)
+	})
+}
+
+func TestAccountErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		ts.login("alice@example.com")
+		verifyToken := ts.outbox.token(t, "alice@example.com", "verify_token")
+
+		cases := []struct {
+			name   string
+			path   string
+			body   interface{}
+			status int
+		}{
+			{"verify with a garbage token", "/auth/verify-email", models.TokenRequest{Token: "garbage"}, http.StatusBadRequest},
+			{"reset with a verification token", "/auth/reset-password",
+				models.ResetPasswordRequest{Token: verifyToken, Password: "secret2"}, http.StatusBadRequest},
+			{"reset to a short password", "/auth/reset-password",
+				models.ResetPasswordRequest{Token: verifyToken, Password: "12345"}, http.StatusBadRequest},
+			// Unknown addresses get the same answer as known ones.
+			{"forgot the password of an unknown user", "/auth/forgot-password",
+				models.ForgotPasswordRequest{Email: "carol@example.com"}, http.StatusAccepted},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(0, "POST", tc.path, tc.body), tc.status, nil)
+			})
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// internal/handlers/apikeys_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/models"
+)
+
+// apiKey creates an API key for the user with the given scopes.
+func (ts *testServer) apiKey(userID int, scopes ...string) models.CreateAPIKeyResponse {
+	ts.t.Helper()
+	var created models.CreateAPIKeyResponse
+	ts.expect(ts.do(userID, "POST", "/auth/api-keys", models.CreateAPIKeyRequest{Name: "script", Scopes: scopes}), http.StatusCreated, &created)
+	return created
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.user("alice")
+		bob := ts.contact(alice, "Bob")
+		debt := ts.debt(alice, bob.ID, "100", "owe_to")
+		key := ts.apiKey(alice, auth.ScopeDebtsWrite, auth.ScopeDebtsRead).Key
+
+		var got models.Debt
+		ts.expect(ts.doWithToken(key, "GET", fmt.Sprintf("/debts/%d", debt.ID), nil), http.StatusOK, &got)
+		if got.ID != debt.ID {
+			t.Errorf("got debt %d, want %d", got.ID, debt.ID)
+		}
+		ts.expect(ts.doWithToken(key, "PUT", fmt.Sprintf("/debts/%d", debt.ID), models.UpdateDebtRequest{Description: "Lunch", Status: "active"}), http.StatusOK, nil)
+
+		// Outside its scopes, and on the account routes, the key is refused.
+		ts.expect(ts.doWithToken(key, "GET", "/contacts", nil), http.StatusForbidden, nil)
+		ts.expect(ts.doWithToken(key, "GET", fmt.Sprintf("/debts/%d/transactions", debt.ID), nil), http.StatusForbidden, nil)
+		ts.expect(ts.doWithToken(key, "GET", "/auth/me", nil), http.StatusForbidden, nil)
+		ts.expect(ts.doWithToken(key, "POST", "/auth/api-keys", models.CreateAPIKeyRequest{Name: "more", Scopes: []string{auth.ScopeDebtsRead}}),
+			http.StatusForbidden, nil)
+
+		readOnly := ts.apiKey(alice, auth.ScopeContactsRead).Key
+		ts.expect(ts.doWithToken(readOnly, "GET", "/contacts", nil), http.StatusOK, nil)
+		ts.expect(ts.doWithToken(readOnly, "POST", "/contacts", models.CreateContactRequest{Name: "Carol"}), http.StatusForbidden, nil)
+	})
+}
+
+func TestListAndRevokeAPIKeys(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.user("alice")
+		created := ts.apiKey(alice, auth.ScopeContactsRead, auth.ScopeContactsRead)
+		if len(created.Scopes) != 1 {
+			t.Errorf("scopes %v are not deduplicated", created.Scopes)
+		}
+		ts.expect(ts.doWithToken(created.Key, "GET", "/contacts", nil), http.StatusOK, nil)
+
+		var keys []models.APIKey
+		ts.expect(ts.do(alice, "GET", "/auth/api-keys", nil), http.StatusOK, &keys)
+		if len(keys) != 1 || keys[0].Prefix != created.Prefix || keys[0].LastUsedAt == nil {
+			t.Fatalf("listed keys %+v, want the used key %s", keys, created.Prefix)
+		}
+
+		// Only the owner can revoke a key.
+		mallory := ts.user("mallory")
+		ts.expect(ts.do(mallory, "DELETE", fmt.Sprintf("/auth/api-keys/%d", created.ID), nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/auth/api-keys/%d", created.ID), nil), http.StatusOK, nil)
+		ts.expect(ts.doWithToken(created.Key, "GET", "/contacts", nil), http.StatusUnauthorized, nil)
+	})
+}
+
+func TestAPIKeyErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.user("alice")
+		past := time.Now().Add(-time.Hour)
+
+		cases := []struct {
+			name   string
+			body   interface{}
+			status int
+		}{
+			{"without a name", models.CreateAPIKeyRequest{Scopes: []string{auth.ScopeDebtsRead}}, http.StatusBadRequest},
+			{"without scopes", models.CreateAPIKeyRequest{Name: "script"}, http.StatusBadRequest},
+			{"with an unknown scope", models.CreateAPIKeyRequest{Name: "script", Scopes: []string{"debts:delete"}}, http.StatusBadRequest},
+			{"expired already", models.CreateAPIKeyRequest{Name: "script", Scopes: []string{auth.ScopeDebtsRead}, ExpiresAt: &past},
+				http.StatusBadRequest},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(alice, "POST", "/auth/api-keys", tc.body), tc.status, nil)
+			})
+		}
+
+		ts.expect(ts.doWithToken(auth.APIKeyPrefix+"unknown", "GET", "/contacts", nil), http.StatusUnauthorized, nil)
+	})
+}
@@ -2,26 +2,49 @@
 package handlers
 
 import (
-	"database/sql"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"net/http"
 	"time"
 
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/middleware"
 	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db        *sql.DB
-	jwtSecret string
+	store store.Store
+	AuthConfig
 }
 
-func NewAuthHandler(db *sql.DB, jwtSecret string) *AuthHandler {
+// AuthConfig holds what AuthHandler needs besides the store.
+type AuthConfig struct {
+	Keys       *auth.Keys
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	// ActionTokens signs the links in verification and password reset
+	// emails, which point at AppURL.
+	ActionTokens *auth.ActionTokens
+	Mailer       mail.Mailer
+	AppURL       string
+
+	Lockout LockoutConfig
+	// TOTPIssuer names the service in authenticator apps.
+	TOTPIssuer string
+}
+
+func NewAuthHandler(s store.Store, config AuthConfig) *AuthHandler {
 	return &AuthHandler{
-		db:        db,
-		jwtSecret: jwtSecret,
+		store:      s,
+		AuthConfig: config,
 	}
 }
 
@@ -33,12 +56,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	var existingUser models.User
-	err := h.db.QueryRow("SELECT id FROM users WHERE email = ?", req.Email).Scan(&existingUser.ID)
+	_, err := h.store.Users().GetByEmail(req.Email)
 	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
-	} else if err != sql.ErrNoRows {
+	} else if err != store.ErrNotFound {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
@@ -51,39 +73,29 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Create user
-	result, err := h.db.Exec(
-		"INSERT INTO users (email, password_hash, name, phone) VALUES (?, ?, ?, ?)",
-		req.Email, string(hashedPassword), req.Name, req.Phone,
-	)
-	if err != nil {
+	user := models.User{
+		Email:           req.Email,
+		PasswordHash:    string(hashedPassword),
+		Name:            req.Name,
+		Phone:           &req.Phone,
+		DefaultCurrency: req.DefaultCurrency,
+	}
+	if err := h.store.Users().Create(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	userID, _ := result.LastInsertId()
-
-	// Get created user
-	var user models.User
-	err = h.db.QueryRow(
-		"SELECT id, email, name, phone, created_at, updated_at FROM users WHERE id = ?",
-		userID,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
-		return
-	}
+	// A failure is logged; the user can ask for another email later
+	h.sendVerificationEmail(user)
 
-	// Generate JWT token
-	token, err := h.generateToken(user.ID)
+	// Start a new session
+	response, err := h.startSession(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.LoginResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -93,14 +105,21 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse locked addresses before looking at the password, so that
+	// guessing cannot continue during the lockout
+	if retryAfter, err := h.lockedOut(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	} else if retryAfter > 0 {
+		middleware.RetryAfter(c, retryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return
+	}
+
 	// Get user by email
-	var user models.User
-	err := h.db.QueryRow(
-		"SELECT id, email, password_hash, name, phone, created_at, updated_at FROM users WHERE email = ?",
-		req.Email,
-	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	user, err := h.store.Users().GetByEmail(req.Email)
+	if err == store.ErrNotFound {
+		h.loginFailed(c, req.Email)
 		return
 	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
@@ -109,31 +128,37 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		h.loginFailed(c, req.Email)
+		return
+	}
+
+	// The second factor is checked by LoginTwoFactor. Failures are only
+	// cleared after it, or knowing the password would allow unlimited
+	// guessing of codes.
+	if user.TwoFactorEnabled {
+		h.startChallenge(c, user)
+		return
+	}
+
+	if err := h.store.LoginAttempts().Clear(loginKey(req.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.generateToken(user.ID)
+	// Start a new session
+	response, err := h.startSession(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	var user models.User
-	err := h.db.QueryRow(
-		"SELECT id, email, name, phone, created_at, updated_at FROM users WHERE id = ?",
-		userID,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
+	user, err := h.store.Users().Get(userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -142,11 +167,157 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func (h *AuthHandler) generateToken(userID int) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
+// Refresh exchanges a refresh token for a new access and refresh token
+// pair. Each refresh token can be used once; presenting one that has
+// already been rotated ends the whole session, since either the client or
+// an attacker is holding a stolen copy.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response models.LoginResponse
+	reused := false
+	err := h.store.WithinTx(func(s store.Store) error {
+		token, err := s.Tokens().GetRefresh(hashToken(req.RefreshToken))
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusUnauthorized, "Invalid refresh token")
+		} else if err != nil {
+			return err
+		}
+
+		if token.RevokedAt != nil {
+			// Returning nil commits the revocation; the 401 is sent below
+			reused = true
+			return s.Tokens().RevokeFamily(token.UserID, token.FamilyID)
+		}
+		if !time.Now().Before(token.ExpiresAt) {
+			return newRequestError(http.StatusUnauthorized, "Refresh token expired")
+		}
+
+		// A concurrent refresh may have rotated the token in the meantime
+		if err := s.Tokens().RevokeRefresh(token.ID); err == store.ErrNotFound {
+			return newRequestError(http.StatusUnauthorized, "Invalid refresh token")
+		} else if err != nil {
+			return err
+		}
+
+		user, err := s.Users().Get(token.UserID)
+		if err != nil {
+			return err
+		}
+		response, err = h.issueTokens(s, user, token.FamilyID)
+		return err
 	})
+	if err != nil {
+		respondError(c, err, "Failed to refresh token")
+		return
+	}
+	if reused {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
 
-	return token.SignedString([]byte(h.jwtSecret))
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout ends the session of the access token used for the request.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	if err := h.store.Tokens().RevokeFamily(userID, c.GetString("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll ends every session of the user, including the current one.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	sessions, err := h.store.Tokens().RevokeUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Logged out of all sessions",
+		"sessions": sessions,
+	})
+}
+
+// JWKS publishes the public keys that verify access tokens, so that other
+// services can check them without calling this API.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": h.Keys.JWKS()})
+}
+
+// startSession issues the first token pair of a new session.
+func (h *AuthHandler) startSession(user models.User) (models.LoginResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	return h.issueTokens(h.store, user, familyID)
+}
+
+// issueTokens creates a refresh token for the session and signs an access
+// token bound to it, so that revoking the session revokes both.
+func (h *AuthHandler) issueTokens(s store.Store, user models.User, familyID string) (models.LoginResponse, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	now := time.Now()
+	accessExpiresAt := now.Add(h.AccessTTL)
+	accessToken, err := h.Keys.Sign(auth.NewClaims(user.ID, familyID, jti, now, accessExpiresAt))
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	err = s.Tokens().CreateRefresh(&models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       hashToken(refreshToken),
+		AccessJTI:       jti,
+		AccessExpiresAt: accessExpiresAt,
+		ExpiresAt:       now.Add(h.RefreshTTL),
+	})
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		Token:        accessToken,
+		ExpiresIn:    int(h.AccessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+// randomToken returns n random bytes encoded for use in URLs and headers.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how refresh tokens are stored. They are random, so a plain
+// SHA-256 is enough to make a leaked table useless.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,126 @@
+// internal/handlers/auth_test.go
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		register := models.RegisterRequest{Email: "alice@example.com", Password: "secret1", Name: "Alice"}
+		var registered models.LoginResponse
+		ts.expect(ts.do(0, "POST", "/auth/register", register), http.StatusCreated, &registered)
+		if registered.Token == "" || registered.User.Email != register.Email {
+			t.Fatalf("register returned %+v", registered)
+		}
+
+		var loggedIn models.LoginResponse
+		login := models.LoginRequest{Email: register.Email, Password: register.Password}
+		ts.expect(ts.do(0, "POST", "/auth/login", login), http.StatusOK, &loggedIn)
+		if loggedIn.User.ID != registered.User.ID {
+			t.Errorf("logged in as user %d, want %d", loggedIn.User.ID, registered.User.ID)
+		}
+
+		var profile models.User
+		ts.expect(ts.do(registered.User.ID, "GET", "/auth/me", nil), http.StatusOK, &profile)
+		if profile.Name != "Alice" {
+			t.Errorf("profile name is %q, want Alice", profile.Name)
+		}
+	})
+}
+
+func TestAuthErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		register := models.RegisterRequest{Email: "alice@example.com", Password: "secret1", Name: "Alice"}
+		ts.expect(ts.do(0, "POST", "/auth/register", register), http.StatusCreated, nil)
+
+		cases := []struct {
+			name   string
+			path   string
+			body   interface{}
+			status int
+		}{
+			{"register twice", "/auth/register", register, http.StatusConflict},
+			{"register with a short password", "/auth/register",
+				models.RegisterRequest{Email: "bob@example.com", Password: "12345", Name: "Bob"}, http.StatusBadRequest},
+			{"log in with the wrong password", "/auth/login",
+				models.LoginRequest{Email: register.Email, Password: "wrong"}, http.StatusUnauthorized},
+			{"log in as an unknown user", "/auth/login",
+				models.LoginRequest{Email: "carol@example.com", Password: "secret1"}, http.StatusUnauthorized},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(0, "POST", tc.path, tc.body), tc.status, nil)
+			})
+		}
+	})
+}
+
+// login registers a user and returns the tokens of the first session.
+func (ts *testServer) login(email string) models.LoginResponse {
+	ts.t.Helper()
+	var response models.LoginResponse
+	register := models.RegisterRequest{Email: email, Password: "secret1", Name: "Alice"}
+	ts.expect(ts.do(0, "POST", "/auth/register", register), http.StatusCreated, &response)
+	return response
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		first := ts.login("alice@example.com")
+
+		var second models.LoginResponse
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: first.RefreshToken}), http.StatusOK, &second)
+		if second.RefreshToken == first.RefreshToken || second.Token == first.Token {
+			t.Fatal("refresh did not rotate the tokens")
+		}
+		ts.expect(ts.doWithToken(second.Token, "GET", "/auth/me", nil), http.StatusOK, nil)
+
+		// Replaying the rotated token ends the session for both holders.
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: first.RefreshToken}), http.StatusUnauthorized, nil)
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: second.RefreshToken}), http.StatusUnauthorized, nil)
+		ts.expect(ts.doWithToken(second.Token, "GET", "/auth/me", nil), http.StatusUnauthorized, nil)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		session := ts.login("alice@example.com")
+		var other models.LoginResponse
+		login := models.LoginRequest{Email: "alice@example.com", Password: "secret1"}
+		ts.expect(ts.do(0, "POST", "/auth/login", login), http.StatusOK, &other)
+
+		ts.expect(ts.doWithToken(session.Token, "POST", "/auth/logout", nil), http.StatusOK, nil)
+		ts.expect(ts.doWithToken(session.Token, "GET", "/auth/me", nil), http.StatusUnauthorized, nil)
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: session.RefreshToken}), http.StatusUnauthorized, nil)
+		ts.expect(ts.doWithToken(other.Token, "GET", "/auth/me", nil), http.StatusOK, nil)
+
+		ts.expect(ts.doWithToken(other.Token, "POST", "/auth/logout-all", nil), http.StatusOK, nil)
+		ts.expect(ts.doWithToken(other.Token, "GET", "/auth/me", nil), http.StatusUnauthorized, nil)
+	})
+}
+
+func TestRefreshErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(0, "POST", "/auth/refresh", models.RefreshRequest{RefreshToken: "unknown"}), http.StatusUnauthorized, nil)
+		ts.expect(ts.doWithToken("not-a-token", "GET", "/auth/me", nil), http.StatusUnauthorized, nil)
+	})
+}
+
+func TestJWKSWithholdsSecrets(t *testing.T) {
+	ts := newTestServer(t, store.NewMemory())
+	var jwks struct {
+		Keys []auth.JWK `json:"keys"`
+	}
+	ts.expect(ts.do(0, "GET", "/.well-known/jwks.json", nil), http.StatusOK, &jwks)
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("JWKS with HS256 is %+v, want an empty list", jwks.Keys)
+	}
+}
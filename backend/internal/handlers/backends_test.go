@@ -0,0 +1,309 @@
+// internal/handlers/backends_test.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/database"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+// The handler tests run against every backend: the in-memory store, SQLite
+// in a temporary file and PostgreSQL. PostgreSQL is the database named by
+// TEST_POSTGRES_URL, or else a throwaway instance that TestMain starts in a
+// temporary directory when initdb and pg_ctl are installed. Each PostgreSQL
+// test gets a schema of its own, which is dropped afterwards. Without
+// PostgreSQL the tests on it are skipped, except in CI, where they fail.
+
+// postgresURL is the database the PostgreSQL tests create their schemas
+// in, and postgresErr why there is none.
+var postgresURL, postgresErr = os.Getenv("TEST_POSTGRES_URL"), error(nil)
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	if postgresURL == "" {
+		dir, err := os.MkdirTemp("", "handlers-test-postgres")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		stop, err := startPostgres(dir)
+		if err != nil {
+			postgresErr = err
+		} else {
+			defer stop()
+		}
+	}
+	return m.Run()
+}
+
+// startPostgres initialises a database cluster in dir and starts a server
+// on it that listens on a free local port.
+func startPostgres(dir string) (stop func(), err error) {
+	initdb, err := postgresBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := postgresBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+
+	data := filepath.Join(dir, "data")
+	output, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("initdb: %v: %s", err, output)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, dir)
+	output, err = exec.Command(pgCtl, "start", "-w", "-D", data, "-l", filepath.Join(dir, "server.log"), "-o", options).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("pg_ctl start: %v: %s", err, output)
+	}
+
+	postgresURL = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	return func() {
+		exec.Command(pgCtl, "stop", "-w", "-D", data, "-m", "immediate").Run()
+	}, nil
+}
+
+// postgresBinary finds a PostgreSQL server program in PATH or in the
+// versioned directories Debian and Ubuntu install it in.
+func postgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql/*/bin", name))
+	if len(matches) == 0 {
+		return "", errors.New(name + " is not installed and TEST_POSTGRES_URL is not set")
+	}
+	return matches[len(matches)-1], nil
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// forEachBackend runs the test once per backend, each with a server over
+// an empty store.
+func forEachBackend(t *testing.T, test func(t *testing.T, ts *testServer)) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) store.Store
+	}{
+		{"memory", func(*testing.T) store.Store { return store.NewMemory() }},
+		{"sqlite", openSQLite},
+		{"postgres", openPostgres},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			test(t, newTestServer(t, backend.open(t)))
+		})
+	}
+}
+
+func openSQLite(t *testing.T) store.Store {
+	t.Helper()
+	return openDatabase(t, "sqlite://"+t.TempDir()+"/debt_tracker.db")
+}
+
+func openPostgres(t *testing.T) store.Store {
+	t.Helper()
+	if postgresErr != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatal(postgresErr)
+		}
+		t.Skip(postgresErr)
+	}
+
+	admin, err := database.Initialize(postgresURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("handlers_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	separator := "?"
+	if strings.Contains(postgresURL, "?") {
+		separator = "&"
+	}
+	return openDatabase(t, postgresURL+separator+"search_path="+schema)
+}
+
+// openDatabase opens the database and brings its schema up to date.
+func openDatabase(t *testing.T, databaseURL string) store.Store {
+	t.Helper()
+	db, err := database.Initialize(databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	return store.NewSQL(db)
+}
+
+// requestFixture is what the requests of TestRequests are made on: Alice
+// owes her contact Bob 100, and Mallory is another user.
+type requestFixture struct {
+	alice, mallory int
+	bob            models.Contact
+	debt           models.Debt
+}
+
+func TestRequests(t *testing.T) {
+	cases := []struct {
+		name    string
+		request func(f requestFixture) (userID int, method, path string, body interface{})
+		status  int
+		// balance is the balance of Alice's debt after the request.
+		balance string
+	}{
+		{
+			name: "get debt",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "GET", fmt.Sprintf("/debts/%d", f.debt.ID), nil
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "get debt with an invalid ID",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "GET", "/debts/first", nil
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "get debt of another user",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.mallory, "GET", fmt.Sprintf("/debts/%d", f.debt.ID), nil
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "create debt with a contact of another user",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.mallory, "POST", "/debts", models.CreateDebtRequest{
+					ContactID: f.bob.ID, Amount: models.NewMoney(1000, "ZAR"), Direction: "owe_to"}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "create debt without an amount",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "POST", "/debts", models.CreateDebtRequest{ContactID: f.bob.ID, Direction: "owe_to"}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "pay part of the debt",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "POST", "/transactions", models.CreateTransactionRequest{
+					DebtID: f.debt.ID, Amount: models.NewMoney(4000, "ZAR"), TransactionType: "paid_back"}
+			},
+			status:  http.StatusCreated,
+			balance: "60.00",
+		},
+		{
+			name: "borrow more",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "POST", "/transactions", models.CreateTransactionRequest{
+					DebtID: f.debt.ID, Amount: models.NewMoney(2500, "ZAR"), TransactionType: "borrowed"}
+			},
+			status:  http.StatusCreated,
+			balance: "125.00",
+		},
+		{
+			name: "pay more than the balance",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "POST", "/transactions", models.CreateTransactionRequest{
+					DebtID: f.debt.ID, Amount: models.NewMoney(10001, "ZAR"), TransactionType: "paid_back"}
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "pay with a type for the other direction",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "POST", "/transactions", models.CreateTransactionRequest{
+					DebtID: f.debt.ID, Amount: models.NewMoney(1000, "ZAR"), TransactionType: "received_back"}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "pay a debt of another user",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.mallory, "POST", "/transactions", models.CreateTransactionRequest{
+					DebtID: f.debt.ID, Amount: models.NewMoney(1000, "ZAR"), TransactionType: "paid_back"}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "settle the debt by its status",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "PUT", fmt.Sprintf("/debts/%d", f.debt.ID), models.UpdateDebtRequest{Status: "settled"}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "update the debt to an unknown status",
+			request: func(f requestFixture) (int, string, string, interface{}) {
+				return f.alice, "PUT", fmt.Sprintf("/debts/%d", f.debt.ID), models.UpdateDebtRequest{Status: "forgiven"}
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		for i, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				f := requestFixture{alice: ts.user(fmt.Sprint("alice", i)), mallory: ts.user(fmt.Sprint("mallory", i))}
+				f.bob = ts.contact(f.alice, "Bob")
+				f.debt = ts.debt(f.alice, f.bob.ID, "100", "owe_to")
+
+				userID, method, path, body := tc.request(f)
+				ts.expect(ts.do(userID, method, path, body), tc.status, nil)
+
+				balance := tc.balance
+				if balance == "" {
+					balance = "100.00"
+				}
+				checkBalance(t, ts.getDebt(f.alice, f.debt.ID), balance, "active")
+			})
+		}
+	})
+}
@@ -0,0 +1,188 @@
+// internal/handlers/confirmations.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Debts and transactions shared with a linked user are pending until the
+// user who did not create them confirms them. They can dispute them
+// instead, with a comment; the creator can then propose a correction (see
+// shared.go), and a disputed entry can still be confirmed later. Both
+// sides of an entry always have the same confirmation.
+
+func (h *DebtHandler) ConfirmDebt(c *gin.Context) {
+	var req models.ConfirmRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.setConfirmation(c, "confirmed", req.Comment)
+}
+
+func (h *DebtHandler) DisputeDebt(c *gin.Context) {
+	comment, ok := bindDispute(c)
+	if !ok {
+		return
+	}
+	h.setConfirmation(c, "disputed", comment)
+}
+
+func (h *DebtHandler) setConfirmation(c *gin.Context, confirmation, comment string) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var debt models.Debt
+	err = h.store.WithinTx(func(s store.Store) error {
+		var err error
+		debt, err = s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if err := checkConfirmation(userID, debt.Shared, debt.Confirmation, debt.CreatedBy, confirmation); err != nil {
+			return err
+		}
+		for _, id := range []int{debt.ID, *debt.CounterpartID} {
+			if err := s.Debts().SetConfirmation(id, confirmation, optionalComment(comment)); err != nil {
+				return err
+			}
+		}
+
+		debt, err = s.Debts().Get(userID, debtID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update debt")
+		return
+	}
+
+	c.JSON(http.StatusOK, debt)
+}
+
+func (h *TransactionHandler) ConfirmTransaction(c *gin.Context) {
+	var req models.ConfirmRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.setConfirmation(c, "confirmed", req.Comment)
+}
+
+func (h *TransactionHandler) DisputeTransaction(c *gin.Context) {
+	comment, ok := bindDispute(c)
+	if !ok {
+		return
+	}
+	h.setConfirmation(c, "disputed", comment)
+}
+
+func (h *TransactionHandler) setConfirmation(c *gin.Context, confirmation, comment string) {
+	userID := c.GetInt("user_id")
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var transaction models.Transaction
+	err = h.store.WithinTx(func(s store.Store) error {
+		var err error
+		transaction, err = s.Transactions().Get(userID, transactionID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Transaction not found")
+		} else if err != nil {
+			return err
+		}
+
+		shared := transaction.CounterpartID != nil
+		if err := checkConfirmation(userID, shared, transaction.Confirmation, transaction.CreatedBy, confirmation); err != nil {
+			return err
+		}
+
+		// Only confirmed transactions count towards the balance, so both
+		// debts are locked and settled or reopened by the change.
+		debt, err := s.Debts().Lock(userID, transaction.DebtID)
+		if err != nil {
+			return err
+		}
+		counterpart, hasCounterpart, err := lockCounterpart(s, debt)
+		if err != nil {
+			return err
+		}
+		for _, id := range []int{transaction.ID, *transaction.CounterpartID} {
+			if err := s.Transactions().SetConfirmation(id, confirmation, optionalComment(comment)); err != nil {
+				return err
+			}
+		}
+		if err := s.Debts().SyncStatus(debt.ID); err != nil {
+			return err
+		}
+		if hasCounterpart {
+			if err := s.Debts().SyncStatus(counterpart.ID); err != nil {
+				return err
+			}
+		}
+
+		transaction, err = s.Transactions().Get(userID, transactionID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// checkConfirmation reports whether the user may move an entry from its
+// current confirmation to the next one.
+func checkConfirmation(userID int, shared bool, current string, createdBy *int, next string) error {
+	if !shared || current == "confirmed" {
+		return newRequestError(http.StatusConflict, "Entry is already confirmed")
+	}
+	if createdBy != nil && *createdBy == userID {
+		return newRequestError(http.StatusForbidden, "Only the other party can confirm or dispute this entry")
+	}
+	if current == next {
+		return newRequestError(http.StatusConflict, "Entry is already "+next)
+	}
+	return nil
+}
+
+// bindDispute reads the comment a dispute requires, responding with an
+// error if there is none.
+func bindDispute(c *gin.Context) (string, bool) {
+	var req models.DisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A comment is required to dispute an entry"})
+		return "", false
+	}
+	return comment, true
+}
+
+func optionalComment(comment string) *string {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return nil
+	}
+	return &comment
+}
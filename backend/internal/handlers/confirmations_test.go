@@ -0,0 +1,90 @@
+// internal/handlers/confirmations_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestOnlyConfirmedPaymentsCount(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+
+		debt := ts.debt(aliceID, bob.ID, "100", "owe_from")
+		theirs := ts.counterpart(aliceID, debt.ID)
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/confirm", theirs), nil), http.StatusOK, nil)
+
+		var payment models.CreateTransactionResponse
+		req := models.CreateTransactionRequest{DebtID: debt.ID, Amount: money(t, "100"), TransactionType: "received_back"}
+		ts.expect(ts.do(aliceID, "POST", "/transactions", req), http.StatusCreated, &payment)
+		mirror := ts.mirror(aliceID, payment.ID)
+
+		// A pending payment is shown apart from the balance, and the rest of
+		// the debt cannot be paid again until it has been confirmed.
+		pending := ts.getDebt(aliceID, debt.ID)
+		checkBalance(t, pending, "100.00", "active")
+		if pending.PendingAmount.String() != "-100.00" {
+			t.Errorf("pending amount is %s, want -100.00", pending.PendingAmount)
+		}
+		req.Amount = money(t, "10")
+		ts.expect(ts.do(aliceID, "POST", "/transactions", req), http.StatusConflict, nil)
+
+		dispute := models.DisputeRequest{Comment: "Not received"}
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/transactions/%d/dispute", mirror), dispute), http.StatusOK, nil)
+		disputed := ts.getDebt(aliceID, debt.ID)
+		checkBalance(t, disputed, "100.00", "active")
+		if !disputed.PendingAmount.IsZero() {
+			t.Errorf("pending amount of a disputed payment is %s, want 0.00", disputed.PendingAmount)
+		}
+
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/transactions/%d/confirm", mirror), nil), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(aliceID, debt.ID), "0.00", "settled")
+		checkBalance(t, ts.getDebt(bobID, theirs), "0.00", "settled")
+	})
+}
+
+func TestCreatorCannotConfirm(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+
+		debt := ts.debt(aliceID, bob.ID, "100", "owe_from")
+		ts.expect(ts.do(aliceID, "POST", fmt.Sprintf("/debts/%d/confirm", debt.ID), nil), http.StatusForbidden, nil)
+	})
+}
+
+func TestSummaryOfPendingAndDisputedDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+
+		debt := ts.debt(aliceID, bob.ID, "100", "owe_from")
+		theirs := ts.counterpart(aliceID, debt.ID)
+
+		var summary models.DebtSummary
+		ts.expect(ts.do(bobID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if !summary.TotalOwedToOthers.IsZero() || summary.Pending.TotalOwedToOthers.String() != "100.00" || summary.Pending.DebtsCount != 1 {
+			t.Errorf("summary of a pending debt is %+v", summary)
+		}
+
+		dispute := models.DisputeRequest{Comment: "I never borrowed this"}
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/dispute", theirs), dispute), http.StatusOK, nil)
+		ts.expect(ts.do(aliceID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if summary.DisputedDebtsCount != 1 || !summary.Pending.TotalOwedFromOthers.IsZero() {
+			t.Errorf("summary of a disputed debt is %+v", summary)
+		}
+
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/confirm", theirs), nil), http.StatusOK, nil)
+		ts.expect(ts.do(aliceID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if summary.TotalOwedFromOthers.String() != "100.00" || summary.DisputedDebtsCount != 0 {
+			t.Errorf("summary of a confirmed debt is %+v", summary)
+		}
+
+		// Confirmed entries stay confirmed.
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/dispute", theirs), dispute), http.StatusConflict, nil)
+	})
+}
@@ -2,53 +2,53 @@
 package handlers
 
 import (
-	"database/sql"
 	"net/http"
 	"strconv"
 
 	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ContactHandler struct {
-	db *sql.DB
+	store store.Store
 }
 
-func NewContactHandler(db *sql.DB) *ContactHandler {
-	return &ContactHandler{db: db}
+func NewContactHandler(s store.Store) *ContactHandler {
+	return &ContactHandler{store: s}
 }
 
 func (h *ContactHandler) GetContacts(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	rows, err := h.db.Query(`
-		SELECT id, user_id, name, phone, email, is_active, created_at, updated_at 
-		FROM contacts 
-		WHERE user_id = ? AND is_active = 1
-		ORDER BY name ASC
-	`, userID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get contacts"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseContactFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
-
-	var contacts []models.Contact
-	for rows.Next() {
-		var contact models.Contact
-		err := rows.Scan(
-			&contact.ID, &contact.UserID, &contact.Name, &contact.Phone,
-			&contact.Email, &contact.IsActive, &contact.CreatedAt, &contact.UpdatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan contact"})
-			return
-		}
-		contacts = append(contacts, contact)
+
+	page, err := h.store.Contacts().List(userID, filter, opts)
+	if err != nil {
+		respondListError(c, err, "Failed to get contacts")
+		return
 	}
 
-	c.JSON(http.StatusOK, contacts)
+	c.JSON(http.StatusOK, page)
+}
+
+// parseContactFilter reads the filters of the contact list.
+func parseContactFilter(c *gin.Context) (store.ContactFilter, error) {
+	var filter store.ContactFilter
+	var err error
+	filter.CreatedFrom, filter.CreatedBefore, err = parseCreatedRange(c)
+	return filter, err
 }
 
 func (h *ContactHandler) CreateContact(c *gin.Context) {
@@ -60,27 +60,14 @@ func (h *ContactHandler) CreateContact(c *gin.Context) {
 		return
 	}
 
-	result, err := h.db.Exec(`
-		INSERT INTO contacts (user_id, name, phone, email) 
-		VALUES (?, ?, ?, ?)
-	`, userID, req.Name, req.Phone, req.Email)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
-		return
+	contact := models.Contact{
+		UserID: userID,
+		Name:   req.Name,
+		Phone:  &req.Phone,
+		Email:  &req.Email,
 	}
-
-	contactID, _ := result.LastInsertId()
-
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT id, user_id, name, phone, email, is_active, created_at, updated_at 
-		FROM contacts WHERE id = ?
-	`, contactID).Scan(
-		&contact.ID, &contact.UserID, &contact.Name, &contact.Phone,
-		&contact.Email, &contact.IsActive, &contact.CreatedAt, &contact.UpdatedAt,
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get created contact"})
+	if err := h.store.Contacts().Create(&contact); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
 		return
 	}
 
@@ -95,15 +82,8 @@ func (h *ContactHandler) GetContact(c *gin.Context) {
 		return
 	}
 
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT id, user_id, name, phone, email, is_active, created_at, updated_at 
-		FROM contacts WHERE id = ? AND user_id = ?
-	`, contactID, userID).Scan(
-		&contact.ID, &contact.UserID, &contact.Name, &contact.Phone,
-		&contact.Email, &contact.IsActive, &contact.CreatedAt, &contact.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
+	contact, err := h.store.Contacts().Get(userID, contactID)
+	if err == store.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
 		return
 	} else if err != nil {
@@ -128,26 +108,19 @@ func (h *ContactHandler) UpdateContact(c *gin.Context) {
 		return
 	}
 
-	_, err = h.db.Exec(`
-		UPDATE contacts 
-		SET name = ?, phone = ?, email = ?, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = ? AND user_id = ?
-	`, req.Name, req.Phone, req.Email, contactID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
-		return
+	contact := models.Contact{
+		ID:     contactID,
+		UserID: userID,
+		Name:   req.Name,
+		Phone:  &req.Phone,
+		Email:  &req.Email,
 	}
-
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT id, user_id, name, phone, email, is_active, created_at, updated_at 
-		FROM contacts WHERE id = ? AND user_id = ?
-	`, contactID, userID).Scan(
-		&contact.ID, &contact.UserID, &contact.Name, &contact.Phone,
-		&contact.Email, &contact.IsActive, &contact.CreatedAt, &contact.UpdatedAt,
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated contact"})
+	err = h.store.Contacts().Update(&contact)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
 		return
 	}
 
@@ -162,15 +135,14 @@ func (h *ContactHandler) DeleteContact(c *gin.Context) {
 		return
 	}
 
-	_, err = h.db.Exec(`
-		UPDATE contacts 
-		SET is_active = 0, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = ? AND user_id = ?
-	`, contactID, userID)
-	if err != nil {
+	err = h.store.Contacts().Deactivate(userID, contactID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
-}
\ No newline at end of file
+}
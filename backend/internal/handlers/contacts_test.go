@@ -0,0 +1,56 @@
+// internal/handlers/contacts_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestContactLifecycle(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		path := fmt.Sprintf("/contacts/%d", bob.ID)
+
+		var updated models.Contact
+		ts.expect(ts.do(userID, "PUT", path, models.UpdateContactRequest{Name: "Robert"}), http.StatusOK, &updated)
+		if updated.Name != "Robert" {
+			t.Errorf("contact is named %q after the update, want Robert", updated.Name)
+		}
+
+		var contacts models.Page[models.Contact]
+		ts.expect(ts.do(userID, "GET", "/contacts", nil), http.StatusOK, &contacts)
+		if len(contacts.Data) != 1 || contacts.Data[0].ID != bob.ID {
+			t.Fatalf("listed %+v, want only contact %d", contacts.Data, bob.ID)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", path, nil), http.StatusOK, nil)
+		contacts = models.Page[models.Contact]{}
+		ts.expect(ts.do(userID, "GET", "/contacts", nil), http.StatusOK, &contacts)
+		if len(contacts.Data) != 0 || contacts.Total != 0 {
+			t.Errorf("listed %+v after deleting the contact", contacts)
+		}
+	})
+}
+
+func TestContactErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, mallory := ts.user("alice"), ts.user("mallory")
+		bob := ts.contact(alice, "Bob")
+
+		ts.expect(ts.do(alice, "POST", "/contacts", models.CreateContactRequest{}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(alice, "GET", "/contacts/bob", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(mallory, "GET", fmt.Sprintf("/contacts/%d", bob.ID), nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(mallory, "PUT", fmt.Sprintf("/contacts/%d", bob.ID), models.UpdateContactRequest{Name: "Eve"}), http.StatusNotFound, nil)
+		ts.expect(ts.do(mallory, "DELETE", fmt.Sprintf("/contacts/%d", bob.ID), nil), http.StatusNotFound, nil)
+
+		var contact models.Contact
+		ts.expect(ts.do(alice, "GET", fmt.Sprintf("/contacts/%d", bob.ID), nil), http.StatusOK, &contact)
+		if contact.Name != "Bob" || !contact.IsActive {
+			t.Errorf("contact is %+v after another user changed it", contact)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+// internal/handlers/currencies.go
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/rates"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// The "currency" binding tag, used after "iso4217", refuses the currencies
+// whose amounts Money cannot hold.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+			return models.SupportedCurrency(fl.Field().String())
+		})
+	}
+}
+
+// currencyFor returns the requested currency, or the user's default
+// currency if none was requested.
+func currencyFor(s store.Store, userID int, requested string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+	user, err := s.Users().Get(userID)
+	if err != nil {
+		return "", err
+	}
+	return user.DefaultCurrency, nil
+}
+
+// convertSummary fills in the totals of the summary in the currency. Each
+// confirmed ledger entry is converted at the rates of the day it was
+// recorded, so a balance reads the same however long ago it was run up;
+// pending amounts have no such day yet and use the latest rates. A
+// currency that cannot be converted in full is left out of the totals
+// altogether and listed in MissingRates.
+func convertSummary(summary *models.DebtSummary, currency string, ledger []models.LedgerEntry, converter *rates.Converter) error {
+	summary.Currency = currency
+	missing := make(map[string]bool)
+	var failure error
+	convert := func(amount models.Money, at time.Time) models.Money {
+		if amount.IsZero() || missing[amount.Currency] || failure != nil {
+			return models.Money{}
+		}
+		converted, err := converter.Convert(amount, currency, at)
+		if errors.Is(err, rates.ErrNoRate) {
+			missing[amount.Currency] = true
+		} else if err != nil {
+			failure = err
+		}
+		return converted
+	}
+
+	owedTo := make(map[string]models.Money)
+	owedFrom := make(map[string]models.Money)
+	for _, entry := range ledger {
+		totals := owedFrom
+		if entry.Direction == "owe_to" {
+			totals = owedTo
+		}
+		totals[entry.Amount.Currency] = totals[entry.Amount.Currency].Add(convert(entry.Amount, entry.Date))
+	}
+
+	now := time.Now()
+	pendingOwedTo := make(map[string]models.Money)
+	pendingOwedFrom := make(map[string]models.Money)
+	for _, totals := range summary.ByCurrency {
+		pendingOwedTo[totals.Currency] = convert(totals.Pending.TotalOwedToOthers, now)
+		pendingOwedFrom[totals.Currency] = convert(totals.Pending.TotalOwedFromOthers, now)
+	}
+	if failure != nil {
+		return failure
+	}
+
+	summary.TotalOwedToOthers = models.NewMoney(0, currency)
+	summary.TotalOwedFromOthers = models.NewMoney(0, currency)
+	pending := &summary.Pending
+	pending.TotalOwedToOthers = models.NewMoney(0, currency)
+	pending.TotalOwedFromOthers = models.NewMoney(0, currency)
+	summary.MissingRates = nil
+	for _, totals := range summary.ByCurrency {
+		if missing[totals.Currency] {
+			summary.MissingRates = append(summary.MissingRates, totals.Currency)
+			continue
+		}
+		summary.TotalOwedToOthers = summary.TotalOwedToOthers.Add(owedTo[totals.Currency])
+		summary.TotalOwedFromOthers = summary.TotalOwedFromOthers.Add(owedFrom[totals.Currency])
+		pending.TotalOwedToOthers = pending.TotalOwedToOthers.Add(pendingOwedTo[totals.Currency])
+		pending.TotalOwedFromOthers = pending.TotalOwedFromOthers.Add(pendingOwedFrom[totals.Currency])
+	}
+	summary.NetBalance = summary.TotalOwedFromOthers.Sub(summary.TotalOwedToOthers)
+	pending.NetBalance = pending.TotalOwedFromOthers.Sub(pending.TotalOwedToOthers)
+	return nil
+}
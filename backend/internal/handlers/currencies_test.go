@@ -0,0 +1,85 @@
+// internal/handlers/currencies_test.go
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/rates"
+)
+
+// debtIn opens a debt of the user with the contact in the currency.
+func (ts *testServer) debtIn(userID, contactID int, amount, currency, direction string) models.Debt {
+	ts.t.Helper()
+	req := models.CreateDebtRequest{ContactID: contactID, Amount: money(ts.t, amount), Direction: direction, Currency: currency}
+	var debt models.Debt
+	ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusCreated, &debt)
+	return debt
+}
+
+func TestSummaryConvertsCurrencies(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		ts.debt(userID, bob.ID, "100", "owe_from")
+		dollars := ts.debtIn(userID, bob.ID, "10", "USD", "owe_from")
+		if dollars.Currency != "USD" {
+			t.Errorf("debt is in %s, want USD", dollars.Currency)
+		}
+
+		var summary models.DebtSummary
+		ts.expect(ts.do(userID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if summary.Currency != "ZAR" || summary.TotalOwedFromOthers.String() != "100.00" ||
+			!slices.Equal(summary.MissingRates, []string{"USD"}) || len(summary.ByCurrency) != 2 {
+			t.Errorf("summary without rates is %+v", summary)
+		}
+
+		today := rates.Day(time.Now())
+		err := ts.store.ExchangeRates().Save([]models.ExchangeRate{
+			{Currency: "USD", Date: today, Rate: "1"},
+			{Currency: "ZAR", Date: today, Rate: "18"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary = models.DebtSummary{}
+		ts.expect(ts.do(userID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if summary.TotalOwedFromOthers.String() != "280.00" || summary.NetBalance.String() != "280.00" || len(summary.MissingRates) != 0 {
+			t.Errorf("summary with rates is %+v", summary)
+		}
+
+		var page models.Page[models.Debt]
+		ts.expect(ts.do(userID, "GET", "/debts?currency=USD", nil), http.StatusOK, &page)
+		if len(page.Data) != 1 || page.Data[0].ID != dollars.ID {
+			t.Errorf("USD debts are %+v", page.Data)
+		}
+	})
+}
+
+func TestCurrencyErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+
+		for _, currency := range []string{"JPY", "KWD", "XAU", "ZZZ", "usd"} {
+			req := models.CreateDebtRequest{ContactID: bob.ID, Amount: money(t, "10"), Direction: "owe_to", Currency: currency}
+			ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusBadRequest, nil)
+		}
+		ts.expect(ts.do(userID, "GET", "/debts?currency=BHD", nil), http.StatusBadRequest, nil)
+
+		yen := "JPY"
+		ts.expect(ts.do(userID, "PATCH", "/auth/me", models.UpdateProfileRequest{DefaultCurrency: &yen}), http.StatusBadRequest, nil)
+		register := models.RegisterRequest{Email: "carol@example.com", Password: "secret1", Name: "Carol", DefaultCurrency: "KRW"}
+		ts.expect(ts.do(0, "POST", "/auth/register", register), http.StatusBadRequest, nil)
+
+		euro := "EUR"
+		var profile models.User
+		ts.expect(ts.do(userID, "PATCH", "/auth/me", models.UpdateProfileRequest{DefaultCurrency: &euro}), http.StatusOK, &profile)
+		if debt := ts.debt(userID, bob.ID, "10", "owe_to"); debt.Currency != "EUR" {
+			t.Errorf("debt is in %s, want the new default EUR", debt.Currency)
+		}
+	})
+}
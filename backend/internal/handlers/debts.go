@@ -2,61 +2,77 @@
 package handlers
 
 import (
-	"database/sql"
 	"net/http"
 	"strconv"
+	"time"
 
 	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/rates"
+	"debt-tracker-backend/internal/recurrence"
+	"debt-tracker-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
 
 type DebtHandler struct {
-	db *sql.DB
+	store store.Store
 }
 
-func NewDebtHandler(db *sql.DB) *DebtHandler {
-	return &DebtHandler{db: db}
+func NewDebtHandler(s store.Store) *DebtHandler {
+	return &DebtHandler{store: s}
 }
 
 func (h *DebtHandler) GetDebts(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	rows, err := h.db.Query(`
-		SELECT d.id, d.user_id, d.contact_id, d.amount, d.direction, d.status, 
-		       d.description, d.created_at, d.updated_at,
-		       c.id, c.name, c.phone, c.email
-		FROM debts d
-		JOIN contacts c ON d.contact_id = c.id
-		WHERE d.user_id = ? AND d.status = 'active'
-		ORDER BY d.created_at DESC
-	`, userID)
+	filter, err := parseDebtFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get debts"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var debts []models.Debt
-	for rows.Next() {
-		var debt models.Debt
-		var contact models.Contact
-		
-		err := rows.Scan(
-			&debt.ID, &debt.UserID, &debt.ContactID, &debt.Amount, &debt.Direction,
-			&debt.Status, &debt.Description, &debt.CreatedAt, &debt.UpdatedAt,
-			&contact.ID, &contact.Name, &contact.Phone, &contact.Email,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan debt"})
-			return
-		}
-		
-		debt.Contact = &contact
-		debts = append(debts, debt)
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.store.Debts().List(userID, filter, opts)
+	if err != nil {
+		respondListError(c, err, "Failed to get debts")
+		return
 	}
 
-	c.JSON(http.StatusOK, debts)
+	c.JSON(http.StatusOK, page)
+}
+
+// parseDebtFilter reads the filters of the debt list.
+func parseDebtFilter(c *gin.Context) (store.DebtFilter, error) {
+	var query models.DebtListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return store.DebtFilter{}, err
+	}
+
+	statuses, err := parseDebtStatuses(query.Status)
+	if err != nil {
+		return store.DebtFilter{}, err
+	}
+
+	filter := store.DebtFilter{
+		Statuses:     statuses,
+		Direction:    query.Direction,
+		ContactID:    query.ContactID,
+		Confirmation: query.Confirmation,
+		ExpenseID:    query.ExpenseID,
+		Currency:     query.Currency,
+		HasDueDate:   query.HasDueDate,
+	}
+	filter.MinAmount, filter.MaxAmount, err = parseAmountRange(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedFrom, filter.CreatedBefore, err = parseCreatedRange(c)
+	return filter, err
 }
 
 func (h *DebtHandler) CreateDebt(c *gin.Context) {
@@ -68,52 +84,61 @@ func (h *DebtHandler) CreateDebt(c *gin.Context) {
 		return
 	}
 
-	// Check if contact belongs to user
-	var contactExists int
-	err := h.db.QueryRow(
-		"SELECT id FROM contacts WHERE id = ? AND user_id = ? AND is_active = 1",
-		req.ContactID, userID,
-	).Scan(&contactExists)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Contact not found"})
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
 		return
 	}
 
-	// Create the debt (allow multiple debts per contact by removing unique constraint check)
-	result, err := h.db.Exec(`
-		INSERT INTO debts (user_id, contact_id, amount, direction, description) 
-		VALUES (?, ?, ?, ?, ?)
-	`, userID, req.ContactID, req.Amount, req.Direction, req.Description)
+	dueDate, instalments, err := dueDates(req.Amount, req.DueRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create debt"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	debtID, _ := result.LastInsertId()
+	var terms *models.Interest
+	if req.Interest != nil {
+		normalized, err := interestTerms(*req.Interest, recurrence.FormatDay(recurrence.Truncate(time.Now())))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		terms = &normalized
+	}
 
 	var debt models.Debt
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT d.id, d.user_id, d.contact_id, d.amount, d.direction, d.status, 
-		       d.description, d.created_at, d.updated_at,
-		       c.id, c.name, c.phone, c.email
-		FROM debts d
-		JOIN contacts c ON d.contact_id = c.id
-		WHERE d.id = ?
-	`, debtID).Scan(
-		&debt.ID, &debt.UserID, &debt.ContactID, &debt.Amount, &debt.Direction,
-		&debt.Status, &debt.Description, &debt.CreatedAt, &debt.UpdatedAt,
-		&contact.ID, &contact.Name, &contact.Phone, &contact.Email,
-	)
+	err = h.store.WithinTx(func(s store.Store) error {
+		// Check if contact belongs to user
+		contact, err := s.Contacts().Get(userID, req.ContactID)
+		if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+			return newRequestError(http.StatusBadRequest, "Contact not found")
+		} else if err != nil {
+			return err
+		}
+
+		currency, err := currencyFor(s, userID, req.Currency)
+		if err != nil {
+			return err
+		}
+
+		// Create the debt (allow multiple debts per contact by removing unique constraint check)
+		debt = models.Debt{
+			UserID:         userID,
+			ContactID:      req.ContactID,
+			OriginalAmount: req.Amount,
+			Currency:       currency,
+			Direction:      req.Direction,
+			Description:    &req.Description,
+			DueDate:        dueDate,
+			Instalments:    instalments,
+			Interest:       terms,
+		}
+		return createDebt(s, &debt, contact)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get created debt"})
+		respondError(c, err, "Failed to create debt")
 		return
 	}
 
-	debt.Contact = &contact
 	c.JSON(http.StatusCreated, debt)
 }
 
@@ -125,21 +150,8 @@ func (h *DebtHandler) GetDebt(c *gin.Context) {
 		return
 	}
 
-	var debt models.Debt
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT d.id, d.user_id, d.contact_id, d.amount, d.direction, d.status, 
-		       d.description, d.created_at, d.updated_at,
-		       c.id, c.name, c.phone, c.email
-		FROM debts d
-		JOIN contacts c ON d.contact_id = c.id
-		WHERE d.id = ? AND d.user_id = ?
-	`, debtID, userID).Scan(
-		&debt.ID, &debt.UserID, &debt.ContactID, &debt.Amount, &debt.Direction,
-		&debt.Status, &debt.Description, &debt.CreatedAt, &debt.UpdatedAt,
-		&contact.ID, &contact.Name, &contact.Phone, &contact.Email,
-	)
-	if err == sql.ErrNoRows {
+	debt, err := h.store.Debts().Get(userID, debtID)
+	if err == store.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Debt not found"})
 		return
 	} else if err != nil {
@@ -147,10 +159,11 @@ func (h *DebtHandler) GetDebt(c *gin.Context) {
 		return
 	}
 
-	debt.Contact = &contact
 	c.JSON(http.StatusOK, debt)
 }
 
+// UpdateDebt edits a debt. Edits of a shared debt are proposed to the other
+// user instead and answered with 202 Accepted.
 func (h *DebtHandler) UpdateDebt(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	debtID, err := strconv.Atoi(c.Param("id"))
@@ -165,36 +178,62 @@ func (h *DebtHandler) UpdateDebt(c *gin.Context) {
 		return
 	}
 
-	_, err = h.db.Exec(`
-		UPDATE debts 
-		SET amount = ?, description = ?, status = ?, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = ? AND user_id = ?
-	`, req.Amount, req.Description, req.Status, debtID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update debt"})
+	if req.Amount != nil && !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
 		return
 	}
 
 	var debt models.Debt
-	var contact models.Contact
-	err = h.db.QueryRow(`
-		SELECT d.id, d.user_id, d.contact_id, d.amount, d.direction, d.status, 
-		       d.description, d.created_at, d.updated_at,
-		       c.id, c.name, c.phone, c.email
-		FROM debts d
-		JOIN contacts c ON d.contact_id = c.id
-		WHERE d.id = ? AND d.user_id = ?
-	`, debtID, userID).Scan(
-		&debt.ID, &debt.UserID, &debt.ContactID, &debt.Amount, &debt.Direction,
-		&debt.Status, &debt.Description, &debt.CreatedAt, &debt.UpdatedAt,
-		&contact.ID, &contact.Name, &contact.Phone, &contact.Email,
-	)
+	var proposal *models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		var err error
+		debt, err = s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if debt.Shared {
+			proposal, err = proposeUpdate(s, debt, req)
+			return err
+		}
+
+		// The balance is derived from the ledger, so the original amount may
+		// only be corrected until the first transaction has been recorded.
+		if req.Amount != nil && req.Amount.Cmp(debt.OriginalAmount) != 0 {
+			count, err := s.Debts().TransactionCount(debtID)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return newRequestError(http.StatusConflict, "Amount cannot be changed once transactions have been recorded; add a transaction instead")
+			}
+			debt.OriginalAmount = *req.Amount
+		}
+
+		debt.Description = &req.Description
+		debt.Status = requestedStatus(debt.Status, req.Status)
+		if err := s.Debts().Update(&debt); err != nil {
+			return err
+		}
+		// Whether the debt is active or settled follows from its balance,
+		// whatever the request says.
+		if err := s.Debts().SyncStatus(debtID); err != nil {
+			return err
+		}
+		debt, err = s.Debts().Get(userID, debtID)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated debt"})
+		respondError(c, err, "Failed to update debt")
 		return
 	}
 
-	debt.Contact = &contact
+	if proposal != nil {
+		c.JSON(http.StatusAccepted, proposal)
+		return
+	}
 	c.JSON(http.StatusOK, debt)
 }
 
@@ -206,41 +245,121 @@ func (h *DebtHandler) DeleteDebt(c *gin.Context) {
 		return
 	}
 
-	_, err = h.db.Exec(`
-		UPDATE debts 
-		SET status = 'removed', updated_at = CURRENT_TIMESTAMP 
-		WHERE id = ? AND user_id = ?
-	`, debtID, userID)
+	var proposal *models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err := s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if debt.Shared && debt.Status != "removed" {
+			status := "removed"
+			proposal = &models.DebtChange{Kind: "update", Status: &status}
+			return proposeChange(s, debt, proposal)
+		}
+		return s.Debts().Remove(userID, debtID)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete debt"})
+		respondError(c, err, "Failed to delete debt")
 		return
 	}
 
+	if proposal != nil {
+		c.JSON(http.StatusAccepted, proposal)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Debt deleted successfully"})
 }
 
+// RestoreDebt brings back a removed debt as active, or as settled if its
+// balance is already paid off.
+func (h *DebtHandler) RestoreDebt(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var debt models.Debt
+	var proposal *models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		var err error
+		debt, err = s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if debt.Status != "removed" {
+			return newRequestError(http.StatusConflict, "Only removed debts can be restored")
+		}
+
+		if debt.Shared {
+			status := "active"
+			proposal = &models.DebtChange{Kind: "update", Status: &status}
+			return proposeChange(s, debt, proposal)
+		}
+
+		if err := s.Debts().Restore(userID, debtID); err != nil {
+			return err
+		}
+		if err := s.Debts().SyncStatus(debtID); err != nil {
+			return err
+		}
+
+		debt, err = s.Debts().Get(userID, debtID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to restore debt")
+		return
+	}
+
+	if proposal != nil {
+		c.JSON(http.StatusAccepted, proposal)
+		return
+	}
+	c.JSON(http.StatusOK, debt)
+}
+
 func (h *DebtHandler) GetDebtSummary(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
 	var summary models.DebtSummary
+	err := h.store.WithinTx(func(s store.Store) error {
+		currency, err := currencyFor(s, userID, "")
+		if err != nil {
+			return err
+		}
+		summary, err = s.Debts().Summary(userID)
+		if err != nil {
+			return err
+		}
+		ledger, err := s.Debts().Ledger(userID)
+		if err != nil {
+			return err
+		}
+		if err := convertSummary(&summary, currency, ledger, rates.NewConverter(s.ExchangeRates())); err != nil {
+			return err
+		}
 
-	// Calculate totals
-	err := h.db.QueryRow(`
-		SELECT 
-			COALESCE(SUM(CASE WHEN direction = 'owe_to' AND status = 'active' THEN amount ELSE 0 END), 0) as total_owed_to,
-			COALESCE(SUM(CASE WHEN direction = 'owe_from' AND status = 'active' THEN amount ELSE 0 END), 0) as total_owed_from,
-			COUNT(CASE WHEN status = 'active' THEN 1 END) as active_count,
-			COUNT(DISTINCT CASE WHEN status = 'active' THEN contact_id END) as contacts_count
-		FROM debts 
-		WHERE user_id = ?
-	`, userID).Scan(&summary.TotalOwedToOthers, &summary.TotalOwedFromOthers, 
-		&summary.ActiveDebtsCount, &summary.ContactsWithDebts)
+		due, err := store.All(func(opts store.ListOptions) (models.Page[models.Debt], error) {
+			return s.Debts().List(userID, store.DebtFilter{HasDueDate: true}, opts)
+		})
+		if err != nil {
+			return err
+		}
+		addOverdue(&summary, due)
+		return nil
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get debt summary"})
 		return
 	}
 
-	summary.NetBalance = summary.TotalOwedFromOthers - summary.TotalOwedToOthers
-
 	c.JSON(http.StatusOK, summary)
-}
\ No newline at end of file
+}
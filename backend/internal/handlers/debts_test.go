@@ -0,0 +1,207 @@
+// internal/handlers/debts_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestUpdateDebtKeepsDerivedStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_from")
+		path := fmt.Sprintf("/debts/%d", debt.ID)
+
+		update := func(status string) models.Debt {
+			t.Helper()
+			var updated models.Debt
+			req := models.UpdateDebtRequest{Description: "Lunch", Status: status}
+			ts.expect(ts.do(userID, "PUT", path, req), http.StatusOK, &updated)
+			return updated
+		}
+
+		checkBalance(t, update("settled"), "100.00", "active")
+
+		ts.pay(userID, debt.ID, "100", "received_back")
+		checkBalance(t, update("active"), "0.00", "settled")
+
+		checkBalance(t, update("removed"), "0.00", "removed")
+		checkBalance(t, update("active"), "0.00", "settled")
+	})
+}
+
+func TestUpdateDebtAmount(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+		path := fmt.Sprintf("/debts/%d", debt.ID)
+
+		amount := money(t, "80")
+		var updated models.Debt
+		ts.expect(ts.do(userID, "PUT", path, models.UpdateDebtRequest{Amount: &amount, Status: "active"}), http.StatusOK, &updated)
+		checkBalance(t, updated, "80.00", "active")
+
+		ts.pay(userID, debt.ID, "30", "paid_back")
+		amount = money(t, "90")
+		ts.expect(ts.do(userID, "PUT", path, models.UpdateDebtRequest{Amount: &amount, Status: "active"}), http.StatusConflict, nil)
+		checkBalance(t, ts.getDebt(userID, debt.ID), "50.00", "active")
+	})
+}
+
+func TestDebtSummary(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		ts.debt(userID, bob.ID, "100", "owe_to")
+		lent := ts.debt(userID, bob.ID, "250.50", "owe_from")
+		ts.pay(userID, lent.ID, "50", "received_back")
+
+		var summary models.DebtSummary
+		ts.expect(ts.do(userID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		if summary.TotalOwedToOthers.String() != "100.00" || summary.TotalOwedFromOthers.String() != "200.50" ||
+			summary.NetBalance.String() != "100.50" || summary.ActiveDebtsCount != 2 || summary.ContactsWithDebts != 1 {
+			t.Errorf("summary is %+v", summary)
+		}
+	})
+}
+
+func TestListDebtsInPages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		var want []int
+		for _, amount := range []string{"10", "20", "20", "30", "40"} {
+			want = append(want, ts.debt(userID, bob.ID, amount, "owe_to").ID)
+		}
+		ts.debt(userID, bob.ID, "25", "owe_from")
+
+		var got []int
+		query := "/debts?direction=owe_to&sort=amount&order=asc&limit=2"
+		for path := query; ; {
+			var page models.Page[models.Debt]
+			ts.expect(ts.do(userID, "GET", path, nil), http.StatusOK, &page)
+			if page.Total != len(want) {
+				t.Fatalf("total is %d, want %d", page.Total, len(want))
+			}
+			for _, debt := range page.Data {
+				got = append(got, debt.ID)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			path = query + "&cursor=" + url.QueryEscape(*page.NextCursor)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("listed debts %v, want %v", got, want)
+		}
+	})
+}
+
+func TestListDebtsRejectsBadQueries(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		for _, query := range []string{"limit=0", "limit=201", "sort=contact", "cursor=abc", "created_from=yesterday", "min_amount=ten"} {
+			t.Run(query, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(userID, "GET", "/debts?"+query, nil), http.StatusBadRequest, nil)
+			})
+		}
+	})
+}
+
+func TestDeleteDebt(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, mallory := ts.user("alice"), ts.user("mallory")
+		bob := ts.contact(alice, "Bob")
+		debt := ts.debt(alice, bob.ID, "100", "owe_to")
+		path := fmt.Sprintf("/debts/%d", debt.ID)
+
+		ts.expect(ts.do(mallory, "DELETE", path, nil), http.StatusNotFound, nil)
+		checkBalance(t, ts.getDebt(alice, debt.ID), "100.00", "active")
+
+		ts.expect(ts.do(alice, "DELETE", path, nil), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(alice, debt.ID), "100.00", "removed")
+	})
+}
+
+func TestOverpaymentIsRejected(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+
+		payment := models.CreateTransactionRequest{DebtID: debt.ID, Amount: money(t, "120"), TransactionType: "paid_back"}
+		ts.expect(ts.do(userID, "POST", "/transactions", payment), http.StatusConflict, nil)
+		checkBalance(t, ts.getDebt(userID, debt.ID), "100.00", "active")
+	})
+}
+
+func TestListDebtsByStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		active := ts.debt(userID, bob.ID, "100", "owe_to")
+		settled := ts.debt(userID, bob.ID, "50", "owe_to")
+		ts.pay(userID, settled.ID, "50", "paid_back")
+		removed := ts.debt(userID, bob.ID, "20", "owe_to")
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("/debts/%d", removed.ID), nil), http.StatusOK, nil)
+
+		cases := []struct {
+			status string
+			want   []int
+		}{
+			{"", []int{active.ID}},
+			{"settled", []int{settled.ID}},
+			{"settled,removed", []int{removed.ID, settled.ID}},
+			{"all", []int{removed.ID, settled.ID, active.ID}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.status, func(t *testing.T) {
+				ts := ts.on(t)
+				var page models.Page[models.Debt]
+				ts.expect(ts.do(userID, "GET", "/debts?status="+tc.status, nil), http.StatusOK, &page)
+				var got []int
+				for _, debt := range page.Data {
+					got = append(got, debt.ID)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+					t.Errorf("listed debts %v, want %v", got, tc.want)
+				}
+			})
+		}
+
+		ts.expect(ts.do(userID, "GET", "/debts?status=active,forgiven", nil), http.StatusBadRequest, nil)
+	})
+}
+
+func TestRestoreDebt(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, mallory := ts.user("alice"), ts.user("mallory")
+		bob := ts.contact(alice, "Bob")
+		open := ts.debt(alice, bob.ID, "100", "owe_to")
+		paid := ts.debt(alice, bob.ID, "50", "owe_to")
+		ts.pay(alice, paid.ID, "50", "paid_back")
+
+		restore := func(userID, debtID, status int) models.Debt {
+			t.Helper()
+			var debt models.Debt
+			ts.expect(ts.do(userID, "POST", fmt.Sprintf("/debts/%d/restore", debtID), nil), status, &debt)
+			return debt
+		}
+
+		restore(alice, open.ID, http.StatusConflict)
+		for _, debt := range []models.Debt{open, paid} {
+			ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/debts/%d", debt.ID), nil), http.StatusOK, nil)
+		}
+		restore(mallory, open.ID, http.StatusNotFound)
+
+		checkBalance(t, restore(alice, open.ID, http.StatusOK), "100.00", "active")
+		checkBalance(t, restore(alice, paid.ID, http.StatusOK), "0.00", "settled")
+	})
+}
@@ -0,0 +1,199 @@
+// internal/handlers/due.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/recurrence"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxInstalments bounds the instalments of a debt, which also keeps an
+// instalment plan without an end from running on forever.
+const maxInstalments = 600
+
+// dueDates reads when a debt of the given total is due. Instalments must
+// add up to the total and an instalment plan splits it equally, the
+// leftover cents going to the first instalments. The due date of a debt
+// in instalments is the day of the last one.
+func dueDates(total models.Money, req models.DueRequest) (*string, []models.Instalment, error) {
+	given := 0
+	for _, set := range []bool{req.DueDate != "", len(req.Instalments) > 0, req.InstalmentPlan != nil} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, nil, errors.New("give only one of due_date, instalments and instalment_plan")
+	}
+
+	switch {
+	case req.DueDate != "":
+		day, err := recurrence.ParseDay(req.DueDate)
+		if err != nil {
+			return nil, nil, errors.New("due_date must be a date such as 2025-07-01")
+		}
+		dueDate := recurrence.FormatDay(day)
+		return &dueDate, nil, nil
+
+	case len(req.Instalments) > 0:
+		if len(req.Instalments) > maxInstalments {
+			return nil, nil, fmt.Errorf("a debt can have at most %d instalments", maxInstalments)
+		}
+		instalments := make([]models.Instalment, len(req.Instalments))
+		sum := models.NewMoney(0, total.Currency)
+		for i, instalment := range req.Instalments {
+			day, err := recurrence.ParseDay(instalment.DueDate)
+			if err != nil {
+				return nil, nil, errors.New("instalment due_date must be a date such as 2025-07-01")
+			}
+			if !instalment.Amount.IsPositive() {
+				return nil, nil, errors.New("instalment amounts must be greater than zero")
+			}
+			instalments[i] = models.Instalment{
+				DueDate: recurrence.FormatDay(day),
+				Amount:  models.NewMoney(instalment.Amount.Minor, total.Currency),
+			}
+			sum = sum.Add(instalments[i].Amount)
+		}
+		sort.Slice(instalments, func(i, j int) bool { return instalments[i].DueDate < instalments[j].DueDate })
+		for i := 1; i < len(instalments); i++ {
+			if instalments[i].DueDate == instalments[i-1].DueDate {
+				return nil, nil, errors.New("instalments must fall on different days")
+			}
+		}
+		if sum.Cmp(total) != 0 {
+			return nil, nil, fmt.Errorf("instalments add up to %s but the debt is %s", sum, total)
+		}
+		dueDate := instalments[len(instalments)-1].DueDate
+		return &dueDate, instalments, nil
+
+	case req.InstalmentPlan != nil:
+		_, days, err := planDays(*req.InstalmentPlan)
+		if err != nil {
+			return nil, nil, err
+		}
+		if total.Minor < int64(len(days)) {
+			return nil, nil, errors.New("the debt is too small for that many instalments")
+		}
+
+		weights := make([]int64, len(days))
+		for i := range weights {
+			weights[i] = 1
+		}
+		instalments := make([]models.Instalment, len(days))
+		for i, minor := range allocate(total.Minor, weights) {
+			instalments[i] = models.Instalment{DueDate: recurrence.FormatDay(days[i]), Amount: models.NewMoney(minor, total.Currency)}
+		}
+		dueDate := instalments[len(instalments)-1].DueDate
+		return &dueDate, instalments, nil
+	}
+	return nil, nil, nil
+}
+
+// planDays returns the days of a plan of payments, which must end after at
+// most maxInstalments of them.
+func planDays(plan models.RecurrenceRequest) (recurrence.Rule, []time.Time, error) {
+	rule, start, err := parseRecurrence(plan)
+	if err != nil {
+		return rule, nil, err
+	}
+	days := rule.Upcoming(start, start, maxInstalments+1)
+	if len(days) == 0 {
+		return rule, nil, errors.New("the plan has no payments")
+	}
+	if len(days) > maxInstalments {
+		return rule, nil, fmt.Errorf("the plan needs a count or until giving at most %d payments", maxInstalments)
+	}
+	return rule, days, nil
+}
+
+// SetDueDate replaces when the debt is due. Instalments cover everything
+// the debt has come to, which is its balance and what has been paid so
+// far; earlier payments count against the first of them. An empty body
+// clears the due date. On a shared debt only the user's own side changes.
+func (h *DebtHandler) SetDueDate(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var req models.DueRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var debt models.Debt
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err = s.Debts().Lock(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if debt.Status == "removed" {
+			return newRequestError(http.StatusConflict, "Cannot change the due date of a removed debt")
+		}
+
+		dueDate, instalments, err := dueDates(debt.Balance.Add(debt.PaidAmount), req)
+		if err != nil {
+			return newRequestError(http.StatusBadRequest, err.Error())
+		}
+		if err := s.Debts().SetDueDate(debt.ID, dueDate, instalments); err != nil {
+			return err
+		}
+
+		debt, err = s.Debts().Get(userID, debt.ID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to set due date")
+		return
+	}
+
+	c.JSON(http.StatusOK, debt)
+}
+
+// addOverdue counts the overdue debts into the summary. Overdue amounts
+// are totalled per currency only.
+func addOverdue(summary *models.DebtSummary, debts []models.Debt) {
+	for i := range summary.ByCurrency {
+		overdue := &summary.ByCurrency[i].Overdue
+		overdue.TotalOwedToOthers = models.NewMoney(0, summary.ByCurrency[i].Currency)
+		overdue.TotalOwedFromOthers = models.NewMoney(0, summary.ByCurrency[i].Currency)
+	}
+
+	for _, debt := range debts {
+		if !debt.Overdue {
+			continue
+		}
+		summary.OverdueDebtsCount++
+		summary.MaxDaysOverdue = max(summary.MaxDaysOverdue, debt.DaysOverdue)
+
+		for i := range summary.ByCurrency {
+			if summary.ByCurrency[i].Currency != debt.Currency {
+				continue
+			}
+			overdue := &summary.ByCurrency[i].Overdue
+			overdue.DebtsCount++
+			overdue.MaxDaysOverdue = max(overdue.MaxDaysOverdue, debt.DaysOverdue)
+			if debt.Direction == "owe_to" {
+				overdue.TotalOwedToOthers = overdue.TotalOwedToOthers.Add(debt.OverdueAmount)
+			} else {
+				overdue.TotalOwedFromOthers = overdue.TotalOwedFromOthers.Add(debt.OverdueAmount)
+			}
+		}
+	}
+}
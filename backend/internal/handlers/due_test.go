@@ -0,0 +1,144 @@
+// internal/handlers/due_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// dueDebt opens a debt of the user that is due as the request says.
+func (ts *testServer) dueDebt(userID, contactID int, amount string, due models.DueRequest) models.Debt {
+	ts.t.Helper()
+	req := models.CreateDebtRequest{ContactID: contactID, Amount: money(ts.t, amount), Direction: "owe_from", DueRequest: due}
+	var debt models.Debt
+	ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusCreated, &debt)
+	return debt
+}
+
+// describeInstalments lists instalments as "day amount".
+func describeInstalments(instalments []models.Instalment) []string {
+	described := make([]string, len(instalments))
+	for i, instalment := range instalments {
+		described[i] = instalment.DueDate + " " + instalment.Amount.String()
+	}
+	return described
+}
+
+func TestDebtDueDates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+
+		late := ts.dueDebt(userID, bob.ID, "100", models.DueRequest{DueDate: daysFromToday(-3)})
+		ts.pay(userID, late.ID, "40", "received_back")
+		late = ts.getDebt(userID, late.ID)
+		if !late.Overdue || late.DaysOverdue != 3 || late.OverdueAmount.String() != "60.00" {
+			t.Errorf("debt due 3 days ago is overdue %v by %d days with %s", late.Overdue, late.DaysOverdue, late.OverdueAmount)
+		}
+
+		// The instalments are sorted, and the debt is due on the last one.
+		first, second := daysFromToday(-1), daysFromToday(30)
+		split := ts.dueDebt(userID, bob.ID, "100", models.DueRequest{Instalments: []models.Instalment{
+			{DueDate: second, Amount: money(t, "75")},
+			{DueDate: first, Amount: money(t, "25")},
+		}})
+		want := []string{first + " 25.00", second + " 75.00"}
+		if got := describeInstalments(split.Instalments); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("instalments are %v, want %v", got, want)
+		}
+		if split.DueDate == nil || *split.DueDate != second || !split.Overdue || split.OverdueAmount.String() != "25.00" {
+			t.Errorf("debt in instalments is due %v and overdue %v with %s", split.DueDate, split.Overdue, split.OverdueAmount)
+		}
+
+		// Paying the first instalment brings the debt up to date. A plan
+		// over everything the debt has come to counts the payment against
+		// its first instalments, and gives leftover cents to the first.
+		ts.pay(userID, split.ID, "25", "received_back")
+		plan := models.RecurrenceRequest{StartDate: daysFromToday(-1), RRule: "FREQ=MONTHLY;COUNT=3"}
+		ts.expect(ts.do(userID, "PUT", fmt.Sprintf("/debts/%d/due-date", split.ID), models.DueRequest{InstalmentPlan: &plan}), http.StatusOK, &split)
+		if len(split.Instalments) != 3 || split.Instalments[0].Amount.String() != "33.34" || split.Instalments[2].Amount.String() != "33.33" {
+			t.Errorf("planned instalments are %v", describeInstalments(split.Instalments))
+		}
+		if !split.Overdue || split.OverdueAmount.String() != "8.34" || split.NextDueDate == nil || split.NextDueAmount.String() != "33.33" {
+			t.Errorf("planned debt is overdue %v with %s and next due %v", split.Overdue, split.OverdueAmount, split.NextDueAmount)
+		}
+
+		ts.dueDebt(userID, bob.ID, "10", models.DueRequest{})
+		var page models.Page[models.Debt]
+		ts.expect(ts.do(userID, "GET", "/debts?has_due_date=true", nil), http.StatusOK, &page)
+		if page.Total != 2 {
+			t.Errorf("%d debts have a due date, want 2", page.Total)
+		}
+
+		var summary models.DebtSummary
+		ts.expect(ts.do(userID, "GET", "/debts/summary", nil), http.StatusOK, &summary)
+		overdue := summary.ByCurrency[0].Overdue
+		if summary.OverdueDebtsCount != 2 || summary.MaxDaysOverdue != 3 || overdue.TotalOwedFromOthers.String() != "68.34" {
+			t.Errorf("summary has %d overdue debts, at most %d days late, owed %s", summary.OverdueDebtsCount, summary.MaxDaysOverdue, overdue.TotalOwedFromOthers)
+		}
+
+		// An empty body clears the due date.
+		var cleared models.Debt
+		ts.expect(ts.do(userID, "PUT", fmt.Sprintf("/debts/%d/due-date", split.ID), nil), http.StatusOK, &cleared)
+		if cleared.DueDate != nil || len(cleared.Instalments) != 0 || cleared.Overdue {
+			t.Errorf("cleared debt is due %v in %d instalments", cleared.DueDate, len(cleared.Instalments))
+		}
+	})
+}
+
+func TestSharedDebtDueDate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+		debt := ts.dueDebt(aliceID, bob.ID, "100", models.DueRequest{DueDate: daysFromToday(7)})
+		theirs := ts.counterpart(aliceID, debt.ID)
+
+		// The other side starts with the same due date, and then each
+		// side keeps its own.
+		if due := ts.getDebt(bobID, theirs).DueDate; due == nil || *due != daysFromToday(7) {
+			t.Errorf("bob's side is due %v, want %s", due, daysFromToday(7))
+		}
+		ts.expect(ts.do(bobID, "PUT", fmt.Sprintf("/debts/%d/due-date", theirs), models.DueRequest{DueDate: daysFromToday(14)}), http.StatusOK, nil)
+		if due := ts.getDebt(aliceID, debt.ID).DueDate; due == nil || *due != daysFromToday(7) {
+			t.Errorf("alice's side is due %v, want %s", due, daysFromToday(7))
+		}
+	})
+}
+
+func TestDueDateErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.contact(userID, "Bob")
+		tomorrow, later := daysFromToday(1), daysFromToday(30)
+		endless := models.RecurrenceRequest{StartDate: tomorrow, Frequency: "monthly"}
+		daily := models.RecurrenceRequest{StartDate: tomorrow, RRule: "FREQ=DAILY;COUNT=3"}
+
+		for _, due := range []models.DueRequest{
+			{DueDate: "soon"},
+			{DueDate: tomorrow, InstalmentPlan: &daily},
+			{Instalments: []models.Instalment{{DueDate: tomorrow, Amount: money(t, "50")}}},
+			{Instalments: []models.Instalment{{DueDate: tomorrow, Amount: money(t, "0.02")}, {DueDate: later, Amount: money(t, "0")}}},
+			{Instalments: []models.Instalment{{DueDate: tomorrow, Amount: money(t, "0.01")}, {DueDate: tomorrow, Amount: money(t, "0.01")}}},
+			{Instalments: []models.Instalment{{DueDate: "later", Amount: money(t, "0.02")}}},
+			{InstalmentPlan: &endless},
+		} {
+			req := models.CreateDebtRequest{ContactID: bob.ID, Amount: money(t, "0.02"), Direction: "owe_from", DueRequest: due}
+			ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusBadRequest, nil)
+		}
+		// Two cents cannot be paid in three instalments.
+		req := models.CreateDebtRequest{ContactID: bob.ID, Amount: money(t, "0.02"), Direction: "owe_from", DueRequest: models.DueRequest{InstalmentPlan: &daily}}
+		ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusBadRequest, nil)
+
+		debt := ts.debt(userID, bob.ID, "10", "owe_from")
+		path := fmt.Sprintf("/debts/%d/due-date", debt.ID)
+		ts.expect(ts.do(userID, "PUT", path, models.DueRequest{DueDate: "soon"}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "PUT", "/debts/abc/due-date", models.DueRequest{DueDate: tomorrow}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(otherID, "PUT", path, models.DueRequest{DueDate: tomorrow}), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("/debts/%d", debt.ID), nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "PUT", path, models.DueRequest{DueDate: tomorrow}), http.StatusConflict, nil)
+	})
+}
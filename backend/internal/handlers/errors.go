@@ -0,0 +1,38 @@
+// internal/handlers/errors.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestError carries an HTTP response out of a store transaction. Returning
+// it from WithinTx rolls the transaction back.
+type requestError struct {
+	status int
+	body   gin.H
+}
+
+func (e *requestError) Error() string {
+	if message, ok := e.body["error"].(string); ok {
+		return message
+	}
+	return http.StatusText(e.status)
+}
+
+func newRequestError(status int, message string) *requestError {
+	return &requestError{status: status, body: gin.H{"error": message}}
+}
+
+// respondError writes a requestError as is and anything else as an internal
+// server error with the given message.
+func respondError(c *gin.Context, err error, message string) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.status, reqErr.body)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,440 @@
+// internal/handlers/expenses.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// An expense is paid by one member of a group and split between members.
+// Debts are always between the user and a contact, so an expense only
+// produces the debts that involve the user: when the user paid, each other
+// member owes the user their share; when a member paid, the user owes them
+// the user's share. What members owe each other is not tracked. The debts
+// point back at the expense and are brought in line with it whenever it is
+// edited.
+
+// expenseInvolves reports whether the contact paid for or shares the
+// expense.
+func expenseInvolves(expense models.Expense, contactID int) bool {
+	if expense.PaidBy != nil && *expense.PaidBy == contactID {
+		return true
+	}
+	for _, split := range expense.Splits {
+		if split.ContactID != nil && *split.ContactID == contactID {
+			return true
+		}
+	}
+	return false
+}
+
+// buildExpense checks an ExpenseRequest against the members of the group
+// and works out the splits in the currency.
+func buildExpense(group models.Group, req models.ExpenseRequest, currency string) (models.Expense, error) {
+	members := make(map[int]bool)
+	for _, member := range group.Members {
+		members[member.ID] = member.IsActive
+	}
+	checkMember := func(contactID *int) error {
+		if contactID != nil && !members[*contactID] {
+			return newRequestError(http.StatusBadRequest,
+				fmt.Sprintf("Contact %d is not an active member of the group", *contactID))
+		}
+		return nil
+	}
+
+	if err := checkMember(req.PaidBy); err != nil {
+		return models.Expense{}, err
+	}
+
+	splits := req.Splits
+	if len(splits) == 0 && req.SplitType == models.SplitEqual {
+		splits = []models.ExpenseSplit{{}}
+		for _, member := range group.Members {
+			if member.IsActive {
+				splits = append(splits, models.ExpenseSplit{ContactID: &member.ID})
+			}
+		}
+	}
+	for _, split := range splits {
+		if err := checkMember(split.ContactID); err != nil {
+			return models.Expense{}, err
+		}
+	}
+
+	amount := models.NewMoney(req.Amount.Minor, currency)
+	splits, err := splitExpense(amount, req.SplitType, splits)
+	if err != nil {
+		return models.Expense{}, newRequestError(http.StatusBadRequest, err.Error())
+	}
+
+	return models.Expense{
+		GroupID:     group.ID,
+		Description: req.Description,
+		Amount:      amount,
+		Currency:    amount.Currency,
+		PaidBy:      req.PaidBy,
+		SplitType:   req.SplitType,
+		Splits:      splits,
+	}, nil
+}
+
+// expenseDebts returns the debts the expense should have produced.
+func expenseDebts(userID int, expense models.Expense) []models.Debt {
+	debt := func(contactID int, amount models.Money, direction string) models.Debt {
+		return models.Debt{
+			UserID:         userID,
+			ContactID:      contactID,
+			OriginalAmount: amount,
+			Currency:       expense.Currency,
+			Direction:      direction,
+			Description:    &expense.Description,
+			ExpenseID:      &expense.ID,
+		}
+	}
+
+	var debts []models.Debt
+	for _, split := range expense.Splits {
+		if !split.Amount.IsPositive() {
+			continue
+		}
+		if expense.PaidBy == nil && split.ContactID != nil {
+			debts = append(debts, debt(*split.ContactID, split.Amount, "owe_from"))
+		} else if expense.PaidBy != nil && split.ContactID == nil {
+			debts = append(debts, debt(*expense.PaidBy, split.Amount, "owe_to"))
+		}
+	}
+	return debts
+}
+
+// listExpenseDebts returns the debts of the expense that are not removed.
+func listExpenseDebts(s store.Store, userID, expenseID int) ([]models.Debt, error) {
+	return store.All(func(opts store.ListOptions) (models.Page[models.Debt], error) {
+		filter := store.DebtFilter{Statuses: []string{"active", "settled"}, ExpenseID: expenseID}
+		return s.Debts().List(userID, filter, opts)
+	})
+}
+
+// requireNoTransactions refuses to change a debt of an expense once
+// transactions have been recorded against it.
+func requireNoTransactions(s store.Store, debt models.Debt) error {
+	count, err := s.Debts().TransactionCount(debt.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return newRequestError(http.StatusConflict,
+			fmt.Sprintf("Debt #%d of this expense already has transactions recorded and can no longer change", debt.ID))
+	}
+	return nil
+}
+
+// reviseExpenseDebt gives a debt of an expense its recomputed amount and
+// description. On a shared debt the edit is proposed to the other user.
+func reviseExpenseDebt(s store.Store, debt, want models.Debt) error {
+	sameAmount := debt.OriginalAmount.Cmp(want.OriginalAmount) == 0
+	if sameAmount && debt.Description != nil && *debt.Description == *want.Description {
+		return nil
+	}
+	if !sameAmount {
+		if err := requireNoTransactions(s, debt); err != nil {
+			return err
+		}
+	}
+
+	if debt.Shared {
+		_, err := proposeUpdate(s, debt, models.UpdateDebtRequest{
+			Amount:      &want.OriginalAmount,
+			Description: *want.Description,
+			Status:      debt.Status,
+		})
+		return err
+	}
+
+	debt.OriginalAmount = want.OriginalAmount
+	debt.Description = want.Description
+	return s.Debts().Update(&debt)
+}
+
+// retireExpenseDebt removes a debt that the expense no longer produces.
+// Removing a shared debt is proposed to the other user.
+func retireExpenseDebt(s store.Store, debt models.Debt) error {
+	if err := requireNoTransactions(s, debt); err != nil {
+		return err
+	}
+
+	if debt.Shared {
+		status := "removed"
+		return proposeChange(s, debt, &models.DebtChange{Kind: "update", Status: &status})
+	}
+	return s.Debts().Remove(debt.UserID, debt.ID)
+}
+
+// syncExpenseDebts brings the debts of an expense in line with its splits:
+// debts still owed are revised, debts no longer owed are removed and new
+// ones are created.
+func syncExpenseDebts(s store.Store, userID int, expense models.Expense) error {
+	existing, err := listExpenseDebts(s, userID, expense.ID)
+	if err != nil {
+		return err
+	}
+
+	wanted := expenseDebts(userID, expense)
+	matched := make([]bool, len(wanted))
+	for _, debt := range existing {
+		i := slices.IndexFunc(wanted, func(want models.Debt) bool {
+			return want.ContactID == debt.ContactID && want.Direction == debt.Direction
+		})
+		if i >= 0 && !matched[i] {
+			matched[i] = true
+			if err := reviseExpenseDebt(s, debt, wanted[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := retireExpenseDebt(s, debt); err != nil {
+			return err
+		}
+	}
+
+	for i, debt := range wanted {
+		if matched[i] {
+			continue
+		}
+		contact, err := s.Contacts().Get(userID, debt.ContactID)
+		if err != nil {
+			return err
+		}
+		if err := createDebt(s, &debt, contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expenseFor loads an expense of the user's group together with its debts.
+func expenseFor(s store.Store, userID, groupID, expenseID int) (models.Expense, error) {
+	if _, err := groupFor(s, userID, groupID); err != nil {
+		return models.Expense{}, err
+	}
+
+	expense, err := s.Expenses().Get(groupID, expenseID)
+	if err == store.ErrNotFound {
+		return expense, newRequestError(http.StatusNotFound, "Expense not found")
+	} else if err != nil {
+		return expense, err
+	}
+
+	expense.Debts, err = listExpenseDebts(s, userID, expense.ID)
+	return expense, err
+}
+
+// parseExpenseIDs reads the group and expense IDs from the path.
+func parseExpenseIDs(c *gin.Context) (groupID, expenseID int, ok bool) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return 0, 0, false
+	}
+	expenseID, err = strconv.Atoi(c.Param("expense_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return 0, 0, false
+	}
+	return groupID, expenseID, true
+}
+
+// GetExpenses lists the expenses of a group, newest first. Their debts
+// are listed by GET /debts?expense_id=.
+func (h *GroupHandler) GetExpenses(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var expenses []models.Expense
+	err = h.store.WithinTx(func(s store.Store) error {
+		if _, err := groupFor(s, userID, groupID); err != nil {
+			return err
+		}
+
+		var err error
+		expenses, err = s.Expenses().List(groupID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to get expenses")
+		return
+	}
+
+	c.JSON(http.StatusOK, expenses)
+}
+
+func (h *GroupHandler) CreateExpense(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req models.ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	var expense models.Expense
+	err = h.store.WithinTx(func(s store.Store) error {
+		group, err := groupFor(s, userID, groupID)
+		if err != nil {
+			return err
+		}
+
+		currency, err := currencyFor(s, userID, req.Currency)
+		if err != nil {
+			return err
+		}
+		expense, err = buildExpense(group, req, currency)
+		if err != nil {
+			return err
+		}
+		if err := s.Expenses().Create(&expense); err != nil {
+			return err
+		}
+		if err := syncExpenseDebts(s, userID, expense); err != nil {
+			return err
+		}
+
+		expense, err = expenseFor(s, userID, groupID, expense.ID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create expense")
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+func (h *GroupHandler) GetExpense(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, expenseID, ok := parseExpenseIDs(c)
+	if !ok {
+		return
+	}
+
+	var expense models.Expense
+	err := h.store.WithinTx(func(s store.Store) error {
+		var err error
+		expense, err = expenseFor(s, userID, groupID, expenseID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to get expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// UpdateExpense replaces an expense and recomputes its debts. Changes to
+// debts shared with a linked contact are proposed to the other user, as
+// for PUT /debts/:id.
+func (h *GroupHandler) UpdateExpense(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, expenseID, ok := parseExpenseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	var expense models.Expense
+	err := h.store.WithinTx(func(s store.Store) error {
+		group, err := groupFor(s, userID, groupID)
+		if err != nil {
+			return err
+		}
+		current, err := expenseFor(s, userID, groupID, expenseID)
+		if err != nil {
+			return err
+		}
+		if req.Currency != "" && req.Currency != current.Currency {
+			return newRequestError(http.StatusBadRequest, "The currency of an expense cannot be changed")
+		}
+
+		expense, err = buildExpense(group, req, current.Currency)
+		if err != nil {
+			return err
+		}
+		expense.ID = expenseID
+		if err := s.Expenses().Update(&expense); err != nil {
+			return err
+		}
+		if err := syncExpenseDebts(s, userID, expense); err != nil {
+			return err
+		}
+
+		expense, err = expenseFor(s, userID, groupID, expenseID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// DeleteExpense deletes an expense together with the debts it produced.
+func (h *GroupHandler) DeleteExpense(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, expenseID, ok := parseExpenseIDs(c)
+	if !ok {
+		return
+	}
+
+	err := h.store.WithinTx(func(s store.Store) error {
+		expense, err := expenseFor(s, userID, groupID, expenseID)
+		if err != nil {
+			return err
+		}
+
+		for _, debt := range expense.Debts {
+			if err := retireExpenseDebt(s, debt); err != nil {
+				return err
+			}
+		}
+		return s.Expenses().Delete(groupID, expenseID)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to delete expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
+}
@@ -0,0 +1,122 @@
+// internal/handlers/expenses_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// checkExpenseDebts fails the test unless the expense's debts are those
+// wanted, given as contact ID, direction and amount in any order.
+func checkExpenseDebts(t *testing.T, expense models.Expense, want ...string) {
+	t.Helper()
+	var got []string
+	for _, debt := range expense.Debts {
+		got = append(got, fmt.Sprintf("%d %s %s", debt.ContactID, debt.Direction, debt.OriginalAmount))
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("expense %d has debts %v, want %v", expense.ID, got, want)
+	}
+}
+
+func TestExpenseDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.member(userID, "Bob", "111")
+		carol := ts.member(userID, "Carol", "222")
+		group := ts.group(userID, bob.ID, carol.ID)
+		path := fmt.Sprintf("/groups/%d/expenses", group.ID)
+
+		var expense models.Expense
+		req := models.ExpenseRequest{Description: "Groceries", Amount: money(t, "100"), SplitType: models.SplitEqual}
+		ts.expect(ts.do(userID, "POST", path, req), http.StatusCreated, &expense)
+		checkExpenseDebts(t, expense,
+			fmt.Sprintf("%d owe_from 33.33", bob.ID), fmt.Sprintf("%d owe_from 33.33", carol.ID))
+		expensePath := fmt.Sprintf("%s/%d", path, expense.ID)
+
+		req.Amount = money(t, "90")
+		req.PaidBy = &bob.ID
+		ts.expect(ts.do(userID, "PUT", expensePath, req), http.StatusOK, &expense)
+		checkExpenseDebts(t, expense, fmt.Sprintf("%d owe_to 30.00", bob.ID))
+
+		var expenses []models.Expense
+		ts.expect(ts.do(userID, "GET", path, nil), http.StatusOK, &expenses)
+		if len(expenses) != 1 || expenses[0].Amount.String() != "90.00" {
+			t.Errorf("expenses are %+v", expenses)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", expensePath, nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "GET", expensePath, nil), http.StatusNotFound, nil)
+		var debts models.Page[models.Debt]
+		ts.expect(ts.do(userID, "GET", "/debts", nil), http.StatusOK, &debts)
+		if len(debts.Data) != 0 {
+			t.Errorf("debts of the deleted expense are still listed: %+v", debts.Data)
+		}
+	})
+}
+
+func TestDeletedGroupKeepsItsDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.member(userID, "Bob", "111")
+		group := ts.group(userID, bob.ID)
+
+		var expense models.Expense
+		req := models.ExpenseRequest{Description: "Taxi", Amount: money(t, "50"), SplitType: models.SplitEqual}
+		ts.expect(ts.do(userID, "POST", fmt.Sprintf("/groups/%d/expenses", group.ID), req), http.StatusCreated, &expense)
+		if len(expense.Debts) != 1 {
+			t.Fatalf("expense has debts %+v", expense.Debts)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("/groups/%d", group.ID), nil), http.StatusOK, nil)
+		debt := ts.getDebt(userID, expense.Debts[0].ID)
+		checkBalance(t, debt, "25.00", "active")
+		if debt.ExpenseID != nil {
+			t.Errorf("debt still points at expense %d", *debt.ExpenseID)
+		}
+	})
+}
+
+func TestExpenseErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.member(userID, "Bob", "111")
+		carol := ts.member(userID, "Carol", "222")
+		group := ts.group(userID, bob.ID)
+		path := fmt.Sprintf("/groups/%d/expenses", group.ID)
+
+		valid := models.ExpenseRequest{Description: "Dinner", Amount: money(t, "60"), SplitType: models.SplitEqual}
+		var expense models.Expense
+		ts.expect(ts.do(userID, "POST", path, valid), http.StatusCreated, &expense)
+		expensePath := fmt.Sprintf("%s/%d", path, expense.ID)
+
+		for _, req := range []models.ExpenseRequest{
+			{Amount: money(t, "60"), SplitType: models.SplitEqual},
+			{Description: "Dinner", SplitType: models.SplitEqual},
+			{Description: "Dinner", Amount: money(t, "60"), SplitType: "halves"},
+			{Description: "Dinner", Amount: money(t, "60"), SplitType: models.SplitEqual, PaidBy: &carol.ID},
+			{Description: "Dinner", Amount: money(t, "60"), SplitType: models.SplitExact,
+				Splits: []models.ExpenseSplit{{Amount: money(t, "10")}, {ContactID: &bob.ID, Amount: money(t, "10")}}},
+		} {
+			ts.expect(ts.do(userID, "POST", path, req), http.StatusBadRequest, nil)
+		}
+
+		ts.expect(ts.do(userID, "GET", path+"/abc", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "GET", path+"/999", nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "GET", expensePath, nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "POST", path, valid), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("/groups/%d/members/%d", group.ID, bob.ID), nil), http.StatusConflict, nil)
+
+		ts.pay(userID, expense.Debts[0].ID, "10", "received_back")
+		valid.Amount = money(t, "80")
+		ts.expect(ts.do(userID, "PUT", expensePath, valid), http.StatusConflict, nil)
+		ts.expect(ts.do(userID, "DELETE", expensePath, nil), http.StatusConflict, nil)
+	})
+}
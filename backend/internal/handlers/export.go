@@ -0,0 +1,245 @@
+// internal/handlers/export.go
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"debt-tracker-backend/internal/export"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The columns of the exports. Columns are only ever added at the end, so
+// that spreadsheets and scripts reading them keep working.
+var (
+	debtColumns = []export.Column{
+		{Name: "id", Kind: export.Integer},
+		{Name: "contact_id", Kind: export.Integer},
+		{Name: "contact_name", Kind: export.Text},
+		{Name: "direction", Kind: export.Text},
+		{Name: "status", Kind: export.Text},
+		{Name: "currency", Kind: export.Text},
+		{Name: "original_amount", Kind: export.Amount},
+		{Name: "paid_amount", Kind: export.Amount},
+		{Name: "balance", Kind: export.Amount},
+		{Name: "description", Kind: export.Text},
+		{Name: "confirmation", Kind: export.Text},
+		{Name: "shared", Kind: export.Bool},
+		{Name: "expense_id", Kind: export.Integer},
+		{Name: "due_date", Kind: export.Date},
+		{Name: "overdue", Kind: export.Bool},
+		{Name: "overdue_amount", Kind: export.Amount},
+		{Name: "days_overdue", Kind: export.Integer},
+		{Name: "interest_rate", Kind: export.Text},
+		{Name: "created_at", Kind: export.Timestamp},
+		{Name: "updated_at", Kind: export.Timestamp},
+		{Name: "removed_at", Kind: export.Timestamp},
+	}
+
+	transactionColumns = []export.Column{
+		{Name: "id", Kind: export.Integer},
+		{Name: "debt_id", Kind: export.Integer},
+		{Name: "transaction_type", Kind: export.Text},
+		{Name: "amount", Kind: export.Amount},
+		{Name: "currency", Kind: export.Text},
+		{Name: "description", Kind: export.Text},
+		{Name: "confirmation", Kind: export.Text},
+		{Name: "interest", Kind: export.Bool},
+		{Name: "created_at", Kind: export.Timestamp},
+	}
+
+	contactColumns = []export.Column{
+		{Name: "id", Kind: export.Integer},
+		{Name: "name", Kind: export.Text},
+		{Name: "phone", Kind: export.Text},
+		{Name: "email", Kind: export.Text},
+		{Name: "is_active", Kind: export.Bool},
+		{Name: "linked", Kind: export.Bool},
+		{Name: "created_at", Kind: export.Timestamp},
+		{Name: "updated_at", Kind: export.Timestamp},
+	}
+)
+
+type ExportHandler struct {
+	store store.Store
+}
+
+func NewExportHandler(s store.Store) *ExportHandler {
+	return &ExportHandler{store: s}
+}
+
+// exportOptions are the format of an export and, for CSV, its separators.
+type exportOptions struct {
+	format string
+	csv    export.CSVOptions
+}
+
+// parseExportOptions reads the format, locale, grouping and delimiter
+// query parameters.
+func parseExportOptions(c *gin.Context) (exportOptions, error) {
+	var query models.ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return exportOptions{}, err
+	}
+
+	opts := exportOptions{format: query.Format}
+	if opts.format == "" {
+		opts.format = "csv"
+	}
+	if query.Locale == "" {
+		query.Locale = "en"
+	}
+	numbers, err := export.LocaleFormat(query.Locale)
+	if err != nil {
+		return opts, err
+	}
+	opts.csv = export.CSVOptions{Numbers: numbers, Grouping: query.Grouping}
+
+	switch query.Delimiter {
+	case "comma":
+		opts.csv.Delimiter = ','
+	case "semicolon":
+		opts.csv.Delimiter = ';'
+	case "tab":
+		opts.csv.Delimiter = '\t'
+	default:
+		opts.csv.Delimiter = ','
+		if numbers.Decimal == "," {
+			opts.csv.Delimiter = ';'
+		}
+	}
+	return opts, nil
+}
+
+// streamExport writes the items of every page of list as an export named
+// after name. The first page is fetched before anything is sent, so a bad
+// filter or sort is still answered with an error; after that the file is
+// written one page at a time, and a failure can only cut it short.
+func streamExport[T any](c *gin.Context, name string, columns []export.Column, list func(opts store.ListOptions) (models.Page[T], error), row func(item T) []interface{}) {
+	opts, err := parseExportOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort := store.ListOptions{Sort: c.Query("sort"), Order: c.Query("order")}
+
+	var w export.Writer
+	err = store.Each(sort, list, func(page []T) error {
+		if w == nil {
+			var err error
+			if w, err = opts.writer(c, name, columns); err != nil {
+				return err
+			}
+		}
+		for _, item := range page {
+			if err := w.Write(row(item)); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if w == nil {
+			respondListError(c, err, "Failed to export "+name)
+			return
+		}
+		log.Printf("Export of %s for user %d failed: %v", name, c.GetInt("user_id"), err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("Export of %s for user %d failed: %v", name, c.GetInt("user_id"), err)
+	}
+}
+
+// writer sends the headers of the download and returns the writer of its
+// rows.
+func (opts exportOptions) writer(c *gin.Context, name string, columns []export.Column) (export.Writer, error) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102"), opts.format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Status(http.StatusOK)
+	if opts.format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		return export.NewXLSX(c.Writer, name, columns)
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	return export.NewCSV(c.Writer, columns, opts.csv)
+}
+
+// ExportDebts exports the debts matching the filters of GET /debts.
+func (h *ExportHandler) ExportDebts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	filter, err := parseDebtFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamExport(c, "debts", debtColumns, func(opts store.ListOptions) (models.Page[models.Debt], error) {
+		return h.store.Debts().List(userID, filter, opts)
+	}, func(debt models.Debt) []interface{} {
+		var contactName, interestRate *string
+		if debt.Contact != nil {
+			contactName = &debt.Contact.Name
+		}
+		if debt.Interest != nil {
+			interestRate = &debt.Interest.Rate
+		}
+		return []interface{}{
+			debt.ID, debt.ContactID, contactName, debt.Direction, debt.Status, debt.Currency,
+			debt.OriginalAmount, debt.PaidAmount, debt.Balance, debt.Description, debt.Confirmation,
+			debt.Shared, debt.ExpenseID, debt.DueDate, debt.Overdue, debt.OverdueAmount, debt.DaysOverdue,
+			interestRate, debt.CreatedAt, debt.UpdatedAt, debt.RemovedAt,
+		}
+	})
+}
+
+// ExportTransactions exports the transactions matching the filters of
+// GET /transactions.
+func (h *ExportHandler) ExportTransactions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamExport(c, "transactions", transactionColumns, func(opts store.ListOptions) (models.Page[models.Transaction], error) {
+		return h.store.Transactions().List(userID, filter, opts)
+	}, func(transaction models.Transaction) []interface{} {
+		return []interface{}{
+			transaction.ID, transaction.DebtID, transaction.TransactionType, transaction.Amount,
+			transaction.Currency, transaction.Description, transaction.Confirmation, transaction.Interest,
+			transaction.CreatedAt,
+		}
+	})
+}
+
+// ExportContacts exports the contacts matching the filters of
+// GET /contacts.
+func (h *ExportHandler) ExportContacts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	filter, err := parseContactFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamExport(c, "contacts", contactColumns, func(opts store.ListOptions) (models.Page[models.Contact], error) {
+		return h.store.Contacts().List(userID, filter, opts)
+	}, func(contact models.Contact) []interface{} {
+		return []interface{}{
+			contact.ID, contact.Name, contact.Phone, contact.Email, contact.IsActive,
+			contact.LinkedUserID != nil, contact.CreatedAt, contact.UpdatedAt,
+		}
+	})
+}
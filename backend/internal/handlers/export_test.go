@@ -0,0 +1,111 @@
+// internal/handlers/export_test.go
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// exportCSV downloads an export as CSV and returns its records, header
+// first.
+func (ts *testServer) exportCSV(userID int, path string, delimiter rune) [][]string {
+	ts.t.Helper()
+	recorder := ts.do(userID, "GET", path, nil)
+	ts.expect(recorder, http.StatusOK, nil)
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		ts.t.Errorf("export is %s, want text/csv", contentType)
+	}
+	reader := csv.NewReader(recorder.Body)
+	reader.Comma = delimiter
+	records, err := reader.ReadAll()
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return records
+}
+
+func TestExportDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.contact(userID, "Bob")
+		big := ts.debt(userID, bob.ID, "1234.56", "owe_from")
+		ts.debt(userID, bob.ID, "5", "owe_to")
+		ts.debt(otherID, ts.contact(otherID, "Eve").ID, "7", "owe_to")
+
+		records := ts.exportCSV(userID, "/export/debts?direction=owe_from&locale=de&grouping=true", ';')
+		if len(records) != 2 {
+			t.Fatalf("export has %d records, want a header and one debt", len(records))
+		}
+		header, row := records[0], records[1]
+		if len(header) != len(debtColumns) || header[0] != "id" || header[len(header)-1] != "removed_at" {
+			t.Errorf("header is %v", header)
+		}
+		column := func(name string) string {
+			for i, field := range header {
+				if field == name {
+					return row[i]
+				}
+			}
+			t.Fatalf("no %s column", name)
+			return ""
+		}
+		if column("id") != fmt.Sprint(big.ID) || column("contact_name") != "Bob" || column("original_amount") != "1.234,56" {
+			t.Errorf("debt row is %v", row)
+		}
+
+		// Every debt is exported, however many pages the list takes.
+		if records := ts.exportCSV(userID, "/export/debts?limit=1&sort=amount&order=asc", ','); len(records) != 3 || records[1][7] != "0.00" {
+			t.Errorf("export of all debts is %v", records)
+		}
+
+		recorder := ts.do(userID, "GET", "/export/debts?format=xlsx", nil)
+		ts.expect(recorder, http.StatusOK, nil)
+		if disposition := recorder.Header().Get("Content-Disposition"); !strings.Contains(disposition, ".xlsx") {
+			t.Errorf("xlsx download is named %q", disposition)
+		}
+		if !strings.HasPrefix(recorder.Body.String(), "PK") {
+			t.Error("xlsx download is not a zip archive")
+		}
+	})
+}
+
+func TestExportTransactionsAndContacts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		ts.member(userID, "=HYPERLINK(\"http://evil.test\")", "+27820000001")
+		debt := ts.debt(userID, bob.ID, "100", "owe_from")
+		ts.pay(userID, debt.ID, "40", "received_back")
+
+		records := ts.exportCSV(userID, fmt.Sprintf("/export/transactions?debt_id=%d&delimiter=tab", debt.ID), '\t')
+		if len(records) != 2 || records[1][2] != "received_back" || records[1][3] != "40.00" {
+			t.Errorf("transaction export is %v", records)
+		}
+
+		records = ts.exportCSV(userID, "/export/contacts?sort=name", ',')
+		if len(records) != 3 || !strings.HasPrefix(records[1][1], "'=") || records[2][1] != "Bob" {
+			t.Errorf("contact export is %v", records)
+		}
+	})
+}
+
+func TestExportErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		for _, path := range []string{
+			"/export/debts?format=pdf",
+			"/export/debts?locale=xx",
+			"/export/debts?delimiter=pipe",
+			"/export/debts?sort=colour",
+			"/export/debts?direction=sideways",
+			"/export/transactions?min_amount=lots",
+			"/export/contacts?order=up",
+		} {
+			ts.expect(ts.do(userID, "GET", path, nil), http.StatusBadRequest, nil)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+// internal/handlers/groups.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GroupHandler struct {
+	store store.Store
+}
+
+func NewGroupHandler(s store.Store) *GroupHandler {
+	return &GroupHandler{store: s}
+}
+
+// groupFor loads one of the user's groups, answering 404 if there is none.
+func groupFor(s store.Store, userID, groupID int) (models.Group, error) {
+	group, err := s.Groups().Get(userID, groupID)
+	if err == store.ErrNotFound {
+		return group, newRequestError(http.StatusNotFound, "Group not found")
+	}
+	return group, err
+}
+
+// addGroupMember adds one of the user's active contacts to the group.
+func addGroupMember(s store.Store, userID, groupID, contactID int) error {
+	contact, err := s.Contacts().Get(userID, contactID)
+	if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+		return newRequestError(http.StatusBadRequest, "Contact not found")
+	} else if err != nil {
+		return err
+	}
+	return s.Groups().AddMember(groupID, contactID)
+}
+
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	groups, err := h.store.Groups().List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group models.Group
+	err := h.store.WithinTx(func(s store.Store) error {
+		group = models.Group{
+			UserID:      userID,
+			Name:        req.Name,
+			Description: &req.Description,
+		}
+		if err := s.Groups().Create(&group); err != nil {
+			return err
+		}
+
+		for _, contactID := range req.ContactIDs {
+			// A contact listed twice is simply already a member.
+			if err := addGroupMember(s, userID, group.ID, contactID); err != nil && err != store.ErrNotFound {
+				return err
+			}
+		}
+
+		var err error
+		group, err = s.Groups().Get(userID, group.ID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	group, err := h.store.Groups().Get(userID, groupID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req models.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := models.Group{
+		ID:          groupID,
+		UserID:      userID,
+		Name:        req.Name,
+		Description: &req.Description,
+	}
+	err = h.store.Groups().Update(&group)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup deletes the group and its expenses. The debts split from the
+// expenses stay as ordinary debts.
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	err = h.store.Groups().Delete(userID, groupID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+func (h *GroupHandler) AddGroupMember(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req models.GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group models.Group
+	err = h.store.WithinTx(func(s store.Store) error {
+		if _, err := groupFor(s, userID, groupID); err != nil {
+			return err
+		}
+
+		err := addGroupMember(s, userID, groupID, req.ContactID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Contact is already a member of the group")
+		} else if err != nil {
+			return err
+		}
+
+		group, err = s.Groups().Get(userID, groupID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to add group member")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// RemoveGroupMember takes a contact out of the group, as long as none of
+// the group's expenses involve them.
+func (h *GroupHandler) RemoveGroupMember(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	contactID, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var group models.Group
+	err = h.store.WithinTx(func(s store.Store) error {
+		if _, err := groupFor(s, userID, groupID); err != nil {
+			return err
+		}
+
+		expenses, err := s.Expenses().List(groupID)
+		if err != nil {
+			return err
+		}
+		for _, expense := range expenses {
+			if expenseInvolves(expense, contactID) {
+				return newRequestError(http.StatusConflict, "Member has expenses in this group; change or delete them first")
+			}
+		}
+
+		if err := s.Groups().RemoveMember(groupID, contactID); err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Member not found")
+		} else if err != nil {
+			return err
+		}
+
+		group, err = s.Groups().Get(userID, groupID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to remove group member")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
@@ -0,0 +1,95 @@
+// internal/handlers/groups_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// member creates a contact of the user with a phone number of its own, so
+// that the user can have several of them.
+func (ts *testServer) member(userID int, name, phone string) models.Contact {
+	ts.t.Helper()
+	var contact models.Contact
+	req := models.CreateContactRequest{Name: name, Phone: phone}
+	ts.expect(ts.do(userID, "POST", "/contacts", req), http.StatusCreated, &contact)
+	return contact
+}
+
+// group creates a group of the user with the contacts as members.
+func (ts *testServer) group(userID int, contactIDs ...int) models.Group {
+	ts.t.Helper()
+	var group models.Group
+	req := models.CreateGroupRequest{Name: "Flat", ContactIDs: contactIDs}
+	ts.expect(ts.do(userID, "POST", "/groups", req), http.StatusCreated, &group)
+	return group
+}
+
+func memberIDs(group models.Group) []int {
+	ids := make([]int, len(group.Members))
+	for i, member := range group.Members {
+		ids[i] = member.ID
+	}
+	return ids
+}
+
+func TestGroupMembers(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.member(userID, "Bob", "111")
+		carol := ts.member(userID, "Carol", "222")
+
+		group := ts.group(userID, bob.ID, bob.ID)
+		if ids := memberIDs(group); len(ids) != 1 || ids[0] != bob.ID {
+			t.Fatalf("group has members %v, want [%d]", ids, bob.ID)
+		}
+		path := fmt.Sprintf("/groups/%d", group.ID)
+
+		ts.expect(ts.do(userID, "POST", path+"/members", models.GroupMemberRequest{ContactID: carol.ID}), http.StatusOK, &group)
+		if len(group.Members) != 2 {
+			t.Fatalf("group has members %v after adding Carol", memberIDs(group))
+		}
+
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("%s/members/%d", path, bob.ID), nil), http.StatusOK, nil)
+		var got models.Group
+		ts.expect(ts.do(userID, "GET", path, nil), http.StatusOK, &got)
+		if ids := memberIDs(got); len(ids) != 1 || ids[0] != carol.ID {
+			t.Errorf("group has members %v, want [%d]", ids, carol.ID)
+		}
+
+		ts.expect(ts.do(userID, "PUT", path, models.UpdateGroupRequest{Name: "House"}), http.StatusOK, &got)
+		var groups []models.Group
+		ts.expect(ts.do(userID, "GET", "/groups", nil), http.StatusOK, &groups)
+		if len(groups) != 1 || groups[0].Name != "House" {
+			t.Errorf("groups are %+v", groups)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", path, nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "GET", path, nil), http.StatusNotFound, nil)
+	})
+}
+
+func TestGroupErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.member(userID, "Bob", "111")
+		group := ts.group(userID, bob.ID)
+		path := fmt.Sprintf("/groups/%d", group.ID)
+
+		ts.expect(ts.do(userID, "POST", "/groups", models.CreateGroupRequest{}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "POST", "/groups", models.CreateGroupRequest{Name: "Flat", ContactIDs: []int{999}}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "GET", "/groups/abc", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "POST", path+"/members", models.GroupMemberRequest{ContactID: bob.ID}), http.StatusConflict, nil)
+		ts.expect(ts.do(userID, "DELETE", path+"/members/999", nil), http.StatusNotFound, nil)
+
+		ts.expect(ts.do(otherID, "GET", path, nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "PUT", path, models.UpdateGroupRequest{Name: "Mine"}), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "DELETE", path, nil), http.StatusNotFound, nil)
+		mallorys := ts.member(otherID, "Bob", "111")
+		ts.expect(ts.do(userID, "POST", path+"/members", models.GroupMemberRequest{ContactID: mallorys.ID}), http.StatusBadRequest, nil)
+	})
+}
@@ -0,0 +1,417 @@
+// internal/handlers/handlers_test.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/middleware"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testServer serves the handlers over a store. The user of a request is
+// given by its bearer token, or by its X-User-ID header if it has none.
+type testServer struct {
+	t      *testing.T
+	store  store.Store
+	router *gin.Engine
+	outbox *outbox
+}
+
+// outbox is a mailer that keeps the messages sent through it.
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// count returns the number of messages sent to the address.
+func (o *outbox) count(to string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, msg := range o.messages {
+		if msg.To == to {
+			n++
+		}
+	}
+	return n
+}
+
+// token returns the value of param in the link of the last message sent
+// to the address.
+func (o *outbox) token(t *testing.T, to, param string) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To != to {
+			continue
+		}
+		for _, field := range strings.Fields(o.messages[i].Body) {
+			if link, err := url.Parse(field); err == nil && link.Query().Get(param) != "" {
+				return link.Query().Get(param)
+			}
+		}
+	}
+	t.Fatalf("no message to %s with a %s link", to, param)
+	return ""
+}
+
+// testLockoutThreshold is the number of failed logins that locks an
+// address in the tests.
+const testLockoutThreshold = 3
+
+func newTestServer(t *testing.T, st store.Store) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keys, err := auth.LoadKeys(auth.KeyOptions{
+		Algorithm: "HS256",
+		Secret:    "test-secret",
+		Issuer:    "debt-tracker-test",
+		Audience:  "debt-tracker-test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	authRequired := middleware.AuthRequired(keys, st.Tokens())
+	apiAuthRequired := middleware.AuthOrAPIKeyRequired(keys, st.Tokens(), st.APIKeys())
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			// As in main, only the resources take API keys
+			if strings.HasPrefix(c.Request.URL.Path, "/auth/") {
+				authRequired(c)
+			} else {
+				apiAuthRequired(c)
+			}
+			return
+		}
+		userID, _ := strconv.Atoi(c.GetHeader("X-User-ID"))
+		c.Set("user_id", userID)
+	})
+
+	sent := &outbox{}
+	authHandler := NewAuthHandler(st, AuthConfig{
+		Keys:         keys,
+		AccessTTL:    15 * time.Minute,
+		RefreshTTL:   24 * time.Hour,
+		ActionTokens: auth.NewActionTokens("test-action-secret"),
+		Mailer:       sent,
+		AppURL:       "http://app.test",
+		Lockout:      LockoutConfig{Threshold: testLockoutThreshold, BaseDelay: time.Minute, MaxDelay: time.Hour},
+	})
+	contactHandler := NewContactHandler(st)
+	debtHandler := NewDebtHandler(st)
+	transactionHandler := NewTransactionHandler(st)
+	apiKeyHandler := NewAPIKeyHandler(st)
+	inviteHandler := NewInviteHandler(st, sent, "http://app.test")
+	groupHandler := NewGroupHandler(st)
+	scheduleHandler := NewScheduleHandler(st)
+	exportHandler := NewExportHandler(st)
+	importHandler := NewImportHandler(st)
+
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+	router.POST("/auth/register", authHandler.Register)
+	router.POST("/auth/login", authHandler.Login)
+	router.POST("/auth/login/2fa", authHandler.LoginTwoFactor)
+	router.GET("/auth/2fa", authHandler.GetTwoFactor)
+	router.POST("/auth/2fa/setup", authHandler.SetupTwoFactor)
+	router.POST("/auth/2fa/enable", authHandler.EnableTwoFactor)
+	router.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+	router.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	router.POST("/auth/refresh", authHandler.Refresh)
+	router.POST("/auth/logout", authHandler.Logout)
+	router.POST("/auth/logout-all", authHandler.LogoutAll)
+	router.GET("/auth/me", authHandler.GetProfile)
+	router.PUT("/auth/me", authHandler.UpdateProfile)
+	router.PATCH("/auth/me", authHandler.UpdateProfile)
+	router.DELETE("/auth/me", authHandler.DeleteAccount)
+	router.GET("/auth/me/export", authHandler.ExportAccount)
+	router.POST("/auth/confirm-email", authHandler.ConfirmEmail)
+	router.POST("/auth/verify-email", authHandler.VerifyEmail)
+	router.POST("/auth/verify-email/send", authHandler.SendVerificationEmail)
+	router.POST("/auth/forgot-password", authHandler.ForgotPassword)
+	router.POST("/auth/reset-password", authHandler.ResetPassword)
+	router.POST("/auth/change-password", authHandler.ChangePassword)
+	router.GET("/auth/api-keys", apiKeyHandler.GetAPIKeys)
+	router.POST("/auth/api-keys", apiKeyHandler.CreateAPIKey)
+	router.DELETE("/auth/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+	contacts := router.Group("/contacts", middleware.RequireScope("contacts"))
+	contacts.GET("", contactHandler.GetContacts)
+	contacts.POST("", contactHandler.CreateContact)
+	contacts.GET("/:id", contactHandler.GetContact)
+	contacts.PUT("/:id", contactHandler.UpdateContact)
+	contacts.DELETE("/:id", contactHandler.DeleteContact)
+	contacts.POST("/:id/invite", inviteHandler.InviteContact)
+
+	invites := router.Group("/invites", middleware.RequireScope("contacts"))
+	invites.GET("", inviteHandler.GetInvites)
+	invites.POST("/:id/accept", inviteHandler.AcceptInvite)
+	invites.POST("/:id/decline", inviteHandler.DeclineInvite)
+	invites.DELETE("/:id", inviteHandler.CancelInvite)
+
+	debts := router.Group("/debts", middleware.RequireScope("debts"))
+	debts.GET("", debtHandler.GetDebts)
+	debts.POST("", debtHandler.CreateDebt)
+	debts.GET("/summary", debtHandler.GetDebtSummary)
+	debts.GET("/settlement-plan", debtHandler.GetSettlementPlan)
+	debts.POST("/settle", middleware.RequireScope("transactions"), debtHandler.Settle)
+	debts.GET("/:id", debtHandler.GetDebt)
+	debts.PUT("/:id", debtHandler.UpdateDebt)
+	debts.PUT("/:id/due-date", debtHandler.SetDueDate)
+	debts.GET("/:id/interest", debtHandler.GetInterest)
+	debts.PUT("/:id/interest", debtHandler.SetInterest)
+	debts.POST("/:id/amortization", debtHandler.PreviewAmortization)
+	debts.DELETE("/:id", debtHandler.DeleteDebt)
+	debts.POST("/:id/restore", debtHandler.RestoreDebt)
+	debts.POST("/:id/confirm", debtHandler.ConfirmDebt)
+	debts.POST("/:id/dispute", debtHandler.DisputeDebt)
+	debts.GET("/:id/transactions", middleware.RequireScope("transactions"), transactionHandler.GetDebtTransactions)
+	debts.GET("/:id/changes", debtHandler.GetDebtChanges)
+	debts.POST("/:id/changes/:change_id/accept", debtHandler.AcceptDebtChange)
+	debts.POST("/:id/changes/:change_id/reject", debtHandler.RejectDebtChange)
+
+	groups := router.Group("/groups", middleware.RequireScope("debts"))
+	groups.GET("", groupHandler.GetGroups)
+	groups.POST("", groupHandler.CreateGroup)
+	groups.GET("/:id", groupHandler.GetGroup)
+	groups.PUT("/:id", groupHandler.UpdateGroup)
+	groups.DELETE("/:id", groupHandler.DeleteGroup)
+	groups.POST("/:id/members", groupHandler.AddGroupMember)
+	groups.DELETE("/:id/members/:contact_id", groupHandler.RemoveGroupMember)
+	groups.GET("/:id/expenses", groupHandler.GetExpenses)
+	groups.POST("/:id/expenses", groupHandler.CreateExpense)
+	groups.GET("/:id/expenses/:expense_id", groupHandler.GetExpense)
+	groups.PUT("/:id/expenses/:expense_id", groupHandler.UpdateExpense)
+	groups.DELETE("/:id/expenses/:expense_id", groupHandler.DeleteExpense)
+
+	schedules := router.Group("/schedules", middleware.RequireScope("debts"), middleware.RequireScope("transactions"))
+	schedules.GET("", scheduleHandler.GetSchedules)
+	schedules.POST("", scheduleHandler.CreateSchedule)
+	schedules.GET("/:id", scheduleHandler.GetSchedule)
+	schedules.PUT("/:id", scheduleHandler.UpdateSchedule)
+	schedules.DELETE("/:id", scheduleHandler.DeleteSchedule)
+	schedules.POST("/:id/pause", scheduleHandler.PauseSchedule)
+	schedules.POST("/:id/resume", scheduleHandler.ResumeSchedule)
+	schedules.GET("/:id/occurrences", scheduleHandler.GetOccurrences)
+	schedules.PUT("/:id/occurrences/:date", scheduleHandler.UpdateOccurrence)
+	schedules.POST("/:id/occurrences/:date/skip", scheduleHandler.SkipOccurrence)
+	schedules.DELETE("/:id/occurrences/:date", scheduleHandler.ResetOccurrence)
+
+	transactions := router.Group("/transactions", middleware.RequireScope("transactions"))
+	transactions.GET("", transactionHandler.GetTransactions)
+	transactions.POST("", transactionHandler.CreateTransaction)
+	transactions.GET("/:id", transactionHandler.GetTransaction)
+	transactions.DELETE("/:id", transactionHandler.DeleteTransaction)
+	transactions.POST("/:id/confirm", transactionHandler.ConfirmTransaction)
+	transactions.POST("/:id/dispute", transactionHandler.DisputeTransaction)
+
+	exports := router.Group("/export")
+	exports.GET("/debts", middleware.RequireScope("debts"), exportHandler.ExportDebts)
+	exports.GET("/transactions", middleware.RequireScope("transactions"), exportHandler.ExportTransactions)
+	exports.GET("/contacts", middleware.RequireScope("contacts"), exportHandler.ExportContacts)
+
+	imports := router.Group("/imports", middleware.RequireScope("contacts"), middleware.RequireScope("debts"), middleware.RequireScope("transactions"))
+	imports.GET("/profiles", importHandler.GetImportProfiles)
+	imports.POST("/profiles", importHandler.CreateImportProfile)
+	imports.GET("/profiles/:id", importHandler.GetImportProfile)
+	imports.PUT("/profiles/:id", importHandler.UpdateImportProfile)
+	imports.DELETE("/profiles/:id", importHandler.DeleteImportProfile)
+	imports.POST("/preview", importHandler.PreviewImport)
+	imports.POST("", importHandler.Import)
+
+	return &testServer{t: t, store: st, router: router, outbox: sent}
+}
+
+// on returns the server for a subtest.
+func (ts *testServer) on(t *testing.T) *testServer {
+	server := *ts
+	server.t = t
+	return &server
+}
+
+// do sends a request as the user, with the body encoded as JSON, and
+// returns the response.
+func (ts *testServer) do(userID int, method, path string, body interface{}) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	return ts.send(userID, ts.request(method, path, body))
+}
+
+// request builds a request with the body encoded as JSON.
+func (ts *testServer) request(method, path string, body interface{}) *http.Request {
+	ts.t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func (ts *testServer) send(userID int, req *http.Request) *httptest.ResponseRecorder {
+	req.Header.Set("X-User-ID", strconv.Itoa(userID))
+	recorder := httptest.NewRecorder()
+	ts.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// doWithToken sends a request authenticated by the access token.
+func (ts *testServer) doWithToken(token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.request(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	ts.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// expect fails the test unless the response has the status, and decodes
+// its body into out if out is not nil.
+func (ts *testServer) expect(recorder *httptest.ResponseRecorder, status int, out interface{}) {
+	ts.t.Helper()
+	if recorder.Code != status {
+		ts.t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			ts.t.Fatalf("decoding %s: %v", recorder.Body, err)
+		}
+	}
+}
+
+// user creates a user with the name.
+func (ts *testServer) user(name string) int {
+	ts.t.Helper()
+	user := models.User{Email: name + "@example.com", PasswordHash: "x", Name: name}
+	if err := ts.store.Users().Create(&user); err != nil {
+		ts.t.Fatal(err)
+	}
+	return user.ID
+}
+
+// contact creates a contact of the user.
+func (ts *testServer) contact(userID int, name string) models.Contact {
+	ts.t.Helper()
+	var contact models.Contact
+	ts.expect(ts.do(userID, "POST", "/contacts", models.CreateContactRequest{Name: name}), http.StatusCreated, &contact)
+	return contact
+}
+
+// link gives each user a contact linked to the other, so that the debts
+// between them are shared, and returns them.
+func (ts *testServer) link(userID, otherID int) (models.Contact, models.Contact) {
+	ts.t.Helper()
+	var contacts [2]models.Contact
+	for i, ids := range [][2]int{{userID, otherID}, {otherID, userID}} {
+		contacts[i] = ts.contact(ids[0], fmt.Sprintf("user %d", ids[1]))
+		if err := ts.store.Contacts().Link(ids[0], contacts[i].ID, ids[1]); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	return contacts[0], contacts[1]
+}
+
+// debt opens a debt of the user with the contact.
+func (ts *testServer) debt(userID, contactID int, amount, direction string) models.Debt {
+	ts.t.Helper()
+	req := models.CreateDebtRequest{ContactID: contactID, Amount: money(ts.t, amount), Direction: direction}
+	var debt models.Debt
+	ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusCreated, &debt)
+	return debt
+}
+
+// getDebt returns the debt as the user sees it.
+func (ts *testServer) getDebt(userID, debtID int) models.Debt {
+	ts.t.Helper()
+	var debt models.Debt
+	ts.expect(ts.do(userID, "GET", fmt.Sprintf("/debts/%d", debtID), nil), http.StatusOK, &debt)
+	return debt
+}
+
+// counterpart returns the ID of the other user's side of a shared debt.
+func (ts *testServer) counterpart(userID, debtID int) int {
+	ts.t.Helper()
+	debt, err := ts.store.Debts().Get(userID, debtID)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if debt.CounterpartID == nil {
+		ts.t.Fatalf("debt %d is not shared", debtID)
+	}
+	return *debt.CounterpartID
+}
+
+// mirror returns the ID of the other user's side of a shared transaction.
+func (ts *testServer) mirror(userID, transactionID int) int {
+	ts.t.Helper()
+	transaction, err := ts.store.Transactions().Get(userID, transactionID)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if transaction.CounterpartID == nil {
+		ts.t.Fatalf("transaction %d is not shared", transactionID)
+	}
+	return *transaction.CounterpartID
+}
+
+// confirm has the other user confirm their side of a shared transaction.
+func (ts *testServer) confirm(userID, otherID, transactionID int) {
+	ts.t.Helper()
+	mirror := ts.mirror(userID, transactionID)
+	ts.expect(ts.do(otherID, "POST", fmt.Sprintf("/transactions/%d/confirm", mirror), nil), http.StatusOK, nil)
+}
+
+// pay records a transaction of the type on the user's debt.
+func (ts *testServer) pay(userID, debtID int, amount, transactionType string) models.Transaction {
+	ts.t.Helper()
+	req := models.CreateTransactionRequest{DebtID: debtID, Amount: money(ts.t, amount), TransactionType: transactionType}
+	var transaction models.Transaction
+	ts.expect(ts.do(userID, "POST", "/transactions", req), http.StatusCreated, &transaction)
+	return transaction
+}
+
+func money(t *testing.T, amount string) models.Money {
+	t.Helper()
+	m, err := models.ParseMoney(amount, "ZAR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// checkBalance fails the test unless the debt has the balance and status.
+func checkBalance(t *testing.T, debt models.Debt, balance, status string) {
+	t.Helper()
+	if debt.Balance.String() != balance || debt.Status != status {
+		t.Errorf("debt %d has a balance of %s and is %s, want %s and %s",
+			debt.ID, debt.Balance, debt.Status, balance, status)
+	}
+}
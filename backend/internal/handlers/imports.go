@@ -0,0 +1,850 @@
+// internal/handlers/imports.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/importer"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxImportSize is the largest upload accepted by the import endpoints.
+const maxImportSize = 5 << 20
+
+// errDryRun rolls back the store transaction of a preview.
+var errDryRun = errors.New("dry run")
+
+type ImportHandler struct {
+	store store.Store
+}
+
+func NewImportHandler(s store.Store) *ImportHandler {
+	return &ImportHandler{store: s}
+}
+
+// currencyCode validates a currency code with the same rule as requests,
+// so rows in a currency without cents are refused as well.
+type currencyCode struct {
+	Code string `binding:"iso4217,currency"`
+}
+
+func validCurrency(code string) bool {
+	return binding.Validator.ValidateStruct(currencyCode{Code: code}) == nil
+}
+
+// validateMapping checks that the mapping can be applied to files of the
+// format, and fills in its default target.
+func validateMapping(format string, mapping *models.ImportMapping) error {
+	if mapping.Target == "" {
+		mapping.Target = "transactions"
+	}
+	columns := mapping.Columns
+
+	if format == importer.CSV {
+		if columns.Date == "" {
+			return errors.New("mapping.columns.date is required for CSV files")
+		}
+		if columns.Amount == "" && columns.Debit == "" && columns.Credit == "" {
+			return errors.New("mapping.columns needs amount, or debit and credit, for CSV files")
+		}
+		if mapping.ContactID == 0 && columns.Contact == "" && columns.DebtID == "" {
+			return errors.New("mapping needs contact_id, or a contact or debt_id column, for CSV files")
+		}
+		if mapping.DateFormat != "" {
+			day := time.Date(2023, time.November, 25, 0, 0, 0, 0, time.UTC)
+			parsed, err := time.Parse(mapping.DateFormat, day.Format(mapping.DateFormat))
+			if err != nil || !parsed.Equal(day) {
+				return errors.New("mapping.date_format must be a Go layout of a day, such as 02.01.2006")
+			}
+		}
+	}
+	if columns.DebtID != "" && mapping.Target != "transactions" {
+		return errors.New("mapping.columns.debt_id can only be used to import transactions")
+	}
+	if columns.DebtID != "" && mapping.ContactID != 0 {
+		return errors.New("mapping.contact_id cannot be combined with a debt_id column")
+	}
+	return nil
+}
+
+// csvOptions converts a mapping into the options of the CSV reader.
+func csvOptions(mapping models.ImportMapping) importer.CSVOptions {
+	columns := mapping.Columns
+	opts := importer.CSVOptions{
+		Columns: importer.Columns{
+			Date:         columns.Date,
+			Amount:       columns.Amount,
+			Debit:        columns.Debit,
+			Credit:       columns.Credit,
+			Currency:     columns.Currency,
+			Description:  columns.Description,
+			Counterparty: columns.Contact,
+			Reference:    columns.Reference,
+			DebtID:       columns.DebtID,
+		},
+		DateFormat: mapping.DateFormat,
+	}
+	if mapping.Decimal == "," {
+		opts.Decimal = ','
+	}
+	switch mapping.Delimiter {
+	case "semicolon":
+		opts.Delimiter = ';'
+	case "tab":
+		opts.Delimiter = '\t'
+	}
+	return opts
+}
+
+func (h *ImportHandler) GetImportProfiles(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	profiles, err := h.store.ImportProfiles().List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get import profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+func (h *ImportHandler) GetImportProfile(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import profile ID"})
+		return
+	}
+
+	profile, err := h.store.ImportProfiles().Get(userID, profileID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import profile not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ImportHandler) CreateImportProfile(c *gin.Context) {
+	h.saveImportProfile(c, 0)
+}
+
+func (h *ImportHandler) UpdateImportProfile(c *gin.Context) {
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import profile ID"})
+		return
+	}
+	h.saveImportProfile(c, profileID)
+}
+
+// saveImportProfile creates a profile, or updates the profile profileID.
+// Names are unique per user, ignoring case.
+func (h *ImportHandler) saveImportProfile(c *gin.Context, profileID int) {
+	userID := c.GetInt("user_id")
+
+	var req models.ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateMapping(req.Format, &req.Mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := models.ImportProfile{
+		ID:      profileID,
+		UserID:  userID,
+		Name:    strings.TrimSpace(req.Name),
+		Format:  req.Format,
+		Mapping: req.Mapping,
+	}
+	err := h.store.WithinTx(func(s store.Store) error {
+		profiles, err := s.ImportProfiles().List(userID)
+		if err != nil {
+			return err
+		}
+		for _, other := range profiles {
+			if other.ID != profileID && strings.EqualFold(other.Name, profile.Name) {
+				return newRequestError(http.StatusConflict, "An import profile with this name already exists")
+			}
+		}
+
+		if profileID == 0 {
+			return s.ImportProfiles().Create(&profile)
+		}
+		err = s.ImportProfiles().Update(&profile)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Import profile not found")
+		}
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to save import profile")
+		return
+	}
+
+	if profileID == 0 {
+		c.JSON(http.StatusCreated, profile)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ImportHandler) DeleteImportProfile(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	profileID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import profile ID"})
+		return
+	}
+
+	err = h.store.ImportProfiles().Delete(userID, profileID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import profile not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Import profile deleted successfully"})
+}
+
+// PreviewImport reports what importing the uploaded file would do, row by
+// row, without saving anything.
+func (h *ImportHandler) PreviewImport(c *gin.Context) {
+	h.runImport(c, true)
+}
+
+// Import imports the uploaded file. Either every row is imported or, if
+// any row has an error, none is.
+func (h *ImportHandler) Import(c *gin.Context) {
+	h.runImport(c, false)
+}
+
+func (h *ImportHandler) runImport(c *gin.Context, dryRun bool) {
+	userID := c.GetInt("user_id")
+
+	format, mapping, records, err := h.readImport(c, userID)
+	if err != nil {
+		respondError(c, err, "Failed to read import")
+		return
+	}
+
+	result := models.ImportResult{DryRun: dryRun, Format: format, Target: mapping.Target}
+	err = h.store.WithinTx(func(s store.Store) error {
+		run, err := newImportRun(s, userID, mapping, dryRun)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			row, err := run.importRecord(record)
+			if err != nil {
+				return err
+			}
+			switch row.Status {
+			case "new":
+				result.New++
+			case "duplicate":
+				result.Duplicates++
+			default:
+				result.Errors++
+			}
+			result.Rows = append(result.Rows, row)
+		}
+		result.ContactsCreated = run.contactsCreated
+
+		if dryRun {
+			return errDryRun
+		}
+		if result.Errors > 0 {
+			return &requestError{status: http.StatusUnprocessableEntity, body: gin.H{
+				"error":  fmt.Sprintf("%d of the rows cannot be imported; nothing was imported", result.Errors),
+				"import": result,
+			}}
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		respondError(c, err, "Failed to import")
+		return
+	}
+	if result.Rows == nil {
+		result.Rows = []models.ImportRow{}
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// readImport reads the multipart form of an import: the file, and its
+// format and mapping given directly or by a saved profile.
+func (h *ImportHandler) readImport(c *gin.Context, userID int) (string, models.ImportMapping, []importer.Record, error) {
+	var mapping models.ImportMapping
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return "", mapping, nil, newRequestError(http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is larger than %d MB", maxImportSize>>20))
+		}
+		return "", mapping, nil, newRequestError(http.StatusBadRequest, "A file is required")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return "", mapping, nil, err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", mapping, nil, err
+	}
+
+	format := c.PostForm("format")
+	if value := c.PostForm("profile_id"); value != "" {
+		profileID, err := strconv.Atoi(value)
+		if err != nil {
+			return "", mapping, nil, newRequestError(http.StatusBadRequest, "Invalid import profile ID")
+		}
+		profile, err := h.store.ImportProfiles().Get(userID, profileID)
+		if err == store.ErrNotFound {
+			return "", mapping, nil, newRequestError(http.StatusBadRequest, "Import profile not found")
+		} else if err != nil {
+			return "", mapping, nil, err
+		}
+		mapping = profile.Mapping
+		if format == "" {
+			format = profile.Format
+		}
+	}
+
+	// A mapping given with the file replaces the profile's.
+	if value := c.PostForm("mapping"); value != "" {
+		mapping = models.ImportMapping{}
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return "", mapping, nil, newRequestError(http.StatusBadRequest, "Invalid mapping: "+err.Error())
+		}
+		if err := binding.Validator.ValidateStruct(&mapping); err != nil {
+			return "", mapping, nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	switch format {
+	case "":
+		head := content
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if format, err = importer.DetectFormat(header.Filename, head); err != nil {
+			return "", mapping, nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+	case importer.CSV, importer.OFX, importer.QFX, importer.CAMT053:
+	default:
+		return "", mapping, nil, newRequestError(http.StatusBadRequest, "format must be one of csv, ofx, qfx, camt053")
+	}
+	if err := validateMapping(format, &mapping); err != nil {
+		return "", mapping, nil, newRequestError(http.StatusBadRequest, err.Error())
+	}
+
+	records, err := importer.Parse(format, bytes.NewReader(content), csvOptions(mapping))
+	if err != nil {
+		return "", mapping, nil, newRequestError(http.StatusBadRequest, err.Error())
+	}
+	return format, mapping, records, nil
+}
+
+// importRun imports the records of one file within a store transaction.
+// Duplicates are found by matching each record with a debt or transaction
+// that existed before the import and has the same key, at most once, so
+// that a file with two equal payments on one day imports the second one if
+// only the first was recorded before. A payment can match a transaction on
+// any active or settled debt of its contact, as it may have settled the
+// debt it was recorded on.
+type importRun struct {
+	s       store.Store
+	userID  int
+	mapping models.ImportMapping
+	dryRun  bool
+	today   string
+	// currency is the default currency of new debts.
+	currency string
+
+	contact *models.Contact
+	// contacts are the active contacts by lowercased name.
+	contacts        map[string][]models.Contact
+	contactsCreated int
+
+	// debts are the active and settled debts by contact, oldest first, as
+	// they were before the import.
+	debts    map[int][]models.Debt
+	debtKeys map[string]int
+	// payments are the transactions on the debts of each contact by key,
+	// loaded when the contact first comes up.
+	payments   map[int]map[string][]recordedPayment
+	references map[string]bool
+}
+
+// recordedPayment is a transaction recorded before the import.
+type recordedPayment struct {
+	transaction models.Transaction
+	direction   string
+}
+
+func newImportRun(s store.Store, userID int, mapping models.ImportMapping, dryRun bool) (*importRun, error) {
+	run := &importRun{
+		s:          s,
+		userID:     userID,
+		mapping:    mapping,
+		dryRun:     dryRun,
+		today:      time.Now().UTC().Format("2006-01-02"),
+		contacts:   make(map[string][]models.Contact),
+		debts:      make(map[int][]models.Debt),
+		debtKeys:   make(map[string]int),
+		payments:   make(map[int]map[string][]recordedPayment),
+		references: make(map[string]bool),
+	}
+
+	var err error
+	if run.currency, err = currencyFor(s, userID, mapping.Currency); err != nil {
+		return nil, err
+	}
+
+	if mapping.ContactID != 0 {
+		contact, err := s.Contacts().Get(userID, mapping.ContactID)
+		if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+			return nil, newRequestError(http.StatusBadRequest, "Contact not found")
+		} else if err != nil {
+			return nil, err
+		}
+		run.contact = &contact
+	}
+
+	contacts, err := store.All(func(opts store.ListOptions) (models.Page[models.Contact], error) {
+		return s.Contacts().List(userID, store.ContactFilter{}, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, contact := range contacts {
+		name := strings.ToLower(strings.TrimSpace(contact.Name))
+		run.contacts[name] = append(run.contacts[name], contact)
+	}
+
+	debts, err := store.All(func(opts store.ListOptions) (models.Page[models.Debt], error) {
+		return s.Debts().List(userID, store.DebtFilter{Statuses: []string{"active", "settled"}}, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Debts created in the same second are ordered by ID, as SQLite keeps
+	// creation times to the second.
+	sort.Slice(debts, func(i, j int) bool {
+		if !debts[i].CreatedAt.Equal(debts[j].CreatedAt) {
+			return debts[i].CreatedAt.Before(debts[j].CreatedAt)
+		}
+		return debts[i].ID < debts[j].ID
+	})
+	for _, debt := range debts {
+		run.debts[debt.ContactID] = append(run.debts[debt.ContactID], debt)
+		run.debtKeys[debtKey(debt.ContactID, debt.Direction, debt.OriginalAmount, debt.CreatedAt)]++
+	}
+	return run, nil
+}
+
+func debtKey(contactID int, direction string, amount models.Money, day time.Time) string {
+	return fmt.Sprintf("%d|%s|%s|%d|%s", contactID, direction, amount.Currency, amount.Minor, day.UTC().Format("2006-01-02"))
+}
+
+// paymentKey identifies a payment by whether the user paid or received
+// it, its amount in minor units and its day; the currency is matched
+// separately, as a record may not give one.
+func paymentKey(paid bool, minor int64, day time.Time) string {
+	if minor < 0 {
+		minor = -minor
+	}
+	return fmt.Sprintf("%t|%d|%s", paid, minor, day.UTC().Format("2006-01-02"))
+}
+
+// paidByUser reports whether a transaction of the type is money the user
+// paid.
+func paidByUser(transactionType string) bool {
+	return transactionType == "lent" || transactionType == "paid_back"
+}
+
+// importRecord imports one record and reports the outcome. Problems with
+// the record are reported in the row; only failures of the store are
+// returned as errors.
+func (r *importRun) importRecord(record importer.Record) (models.ImportRow, error) {
+	row := models.ImportRow{
+		Row:         record.Row,
+		Errors:      record.Errors,
+		Description: record.Description,
+		Contact:     record.Counterparty,
+		Reference:   record.Reference,
+	}
+	if !record.Date.IsZero() {
+		row.Date = record.Date.Format("2006-01-02")
+		if row.Date > r.today {
+			row.Errors = append(row.Errors, "the date is in the future")
+		}
+	}
+	if len(record.Errors) == 0 && record.Amount == 0 {
+		row.Errors = append(row.Errors, "the amount is zero")
+	}
+	if record.Currency != "" && !validCurrency(record.Currency) {
+		row.Errors = append(row.Errors, fmt.Sprintf("unsupported currency %q", record.Currency))
+	}
+	if len(row.Errors) > 0 {
+		row.Status = "error"
+		return row, nil
+	}
+
+	// Banks give every payment its own reference, so a reference seen
+	// before in the file is the same payment listed twice.
+	if record.Reference != "" {
+		if r.references[record.Reference] {
+			row.Status = "duplicate"
+			return row, nil
+		}
+		r.references[record.Reference] = true
+	}
+
+	var err error
+	if r.mapping.Target == "debts" {
+		err = r.importDebt(record, &row)
+	} else {
+		err = r.importTransaction(record, &row)
+	}
+	if err != nil {
+		return row, err
+	}
+	switch {
+	case len(row.Errors) > 0:
+		row.Status = "error"
+	case row.Status == "":
+		row.Status = "new"
+	}
+	return row, nil
+}
+
+// findContact returns the contact of a record: the contact of the mapping,
+// or the one named by the record. It reports false, with the reason in the
+// row, if there is none; a record naming no contact that matches any is
+// given a new contact if the mapping creates contacts.
+func (r *importRun) findContact(record importer.Record, row *models.ImportRow, create bool) (models.Contact, bool) {
+	if r.contact != nil {
+		return *r.contact, true
+	}
+
+	name := strings.TrimSpace(record.Counterparty)
+	if name == "" {
+		row.Errors = append(row.Errors, "the contact is missing")
+		return models.Contact{}, false
+	}
+	matches := r.contacts[strings.ToLower(name)]
+	switch {
+	case len(matches) == 1:
+		return matches[0], true
+	case len(matches) > 1:
+		row.Errors = append(row.Errors, fmt.Sprintf("%d contacts are named %q", len(matches), name))
+		return models.Contact{}, false
+	case !create:
+		row.Errors = append(row.Errors, fmt.Sprintf("no contact is named %q", name))
+		return models.Contact{}, false
+	}
+	row.NewContact = true
+	return models.Contact{UserID: r.userID, Name: name}, true
+}
+
+// importDebt opens a debt for a record: money the user paid is lent to
+// the contact, and money they received is borrowed from them.
+func (r *importRun) importDebt(record importer.Record, row *models.ImportRow) error {
+	contact, ok := r.findContact(record, row, r.mapping.CreateContacts)
+	if !ok {
+		return nil
+	}
+
+	currency := record.Currency
+	if currency == "" {
+		currency = r.currency
+	}
+	direction := "owe_to"
+	if record.Amount < 0 {
+		direction = "owe_from"
+	}
+	amount := models.NewMoney(record.Amount, currency)
+	if amount.IsNegative() {
+		amount = amount.Neg()
+	}
+	row.Amount = &amount
+	row.Direction = direction
+	if contact.ID != 0 {
+		row.ContactID = &contact.ID
+	}
+
+	if contact.ID != 0 {
+		key := debtKey(contact.ID, direction, amount, record.Date)
+		if r.debtKeys[key] > 0 {
+			r.debtKeys[key]--
+			row.Status = "duplicate"
+			return nil
+		}
+	}
+
+	if contact.ID == 0 {
+		if err := r.s.Contacts().Create(&contact); err != nil {
+			return err
+		}
+		name := strings.ToLower(contact.Name)
+		r.contacts[name] = append(r.contacts[name], contact)
+		r.contactsCreated++
+		if !r.dryRun {
+			row.ContactID = &contact.ID
+		}
+	}
+
+	debt := models.Debt{
+		UserID:         r.userID,
+		ContactID:      contact.ID,
+		OriginalAmount: amount,
+		Currency:       currency,
+		Direction:      direction,
+		Description:    optionalText(record.Description),
+		CreatedAt:      record.Date,
+	}
+	if err := createDebt(r.s, &debt, contact); err != nil {
+		return err
+	}
+	if !r.dryRun {
+		row.DebtID = &debt.ID
+	}
+	return nil
+}
+
+// importTransaction records a record as a transaction on a debt: the debt
+// of its debt_id column, or else the oldest active debt of its contact in
+// its currency that is not yet paid off. Money the user paid increases a
+// debt they are owed and decreases one they owe, and the other way around.
+func (r *importRun) importTransaction(record importer.Record, row *models.ImportRow) error {
+	paid := record.Amount < 0
+	var debt models.Debt
+	if record.DebtID != "" {
+		debtID, err := strconv.Atoi(record.DebtID)
+		if err != nil || debtID < 1 {
+			row.Errors = append(row.Errors, fmt.Sprintf("invalid debt ID %q", record.DebtID))
+			return nil
+		}
+		debt, err = r.s.Debts().Lock(r.userID, debtID)
+		if err == store.ErrNotFound {
+			row.Errors = append(row.Errors, fmt.Sprintf("debt %d not found", debtID))
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if debt.Status == "removed" {
+			row.Errors = append(row.Errors, fmt.Sprintf("debt %d is removed", debt.ID))
+			return nil
+		}
+		if record.Currency != "" && record.Currency != debt.Currency {
+			row.Errors = append(row.Errors, fmt.Sprintf("the currency %s is not the %s of debt %d", record.Currency, debt.Currency, debt.ID))
+			return nil
+		}
+		if ok, err := r.duplicatePayment(debt.ContactID, debt.ID, debt.Currency, paid, record, row); ok || err != nil {
+			return err
+		}
+	} else {
+		contact, ok := r.findContact(record, row, false)
+		if !ok {
+			return nil
+		}
+		row.ContactID = &contact.ID
+		currency := record.Currency
+		if currency == "" {
+			currency = r.mapping.Currency
+		}
+		if ok, err := r.duplicatePayment(contact.ID, 0, currency, paid, record, row); ok || err != nil {
+			return err
+		}
+		var err error
+		if debt, ok, err = r.findDebt(contact, currency, paid, row); !ok || err != nil {
+			return err
+		}
+	}
+
+	row.DebtID = &debt.ID
+	row.ContactID = &debt.ContactID
+	row.Direction = debt.Direction
+	amount := models.NewMoney(record.Amount, debt.Currency)
+	if amount.IsNegative() {
+		amount = amount.Neg()
+	}
+	row.Amount = &amount
+	row.TransactionType = importedType(debt.Direction, paid)
+
+	_, decrease := transactionTypesFor(debt.Direction)
+	if remaining := payable(debt); row.TransactionType == decrease && amount.Cmp(remaining) > 0 {
+		row.Errors = append(row.Errors, fmt.Sprintf("the payment of %s exceeds the balance of %s of debt %d", amount, remaining, debt.ID))
+		return nil
+	}
+
+	counterpart, shared, err := lockCounterpart(r.s, debt)
+	if err != nil {
+		return err
+	}
+	transaction := models.Transaction{
+		DebtID:          debt.ID,
+		Amount:          amount,
+		TransactionType: row.TransactionType,
+		Description:     optionalText(record.Description),
+		CreatedAt:       record.Date,
+	}
+	if shared {
+		transaction.Confirmation = "pending"
+		transaction.CreatedBy = &r.userID
+	}
+	if err := r.s.Transactions().Create(&transaction); err != nil {
+		return err
+	}
+	if err := r.s.Debts().SyncStatus(debt.ID); err != nil {
+		return err
+	}
+	if shared {
+		if err := mirrorTransaction(r.s, transaction, debt, counterpart); err != nil {
+			return err
+		}
+	}
+	if !r.dryRun {
+		row.TransactionID = &transaction.ID
+	}
+	return nil
+}
+
+// importedType is the type of a transaction the user paid or received on a
+// debt of the direction.
+func importedType(direction string, paid bool) string {
+	increase, decrease := transactionTypesFor(direction)
+	if paid == (direction == "owe_from") {
+		return increase
+	}
+	return decrease
+}
+
+// findDebt locks the debt of the contact a record is a transaction on. The
+// debts are checked as they are now, since earlier records may have paid
+// some of them off. A payment goes to the oldest debt it reduces that is
+// not paid off yet, and anything else to the oldest active debt.
+func (r *importRun) findDebt(contact models.Contact, currency string, paid bool, row *models.ImportRow) (models.Debt, bool, error) {
+	target := 0
+	for _, candidate := range r.debts[contact.ID] {
+		debt, err := r.s.Debts().Get(r.userID, candidate.ID)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return debt, false, err
+		}
+		if debt.Status != "active" || (currency != "" && debt.Currency != currency) {
+			continue
+		}
+		if target == 0 {
+			target = debt.ID
+		}
+		_, decrease := transactionTypesFor(debt.Direction)
+		if importedType(debt.Direction, paid) != decrease || payable(debt).IsPositive() {
+			target = debt.ID
+			break
+		}
+	}
+	if target == 0 {
+		if currency != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s has no active debt in %s", contact.Name, currency))
+		} else {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s has no active debt", contact.Name))
+		}
+		return models.Debt{}, false, nil
+	}
+
+	debt, err := r.s.Debts().Lock(r.userID, target)
+	return debt, err == nil, err
+}
+
+// duplicatePayment reports whether the record repeats a transaction
+// recorded before the import on a debt of the contact, or only on the debt
+// if debtID is not 0, and marks the row as a duplicate of it. A record
+// without a currency matches one in any currency.
+func (r *importRun) duplicatePayment(contactID, debtID int, currency string, paid bool, record importer.Record, row *models.ImportRow) (bool, error) {
+	payments, err := r.recordedPayments(contactID)
+	if err != nil {
+		return false, err
+	}
+
+	key := paymentKey(paid, record.Amount, record.Date)
+	for i, payment := range payments[key] {
+		transaction := payment.transaction
+		if (debtID != 0 && transaction.DebtID != debtID) || (currency != "" && transaction.Amount.Currency != currency) {
+			continue
+		}
+		payments[key] = append(payments[key][:i], payments[key][i+1:]...)
+
+		row.Status = "duplicate"
+		row.ContactID = &contactID
+		row.DebtID = &transaction.DebtID
+		row.Direction = payment.direction
+		row.Amount = &transaction.Amount
+		row.TransactionType = transaction.TransactionType
+		return true, nil
+	}
+	return false, nil
+}
+
+// recordedPayments returns the transactions recorded on the active and
+// settled debts of the contact before the import, by key.
+func (r *importRun) recordedPayments(contactID int) (map[string][]recordedPayment, error) {
+	if payments, ok := r.payments[contactID]; ok {
+		return payments, nil
+	}
+
+	payments := make(map[string][]recordedPayment)
+	for _, debt := range r.debts[contactID] {
+		transactions, err := store.All(func(opts store.ListOptions) (models.Page[models.Transaction], error) {
+			return r.s.Transactions().List(r.userID, store.TransactionFilter{DebtID: debt.ID}, opts)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range transactions {
+			key := paymentKey(paidByUser(transaction.TransactionType), transaction.Amount.Minor, transaction.CreatedAt)
+			payments[key] = append(payments[key], recordedPayment{transaction: transaction, direction: debt.Direction})
+		}
+	}
+	r.payments[contactID] = payments
+	return payments, nil
+}
+
+func optionalText(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
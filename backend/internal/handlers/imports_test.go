@@ -0,0 +1,272 @@
+// internal/handlers/imports_test.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+var paymentMapping = models.ImportMapping{
+	Currency: "ZAR",
+	Columns:  models.ImportColumns{Date: "date", Amount: "amount", Contact: "contact"},
+}
+
+// upload posts the file as a multipart form with the other fields.
+func (ts *testServer) upload(userID int, path, filename, content string, fields map[string]string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if filename != "" {
+		file, err := form.CreateFormFile("file", filename)
+		if err != nil {
+			ts.t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	form.Close()
+
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return ts.send(userID, req)
+}
+
+// importCSV posts the CSV file to path with the mapping.
+func (ts *testServer) importCSV(userID int, path, content string, mapping models.ImportMapping) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	encoded, err := json.Marshal(mapping)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return ts.upload(userID, path, "statement.csv", content, map[string]string{"mapping": string(encoded)})
+}
+
+// rowStatuses lists the status of each row of an import.
+func rowStatuses(result models.ImportResult) []string {
+	statuses := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		statuses[i] = row.Status
+	}
+	return statuses
+}
+
+func TestImportPaysOffDebtsInTurn(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		dave := ts.contact(userID, "Dave")
+		first := ts.debt(userID, dave.ID, "100", "owe_from")
+		second := ts.debt(userID, dave.ID, "50", "owe_from")
+
+		file := "date,amount,contact\n2024-03-01,100,Dave\n2024-03-02,50,dave\n"
+		var result models.ImportResult
+		ts.expect(ts.importCSV(userID, "/imports", file, paymentMapping), http.StatusCreated, &result)
+		if result.New != 2 {
+			t.Fatalf("imported %d rows, want 2: %+v", result.New, result.Rows)
+		}
+		checkBalance(t, ts.getDebt(userID, first.ID), "0.00", "settled")
+		checkBalance(t, ts.getDebt(userID, second.ID), "0.00", "settled")
+
+		transactions := ts.debtTransactions(userID, first.ID)
+		if len(transactions) != 1 || transactions[0].TransactionType != "received_back" ||
+			transactions[0].CreatedAt.Format("2006-01-02") != "2024-03-01" {
+			t.Errorf("imported transactions are %+v", transactions)
+		}
+	})
+}
+
+func TestImportSkipsPaymentsOnSettledDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		dave := ts.contact(userID, "Dave")
+		first := ts.debt(userID, dave.ID, "100", "owe_from")
+		second := ts.debt(userID, dave.ID, "100", "owe_from")
+
+		file := "date,amount,contact\n2024-03-01,100,Dave\n"
+		ts.expect(ts.importCSV(userID, "/imports", file, paymentMapping), http.StatusCreated, nil)
+
+		var result models.ImportResult
+		ts.expect(ts.importCSV(userID, "/imports", file, paymentMapping), http.StatusCreated, &result)
+		if result.New != 0 || result.Duplicates != 1 {
+			t.Fatalf("got %d new and %d duplicate rows, want 0 and 1", result.New, result.Duplicates)
+		}
+		if row := result.Rows[0]; row.DebtID == nil || *row.DebtID != first.ID {
+			t.Errorf("the duplicate is reported on debt %v, want %d", row.DebtID, first.ID)
+		}
+		checkBalance(t, ts.getDebt(userID, first.ID), "0.00", "settled")
+		checkBalance(t, ts.getDebt(userID, second.ID), "100.00", "active")
+		if transactions := ts.debtTransactions(userID, second.ID); len(transactions) != 0 {
+			t.Errorf("debt %d has %d transactions, want none", second.ID, len(transactions))
+		}
+	})
+}
+
+func TestImportDebts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		ts.contact(userID, "Bob")
+		mapping := paymentMapping
+		mapping.Target, mapping.CreateContacts = "debts", true
+		mapping.Columns.Reference = "reference"
+
+		// Paying Bob opens a debt he owes, being paid by Carol one she is
+		// owed. The same bank reference twice is the same payment.
+		file := "date,amount,contact,reference\n" +
+			"2024-03-01,-25.50,Bob,R1\n" +
+			"2024-03-02,40,Carol,R2\n" +
+			"2024-03-02,40,Carol,R2\n"
+		var result models.ImportResult
+		ts.expect(ts.importCSV(userID, "/imports/preview", file, mapping), http.StatusOK, &result)
+		if !result.DryRun || fmt.Sprint(rowStatuses(result)) != "[new new duplicate]" || result.ContactsCreated != 1 {
+			t.Errorf("preview is %v with %d contacts created", rowStatuses(result), result.ContactsCreated)
+		}
+		var page models.Page[models.Debt]
+		ts.expect(ts.do(userID, "GET", "/debts", nil), http.StatusOK, &page)
+		if page.Total != 0 {
+			t.Fatalf("the preview saved %d debts", page.Total)
+		}
+
+		ts.expect(ts.importCSV(userID, "/imports", file, mapping), http.StatusCreated, &result)
+		ts.expect(ts.do(userID, "GET", "/debts?sort=created_at&order=asc", nil), http.StatusOK, &page)
+		if page.Total != 2 {
+			t.Fatalf("import opened %d debts, want 2", page.Total)
+		}
+		bob, carol := page.Data[0], page.Data[1]
+		if bob.Direction != "owe_from" || bob.Balance.String() != "25.50" || bob.Contact.Name != "Bob" {
+			t.Errorf("Bob's debt is %s %s with %v", bob.Direction, bob.Balance, bob.Contact)
+		}
+		if carol.Direction != "owe_to" || carol.Balance.String() != "40.00" || carol.Contact.Name != "Carol" {
+			t.Errorf("Carol's debt is %s %s with %v", carol.Direction, carol.Balance, carol.Contact)
+		}
+
+		ts.expect(ts.importCSV(userID, "/imports", file, mapping), http.StatusCreated, &result)
+		if result.New != 0 || result.Duplicates != 3 {
+			t.Errorf("importing again gave %v", rowStatuses(result))
+		}
+	})
+}
+
+func TestImportSavesNothingWithErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		dave := ts.contact(userID, "Dave")
+		debt := ts.debt(userID, dave.ID, "100", "owe_from")
+
+		file := "date,amount,contact,currency\n" +
+			"2024-03-01,10,Dave,\n" +
+			"2024-03-02,10,Nobody,\n" +
+			"2024-03-03,10,Dave,JPY\n" +
+			"2024-03-04,500,Dave,\n" +
+			"2999-01-01,10,Dave,\n" +
+			"2024-03-05,0,Dave,\n"
+		mapping := paymentMapping
+		mapping.Columns.Currency = "currency"
+		var body struct {
+			Import models.ImportResult `json:"import"`
+		}
+		ts.expect(ts.importCSV(userID, "/imports", file, mapping), http.StatusUnprocessableEntity, &body)
+		if got := fmt.Sprint(rowStatuses(body.Import)); got != "[new error error error error error]" {
+			t.Errorf("rows are %s", got)
+		}
+		checkBalance(t, ts.getDebt(userID, debt.ID), "100.00", "active")
+	})
+}
+
+func TestImportStatements(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_from")
+
+		ofx := "OFXHEADER:100\n\n<OFX><CURDEF>ZAR<BANKTRANLIST>" +
+			"<STMTTRN><DTPOSTED>20240301<TRNAMT>30.00<FITID>F1<NAME>Bob" +
+			"</BANKTRANLIST></OFX>"
+		var result models.ImportResult
+		ts.expect(ts.upload(userID, "/imports", "bank.ofx", ofx, nil), http.StatusCreated, &result)
+		if result.Format != "ofx" || result.New != 1 {
+			t.Errorf("OFX import is %s with %v", result.Format, rowStatuses(result))
+		}
+
+		camt := `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"><BkToCstmrStmt><Stmt><Ntry>` +
+			`<Amt Ccy="ZAR">20.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2024-03-02</Dt></BookgDt>` +
+			`<NtryDtls><TxDtls><RltdPties><Dbtr><Nm>Bob</Nm></Dbtr></RltdPties></TxDtls></NtryDtls>` +
+			`</Ntry></Stmt></BkToCstmrStmt></Document>`
+		ts.expect(ts.upload(userID, "/imports", "statement.xml", camt, nil), http.StatusCreated, &result)
+		if result.Format != "camt053" || result.New != 1 {
+			t.Errorf("CAMT.053 import is %s with %v", result.Format, rowStatuses(result))
+		}
+		checkBalance(t, ts.getDebt(userID, debt.ID), "50.00", "active")
+	})
+}
+
+func TestImportProfiles(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		dave := ts.contact(userID, "Dave")
+		debt := ts.debt(userID, dave.ID, "100", "owe_from")
+
+		req := models.ImportProfileRequest{Name: "Savings", Format: "csv", Mapping: paymentMapping}
+		var profile models.ImportProfile
+		ts.expect(ts.do(userID, "POST", "/imports/profiles", req), http.StatusCreated, &profile)
+		ts.expect(ts.do(userID, "POST", "/imports/profiles", models.ImportProfileRequest{Name: "savings", Format: "ofx"}), http.StatusConflict, nil)
+
+		// The profile gives the format and the mapping of the file.
+		file := "date,amount,contact\n2024-03-01,10,Dave\n"
+		fields := map[string]string{"profile_id": fmt.Sprint(profile.ID)}
+		ts.expect(ts.upload(userID, "/imports", "download", file, fields), http.StatusCreated, nil)
+		checkBalance(t, ts.getDebt(userID, debt.ID), "90.00", "active")
+		ts.expect(ts.upload(otherID, "/imports", "download", file, fields), http.StatusBadRequest, nil)
+
+		path := fmt.Sprintf("/imports/profiles/%d", profile.ID)
+		req.Name = "Current account"
+		ts.expect(ts.do(userID, "PUT", path, req), http.StatusOK, &profile)
+		if profile.Name != "Current account" || profile.Mapping.Columns.Date != "date" {
+			t.Errorf("updated profile is %+v", profile)
+		}
+		ts.expect(ts.do(otherID, "GET", path, nil), http.StatusNotFound, nil)
+
+		var profiles []models.ImportProfile
+		ts.expect(ts.do(userID, "GET", "/imports/profiles", nil), http.StatusOK, &profiles)
+		if len(profiles) != 1 {
+			t.Errorf("user has %d profiles, want 1", len(profiles))
+		}
+		ts.expect(ts.do(userID, "DELETE", path, nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "GET", path, nil), http.StatusNotFound, nil)
+	})
+}
+
+func TestImportErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		file := "date,amount,contact\n2024-03-01,10,Dave\n"
+
+		ts.expect(ts.upload(userID, "/imports", "", "", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.upload(userID, "/imports", "statement.pdf", "%PDF-1.7", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.upload(userID, "/imports", "statement.csv", file, map[string]string{"format": "xls"}), http.StatusBadRequest, nil)
+		ts.expect(ts.upload(userID, "/imports", "statement.csv", file, map[string]string{"mapping": "{"}), http.StatusBadRequest, nil)
+		ts.expect(ts.upload(userID, "/imports", "statement.csv", file, map[string]string{"profile_id": "abc"}), http.StatusBadRequest, nil)
+		ts.expect(ts.upload(userID, "/imports", "bank.ofx", "date,amount\n", nil), http.StatusBadRequest, nil)
+
+		for _, mapping := range []models.ImportMapping{
+			{Columns: models.ImportColumns{Amount: "amount"}},
+			{Columns: models.ImportColumns{Date: "date", Amount: "value"}},
+			{Currency: "JPY", Columns: paymentMapping.Columns},
+			{Target: "loans", Columns: paymentMapping.Columns},
+		} {
+			ts.expect(ts.importCSV(userID, "/imports/preview", file, mapping), http.StatusBadRequest, nil)
+		}
+
+		ts.expect(ts.do(userID, "POST", "/imports/profiles", models.ImportProfileRequest{Name: "Bank", Format: "pdf"}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "GET", "/imports/profiles/abc", nil), http.StatusBadRequest, nil)
+	})
+}
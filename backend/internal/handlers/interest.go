@@ -0,0 +1,400 @@
+// internal/handlers/interest.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"debt-tracker-backend/internal/interest"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/recurrence"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Interest is never stored as it accrues. It is worked out from the debt's
+// terms and transactions whenever it is asked for, and added to the
+// balance only when PostInterest posts it as an interest transaction.
+// Transactions up to the day interest starts count as the opening balance;
+// later ones change the balance on the day they were recorded.
+
+// interestTerms checks the interest terms of a request and fills in their
+// defaults. Interest starts on the day start unless the request gives
+// another day.
+func interestTerms(req models.Interest, start string) (models.Interest, error) {
+	if req.Method == "" {
+		req.Method = interest.Simple
+	}
+	if req.Compounding == "" {
+		req.Compounding = interest.Monthly
+	}
+	if req.DayCount == "" {
+		req.DayCount = interest.Actual365
+	}
+	if req.StartDate == "" {
+		req.StartDate = start
+	}
+
+	day, err := recurrence.ParseDay(req.StartDate)
+	if err != nil {
+		return req, errors.New("start_date must be a date such as 2025-07-01")
+	}
+	req.StartDate = recurrence.FormatDay(day)
+	if _, err := parseTerms(req); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// parseTerms reads the stored interest terms of a debt.
+func parseTerms(terms models.Interest) (interest.Terms, error) {
+	rate, err := interest.ParseRate(terms.Rate)
+	if err != nil {
+		return interest.Terms{}, err
+	}
+	parsed := interest.Terms{
+		Rate:        rate,
+		Method:      terms.Method,
+		Compounding: terms.Compounding,
+		DayCount:    terms.DayCount,
+	}
+	return parsed, parsed.Validate()
+}
+
+// accrue works out the interest the debt has accrued by the day to. Posted
+// counts all the interest posted since the terms started.
+func accrue(s store.Store, debt models.Debt, to time.Time) (models.InterestAccrual, error) {
+	accrual := models.InterestAccrual{
+		DebtID:    debt.ID,
+		StartDate: debt.Interest.StartDate,
+		Date:      recurrence.FormatDay(to),
+		Accrued:   models.NewMoney(0, debt.Currency),
+		Posted:    models.NewMoney(0, debt.Currency),
+	}
+
+	terms, err := parseTerms(*debt.Interest)
+	if err != nil {
+		return accrual, err
+	}
+	start, err := recurrence.ParseDay(debt.Interest.StartDate)
+	if err != nil {
+		return accrual, err
+	}
+
+	transactions, err := store.All(func(opts store.ListOptions) (models.Page[models.Transaction], error) {
+		return s.Transactions().List(debt.UserID, store.TransactionFilter{DebtID: debt.ID}, opts)
+	})
+	if err != nil {
+		return accrual, err
+	}
+
+	increase, _ := transactionTypesFor(debt.Direction)
+	opening := debt.OriginalAmount.Minor
+	var events []interest.Event
+	for _, transaction := range transactions {
+		amount := transaction.Amount.Minor
+		if transaction.TransactionType != increase {
+			amount = -amount
+		}
+		day := recurrence.Truncate(transaction.CreatedAt)
+
+		switch {
+		case !day.After(start):
+			opening += amount
+		case transaction.Interest:
+			accrual.Posted = accrual.Posted.Add(transaction.Amount)
+		default:
+			events = append(events, interest.Event{Day: day, Amount: amount})
+		}
+	}
+
+	accrual.Accrued = models.NewMoney(terms.Accrue(opening, start, events, to), debt.Currency)
+	accrual.Unposted = accrual.Accrued.Sub(accrual.Posted)
+	accrual.Payoff = debt.Balance.Add(accrual.Unposted)
+	return accrual, nil
+}
+
+// SetInterest replaces the interest terms of the debt. Terms without a
+// start_date start today. An empty body removes them. On a shared debt
+// only the user's own side changes.
+func (h *DebtHandler) SetInterest(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var req models.Interest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var terms *models.Interest
+	if req.Rate != "" {
+		normalized, err := interestTerms(req, recurrence.FormatDay(recurrence.Truncate(time.Now())))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		terms = &normalized
+	}
+
+	var debt models.Debt
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err = s.Debts().Lock(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		if debt.Status == "removed" {
+			return newRequestError(http.StatusConflict, "Cannot change the interest of a removed debt")
+		}
+		if err := s.Debts().SetInterest(debt.ID, terms); err != nil {
+			return err
+		}
+
+		debt, err = s.Debts().Get(userID, debt.ID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to set interest")
+		return
+	}
+
+	c.JSON(http.StatusOK, debt)
+}
+
+// GetInterest works out the interest the debt has accrued by today, or by
+// a later day to project what it will have accrued without further
+// payments.
+func (h *DebtHandler) GetInterest(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var query models.InterestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	today := recurrence.Truncate(time.Now())
+	to := today
+	if query.Date != "" {
+		to, err = recurrence.ParseDay(query.Date)
+		if err != nil || to.Before(today) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be today or a later date such as 2025-07-01"})
+			return
+		}
+	}
+
+	var accrual models.InterestAccrual
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err := s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+		if debt.Interest == nil {
+			return newRequestError(http.StatusNotFound, "Debt bears no interest")
+		}
+
+		accrual, err = accrue(s, debt, to)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to compute interest")
+		return
+	}
+
+	c.JSON(http.StatusOK, accrual)
+}
+
+// PreviewAmortization works out how to repay the debt in equal payments on
+// the days of a plan, without changing it. The payments cover what is owed
+// today, the balance and the interest accrued but not posted, and the
+// interest on what is left at the debt's rate, divided over the payments
+// of a year. A debt without interest is split into equal payments.
+func (h *DebtHandler) PreviewAmortization(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	var req models.RecurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, days, err := planDays(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var amortization models.Amortization
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err := s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		principal, rate := debt.Balance, new(big.Rat)
+		if debt.Interest != nil {
+			accrual, err := accrue(s, debt, recurrence.Truncate(time.Now()))
+			if err != nil {
+				return err
+			}
+			terms, err := parseTerms(*debt.Interest)
+			if err != nil {
+				return err
+			}
+			principal, rate = accrual.Payoff, terms.Rate
+		}
+		if !principal.IsPositive() {
+			return newRequestError(http.StatusConflict, "Debt has nothing left to repay")
+		}
+
+		amortization = models.Amortization{
+			DebtID:        debt.ID,
+			Principal:     principal,
+			TotalInterest: models.NewMoney(0, debt.Currency),
+			Payments:      []models.AmortizationPayment{},
+		}
+		payments := interest.Amortize(principal.Minor, rate, interest.PeriodsPerYear(rule), len(days))
+		for i, payment := range payments {
+			amortization.Payments = append(amortization.Payments, models.AmortizationPayment{
+				Number:    i + 1,
+				DueDate:   recurrence.FormatDay(days[i]),
+				Payment:   models.NewMoney(payment.Payment, debt.Currency),
+				Interest:  models.NewMoney(payment.Interest, debt.Currency),
+				Principal: models.NewMoney(payment.Principal, debt.Currency),
+				Balance:   models.NewMoney(payment.Balance, debt.Currency),
+			})
+			amortization.TotalInterest = amortization.TotalInterest.Add(models.NewMoney(payment.Interest, debt.Currency))
+		}
+		amortization.Payment = amortization.Payments[0].Payment
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to compute amortization")
+		return
+	}
+
+	c.JSON(http.StatusOK, amortization)
+}
+
+// PostInterest returns a job that posts the interest debts with automatic
+// posting have accrued up to the start of the current compounding period,
+// so interest is added to the balance once a period. Posting is worked out
+// from what has been posted before, so running the job again posts
+// nothing new.
+func PostInterest(st store.Store) func() error {
+	return func() error {
+		debts, err := st.Debts().WithInterest()
+		if err != nil {
+			return err
+		}
+
+		today := recurrence.Truncate(time.Now())
+		posted := 0
+		var failures []error
+		for _, debt := range debts {
+			ok, err := postInterest(st, debt, today)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("debt %d: %w", debt.ID, err))
+				continue
+			}
+			if ok {
+				posted++
+			}
+		}
+
+		if posted > 0 {
+			log.Printf("Posted interest on %d debt(s)", posted)
+		}
+		return errors.Join(failures...)
+	}
+}
+
+// postInterest posts the interest the debt has accrued but not posted by
+// the start of the period that today falls in. It reports whether there
+// was any to post.
+func postInterest(st store.Store, debt models.Debt, today time.Time) (bool, error) {
+	posted := false
+	err := st.WithinTx(func(s store.Store) error {
+		posted = false
+
+		current, err := s.Debts().Lock(debt.UserID, debt.ID)
+		if err == store.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		counterpart, shared, err := lockCounterpart(s, current)
+		if err != nil {
+			return err
+		}
+		if current.Status != "active" || current.Interest == nil || !current.Interest.AutoPost {
+			return nil
+		}
+
+		terms, err := parseTerms(*current.Interest)
+		if err != nil {
+			return err
+		}
+		through := terms.PeriodStart(today)
+		accrual, err := accrue(s, current, through)
+		if err != nil {
+			return err
+		}
+		if !accrual.Unposted.IsPositive() {
+			return nil
+		}
+
+		increase, _ := transactionTypesFor(current.Direction)
+		description := "Interest to " + recurrence.FormatDay(through)
+		transaction := models.Transaction{
+			DebtID:          current.ID,
+			Amount:          accrual.Unposted,
+			TransactionType: increase,
+			Description:     &description,
+			Interest:        true,
+		}
+		if shared {
+			transaction.Confirmation = "pending"
+			transaction.CreatedBy = &current.UserID
+		}
+		if err := s.Transactions().Create(&transaction); err != nil {
+			return err
+		}
+		if err := s.Debts().SyncStatus(current.ID); err != nil {
+			return err
+		}
+		if shared {
+			if err := mirrorTransaction(s, transaction, current, counterpart); err != nil {
+				return err
+			}
+		}
+		posted = true
+		return nil
+	})
+	return posted, err
+}
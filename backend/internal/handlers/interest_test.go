@@ -0,0 +1,155 @@
+// internal/handlers/interest_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// interestDebt opens a debt of the user with interest on the terms.
+func (ts *testServer) interestDebt(userID, contactID int, amount string, terms models.Interest) models.Debt {
+	ts.t.Helper()
+	req := models.CreateDebtRequest{ContactID: contactID, Amount: money(ts.t, amount), Direction: "owe_from", Interest: &terms}
+	var debt models.Debt
+	ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusCreated, &debt)
+	return debt
+}
+
+// accrual returns the interest the user's debt has accrued by the day, or
+// by today if day is empty.
+func (ts *testServer) accrual(userID, debtID int, day string) models.InterestAccrual {
+	ts.t.Helper()
+	path := fmt.Sprintf("/debts/%d/interest", debtID)
+	if day != "" {
+		path += "?date=" + day
+	}
+	var accrual models.InterestAccrual
+	ts.expect(ts.do(userID, "GET", path, nil), http.StatusOK, &accrual)
+	return accrual
+}
+
+func TestDebtInterest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+
+		// 12% a year on 1000 is 10 for each 30 days on actual/360.
+		debt := ts.interestDebt(userID, bob.ID, "1000", models.Interest{Rate: "12", DayCount: "actual/360", StartDate: daysFromToday(-30)})
+		if debt.Interest == nil || debt.Interest.Method != "simple" || debt.Interest.Compounding != "monthly" {
+			t.Errorf("debt has interest %+v, want simple with the defaults", debt.Interest)
+		}
+		accrual := ts.accrual(userID, debt.ID, "")
+		if accrual.Accrued.String() != "10.00" || accrual.Posted.String() != "0.00" || accrual.Payoff.String() != "1010.00" {
+			t.Errorf("accrued %s, posted %s, payoff %s; want 10.00, 0.00, 1010.00", accrual.Accrued, accrual.Posted, accrual.Payoff)
+		}
+		if projected := ts.accrual(userID, debt.ID, daysFromToday(60)); projected.Accrued.String() != "30.00" {
+			t.Errorf("projected interest is %s, want 30.00", projected.Accrued)
+		}
+
+		// Daily posting adds everything accrued by today, once.
+		terms := *debt.Interest
+		terms.Compounding, terms.AutoPost = "daily", true
+		ts.expect(ts.do(userID, "PUT", fmt.Sprintf("/debts/%d/interest", debt.ID), terms), http.StatusOK, nil)
+		for range 2 {
+			if err := PostInterest(ts.store)(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkBalance(t, ts.getDebt(userID, debt.ID), "1010.00", "active")
+		transactions := ts.debtTransactions(userID, debt.ID)
+		if len(transactions) != 1 || !transactions[0].Interest || transactions[0].TransactionType != "lent" {
+			t.Errorf("posting recorded %+v, want one interest transaction", transactions)
+		}
+		if accrual := ts.accrual(userID, debt.ID, ""); accrual.Posted.String() != "10.00" || !accrual.Unposted.IsZero() {
+			t.Errorf("after posting, %s is posted and %s is not", accrual.Posted, accrual.Unposted)
+		}
+
+		// An empty body removes the terms.
+		var cleared models.Debt
+		ts.expect(ts.do(userID, "PUT", fmt.Sprintf("/debts/%d/interest", debt.ID), nil), http.StatusOK, &cleared)
+		if cleared.Interest != nil {
+			t.Errorf("cleared debt has interest %+v", cleared.Interest)
+		}
+		ts.expect(ts.do(userID, "GET", fmt.Sprintf("/debts/%d/interest", debt.ID), nil), http.StatusNotFound, nil)
+	})
+}
+
+func TestSharedDebtInterest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+		debt := ts.interestDebt(aliceID, bob.ID, "100", models.Interest{Rate: "5", AutoPost: true})
+
+		theirs := ts.getDebt(bobID, ts.counterpart(aliceID, debt.ID))
+		if theirs.Interest == nil || theirs.Interest.Rate != "5" || theirs.Interest.AutoPost {
+			t.Errorf("bob's side has interest %+v, want 5%% without auto_post", theirs.Interest)
+		}
+	})
+}
+
+func TestAmortization(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		plan := models.RecurrenceRequest{StartDate: daysFromToday(30), RRule: "FREQ=MONTHLY;COUNT=12"}
+
+		// Without interest the leftover cents go to the first payments.
+		plain := ts.debt(userID, bob.ID, "100", "owe_from")
+		var amortization models.Amortization
+		ts.expect(ts.do(userID, "POST", fmt.Sprintf("/debts/%d/amortization", plain.ID), plan), http.StatusOK, &amortization)
+		if len(amortization.Payments) != 12 || amortization.Payment.String() != "8.34" || !amortization.TotalInterest.IsZero() {
+			t.Errorf("plain amortization is %d payments of %s with %s interest", len(amortization.Payments), amortization.Payment, amortization.TotalInterest)
+		}
+
+		loan := ts.interestDebt(userID, bob.ID, "1000", models.Interest{Rate: "12"})
+		ts.expect(ts.do(userID, "POST", fmt.Sprintf("/debts/%d/amortization", loan.ID), plan), http.StatusOK, &amortization)
+		first, last := amortization.Payments[0], amortization.Payments[11]
+		if amortization.Payment.String() != "88.85" || first.Interest.String() != "10.00" || first.DueDate != plan.StartDate {
+			t.Errorf("first payment is %+v", first)
+		}
+		if !last.Balance.IsZero() || amortization.TotalInterest.String() != "66.19" {
+			t.Errorf("last payment leaves %s after %s interest", last.Balance, amortization.TotalInterest)
+		}
+	})
+}
+
+func TestInterestErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.contact(userID, "Bob")
+
+		for _, terms := range []models.Interest{
+			{},
+			{Rate: "abc"},
+			{Rate: "0"},
+			{Rate: "1001"},
+			{Rate: "5", Method: "weird"},
+			{Rate: "5", Compounding: "hourly"},
+			{Rate: "5", DayCount: "actual/364"},
+			{Rate: "5", StartDate: "soon"},
+		} {
+			req := models.CreateDebtRequest{ContactID: bob.ID, Amount: money(t, "10"), Direction: "owe_from", Interest: &terms}
+			ts.expect(ts.do(userID, "POST", "/debts", req), http.StatusBadRequest, nil)
+		}
+
+		debt := ts.interestDebt(userID, bob.ID, "10", models.Interest{Rate: "5"})
+		path := fmt.Sprintf("/debts/%d", debt.ID)
+		plan := models.RecurrenceRequest{StartDate: daysFromToday(1), RRule: "FREQ=MONTHLY;COUNT=3"}
+		ts.expect(ts.do(userID, "PUT", path+"/interest", models.Interest{Rate: "-1"}), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "GET", path+"/interest?date="+daysFromToday(-1), nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "GET", "/debts/abc/interest", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(otherID, "GET", path+"/interest", nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "PUT", path+"/interest", models.Interest{Rate: "5"}), http.StatusNotFound, nil)
+		ts.expect(ts.do(otherID, "POST", path+"/amortization", plan), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "POST", path+"/amortization", models.RecurrenceRequest{StartDate: daysFromToday(1), Frequency: "monthly"}), http.StatusBadRequest, nil)
+
+		ts.pay(userID, debt.ID, "10", "received_back")
+		ts.expect(ts.do(userID, "POST", path+"/amortization", plan), http.StatusConflict, nil)
+		ts.expect(ts.do(userID, "DELETE", path, nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "PUT", path+"/interest", models.Interest{Rate: "5"}), http.StatusConflict, nil)
+	})
+}
@@ -0,0 +1,282 @@
+// internal/handlers/invites.go
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteHandler links contacts to the accounts of the people they stand
+// for. Once linked, debts between the two users are shared (see shared.go).
+type InviteHandler struct {
+	store  store.Store
+	mailer mail.Mailer
+	appURL string
+}
+
+func NewInviteHandler(s store.Store, mailer mail.Mailer, appURL string) *InviteHandler {
+	return &InviteHandler{store: s, mailer: mailer, appURL: appURL}
+}
+
+// GetInvites lists the pending invites addressed to the user's verified
+// email address and the ones the user has sent.
+func (h *InviteHandler) GetInvites(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	user, err := h.store.Users().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invites"})
+		return
+	}
+
+	list := models.InviteList{Received: []models.ContactInvite{}}
+	if user.EmailVerifiedAt != nil {
+		list.Received, err = h.store.Invites().ListReceived(user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invites"})
+			return
+		}
+	}
+	list.Sent, err = h.store.Invites().ListSent(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// InviteContact invites the person behind a contact to link the contact
+// to their account. The invite is mailed to them, but can also be found
+// through GetInvites once they have verified the address.
+func (h *InviteHandler) InviteContact(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	contactID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req models.InviteContactRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var invite models.ContactInvite
+	err = h.store.WithinTx(func(s store.Store) error {
+		contact, err := s.Contacts().Get(userID, contactID)
+		if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+			return newRequestError(http.StatusNotFound, "Contact not found")
+		} else if err != nil {
+			return err
+		}
+		if contact.LinkedUserID != nil {
+			return newRequestError(http.StatusConflict, "Contact is already linked to an account")
+		}
+
+		email := strings.TrimSpace(req.Email)
+		if email == "" && contact.Email != nil {
+			email = strings.TrimSpace(*contact.Email)
+		}
+		if email == "" {
+			return newRequestError(http.StatusBadRequest, "Contact has no email address; pass one in the request")
+		}
+
+		user, err := s.Users().Get(userID)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(email, user.Email) {
+			return newRequestError(http.StatusBadRequest, "You cannot invite yourself")
+		}
+
+		sent, err := s.Invites().ListSent(userID)
+		if err != nil {
+			return err
+		}
+		for _, pending := range sent {
+			if pending.ContactID == contactID {
+				return newRequestError(http.StatusConflict, "An invite for this contact is already pending")
+			}
+		}
+
+		invite = models.ContactInvite{InviterID: userID, ContactID: contactID, Email: email}
+		return s.Invites().Create(&invite)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create invite")
+		return
+	}
+
+	// The invite stands even if the email cannot be sent.
+	link := strings.TrimRight(h.appURL, "/") + "/"
+	if err := h.mailer.Send(mail.ContactInvite(invite.Email, invite.InviterName, link)); err != nil {
+		log.Printf("Failed to send invite %d: %v", invite.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// AcceptInvite links the inviter's contact to the user, and links the
+// user's contact for the inviter back, creating it if needed. Debts
+// recorded before the link stay private to each side.
+func (h *InviteHandler) AcceptInvite(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	inviteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	var req models.AcceptInviteRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var contact models.Contact
+	err = h.store.WithinTx(func(s store.Store) error {
+		invite, err := h.receivedInvite(s, userID, inviteID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.Contacts().GetLinked(userID, invite.InviterID); err == nil {
+			return newRequestError(http.StatusConflict, "You are already linked to this user")
+		} else if err != store.ErrNotFound {
+			return err
+		}
+
+		if req.ContactID != 0 {
+			contact, err = s.Contacts().Get(userID, req.ContactID)
+			if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+				return newRequestError(http.StatusBadRequest, "Contact not found")
+			} else if err != nil {
+				return err
+			}
+		} else {
+			contact = models.Contact{
+				UserID: userID,
+				Name:   invite.InviterName,
+				Email:  &invite.InviterEmail,
+			}
+			if err := s.Contacts().Create(&contact); err != nil {
+				return err
+			}
+		}
+
+		if err := s.Contacts().Link(userID, contact.ID, invite.InviterID); err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Contact is already linked to an account")
+		} else if err != nil {
+			return err
+		}
+		if err := s.Contacts().Link(invite.InviterID, invite.ContactID, userID); err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "The invite is no longer valid")
+		} else if err != nil {
+			return err
+		}
+		if err := s.Invites().Resolve(inviteID, "accepted"); err != nil {
+			return err
+		}
+
+		contact, err = s.Contacts().Get(userID, contact.ID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to accept invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *InviteHandler) DeclineInvite(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	inviteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	err = h.store.WithinTx(func(s store.Store) error {
+		if _, err := h.receivedInvite(s, userID, inviteID); err != nil {
+			return err
+		}
+		return s.Invites().Resolve(inviteID, "declined")
+	})
+	if err != nil {
+		respondError(c, err, "Failed to decline invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite declined"})
+}
+
+// CancelInvite withdraws an invite the user has sent.
+func (h *InviteHandler) CancelInvite(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	inviteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	err = h.store.WithinTx(func(s store.Store) error {
+		invite, err := s.Invites().Get(inviteID)
+		if err == store.ErrNotFound || (err == nil && (invite.InviterID != userID || invite.Status != "pending")) {
+			return newRequestError(http.StatusNotFound, "Invite not found")
+		} else if err != nil {
+			return err
+		}
+		return s.Invites().Resolve(inviteID, "cancelled")
+	})
+	if err != nil {
+		respondError(c, err, "Failed to cancel invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite cancelled"})
+}
+
+// receivedInvite loads a pending invite addressed to the user. Only a
+// verified address proves that the invite reached the right person.
+func (h *InviteHandler) receivedInvite(s store.Store, userID, inviteID int) (models.ContactInvite, error) {
+	user, err := s.Users().Get(userID)
+	if err != nil {
+		return models.ContactInvite{}, err
+	}
+
+	invite, err := s.Invites().Get(inviteID)
+	if err == store.ErrNotFound || (err == nil && (invite.Status != "pending" || !strings.EqualFold(invite.Email, user.Email))) {
+		return invite, newRequestError(http.StatusNotFound, "Invite not found")
+	} else if err != nil {
+		return invite, err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return invite, newRequestError(http.StatusForbidden, "Verify your email address to accept invites")
+	}
+	if invite.InviterID == userID {
+		return invite, newRequestError(http.StatusBadRequest, "You cannot accept your own invite")
+	}
+	return invite, nil
+}
+
+// bindOptionalJSON binds the request body like ShouldBindJSON, but accepts
+// an empty body for requests whose fields are all optional.
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if err := c.ShouldBindJSON(obj); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// internal/handlers/invites_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// verifiedUser creates a user whose email address is verified, as it has
+// to be to receive invites.
+func (ts *testServer) verifiedUser(name string) int {
+	ts.t.Helper()
+	userID := ts.user(name)
+	if err := ts.store.Users().MarkEmailVerified(userID); err != nil {
+		ts.t.Fatal(err)
+	}
+	return userID
+}
+
+// invite invites the person behind the contact at the email address.
+func (ts *testServer) invite(userID, contactID int, email string) models.ContactInvite {
+	ts.t.Helper()
+	var invite models.ContactInvite
+	ts.expect(ts.do(userID, "POST", fmt.Sprintf("/contacts/%d/invite", contactID), models.InviteContactRequest{Email: email}),
+		http.StatusCreated, &invite)
+	return invite
+}
+
+func TestAcceptInvite(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.verifiedUser("alice"), ts.verifiedUser("bob")
+		contact := ts.contact(alice, "Bob")
+		invite := ts.invite(alice, contact.ID, "bob@example.com")
+		if ts.outbox.count("bob@example.com") != 1 {
+			t.Error("the invite was not mailed to bob")
+		}
+
+		var invites models.InviteList
+		ts.expect(ts.do(bob, "GET", "/invites", nil), http.StatusOK, &invites)
+		if len(invites.Received) != 1 || invites.Received[0].ID != invite.ID || invites.Received[0].InviterName != "alice" {
+			t.Fatalf("bob received invites %+v", invites.Received)
+		}
+
+		var linked models.Contact
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/invites/%d/accept", invite.ID), nil), http.StatusOK, &linked)
+		if linked.LinkedUserID == nil || *linked.LinkedUserID != alice || linked.Name != "alice" {
+			t.Errorf("bob's new contact is %+v, want one linked to alice", linked)
+		}
+
+		// Debts with the contact are now shared.
+		debt := ts.debt(alice, contact.ID, "100", "owe_to")
+		theirs := ts.getDebt(bob, ts.counterpart(alice, debt.ID))
+		if theirs.Direction != "owe_from" || theirs.ContactID != linked.ID {
+			t.Errorf("bob's side of the debt is %+v", theirs)
+		}
+		checkBalance(t, theirs, "100.00", "active")
+
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/invites/%d/accept", invite.ID), nil), http.StatusNotFound, nil)
+	})
+}
+
+func TestDeclineAndCancelInvites(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.verifiedUser("alice"), ts.verifiedUser("bob")
+		contact := ts.contact(alice, "Bob")
+
+		declined := ts.invite(alice, contact.ID, "bob@example.com")
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/invites/%d/decline", declined.ID), nil), http.StatusOK, nil)
+
+		cancelled := ts.invite(alice, contact.ID, "bob@example.com")
+		ts.expect(ts.do(bob, "DELETE", fmt.Sprintf("/invites/%d", cancelled.ID), nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/invites/%d", cancelled.ID), nil), http.StatusOK, nil)
+
+		var invites models.InviteList
+		ts.expect(ts.do(bob, "GET", "/invites", nil), http.StatusOK, &invites)
+		if len(invites.Received) != 0 {
+			t.Errorf("bob still has the invites %+v", invites.Received)
+		}
+	})
+}
+
+func TestInviteErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob, carol := ts.verifiedUser("alice"), ts.verifiedUser("bob"), ts.user("carol")
+		contact := ts.contact(alice, "Bob")
+		pending := ts.invite(alice, contact.ID, "bob@example.com")
+
+		toCarol := ts.contact(bob, "Carol")
+		unverified := ts.invite(bob, toCarol.ID, "carol@example.com")
+
+		cases := []struct {
+			name   string
+			userID int
+			path   string
+			body   interface{}
+			status int
+		}{
+			{"invite twice", alice, fmt.Sprintf("/contacts/%d/invite", contact.ID),
+				models.InviteContactRequest{Email: "bob@example.com"}, http.StatusConflict},
+			{"invite yourself", bob, fmt.Sprintf("/contacts/%d/invite", toCarol.ID),
+				models.InviteContactRequest{Email: "bob@example.com"}, http.StatusBadRequest},
+			{"invite a contact without an email address", alice, fmt.Sprintf("/contacts/%d/invite", contact.ID),
+				nil, http.StatusBadRequest},
+			{"invite the contact of another user", carol, fmt.Sprintf("/contacts/%d/invite", contact.ID),
+				models.InviteContactRequest{Email: "dave@example.com"}, http.StatusNotFound},
+			{"accept an invite to someone else", carol, fmt.Sprintf("/invites/%d/accept", pending.ID), nil, http.StatusNotFound},
+			{"accept with an unverified address", carol, fmt.Sprintf("/invites/%d/accept", unverified.ID), nil, http.StatusForbidden},
+			{"accept your own invite", alice, fmt.Sprintf("/invites/%d/accept", pending.ID), nil, http.StatusNotFound},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(tc.userID, "POST", tc.path, tc.body), tc.status, nil)
+			})
+		}
+	})
+}
@@ -0,0 +1,59 @@
+// internal/handlers/ledger.go
+package handlers
+
+import (
+	"fmt"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// transactionTypesFor returns the transaction types that increase and
+// decrease the balance of a debt with the given direction.
+func transactionTypesFor(direction string) (increase, decrease string) {
+	if direction == "owe_to" {
+		return "borrowed", "paid_back"
+	}
+	return "lent", "received_back"
+}
+
+// validateTransactionType checks that a transaction type makes sense for the
+// direction of the debt it is recorded against.
+func validateTransactionType(direction, transactionType string) error {
+	increase, decrease := transactionTypesFor(direction)
+	if transactionType != increase && transactionType != decrease {
+		return fmt.Errorf("transaction type %q does not apply to a %s debt; use %q or %q",
+			transactionType, direction, increase, decrease)
+	}
+	return nil
+}
+
+// payable is the most that can still be paid off the debt: its balance
+// less the repayments waiting for confirmation, which would otherwise be
+// paid twice once confirmed.
+func payable(debt models.Debt) models.Money {
+	if debt.PendingAmount.IsNegative() {
+		return debt.Balance.Add(debt.PendingAmount)
+	}
+	return debt.Balance
+}
+
+func reverseDirection(direction string) string {
+	if direction == "owe_to" {
+		return "owe_from"
+	}
+	return "owe_to"
+}
+
+// requestedStatus is the status an update asks for. Only removal is up to
+// the user: asking for "active" or "settled" restores a removed debt and
+// otherwise keeps the current status, which SyncStatus derives from the
+// balance.
+func requestedStatus(current, requested string) string {
+	switch {
+	case requested == "removed":
+		return "removed"
+	case current == "removed":
+		return "active"
+	}
+	return current
+}
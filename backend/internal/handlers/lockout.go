@@ -0,0 +1,93 @@
+// internal/handlers/lockout.go
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginFailureMemory is how long a failed login counts towards a lockout.
+const LoginFailureMemory = 24 * time.Hour
+
+// LockoutConfig throttles password guessing against a single account. Once
+// an email address has Threshold failed logins in a row it is locked for
+// BaseDelay, and every further failure doubles the delay up to MaxDelay.
+// A zero Threshold disables the lockout.
+type LockoutConfig struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// delay is the lockout that follows the given number of failures.
+func (l LockoutConfig) delay(failures int) time.Duration {
+	if l.Threshold <= 0 || failures < l.Threshold {
+		return 0
+	}
+
+	delay := l.BaseDelay
+	for i := l.Threshold; i < failures && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, l.MaxDelay)
+}
+
+// loginKey normalises an email address for counting failures, so that
+// changing its case does not reset the count.
+func loginKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// lockedOut returns how long logins to the address stay locked, or zero.
+func (h *AuthHandler) lockedOut(email string) (time.Duration, error) {
+	if h.Lockout.Threshold <= 0 {
+		return 0, nil
+	}
+
+	attempt, err := h.store.LoginAttempts().Get(loginKey(email))
+	if err == store.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if attempt.LockedUntil == nil {
+		return 0, nil
+	}
+	return max(time.Until(*attempt.LockedUntil), 0), nil
+}
+
+// loginFailed counts a failed login and responds 401. Unknown addresses are
+// counted as well, so the responses do not reveal which addresses have
+// accounts.
+func (h *AuthHandler) loginFailed(c *gin.Context, email string) {
+	if err := h.recordLoginFailure(email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+}
+
+// recordLoginFailure counts a failed login and locks the address once there
+// have been too many.
+func (h *AuthHandler) recordLoginFailure(email string) error {
+	if h.Lockout.Threshold <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	failures, err := h.store.LoginAttempts().RecordFailure(loginKey(email), now, now.Add(-LoginFailureMemory))
+	if err != nil {
+		return err
+	}
+	if delay := h.Lockout.delay(failures); delay > 0 {
+		return h.store.LoginAttempts().LockUntil(loginKey(email), now.Add(delay))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// internal/handlers/lockout_test.go
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	lockout := LockoutConfig{Threshold: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{6, 8 * time.Minute},
+		{7, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := lockout.delay(tc.failures); got != tc.want {
+			t.Errorf("delay after %d failures = %s, want %s", tc.failures, got, tc.want)
+		}
+	}
+
+	if got := (LockoutConfig{}).delay(100); got != 0 {
+		t.Errorf("disabled lockout delays %s", got)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		ts.login("alice@example.com")
+		wrong := models.LoginRequest{Email: "alice@example.com", Password: "wrong1"}
+		right := models.LoginRequest{Email: "alice@example.com", Password: "secret1"}
+
+		for i := 1; i < testLockoutThreshold; i++ {
+			ts.expect(ts.do(0, "POST", "/auth/login", wrong), http.StatusUnauthorized, nil)
+		}
+		// A success clears the failures.
+		ts.expect(ts.do(0, "POST", "/auth/login", right), http.StatusOK, nil)
+
+		for i := 0; i < testLockoutThreshold; i++ {
+			ts.expect(ts.do(0, "POST", "/auth/login", wrong), http.StatusUnauthorized, nil)
+		}
+		// Locked, even with the right password and in another case.
+		right.Email = "Alice@Example.com"
+		recorder := ts.do(0, "POST", "/auth/login", right)
+		ts.expect(recorder, http.StatusTooManyRequests, nil)
+		if recorder.Header().Get("Retry-After") == "" {
+			t.Error("lockout has no Retry-After header")
+		}
+	})
+}
+
+func TestUnknownAddressesAreLockedToo(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		unknown := models.LoginRequest{Email: "carol@example.com", Password: "secret1"}
+		for i := 0; i < testLockoutThreshold; i++ {
+			ts.expect(ts.do(0, "POST", "/auth/login", unknown), http.StatusUnauthorized, nil)
+		}
+		ts.expect(ts.do(0, "POST", "/auth/login", unknown), http.StatusTooManyRequests, nil)
+	})
+}
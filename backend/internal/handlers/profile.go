@@ -0,0 +1,306 @@
+// internal/handlers/profile.go
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const changeEmailTTL = 48 * time.Hour
+
+// UpdateProfile serves PUT /auth/me, which replaces the name, phone and
+// email, and PATCH /auth/me, which changes only the fields sent. A new
+// email address only takes effect once it is confirmed through the link
+// mailed to it.
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Request.Method == http.MethodPut && (req.Name == nil || req.Email == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and email are required"})
+		return
+	}
+
+	var user models.User
+	emailChanged := false
+	err := h.store.WithinTx(func(s store.Store) error {
+		var err error
+		user, err = s.Users().GetWithPassword(userID)
+		if err != nil {
+			return err
+		}
+
+		if req.Name != nil {
+			user.Name = *req.Name
+		}
+		if req.Phone != nil || c.Request.Method == http.MethodPut {
+			user.Phone = req.Phone
+		}
+		if req.DefaultCurrency != nil {
+			user.DefaultCurrency = *req.DefaultCurrency
+		}
+		if err := s.Users().Update(&user); err != nil {
+			return err
+		}
+
+		if req.Email == nil {
+			return nil
+		}
+		if strings.EqualFold(*req.Email, user.Email) {
+			// Asking for the current address cancels a pending change
+			if user.PendingEmail == nil {
+				return nil
+			}
+			user.PendingEmail = nil
+			return s.Users().SetPendingEmail(userID, nil)
+		}
+
+		if err := h.checkPassword(s, userID, req.Password); err != nil {
+			return err
+		}
+		if _, err := s.Users().GetByEmail(*req.Email); err == nil {
+			return newRequestError(http.StatusConflict, "Email address is already in use")
+		} else if err != store.ErrNotFound {
+			return err
+		}
+
+		user.PendingEmail = req.Email
+		emailChanged = true
+		return s.Users().SetPendingEmail(userID, req.Email)
+	})
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		respondError(c, err, "Failed to update profile")
+		return
+	}
+
+	if emailChanged {
+		// A failure is logged; the link can be sent again from
+		// /auth/verify-email/send
+		h.sendEmailChangeConfirmation(user)
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ConfirmEmail completes an email change with the token mailed to the new
+// address, and lets the old address know about it.
+func (h *AuthHandler) ConfirmEmail(c *gin.Context) {
+	var req models.TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var previous, user models.User
+	err := h.store.WithinTx(func(s store.Store) error {
+		userID, err := h.ActionTokens.Verify(req.Token, auth.PurposeChangeEmail, fingerprint(s, pendingEmailFingerprint))
+		if err == auth.ErrInvalidActionToken {
+			return newRequestError(http.StatusBadRequest, "Invalid or expired token")
+		} else if err != nil {
+			return err
+		}
+
+		previous, err = s.Users().Get(userID)
+		if err != nil {
+			return err
+		}
+		// Another account may have claimed the address in the meantime
+		if other, err := s.Users().GetByEmail(*previous.PendingEmail); err == nil && other.ID != userID {
+			return newRequestError(http.StatusConflict, "Email address is already in use")
+		} else if err != nil && err != store.ErrNotFound {
+			return err
+		}
+
+		if err := s.Users().ConfirmPendingEmail(userID); err != nil {
+			return err
+		}
+		user, err = s.Users().Get(userID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to confirm email address")
+		return
+	}
+
+	if err := h.Mailer.Send(mail.EmailChanged(previous.Email, user.Name, user.Email)); err != nil {
+		log.Printf("Failed to notify user %d of their email change: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ExportAccount responds with an archive of all the user's data.
+func (h *AuthHandler) ExportAccount(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var export models.AccountExport
+	err := h.store.WithinTx(func(s store.Store) error {
+		var err error
+		export, err = exportAccount(s, userID)
+		return err
+	})
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account"})
+		return
+	}
+
+	respondWithExport(c, export)
+}
+
+// DeleteAccount deletes the user and, through the cascading foreign keys,
+// everything they own. The response is the archive of their data taken
+// just before the delete, as the last chance to keep a copy.
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var export models.AccountExport
+	err := h.store.WithinTx(func(s store.Store) error {
+		if err := h.checkPassword(s, userID, req.Password); err != nil {
+			return err
+		}
+
+		var err error
+		export, err = exportAccount(s, userID)
+		if err != nil {
+			return err
+		}
+		return s.Users().Delete(userID)
+	})
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		respondError(c, err, "Failed to delete account")
+		return
+	}
+
+	respondWithExport(c, export)
+}
+
+// checkPassword confirms a sensitive change with the user's password.
+func (h *AuthHandler) checkPassword(s store.Store, userID int, password string) error {
+	if password == "" {
+		return newRequestError(http.StatusBadRequest, "Current password is required")
+	}
+
+	user, err := s.Users().GetWithPassword(userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return newRequestError(http.StatusForbidden, "Current password is incorrect")
+	}
+	return nil
+}
+
+// sendEmailChangeConfirmation mails a confirmation link to the pending
+// address of the user.
+func (h *AuthHandler) sendEmailChangeConfirmation(user models.User) error {
+	recipient := user
+	recipient.Email = *user.PendingEmail
+
+	err := h.sendMail(recipient, auth.PurposeChangeEmail, pendingEmailFingerprint(user), changeEmailTTL, "email_token", mail.ConfirmEmailChange)
+	if err != nil {
+		log.Printf("Failed to send email change confirmation to user %d: %v", user.ID, err)
+	}
+	return err
+}
+
+// pendingEmailFingerprint changes once the pending address is confirmed,
+// cancelled or replaced.
+func pendingEmailFingerprint(user models.User) string {
+	if user.PendingEmail == nil {
+		return "none"
+	}
+	return "pending:" + *user.PendingEmail
+}
+
+func exportAccount(s store.Store, userID int) (models.AccountExport, error) {
+	export := models.AccountExport{ExportedAt: time.Now().UTC()}
+
+	var err error
+	export.User, err = s.Users().Get(userID)
+	if err != nil {
+		return export, err
+	}
+
+	export.Contacts, err = store.All(func(opts store.ListOptions) (models.Page[models.Contact], error) {
+		return s.Contacts().List(userID, store.ContactFilter{IncludeInactive: true}, opts)
+	})
+	if err != nil {
+		return export, err
+	}
+
+	export.Debts, err = store.All(func(opts store.ListOptions) (models.Page[models.Debt], error) {
+		return s.Debts().List(userID, store.DebtFilter{Statuses: debtStatuses}, opts)
+	})
+	if err != nil {
+		return export, err
+	}
+
+	export.Transactions, err = store.All(func(opts store.ListOptions) (models.Page[models.Transaction], error) {
+		return s.Transactions().List(userID, store.TransactionFilter{}, opts)
+	})
+	if err != nil {
+		return export, err
+	}
+
+	export.Groups, err = s.Groups().List(userID)
+	if err != nil {
+		return export, err
+	}
+
+	export.Expenses = []models.Expense{}
+	for _, group := range export.Groups {
+		expenses, err := s.Expenses().List(group.ID)
+		if err != nil {
+			return export, err
+		}
+		export.Expenses = append(export.Expenses, expenses...)
+	}
+
+	export.Schedules, err = s.Schedules().List(userID)
+	if err != nil {
+		return export, err
+	}
+
+	export.ImportProfiles, err = s.ImportProfiles().List(userID)
+	if err != nil {
+		return export, err
+	}
+	return export, nil
+}
+
+// respondWithExport sends the archive as a file download.
+func respondWithExport(c *gin.Context, export models.AccountExport) {
+	filename := fmt.Sprintf("debt-tracker-account-%d-%s.json", export.User.ID, export.ExportedAt.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
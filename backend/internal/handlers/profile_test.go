@@ -0,0 +1,110 @@
+// internal/handlers/profile_test.go
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		name, phone := "Alice Smith", "+27 82 555 0100"
+
+		var user models.User
+		ts.expect(ts.do(alice, "PATCH", "/auth/me", models.UpdateProfileRequest{Phone: &phone}), http.StatusOK, &user)
+		if user.Name != "Alice" || user.Phone == nil || *user.Phone != phone {
+			t.Errorf("patched profile is %+v", user)
+		}
+
+		// PUT replaces the profile, so the phone left out is cleared.
+		email := "alice@example.com"
+		var replaced models.User
+		ts.expect(ts.do(alice, "PUT", "/auth/me", models.UpdateProfileRequest{Name: &name, Email: &email}), http.StatusOK, &replaced)
+		if replaced.Name != name || replaced.Phone != nil {
+			t.Errorf("replaced profile is %+v", replaced)
+		}
+	})
+}
+
+func TestChangeEmail(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		email := "alice@example.org"
+
+		var user models.User
+		ts.expect(ts.do(alice, "PATCH", "/auth/me", models.UpdateProfileRequest{Email: &email, Password: "secret1"}), http.StatusOK, &user)
+		if user.Email != "alice@example.com" || user.PendingEmail == nil || *user.PendingEmail != email {
+			t.Fatalf("profile with a pending email is %+v", user)
+		}
+
+		token := ts.outbox.token(t, email, "email_token")
+		var confirmed models.User
+		ts.expect(ts.do(0, "POST", "/auth/confirm-email", models.TokenRequest{Token: token}), http.StatusOK, &confirmed)
+		if confirmed.Email != email || confirmed.PendingEmail != nil {
+			t.Errorf("profile after the confirmation is %+v", confirmed)
+		}
+		ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: email, Password: "secret1"}), http.StatusOK, nil)
+
+		// The link is spent once the change is made.
+		ts.expect(ts.do(0, "POST", "/auth/confirm-email", models.TokenRequest{Token: token}), http.StatusBadRequest, nil)
+	})
+}
+
+func TestProfileErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		ts.login("bob@example.com")
+		name, taken, other := "Alice", "bob@example.com", "alice@example.org"
+
+		cases := []struct {
+			name   string
+			method string
+			body   interface{}
+			status int
+		}{
+			{"replace without an email", "PUT", models.UpdateProfileRequest{Name: &name}, http.StatusBadRequest},
+			{"change the email without the password", "PATCH", models.UpdateProfileRequest{Email: &other}, http.StatusBadRequest},
+			{"change the email with the wrong password", "PATCH",
+				models.UpdateProfileRequest{Email: &other, Password: "wrong1"}, http.StatusForbidden},
+			{"change to the email of another user", "PATCH",
+				models.UpdateProfileRequest{Email: &taken, Password: "secret1"}, http.StatusConflict},
+			{"delete with the wrong password", "DELETE", models.PasswordRequest{Password: "wrong1"}, http.StatusForbidden},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(alice, tc.method, "/auth/me", tc.body), tc.status, nil)
+			})
+		}
+	})
+}
+
+func TestExportAndDeleteAccount(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		bob := ts.contact(alice, "Bob")
+		debt := ts.debt(alice, bob.ID, "100", "owe_to")
+		ts.pay(alice, debt.ID, "40", "paid_back")
+
+		var export models.AccountExport
+		ts.expect(ts.do(alice, "GET", "/auth/me/export", nil), http.StatusOK, &export)
+		if export.User.ID != alice || len(export.Contacts) != 1 || len(export.Debts) != 1 || len(export.Transactions) != 1 {
+			t.Errorf("export has user %d, %d contacts, %d debts and %d transactions",
+				export.User.ID, len(export.Contacts), len(export.Debts), len(export.Transactions))
+		}
+
+		var deleted models.AccountExport
+		ts.expect(ts.do(alice, "DELETE", "/auth/me", models.PasswordRequest{Password: "secret1"}), http.StatusOK, &deleted)
+		if len(deleted.Debts) != 1 {
+			t.Errorf("archive of the deleted account has %d debts", len(deleted.Debts))
+		}
+
+		ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "secret1"}), http.StatusUnauthorized, nil)
+		if _, err := ts.store.Debts().Get(alice, debt.ID); err == nil {
+			t.Error("debt of the deleted account is still stored")
+		}
+	})
+}
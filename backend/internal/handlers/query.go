@@ -0,0 +1,129 @@
+// internal/handlers/query.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseListOptions reads the limit, cursor, sort and order query parameters.
+func parseListOptions(c *gin.Context) (store.ListOptions, error) {
+	opts := store.ListOptions{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+		Order:  c.Query("order"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > store.MaxLimit {
+			return opts, fmt.Errorf("limit must be between 1 and %d", store.MaxLimit)
+		}
+		opts.Limit = n
+	}
+
+	return opts, nil
+}
+
+// parseCreatedRange reads created_from and created_to. Both accept a date
+// (YYYY-MM-DD) or an RFC 3339 timestamp; a date in created_to includes the
+// whole day. It returns the half-open range [from, before).
+func parseCreatedRange(c *gin.Context) (from, before *time.Time, err error) {
+	if value := c.Query("created_from"); value != "" {
+		t, _, err := parseDateOrTime(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("created_from: %w", err)
+		}
+		from = &t
+	}
+
+	if value := c.Query("created_to"); value != "" {
+		t, dateOnly, err := parseDateOrTime(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("created_to: %w", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		} else {
+			t = t.Add(time.Microsecond)
+		}
+		before = &t
+	}
+
+	return from, before, nil
+}
+
+func parseDateOrTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, false, fmt.Errorf("expected a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+	return t.UTC(), false, nil
+}
+
+// parseAmountRange reads min_amount and max_amount.
+func parseAmountRange(c *gin.Context) (min, max *models.Money, err error) {
+	if value := c.Query("min_amount"); value != "" {
+		amount, err := models.ParseMoney(value, models.DefaultCurrency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("min_amount: %w", err)
+		}
+		min = &amount
+	}
+
+	if value := c.Query("max_amount"); value != "" {
+		amount, err := models.ParseMoney(value, models.DefaultCurrency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("max_amount: %w", err)
+		}
+		max = &amount
+	}
+
+	return min, max, nil
+}
+
+var debtStatuses = []string{"active", "settled", "removed"}
+
+// parseDebtStatuses reads the status filter of the debt list: a
+// comma-separated set of statuses, or "all".
+func parseDebtStatuses(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if value == "all" {
+		return debtStatuses, nil
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(value, ",") {
+		status = strings.TrimSpace(status)
+		if !slices.Contains(debtStatuses, status) {
+			return nil, fmt.Errorf("invalid status %q; use %s or all", status, strings.Join(debtStatuses, ", "))
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// respondListError answers a failed list query: invalid sort fields and
+// cursors are the client's fault, anything else is ours.
+func respondListError(c *gin.Context, err error, message string) {
+	if errors.Is(err, store.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
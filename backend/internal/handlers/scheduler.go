@@ -0,0 +1,255 @@
+// internal/handlers/scheduler.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/recurrence"
+	"debt-tracker-backend/internal/store"
+)
+
+// Schedules are run by RunSchedules, and once straight away when a
+// schedule is created with occurrences already due. A run creates every
+// occurrence from next_date up to today and then moves next_date past
+// them, all in one store transaction. Moving next_date only succeeds if no
+// other run has moved it in the meantime, so an occurrence is created
+// exactly once however often or concurrently schedules are run.
+
+// errScheduleEnded stops a run whose debt or contact is gone, or whose
+// debt has been paid off, and finishes the schedule.
+var errScheduleEnded = errors.New("schedule has nothing left to act on")
+
+// RunSchedules returns a job that creates the occurrences of all schedules
+// that have fallen due.
+func RunSchedules(st store.Store) func() error {
+	return func() error {
+		today := recurrence.Truncate(time.Now())
+		due, err := st.Schedules().Due(recurrence.FormatDay(today))
+		if err != nil {
+			return err
+		}
+
+		created := 0
+		var failures []error
+		for _, schedule := range due {
+			n, err := runSchedule(st, schedule, today)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("schedule %d: %w", schedule.ID, err))
+				continue
+			}
+			created += n
+		}
+
+		if created > 0 {
+			log.Printf("Created %d scheduled occurrence(s)", created)
+		}
+		return errors.Join(failures...)
+	}
+}
+
+// scheduleRule reads the stored rule and start day of a schedule.
+func scheduleRule(schedule models.Schedule) (recurrence.Rule, time.Time, error) {
+	rule, err := recurrence.Parse(schedule.RRule)
+	if err != nil {
+		return rule, time.Time{}, err
+	}
+	start, err := recurrence.ParseDay(schedule.StartDate)
+	return rule, start, err
+}
+
+// runSchedule creates the occurrences of the schedule that are due by
+// today and returns how many it created. Skipped occurrences are passed
+// over and edited ones use their own amount and description.
+func runSchedule(st store.Store, schedule models.Schedule, today time.Time) (int, error) {
+	created := 0
+	err := st.WithinTx(func(s store.Store) error {
+		created = 0
+
+		// Lock the debt first, as CreateTransaction does, so that payments
+		// are checked against an up-to-date balance
+		var counterpart models.Debt
+		var shared bool
+		if schedule.Kind == "transaction" {
+			debt, err := s.Debts().Lock(schedule.UserID, *schedule.DebtID)
+			if err != nil && err != store.ErrNotFound {
+				return err
+			}
+			if err == nil {
+				if counterpart, shared, err = lockCounterpart(s, debt); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Reload the schedule now that the debt is locked; it may have been
+		// paused, edited or run since it was listed.
+		current, err := s.Schedules().Get(schedule.UserID, schedule.ID)
+		if err == store.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if current.Status != "active" || current.NextDate == nil {
+			return nil
+		}
+		from := *current.NextDate
+		if from > recurrence.FormatDay(today) {
+			return nil
+		}
+
+		rule, start, err := scheduleRule(current)
+		if err != nil {
+			return err
+		}
+		first, err := recurrence.ParseDay(from)
+		if err != nil {
+			return err
+		}
+
+		var next *string
+		var failure error
+		rule.Iterate(start, func(day time.Time) bool {
+			if day.Before(first) {
+				return true
+			}
+			if day.After(today) {
+				formatted := recurrence.FormatDay(day)
+				next = &formatted
+				return false
+			}
+
+			ok, err := createOccurrence(s, current, recurrence.FormatDay(day), counterpart, shared)
+			if err != nil {
+				failure = err
+				return false
+			}
+			if ok {
+				created++
+			}
+			return true
+		})
+		if failure == errScheduleEnded {
+			next = nil
+		} else if failure != nil {
+			return failure
+		}
+
+		return s.Schedules().Advance(current.ID, from, next)
+	})
+	if err == store.ErrNotFound {
+		// Another run moved next_date first and has created the occurrences.
+		return 0, nil
+	}
+	return created, err
+}
+
+// createOccurrence creates the debt or transaction of one occurrence and
+// records it. It reports false if the occurrence was skipped.
+func createOccurrence(s store.Store, schedule models.Schedule, day string, counterpart models.Debt, shared bool) (bool, error) {
+	occurrence, err := s.Schedules().GetOccurrence(schedule.ID, day)
+	if err == store.ErrNotFound {
+		occurrence = models.ScheduleOccurrence{ScheduleID: schedule.ID, Date: day}
+	} else if err != nil {
+		return false, err
+	}
+	if occurrence.Status == "skipped" || occurrence.Status == "created" {
+		return false, nil
+	}
+	occurrence = resolveOccurrence(schedule, occurrence)
+
+	if schedule.Kind == "debt" {
+		contact, err := s.Contacts().Get(schedule.UserID, *schedule.ContactID)
+		if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+			return false, errScheduleEnded
+		} else if err != nil {
+			return false, err
+		}
+
+		debt := models.Debt{
+			UserID:         schedule.UserID,
+			ContactID:      contact.ID,
+			OriginalAmount: occurrence.Amount,
+			Currency:       schedule.Currency,
+			Direction:      *schedule.Direction,
+			Description:    occurrence.Description,
+		}
+		if err := createDebt(s, &debt, contact); err != nil {
+			return false, err
+		}
+		occurrence.DebtID = &debt.ID
+	} else {
+		debt, err := s.Debts().Get(schedule.UserID, *schedule.DebtID)
+		if err == store.ErrNotFound || (err == nil && debt.Status == "removed") {
+			return false, errScheduleEnded
+		} else if err != nil {
+			return false, err
+		}
+		if err := validateTransactionType(debt.Direction, *schedule.TransactionType); err != nil {
+			return false, errScheduleEnded
+		}
+
+		// A repayment never takes the debt below zero; the last one is cut
+		// down to what is left, and a paid-off debt ends the schedule. While
+		// repayments waiting for confirmation cover the rest, the
+		// occurrence is left out.
+		_, decrease := transactionTypesFor(debt.Direction)
+		remaining := payable(debt)
+		if *schedule.TransactionType == decrease && occurrence.Amount.Cmp(remaining) > 0 {
+			if !debt.Balance.IsPositive() {
+				return false, errScheduleEnded
+			}
+			if !remaining.IsPositive() {
+				return false, nil
+			}
+			occurrence.Amount = remaining
+		}
+
+		transaction := models.Transaction{
+			DebtID:          debt.ID,
+			Amount:          occurrence.Amount,
+			TransactionType: *schedule.TransactionType,
+			Description:     occurrence.Description,
+		}
+		if shared {
+			transaction.Confirmation = "pending"
+			transaction.CreatedBy = &schedule.UserID
+		}
+		if err := s.Transactions().Create(&transaction); err != nil {
+			return false, err
+		}
+		if err := s.Debts().SyncStatus(debt.ID); err != nil {
+			return false, err
+		}
+		if shared {
+			if err := mirrorTransaction(s, transaction, debt, counterpart); err != nil {
+				return false, err
+			}
+		}
+		occurrence.TransactionID = &transaction.ID
+	}
+
+	occurrence.Status = "created"
+	return true, s.Schedules().SaveOccurrence(&occurrence)
+}
+
+// resolveOccurrence fills in the amount and description an edited or
+// plain occurrence takes from its schedule.
+func resolveOccurrence(schedule models.Schedule, occurrence models.ScheduleOccurrence) models.ScheduleOccurrence {
+	if occurrence.Status == "" {
+		occurrence.Status = "scheduled"
+	}
+	if occurrence.Status == "scheduled" || occurrence.Status == "edited" {
+		if occurrence.Amount.IsZero() {
+			occurrence.Amount = schedule.Amount
+		}
+		if occurrence.Description == nil {
+			occurrence.Description = schedule.Description
+		}
+	}
+	occurrence.Amount.Currency = schedule.Currency
+	return occurrence
+}
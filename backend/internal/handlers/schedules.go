@@ -0,0 +1,553 @@
+// internal/handlers/schedules.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/recurrence"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultOccurrenceLimit = 10
+
+type ScheduleHandler struct {
+	store store.Store
+}
+
+func NewScheduleHandler(s store.Store) *ScheduleHandler {
+	return &ScheduleHandler{store: s}
+}
+
+// parseRecurrence reads the rule and start day of a request, which gives
+// either an RRULE or a frequency.
+func parseRecurrence(req models.RecurrenceRequest) (recurrence.Rule, time.Time, error) {
+	var rule recurrence.Rule
+	start, err := recurrence.ParseDay(req.StartDate)
+	if err != nil {
+		return rule, start, errors.New("start_date must be a date such as 2025-07-01")
+	}
+
+	switch {
+	case req.RRule != "" && req.Frequency != "":
+		return rule, start, errors.New("give either rrule or frequency, not both")
+	case req.RRule != "":
+		rule, err = recurrence.Parse(req.RRule)
+		return rule, start, err
+	case req.Frequency == "":
+		return rule, start, errors.New("rrule or frequency is required")
+	}
+
+	rule = recurrence.Rule{
+		Freq:     strings.ToUpper(req.Frequency),
+		Interval: max(req.Interval, 1),
+		Count:    req.Count,
+	}
+	if req.Until != "" {
+		if rule.Until, err = recurrence.ParseDay(req.Until); err != nil {
+			return rule, start, errors.New("until must be a date such as 2025-12-31")
+		}
+	}
+	return rule, start, rule.Validate()
+}
+
+// nextOccurrence returns the first occurrence of the rule on or after the
+// day, or nil if there is none.
+func nextOccurrence(rule recurrence.Rule, start, day time.Time) *string {
+	next, ok := rule.Next(start, day)
+	if !ok {
+		return nil
+	}
+	formatted := recurrence.FormatDay(next)
+	return &formatted
+}
+
+// scheduleFor loads one of the user's schedules, answering 404 if there is
+// none.
+func scheduleFor(s store.Store, userID, scheduleID int) (models.Schedule, error) {
+	schedule, err := s.Schedules().Get(userID, scheduleID)
+	if err == store.ErrNotFound {
+		return schedule, newRequestError(http.StatusNotFound, "Schedule not found")
+	}
+	return schedule, err
+}
+
+// runIfDue creates the occurrences of a schedule that are already due, as
+// for a start date in the past, and returns the schedule as it then is.
+// A failure is left for the next run of RunSchedules.
+func (h *ScheduleHandler) runIfDue(schedule models.Schedule) models.Schedule {
+	today := recurrence.Truncate(time.Now())
+	if schedule.Status != "active" || schedule.NextDate == nil || *schedule.NextDate > recurrence.FormatDay(today) {
+		return schedule
+	}
+
+	if _, err := runSchedule(h.store, schedule, today); err != nil {
+		log.Printf("Failed to run schedule %d: %v", schedule.ID, err)
+		return schedule
+	}
+	if updated, err := h.store.Schedules().Get(schedule.UserID, schedule.ID); err == nil {
+		schedule = updated
+	}
+	return schedule
+}
+
+func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	schedules, err := h.store.Schedules().List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+	if req.Kind == "debt" && (req.ContactID == 0 || req.Direction == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A debt schedule needs contact_id and direction"})
+		return
+	}
+	if req.Kind == "transaction" && (req.DebtID == 0 || req.TransactionType == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A transaction schedule needs debt_id and transaction_type"})
+		return
+	}
+
+	rule, start, err := parseRecurrence(req.RecurrenceRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// A start date in the past is allowed; the missed occurrences are
+	// created straight away.
+	next := nextOccurrence(rule, start, start)
+	if next == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recurrence rule has no occurrences"})
+		return
+	}
+
+	schedule := models.Schedule{
+		UserID:      userID,
+		Kind:        req.Kind,
+		Amount:      req.Amount,
+		Description: &req.Description,
+		RRule:       rule.String(),
+		StartDate:   recurrence.FormatDay(start),
+		NextDate:    next,
+	}
+	err = h.store.WithinTx(func(s store.Store) error {
+		if req.Kind == "debt" {
+			contact, err := s.Contacts().Get(userID, req.ContactID)
+			if err == store.ErrNotFound || (err == nil && !contact.IsActive) {
+				return newRequestError(http.StatusBadRequest, "Contact not found")
+			} else if err != nil {
+				return err
+			}
+
+			currency, err := currencyFor(s, userID, req.Currency)
+			if err != nil {
+				return err
+			}
+			schedule.ContactID = &contact.ID
+			schedule.Direction = &req.Direction
+			schedule.Currency = currency
+		} else {
+			debt, err := s.Debts().Get(userID, req.DebtID)
+			if err == store.ErrNotFound {
+				return newRequestError(http.StatusBadRequest, "Debt not found")
+			} else if err != nil {
+				return err
+			}
+
+			if debt.Status == "removed" {
+				return newRequestError(http.StatusConflict, "Cannot schedule transactions on a removed debt")
+			}
+			if err := validateTransactionType(debt.Direction, req.TransactionType); err != nil {
+				return newRequestError(http.StatusBadRequest, err.Error())
+			}
+			if req.Currency != "" && req.Currency != debt.Currency {
+				return newRequestError(http.StatusBadRequest, "Transactions must be in the currency of the debt")
+			}
+			schedule.DebtID = &debt.ID
+			schedule.TransactionType = &req.TransactionType
+			schedule.Currency = debt.Currency
+		}
+		return s.Schedules().Create(&schedule)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create schedule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.runIfDue(schedule))
+}
+
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	schedule, err := h.store.Schedules().Get(userID, scheduleID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule changes the amount, description and rule of the
+// occurrences still to come. Those already created are left as they are.
+// A finished schedule is reopened if the new rule has occurrences left.
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	var req models.UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	rule, start, err := parseRecurrence(req.RecurrenceRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var schedule models.Schedule
+	err = h.store.WithinTx(func(s store.Store) error {
+		schedule, err = scheduleFor(s, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		// Occurrences before the old next date have been created already.
+		from := recurrence.Truncate(time.Now())
+		if schedule.NextDate != nil {
+			if from, err = recurrence.ParseDay(*schedule.NextDate); err != nil {
+				return err
+			}
+		}
+
+		schedule.Amount = req.Amount
+		schedule.Description = &req.Description
+		schedule.RRule = rule.String()
+		schedule.StartDate = recurrence.FormatDay(start)
+		schedule.NextDate = nextOccurrence(rule, start, from)
+		if schedule.NextDate == nil {
+			schedule.Status = "finished"
+		} else if schedule.Status == "finished" {
+			schedule.Status = "active"
+		}
+		return s.Schedules().Update(&schedule)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.runIfDue(schedule))
+}
+
+// DeleteSchedule stops the schedule for good. The debts and transactions
+// it has created are kept.
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	err = h.store.Schedules().Delete(userID, scheduleID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+func (h *ScheduleHandler) PauseSchedule(c *gin.Context) {
+	h.setScheduleStatus(c, "paused")
+}
+
+// ResumeSchedule carries on from today. Occurrences that fell due while
+// the schedule was paused are not created.
+func (h *ScheduleHandler) ResumeSchedule(c *gin.Context) {
+	h.setScheduleStatus(c, "active")
+}
+
+func (h *ScheduleHandler) setScheduleStatus(c *gin.Context, status string) {
+	userID := c.GetInt("user_id")
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	var schedule models.Schedule
+	err = h.store.WithinTx(func(s store.Store) error {
+		schedule, err = scheduleFor(s, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+		if schedule.Status == "finished" {
+			return newRequestError(http.StatusConflict, "Schedule has finished")
+		}
+		if schedule.Status == status {
+			return nil
+		}
+
+		if status == "active" {
+			rule, start, err := scheduleRule(schedule)
+			if err != nil {
+				return err
+			}
+			today := recurrence.FormatDay(recurrence.Truncate(time.Now()))
+			if schedule.NextDate == nil || *schedule.NextDate < today {
+				schedule.NextDate = nextOccurrence(rule, start, recurrence.Truncate(time.Now()))
+			}
+			if schedule.NextDate == nil {
+				status = "finished"
+			}
+		}
+		schedule.Status = status
+		return s.Schedules().Update(&schedule)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.runIfDue(schedule))
+}
+
+// GetOccurrences previews the upcoming occurrences of the schedule with
+// any edits and skips applied.
+func (h *ScheduleHandler) GetOccurrences(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	var query models.OccurrenceListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultOccurrenceLimit
+	}
+
+	var occurrences []models.ScheduleOccurrence
+	err = h.store.WithinTx(func(s store.Store) error {
+		schedule, err := scheduleFor(s, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		occurrences = []models.ScheduleOccurrence{}
+		if schedule.NextDate == nil {
+			return nil
+		}
+		rule, start, err := scheduleRule(schedule)
+		if err != nil {
+			return err
+		}
+		next, err := recurrence.ParseDay(*schedule.NextDate)
+		if err != nil {
+			return err
+		}
+
+		stored, err := s.Schedules().Occurrences(schedule.ID)
+		if err != nil {
+			return err
+		}
+		byDay := make(map[string]models.ScheduleOccurrence, len(stored))
+		for _, occurrence := range stored {
+			byDay[occurrence.Date] = occurrence
+		}
+
+		for _, day := range rule.Upcoming(start, next, query.Limit) {
+			occurrence, ok := byDay[recurrence.FormatDay(day)]
+			if !ok {
+				occurrence = models.ScheduleOccurrence{ScheduleID: schedule.ID, Date: recurrence.FormatDay(day)}
+			}
+			occurrences = append(occurrences, resolveOccurrence(schedule, occurrence))
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to get occurrences")
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+// futureOccurrence checks that the day in the URL is an occurrence of the
+// schedule that has not been created yet.
+func futureOccurrence(c *gin.Context, s store.Store, userID int) (models.Schedule, string, error) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.Schedule{}, "", newRequestError(http.StatusBadRequest, "Invalid schedule ID")
+	}
+	day, err := recurrence.ParseDay(c.Param("date"))
+	if err != nil {
+		return models.Schedule{}, "", newRequestError(http.StatusBadRequest, "Invalid occurrence date")
+	}
+
+	schedule, err := scheduleFor(s, userID, scheduleID)
+	if err != nil {
+		return schedule, "", err
+	}
+	if schedule.NextDate == nil {
+		return schedule, "", newRequestError(http.StatusConflict, "Schedule has finished")
+	}
+
+	rule, start, err := scheduleRule(schedule)
+	if err != nil {
+		return schedule, "", err
+	}
+	if !rule.Includes(start, day) {
+		return schedule, "", newRequestError(http.StatusNotFound, "Occurrence not found")
+	}
+	if recurrence.FormatDay(day) < *schedule.NextDate {
+		return schedule, "", newRequestError(http.StatusConflict, "Occurrence has already been created")
+	}
+	return schedule, recurrence.FormatDay(day), nil
+}
+
+// UpdateOccurrence changes the amount or description of one upcoming
+// occurrence. Editing a skipped occurrence brings it back.
+func (h *ScheduleHandler) UpdateOccurrence(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.OccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount != nil && !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	var occurrence models.ScheduleOccurrence
+	err := h.store.WithinTx(func(s store.Store) error {
+		schedule, day, err := futureOccurrence(c, s, userID)
+		if err != nil {
+			return err
+		}
+
+		// A zero amount or no description takes the schedule's, even if
+		// that changes later.
+		occurrence = models.ScheduleOccurrence{
+			ScheduleID:  schedule.ID,
+			Date:        day,
+			Status:      "edited",
+			Description: req.Description,
+		}
+		if req.Amount != nil {
+			occurrence.Amount = *req.Amount
+		}
+		if err := s.Schedules().SaveOccurrence(&occurrence); err != nil {
+			return err
+		}
+		occurrence = resolveOccurrence(schedule, occurrence)
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrence)
+}
+
+func (h *ScheduleHandler) SkipOccurrence(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var occurrence models.ScheduleOccurrence
+	err := h.store.WithinTx(func(s store.Store) error {
+		schedule, day, err := futureOccurrence(c, s, userID)
+		if err != nil {
+			return err
+		}
+
+		occurrence = models.ScheduleOccurrence{ScheduleID: schedule.ID, Date: day, Status: "skipped"}
+		return s.Schedules().SaveOccurrence(&occurrence)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to skip occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrence)
+}
+
+// ResetOccurrence undoes the edit or skip of an upcoming occurrence.
+func (h *ScheduleHandler) ResetOccurrence(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	err := h.store.WithinTx(func(s store.Store) error {
+		schedule, day, err := futureOccurrence(c, s, userID)
+		if err != nil {
+			return err
+		}
+
+		err = s.Schedules().DeleteOccurrence(schedule.ID, day)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Occurrence has not been edited or skipped")
+		}
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to reset occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrence reset successfully"})
+}
@@ -0,0 +1,190 @@
+// internal/handlers/schedules_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/recurrence"
+)
+
+// daysFromToday returns the day n days from today, as schedules give it.
+func daysFromToday(n int) string {
+	return recurrence.FormatDay(recurrence.Truncate(time.Now()).AddDate(0, 0, n))
+}
+
+// schedule creates a schedule of the user.
+func (ts *testServer) schedule(userID int, req models.CreateScheduleRequest) models.Schedule {
+	ts.t.Helper()
+	var schedule models.Schedule
+	ts.expect(ts.do(userID, "POST", "/schedules", req), http.StatusCreated, &schedule)
+	return schedule
+}
+
+// debtTransactions lists the transactions of the user's debt.
+func (ts *testServer) debtTransactions(userID, debtID int) []models.Transaction {
+	ts.t.Helper()
+	var page models.Page[models.Transaction]
+	ts.expect(ts.do(userID, "GET", fmt.Sprintf("/transactions?debt_id=%d", debtID), nil), http.StatusOK, &page)
+	return page.Data
+}
+
+func TestScheduleCatchesUpAndStopsAtZero(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+
+		// Six daily repayments of 30 are due, but the fourth pays off the
+		// last 10 and the fifth finds nothing left.
+		schedule := ts.schedule(userID, models.CreateScheduleRequest{
+			Kind: "transaction", DebtID: debt.ID, TransactionType: "paid_back", Amount: money(t, "30"),
+			RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(-5), Frequency: "daily"},
+		})
+		if schedule.Status != "finished" || schedule.NextDate != nil {
+			t.Errorf("schedule is %s with next date %v, want finished", schedule.Status, schedule.NextDate)
+		}
+
+		transactions := ts.debtTransactions(userID, debt.ID)
+		if len(transactions) != 4 {
+			t.Fatalf("schedule recorded %d transactions, want 4", len(transactions))
+		}
+		checkBalance(t, ts.getDebt(userID, debt.ID), "0.00", "settled")
+	})
+}
+
+func TestScheduleLeavesOutPendingRepayments(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+		debt := ts.debt(aliceID, bob.ID, "100", "owe_to")
+		theirs := ts.counterpart(aliceID, debt.ID)
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/confirm", theirs), nil), http.StatusOK, nil)
+
+		// The first repayment is 60 and the second the 40 that is left.
+		// Until bob confirms them, the third has nothing to pay.
+		schedule := ts.schedule(aliceID, models.CreateScheduleRequest{
+			Kind: "transaction", DebtID: debt.ID, TransactionType: "paid_back", Amount: money(t, "60"),
+			RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(-2), Frequency: "daily"},
+		})
+		if schedule.Status != "active" || schedule.NextDate == nil || *schedule.NextDate != daysFromToday(1) {
+			t.Errorf("schedule is %s with next date %v, want active tomorrow", schedule.Status, schedule.NextDate)
+		}
+
+		transactions := ts.debtTransactions(aliceID, debt.ID)
+		var total models.Money
+		for _, transaction := range transactions {
+			total = total.Add(transaction.Amount)
+		}
+		if len(transactions) != 2 || total.String() != "100.00" {
+			t.Errorf("schedule recorded %d transactions of %s, want 2 of 100.00", len(transactions), total)
+		}
+		if pending := ts.getDebt(aliceID, debt.ID); pending.PendingAmount.String() != "-100.00" {
+			t.Errorf("pending amount is %s, want -100.00", pending.PendingAmount)
+		}
+	})
+}
+
+func TestScheduleOccurrences(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		schedule := ts.schedule(userID, models.CreateScheduleRequest{
+			Kind: "debt", ContactID: bob.ID, Direction: "owe_from", Amount: money(t, "50"), Description: "Rent",
+			RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(1), RRule: "FREQ=WEEKLY;COUNT=3"},
+		})
+		path := fmt.Sprintf("/schedules/%d", schedule.ID)
+		first, second, third := daysFromToday(1), daysFromToday(8), daysFromToday(15)
+
+		ts.expect(ts.do(userID, "POST", path+"/occurrences/"+first+"/skip", nil), http.StatusOK, nil)
+		amount := money(t, "75")
+		ts.expect(ts.do(userID, "PUT", path+"/occurrences/"+second, models.OccurrenceRequest{Amount: &amount}), http.StatusOK, nil)
+
+		var occurrences []models.ScheduleOccurrence
+		ts.expect(ts.do(userID, "GET", path+"/occurrences", nil), http.StatusOK, &occurrences)
+		got := make([]string, len(occurrences))
+		for i, occurrence := range occurrences {
+			got[i] = fmt.Sprintf("%s %s %s", occurrence.Date, occurrence.Status, occurrence.Amount)
+		}
+		want := []string{first + " skipped 0.00", second + " edited 75.00", third + " scheduled 50.00"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("occurrences are %v, want %v", got, want)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", path+"/occurrences/"+first, nil), http.StatusOK, nil)
+		ts.expect(ts.do(userID, "GET", path+"/occurrences?limit=1", nil), http.StatusOK, &occurrences)
+		if len(occurrences) != 1 || occurrences[0].Status != "scheduled" {
+			t.Errorf("occurrences after the reset are %+v", occurrences)
+		}
+
+		ts.expect(ts.do(userID, "POST", path+"/pause", nil), http.StatusOK, &schedule)
+		if schedule.Status != "paused" {
+			t.Errorf("paused schedule is %s", schedule.Status)
+		}
+		ts.expect(ts.do(userID, "POST", path+"/resume", nil), http.StatusOK, &schedule)
+		if schedule.Status != "active" || schedule.NextDate == nil || *schedule.NextDate != first {
+			t.Errorf("resumed schedule is %s with next date %v", schedule.Status, schedule.NextDate)
+		}
+
+		update := models.UpdateScheduleRequest{Amount: money(t, "55"), Description: "Rent",
+			RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(1), Frequency: "monthly"}}
+		ts.expect(ts.do(userID, "PUT", path, update), http.StatusOK, &schedule)
+		if schedule.RRule != "FREQ=MONTHLY" || schedule.Amount.String() != "55.00" {
+			t.Errorf("updated schedule is %+v", schedule)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", path, nil), http.StatusOK, nil)
+		var schedules []models.Schedule
+		ts.expect(ts.do(userID, "GET", "/schedules", nil), http.StatusOK, &schedules)
+		if len(schedules) != 0 {
+			t.Errorf("schedules after the delete are %+v", schedules)
+		}
+	})
+}
+
+func TestScheduleErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		otherID := ts.user("mallory")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+		weekly := models.RecurrenceRequest{StartDate: daysFromToday(1), Frequency: "weekly"}
+
+		for _, req := range []models.CreateScheduleRequest{
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", RecurrenceRequest: weekly},
+			{Kind: "debt", Amount: money(t, "10"), RecurrenceRequest: weekly},
+			{Kind: "transaction", DebtID: debt.ID, Amount: money(t, "10"), RecurrenceRequest: weekly},
+			{Kind: "transaction", DebtID: debt.ID, TransactionType: "received_back", Amount: money(t, "10"), RecurrenceRequest: weekly},
+			{Kind: "transaction", DebtID: debt.ID, TransactionType: "paid_back", Amount: money(t, "10"), Currency: "USD", RecurrenceRequest: weekly},
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"), Currency: "JPY", RecurrenceRequest: weekly},
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"),
+				RecurrenceRequest: models.RecurrenceRequest{StartDate: "tomorrow", Frequency: "weekly"}},
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"),
+				RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(1), Frequency: "weekly", RRule: "FREQ=WEEKLY"}},
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"),
+				RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(1), RRule: "FREQ=YEARLY"}},
+			{Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"),
+				RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(1), Frequency: "daily", Until: daysFromToday(0)}},
+		} {
+			ts.expect(ts.do(userID, "POST", "/schedules", req), http.StatusBadRequest, nil)
+		}
+		ts.expect(ts.do(otherID, "POST", "/schedules", models.CreateScheduleRequest{
+			Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"), RecurrenceRequest: weekly,
+		}), http.StatusBadRequest, nil)
+
+		schedule := ts.schedule(userID, models.CreateScheduleRequest{
+			Kind: "debt", ContactID: bob.ID, Direction: "owe_to", Amount: money(t, "10"),
+			RecurrenceRequest: models.RecurrenceRequest{StartDate: daysFromToday(-7), Frequency: "weekly"},
+		})
+		path := fmt.Sprintf("/schedules/%d", schedule.ID)
+		ts.expect(ts.do(otherID, "GET", path, nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "GET", "/schedules/abc", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "POST", path+"/occurrences/"+daysFromToday(8)+"/skip", nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "POST", path+"/occurrences/"+daysFromToday(-7)+"/skip", nil), http.StatusConflict, nil)
+		ts.expect(ts.do(userID, "POST", path+"/occurrences/someday/skip", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(userID, "DELETE", path+"/occurrences/"+daysFromToday(7), nil), http.StatusNotFound, nil)
+	})
+}
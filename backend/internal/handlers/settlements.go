@@ -0,0 +1,261 @@
+// internal/handlers/settlements.go
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A settlement plan settles the user's active, confirmed debts, optionally
+// only those of one group, with as few transfers as it can. Each person's
+// debts are netted into a single balance, and the largest debtor then pays
+// the largest creditor until everyone is even. That takes at most one
+// transfer fewer than the number of people with a balance; people whose
+// balances cancel out exactly are paired first. Settling the plan records
+// a repayment of what is left to pay on every debt it covers.
+
+// settlementDebts returns the debts a settlement plan covers, ordered by
+// ID.
+func settlementDebts(s store.Store, userID, groupID int) ([]models.Debt, error) {
+	debts, err := store.All(func(opts store.ListOptions) (models.Page[models.Debt], error) {
+		return s.Debts().List(userID, store.DebtFilter{Confirmation: "confirmed", GroupID: groupID}, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Repayments waiting for confirmation are not planned again.
+	open := debts[:0]
+	for _, debt := range debts {
+		if payable(debt).IsPositive() {
+			open = append(open, debt)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].ID < open[j].ID })
+	return open, nil
+}
+
+// planSettlement works out the transfers that settle the debts.
+func planSettlement(groupID int, debts []models.Debt) models.SettlementPlan {
+	plan := models.SettlementPlan{Transfers: []models.Transfer{}, DebtIDs: []int{}}
+	if groupID != 0 {
+		plan.GroupID = &groupID
+	}
+
+	// Balances per currency and person, positive for those who are owed
+	// money. The user is person 0.
+	balances := make(map[string]map[int]int64)
+	fingerprint := sha256.New()
+	for _, debt := range debts {
+		plan.DebtIDs = append(plan.DebtIDs, debt.ID)
+		fmt.Fprintf(fingerprint, "%d:%s:%d;", debt.ID, debt.Currency, payable(debt).Minor)
+
+		if balances[debt.Currency] == nil {
+			balances[debt.Currency] = make(map[int]int64)
+		}
+		owed := payable(debt).Minor
+		if debt.Direction == "owe_to" {
+			owed = -owed
+		}
+		balances[debt.Currency][0] += owed
+		balances[debt.Currency][debt.ContactID] -= owed
+	}
+	plan.Fingerprint = hex.EncodeToString(fingerprint.Sum(nil))[:16]
+
+	currencies := make([]string, 0, len(balances))
+	for currency := range balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		plan.Transfers = append(plan.Transfers, settleBalances(currency, balances[currency])...)
+	}
+	return plan
+}
+
+// settleBalances returns transfers that bring every balance to zero.
+func settleBalances(currency string, balances map[int]int64) []models.Transfer {
+	var people []int
+	for person, balance := range balances {
+		if balance != 0 {
+			people = append(people, person)
+		}
+	}
+	sort.Ints(people)
+
+	var transfers []models.Transfer
+	pay := func(from, to int, amount int64) {
+		transfer := models.Transfer{Amount: models.NewMoney(amount, currency), Currency: currency}
+		if from != 0 {
+			transfer.FromContactID = &from
+		}
+		if to != 0 {
+			transfer.ToContactID = &to
+		}
+		transfers = append(transfers, transfer)
+		balances[from] += amount
+		balances[to] -= amount
+	}
+
+	for _, debtor := range people {
+		for _, creditor := range people {
+			if balances[debtor] < 0 && balances[creditor] == -balances[debtor] {
+				pay(debtor, creditor, balances[creditor])
+			}
+		}
+	}
+
+	for {
+		debtor, creditor := 0, 0
+		var most, least int64
+		for _, person := range people {
+			if balances[person] > most {
+				creditor, most = person, balances[person]
+			}
+			if balances[person] < least {
+				debtor, least = person, balances[person]
+			}
+		}
+		if most == 0 || least == 0 {
+			return transfers
+		}
+		pay(debtor, creditor, min(most, -least))
+	}
+}
+
+func (h *DebtHandler) GetSettlementPlan(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var query models.SettlementPlanQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var plan models.SettlementPlan
+	err := h.store.WithinTx(func(s store.Store) error {
+		if query.GroupID != 0 {
+			if _, err := groupFor(s, userID, query.GroupID); err != nil {
+				return err
+			}
+		}
+
+		debts, err := settlementDebts(s, userID, query.GroupID)
+		if err != nil {
+			return err
+		}
+		plan = planSettlement(query.GroupID, debts)
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to plan settlement")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// Settle records the repayments of a settlement plan in one transaction.
+// If a fingerprint is given, it fails with 409 Conflict when the balances
+// have changed since that plan was made.
+func (h *DebtHandler) Settle(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.SettleRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find the debts first, so that the transaction can start by locking
+	// them.
+	debts, err := settlementDebts(h.store, userID, req.GroupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to settle debts"})
+		return
+	}
+
+	var response models.SettleResponse
+	err = h.store.WithinTx(func(s store.Store) error {
+		locked := make([]models.Debt, 0, len(debts))
+		for _, debt := range debts {
+			debt, err := s.Debts().Lock(userID, debt.ID)
+			if err == store.ErrNotFound {
+				return newRequestError(http.StatusConflict, "Balances changed while settling; try again")
+			} else if err != nil {
+				return err
+			}
+			locked = append(locked, debt)
+		}
+
+		if req.GroupID != 0 {
+			if _, err := groupFor(s, userID, req.GroupID); err != nil {
+				return err
+			}
+		}
+
+		// The debts may have changed before they were locked.
+		current, err := settlementDebts(s, userID, req.GroupID)
+		if err != nil {
+			return err
+		}
+		response.SettlementPlan = planSettlement(req.GroupID, current)
+		if req.Fingerprint != "" && req.Fingerprint != response.SettlementPlan.Fingerprint {
+			return newRequestError(http.StatusConflict, "Balances have changed since the plan was made; review the new plan")
+		}
+		if planSettlement(req.GroupID, locked).Fingerprint != response.SettlementPlan.Fingerprint {
+			return newRequestError(http.StatusConflict, "Balances changed while settling; try again")
+		}
+		if len(current) == 0 {
+			return newRequestError(http.StatusConflict, "There are no debts to settle")
+		}
+
+		response.Transactions = []models.Transaction{}
+		description := "Settlement plan"
+		for _, debt := range current {
+			counterpart, shared, err := lockCounterpart(s, debt)
+			if err != nil {
+				return err
+			}
+
+			_, decrease := transactionTypesFor(debt.Direction)
+			transaction := models.Transaction{
+				DebtID:          debt.ID,
+				Amount:          payable(debt),
+				TransactionType: decrease,
+				Description:     &description,
+			}
+			if shared {
+				transaction.Confirmation = "pending"
+				transaction.CreatedBy = &userID
+			}
+			if err := s.Transactions().Create(&transaction); err != nil {
+				return err
+			}
+			if err := s.Debts().SyncStatus(debt.ID); err != nil {
+				return err
+			}
+			if shared {
+				if err := mirrorTransaction(s, transaction, debt, counterpart); err != nil {
+					return err
+				}
+			}
+			response.Transactions = append(response.Transactions, transaction)
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to settle debts")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
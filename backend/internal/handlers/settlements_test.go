@@ -0,0 +1,127 @@
+// internal/handlers/settlements_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// describeTransfers renders transfers as "from->to amount", with 0 for the
+// user.
+func describeTransfers(transfers []models.Transfer) []string {
+	person := func(id *int) int {
+		if id == nil {
+			return 0
+		}
+		return *id
+	}
+	described := make([]string, len(transfers))
+	for i, transfer := range transfers {
+		described[i] = fmt.Sprintf("%d->%d %s", person(transfer.FromContactID), person(transfer.ToContactID), transfer.Amount)
+	}
+	return described
+}
+
+func TestSettleBalances(t *testing.T) {
+	cases := []struct {
+		name     string
+		balances map[int]int64
+		want     []string
+	}{
+		{name: "nothing owed", balances: map[int]int64{0: 0, 1: 0}},
+		{name: "one debt", balances: map[int]int64{0: 3000, 1: -3000}, want: []string{"1->0 30.00"}},
+		{
+			name:     "between contacts",
+			balances: map[int]int64{0: 0, 1: -3000, 2: 3000},
+			want:     []string{"1->2 30.00"},
+		},
+		{
+			name:     "largest first",
+			balances: map[int]int64{0: 5000, 1: -2000, 2: -3000},
+			want:     []string{"2->0 30.00", "1->0 20.00"},
+		},
+		{
+			name:     "exact matches first",
+			balances: map[int]int64{1: -5000, 2: -2000, 3: 3000, 4: 2000, 5: 2000},
+			want:     []string{"2->4 20.00", "1->3 30.00", "1->5 20.00"},
+		},
+	}
+	for _, tc := range cases {
+		transfers := settleBalances("ZAR", tc.balances)
+		if got := describeTransfers(transfers); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: transfers are %v, want %v", tc.name, got, tc.want)
+		}
+		for person, balance := range tc.balances {
+			if balance != 0 {
+				t.Errorf("%s: person %d is left with %d", tc.name, person, balance)
+			}
+		}
+	}
+}
+
+func TestSettle(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.member(userID, "Bob", "111")
+		carol := ts.member(userID, "Carol", "222")
+		lent := ts.debt(userID, bob.ID, "30", "owe_from")
+		borrowed := ts.debt(userID, carol.ID, "30", "owe_to")
+
+		var plan models.SettlementPlan
+		ts.expect(ts.do(userID, "GET", "/debts/settlement-plan", nil), http.StatusOK, &plan)
+		want := []string{fmt.Sprintf("%d->%d 30.00", bob.ID, carol.ID)}
+		if got := describeTransfers(plan.Transfers); !slices.Equal(got, want) {
+			t.Errorf("transfers are %v, want %v", got, want)
+		}
+		if !slices.Equal(plan.DebtIDs, []int{lent.ID, borrowed.ID}) {
+			t.Errorf("plan covers debts %v", plan.DebtIDs)
+		}
+
+		var settled models.SettleResponse
+		ts.expect(ts.do(userID, "POST", "/debts/settle", models.SettleRequest{Fingerprint: plan.Fingerprint}), http.StatusCreated, &settled)
+		if len(settled.Transactions) != 2 {
+			t.Errorf("settling recorded %+v", settled.Transactions)
+		}
+		checkBalance(t, ts.getDebt(userID, lent.ID), "0.00", "settled")
+		checkBalance(t, ts.getDebt(userID, borrowed.ID), "0.00", "settled")
+	})
+}
+
+func TestSettlementSkipsPendingRepayments(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		aliceID, bobID := ts.user("alice"), ts.user("bob")
+		bob, _ := ts.link(aliceID, bobID)
+		debt := ts.debt(aliceID, bob.ID, "100", "owe_from")
+		theirs := ts.counterpart(aliceID, debt.ID)
+		ts.expect(ts.do(bobID, "POST", fmt.Sprintf("/debts/%d/confirm", theirs), nil), http.StatusOK, nil)
+		ts.pay(aliceID, debt.ID, "40", "received_back")
+
+		var settled models.SettleResponse
+		ts.expect(ts.do(aliceID, "POST", "/debts/settle", nil), http.StatusCreated, &settled)
+		if len(settled.Transactions) != 1 || settled.Transactions[0].Amount.String() != "60.00" {
+			t.Errorf("settling recorded %+v, want one repayment of 60.00", settled.Transactions)
+		}
+	})
+}
+
+func TestSettleErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+
+		ts.expect(ts.do(userID, "POST", "/debts/settle", nil), http.StatusConflict, nil)
+		ts.expect(ts.do(userID, "GET", "/debts/settlement-plan?group_id=999", nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(userID, "GET", "/debts/settlement-plan?group_id=abc", nil), http.StatusBadRequest, nil)
+
+		debt := ts.debt(userID, bob.ID, "50", "owe_to")
+		var plan models.SettlementPlan
+		ts.expect(ts.do(userID, "GET", "/debts/settlement-plan", nil), http.StatusOK, &plan)
+		ts.pay(userID, debt.ID, "10", "paid_back")
+		ts.expect(ts.do(userID, "POST", "/debts/settle", models.SettleRequest{Fingerprint: plan.Fingerprint}), http.StatusConflict, nil)
+		checkBalance(t, ts.getDebt(userID, debt.ID), "40.00", "active")
+	})
+}
@@ -0,0 +1,367 @@
+// internal/handlers/shared.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A debt with a linked contact is shared: the other user holds a mirror of
+// it with the opposite direction, and every transaction is recorded on
+// both. New debts and transactions are recorded on both sides at once as
+// pending, until the other user confirms or disputes them (see
+// confirmations.go). Edits and deletions are proposed as a DebtChange that
+// the other user has to accept.
+
+// createDebt inserts the debt and, if the contact is linked to another
+// user, its mirror in that user's account.
+func createDebt(s store.Store, debt *models.Debt, contact models.Contact) error {
+	if contact.LinkedUserID == nil {
+		return s.Debts().Create(debt)
+	}
+
+	theirs, err := s.Contacts().GetLinked(*contact.LinkedUserID, debt.UserID)
+	if err == store.ErrNotFound {
+		return s.Debts().Create(debt)
+	} else if err != nil {
+		return err
+	}
+
+	debt.Confirmation = "pending"
+	debt.CreatedBy = &debt.UserID
+	if err := s.Debts().Create(debt); err != nil {
+		return err
+	}
+
+	mirror := models.Debt{
+		UserID:         theirs.UserID,
+		ContactID:      theirs.ID,
+		OriginalAmount: debt.OriginalAmount,
+		Currency:       debt.Currency,
+		Direction:      reverseDirection(debt.Direction),
+		Description:    debt.Description,
+		Confirmation:   debt.Confirmation,
+		CreatedBy:      debt.CreatedBy,
+		DueDate:        debt.DueDate,
+		Instalments:    debt.Instalments,
+		CreatedAt:      debt.CreatedAt,
+	}
+	if debt.Interest != nil {
+		// Interest is only posted automatically on the side that asked for it.
+		terms := *debt.Interest
+		terms.AutoPost = false
+		mirror.Interest = &terms
+	}
+	if err := s.Debts().Create(&mirror); err != nil {
+		return err
+	}
+	if err := s.Debts().Link(debt.ID, mirror.ID); err != nil {
+		return err
+	}
+	debt.CounterpartID = &mirror.ID
+	debt.Shared = true
+	return nil
+}
+
+// lockCounterpart locks the mirror of a shared debt, after the debt itself
+// has been locked. It reports false if the debt is not shared.
+func lockCounterpart(s store.Store, debt models.Debt) (models.Debt, bool, error) {
+	if !debt.Shared || debt.Contact == nil || debt.Contact.LinkedUserID == nil {
+		return models.Debt{}, false, nil
+	}
+
+	counterpart, err := s.Debts().Lock(*debt.Contact.LinkedUserID, *debt.CounterpartID)
+	if err == store.ErrNotFound {
+		return counterpart, false, nil
+	}
+	return counterpart, err == nil, err
+}
+
+// mirrorTransaction records a transaction on the counterpart of its debt,
+// with the matching type for the opposite direction.
+func mirrorTransaction(s store.Store, transaction models.Transaction, debt, counterpart models.Debt) error {
+	increase, _ := transactionTypesFor(debt.Direction)
+	theirIncrease, theirDecrease := transactionTypesFor(counterpart.Direction)
+	transactionType := theirDecrease
+	if transaction.TransactionType == increase {
+		transactionType = theirIncrease
+	}
+
+	mirror := models.Transaction{
+		DebtID:          counterpart.ID,
+		Amount:          transaction.Amount,
+		TransactionType: transactionType,
+		Description:     transaction.Description,
+		Confirmation:    transaction.Confirmation,
+		CreatedBy:       transaction.CreatedBy,
+		Interest:        transaction.Interest,
+		CreatedAt:       transaction.CreatedAt,
+	}
+	if err := s.Transactions().Create(&mirror); err != nil {
+		return err
+	}
+	if err := s.Transactions().Link(transaction.ID, mirror.ID); err != nil {
+		return err
+	}
+	return s.Debts().SyncStatus(counterpart.ID)
+}
+
+// proposeChange records a change to a shared debt for the other user to
+// accept. Only one change per debt can be pending at a time.
+func proposeChange(s store.Store, debt models.Debt, change *models.DebtChange) error {
+	pending, err := s.DebtChanges().List(debt.ID, *debt.CounterpartID)
+	if err != nil {
+		return err
+	}
+	for _, other := range pending {
+		if other.State == "pending" {
+			return newRequestError(http.StatusConflict, "Another change to this debt is waiting for approval")
+		}
+	}
+
+	change.DebtID = debt.ID
+	change.ProposedBy = debt.UserID
+	return s.DebtChanges().Create(change)
+}
+
+// proposeUpdate proposes the edits of an UpdateDebtRequest to a shared
+// debt. It returns nil if the request changes nothing.
+func proposeUpdate(s store.Store, debt models.Debt, req models.UpdateDebtRequest) (*models.DebtChange, error) {
+	change := models.DebtChange{Kind: "update"}
+	if req.Amount != nil && req.Amount.Cmp(debt.OriginalAmount) != 0 {
+		count, err := s.Debts().TransactionCount(debt.ID)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, newRequestError(http.StatusConflict, "Amount cannot be changed once transactions have been recorded; add a transaction instead")
+		}
+		change.Amount = req.Amount
+	}
+	if debt.Description == nil || *debt.Description != req.Description {
+		change.Description = &req.Description
+	}
+	if status := requestedStatus(debt.Status, req.Status); status != debt.Status {
+		change.Status = &status
+	}
+	if change.Amount == nil && change.Description == nil && change.Status == nil {
+		return nil, nil
+	}
+
+	if err := proposeChange(s, debt, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// applyChange makes an accepted change to both sides of a shared debt.
+func applyChange(s store.Store, change models.DebtChange, debts ...models.Debt) error {
+	switch change.Kind {
+	case "update":
+		for _, debt := range debts {
+			if change.Amount != nil {
+				count, err := s.Debts().TransactionCount(debt.ID)
+				if err != nil {
+					return err
+				}
+				if count > 0 {
+					return newRequestError(http.StatusConflict, "Amount cannot be changed once transactions have been recorded")
+				}
+				debt.OriginalAmount = *change.Amount
+			}
+			if change.Description != nil {
+				debt.Description = change.Description
+			}
+			if change.Status != nil {
+				debt.Status = *change.Status
+			}
+			if err := s.Debts().Update(&debt); err != nil {
+				return err
+			}
+			// A restored debt, or one whose status was proposed before
+			// the balance changed, is reopened or settled by its balance.
+			if err := s.Debts().SyncStatus(debt.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "delete_transaction":
+		transaction, err := s.Transactions().Get(change.ProposedBy, *change.TransactionID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Transaction no longer exists")
+		} else if err != nil {
+			return err
+		}
+		if err := s.Transactions().Delete(transaction.ID); err != nil {
+			return err
+		}
+		if transaction.CounterpartID != nil {
+			if err := s.Transactions().Delete(*transaction.CounterpartID); err != nil {
+				return err
+			}
+		}
+		for _, debt := range debts {
+			if err := s.Debts().SyncStatus(debt.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return newRequestError(http.StatusConflict, "Unknown change")
+}
+
+// changeFor loads a change proposed for either side of the user's debt.
+func changeFor(s store.Store, debt models.Debt, changeID int) (models.DebtChange, error) {
+	change, err := s.DebtChanges().Get(changeID)
+	if err == store.ErrNotFound || (err == nil && change.DebtID != debt.ID &&
+		(debt.CounterpartID == nil || change.DebtID != *debt.CounterpartID)) {
+		return change, newRequestError(http.StatusNotFound, "Change not found")
+	}
+	return change, err
+}
+
+// GetDebtChanges lists the changes proposed by either side of a debt.
+func (h *DebtHandler) GetDebtChanges(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+
+	debt, err := h.store.Debts().Get(userID, debtID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Debt not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get changes"})
+		return
+	}
+
+	debtIDs := []int{debt.ID}
+	if debt.CounterpartID != nil {
+		debtIDs = append(debtIDs, *debt.CounterpartID)
+	}
+	changes, err := h.store.DebtChanges().List(debtIDs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// AcceptDebtChange applies a change the other user proposed to both sides
+// of the debt.
+func (h *DebtHandler) AcceptDebtChange(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+	changeID, err := strconv.Atoi(c.Param("change_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	var change models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err := s.Debts().Lock(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		change, err = changeFor(s, debt, changeID)
+		if err != nil {
+			return err
+		}
+		if change.State != "pending" {
+			return newRequestError(http.StatusConflict, "Change is no longer pending")
+		}
+		if change.ProposedBy == userID {
+			return newRequestError(http.StatusForbidden, "Only the other party can accept this change")
+		}
+
+		counterpart, shared, err := lockCounterpart(s, debt)
+		if err != nil {
+			return err
+		}
+		if !shared {
+			return newRequestError(http.StatusConflict, "Debt is no longer shared")
+		}
+
+		if err := applyChange(s, change, debt, counterpart); err != nil {
+			return err
+		}
+		if err := s.DebtChanges().Resolve(changeID, "accepted"); err != nil {
+			return err
+		}
+		change, err = s.DebtChanges().Get(changeID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to accept change")
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// RejectDebtChange turns down a change proposed by the other user, or
+// withdraws one the user proposed.
+func (h *DebtHandler) RejectDebtChange(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	debtID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debt ID"})
+		return
+	}
+	changeID, err := strconv.Atoi(c.Param("change_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	var change models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		debt, err := s.Debts().Get(userID, debtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Debt not found")
+		} else if err != nil {
+			return err
+		}
+
+		change, err = changeFor(s, debt, changeID)
+		if err != nil {
+			return err
+		}
+
+		state := "rejected"
+		if change.ProposedBy == userID {
+			state = "cancelled"
+		}
+		if err := s.DebtChanges().Resolve(changeID, state); err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Change is no longer pending")
+		} else if err != nil {
+			return err
+		}
+		change, err = s.DebtChanges().Get(changeID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to reject change")
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
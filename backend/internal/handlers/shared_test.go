@@ -0,0 +1,108 @@
+// internal/handlers/shared_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestSharedDebtIsMirrored(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.user("alice"), ts.user("bob")
+		contact, _ := ts.link(alice, bob)
+
+		debt := ts.debt(alice, contact.ID, "100", "owe_from")
+		theirs := ts.counterpart(alice, debt.ID)
+		if got := ts.getDebt(bob, theirs); got.Direction != "owe_to" || !got.Shared {
+			t.Errorf("bob's side is %+v, want a shared owe_to debt", got)
+		}
+
+		payment := ts.pay(alice, debt.ID, "40", "received_back")
+		ts.confirm(alice, bob, payment.ID)
+		mirror, err := ts.store.Transactions().Get(bob, ts.mirror(alice, payment.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mirror.TransactionType != "paid_back" || mirror.Amount.String() != "40.00" {
+			t.Errorf("bob's side of the payment is %s %s", mirror.TransactionType, mirror.Amount)
+		}
+		checkBalance(t, ts.getDebt(alice, debt.ID), "60.00", "active")
+		checkBalance(t, ts.getDebt(bob, theirs), "60.00", "active")
+	})
+}
+
+func TestSharedDebtChanges(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.user("alice"), ts.user("bob")
+		contact, _ := ts.link(alice, bob)
+		debt := ts.debt(alice, contact.ID, "100", "owe_from")
+		theirs := ts.counterpart(alice, debt.ID)
+
+		amount := money(t, "120")
+		var change models.DebtChange
+		update := models.UpdateDebtRequest{Amount: &amount, Status: "active"}
+		ts.expect(ts.do(alice, "PUT", fmt.Sprintf("/debts/%d", debt.ID), update), http.StatusAccepted, &change)
+		checkBalance(t, ts.getDebt(bob, theirs), "100.00", "active")
+
+		// Only one change can wait at a time, and only the other side may
+		// accept it.
+		ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/debts/%d", debt.ID), nil), http.StatusConflict, nil)
+		ts.expect(ts.do(alice, "POST", fmt.Sprintf("/debts/%d/changes/%d/accept", debt.ID, change.ID), nil), http.StatusForbidden, nil)
+
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/debts/%d/changes/%d/accept", theirs, change.ID), nil), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(alice, debt.ID), "120.00", "active")
+		checkBalance(t, ts.getDebt(bob, theirs), "120.00", "active")
+
+		// A rejected removal leaves the debt as it is.
+		ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/debts/%d", debt.ID), nil), http.StatusAccepted, &change)
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/debts/%d/changes/%d/reject", theirs, change.ID), nil), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(bob, theirs), "120.00", "active")
+
+		var changes []models.DebtChange
+		ts.expect(ts.do(bob, "GET", fmt.Sprintf("/debts/%d/changes", theirs), nil), http.StatusOK, &changes)
+		if len(changes) != 2 {
+			t.Errorf("bob sees %d changes, want 2", len(changes))
+		}
+	})
+}
+
+func TestDeleteSharedTransaction(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.user("alice"), ts.user("bob")
+		contact, _ := ts.link(alice, bob)
+		debt := ts.debt(alice, contact.ID, "100", "owe_from")
+		theirs := ts.counterpart(alice, debt.ID)
+		payment := ts.pay(alice, debt.ID, "100", "received_back")
+		ts.confirm(alice, bob, payment.ID)
+		checkBalance(t, ts.getDebt(bob, theirs), "0.00", "settled")
+
+		var change models.DebtChange
+		ts.expect(ts.do(alice, "DELETE", fmt.Sprintf("/transactions/%d", payment.ID), nil), http.StatusAccepted, &change)
+		ts.expect(ts.do(bob, "POST", fmt.Sprintf("/debts/%d/changes/%d/accept", theirs, change.ID), nil), http.StatusOK, nil)
+
+		checkBalance(t, ts.getDebt(alice, debt.ID), "100.00", "active")
+		checkBalance(t, ts.getDebt(bob, theirs), "100.00", "active")
+	})
+}
+
+func TestSharedDebtStatusFollowsBalance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, bob := ts.user("alice"), ts.user("bob")
+		contact, _ := ts.link(alice, bob)
+		debt := ts.debt(alice, contact.ID, "100", "owe_from")
+
+		// Asking to settle a debt with a balance proposes nothing.
+		settle := models.UpdateDebtRequest{Status: "settled"}
+		ts.expect(ts.do(alice, "PUT", fmt.Sprintf("/debts/%d", debt.ID), settle), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(alice, debt.ID), "100.00", "active")
+
+		var changes []models.DebtChange
+		ts.expect(ts.do(alice, "GET", fmt.Sprintf("/debts/%d/changes", debt.ID), nil), http.StatusOK, &changes)
+		if len(changes) != 0 {
+			t.Errorf("settling proposed %+v", changes)
+		}
+	})
+}
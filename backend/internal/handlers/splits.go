@@ -0,0 +1,136 @@
+// internal/handlers/splits.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// hundredPercent is 100% in the hundredths of a percent of models.Percent.
+const hundredPercent = 10000
+
+// splitExpense works out each member's share of an expense and returns the
+// splits with their amounts filled in. Shares always add up to the amount:
+// the cents left over by percentages and shares go one at a time to the
+// members with the largest remainders, and to the earliest of them on a
+// tie.
+func splitExpense(amount models.Money, splitType string, splits []models.ExpenseSplit) ([]models.ExpenseSplit, error) {
+	if len(splits) == 0 {
+		return nil, errors.New("an expense needs at least one member to split it with")
+	}
+
+	seen := make(map[int]bool)
+	for _, split := range splits {
+		id := 0
+		if split.ContactID != nil {
+			id = *split.ContactID
+		}
+		if seen[id] {
+			return nil, errors.New("each member can appear only once in the splits")
+		}
+		seen[id] = true
+	}
+
+	result := make([]models.ExpenseSplit, len(splits))
+	weights := make([]int64, len(splits))
+	for i, split := range splits {
+		result[i] = models.ExpenseSplit{ContactID: split.ContactID}
+		switch splitType {
+		case models.SplitEqual:
+			weights[i] = 1
+		case models.SplitPercentage:
+			if split.Percentage == nil || *split.Percentage < 0 || *split.Percentage > hundredPercent {
+				return nil, errors.New("every split needs a percentage between 0 and 100")
+			}
+			weights[i] = int64(*split.Percentage)
+			result[i].Percentage = split.Percentage
+		case models.SplitShares:
+			if split.Shares == nil || *split.Shares < 0 {
+				return nil, errors.New("every split needs a number of shares of at least zero")
+			}
+			weights[i] = *split.Shares
+			result[i].Shares = split.Shares
+		case models.SplitExact:
+			if split.Amount.IsNegative() {
+				return nil, errors.New("split amounts cannot be negative")
+			}
+			result[i].Amount = models.NewMoney(split.Amount.Minor, amount.Currency)
+		default:
+			return nil, fmt.Errorf("unknown split type %q", splitType)
+		}
+	}
+
+	switch splitType {
+	case models.SplitExact:
+		var total models.Money
+		for _, split := range result {
+			total = total.Add(split.Amount)
+		}
+		if total.Cmp(amount) != 0 {
+			return nil, fmt.Errorf("split amounts add up to %s instead of %s", total, amount)
+		}
+		return result, nil
+
+	case models.SplitPercentage:
+		var total int64
+		for _, weight := range weights {
+			total += weight
+		}
+		if total != hundredPercent {
+			return nil, fmt.Errorf("percentages add up to %s%% instead of 100%%", models.Money{Minor: total})
+		}
+
+	case models.SplitShares:
+		var total int64
+		for _, weight := range weights {
+			total += weight
+			if total > 1<<31 {
+				return nil, errors.New("too many shares")
+			}
+		}
+		if total == 0 {
+			return nil, errors.New("at least one member needs a share")
+		}
+	}
+
+	for i, minor := range allocate(amount.Minor, weights) {
+		result[i].Amount = models.NewMoney(minor, amount.Currency)
+	}
+	return result, nil
+}
+
+// allocate divides a positive amount in proportion to the weights, which
+// are not negative and do not sum to zero.
+func allocate(amount int64, weights []int64) []int64 {
+	var total uint64
+	for _, weight := range weights {
+		total += uint64(weight)
+	}
+
+	shares := make([]int64, len(weights))
+	remainders := make([]uint64, len(weights))
+	left := amount
+	for i, weight := range weights {
+		// amount * weight can exceed 64 bits; the quotient cannot, as
+		// weight <= total.
+		hi, lo := bits.Mul64(uint64(amount), uint64(weight))
+		quotient, remainder := bits.Div64(hi, lo, total)
+		shares[i] = int64(quotient)
+		remainders[i] = remainder
+		left -= shares[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, i := range order[:left] {
+		shares[i]++
+	}
+	return shares
+}
@@ -0,0 +1,117 @@
+// internal/handlers/splits_test.go
+package handlers
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestAllocate(t *testing.T) {
+	cases := []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{amount: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{amount: 200, weights: []int64{1, 1, 1}, want: []int64{67, 67, 66}},
+		{amount: 1000, weights: []int64{3333, 3333, 3334}, want: []int64{333, 333, 334}},
+		{amount: 1, weights: []int64{1, 1}, want: []int64{1, 0}},
+		{amount: 10, weights: []int64{0, 1, 0}, want: []int64{0, 10, 0}},
+		{amount: 101, weights: []int64{1, 2, 2}, want: []int64{20, 41, 40}},
+		{amount: math.MaxInt64, weights: []int64{1 << 31, 1 << 31}, want: []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	}
+	for _, tc := range cases {
+		got := allocate(tc.amount, tc.weights)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("allocate(%d, %v) = %v, want %v", tc.amount, tc.weights, got, tc.want)
+		}
+		var total int64
+		for _, share := range got {
+			total += share
+		}
+		if total != tc.amount {
+			t.Errorf("allocate(%d, %v) adds up to %d", tc.amount, tc.weights, total)
+		}
+	}
+}
+
+func TestSplitExpense(t *testing.T) {
+	bob, carol := 1, 2
+	percent := func(p models.Percent) *models.Percent { return &p }
+	shares := func(n int64) *int64 { return &n }
+	exact := func(minor int64) models.Money { return models.NewMoney(minor, "ZAR") }
+
+	cases := []struct {
+		name      string
+		splitType string
+		splits    []models.ExpenseSplit
+		want      []int64
+	}{
+		{
+			name:      "equal",
+			splitType: models.SplitEqual,
+			splits:    []models.ExpenseSplit{{}, {ContactID: &bob}, {ContactID: &carol}},
+			want:      []int64{3334, 3333, 3333},
+		},
+		{
+			name:      "percentage",
+			splitType: models.SplitPercentage,
+			splits:    []models.ExpenseSplit{{Percentage: percent(5000)}, {ContactID: &bob, Percentage: percent(5000)}},
+			want:      []int64{5000, 5000},
+		},
+		{
+			name:      "shares",
+			splitType: models.SplitShares,
+			splits:    []models.ExpenseSplit{{Shares: shares(1)}, {ContactID: &bob, Shares: shares(3)}},
+			want:      []int64{2500, 7500},
+		},
+		{
+			name:      "exact",
+			splitType: models.SplitExact,
+			splits:    []models.ExpenseSplit{{Amount: exact(1)}, {ContactID: &bob, Amount: exact(9999)}},
+			want:      []int64{1, 9999},
+		},
+	}
+	for _, tc := range cases {
+		got, err := splitExpense(models.NewMoney(10000, "ZAR"), tc.splitType, tc.splits)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		for i, split := range got {
+			if split.Amount.Minor != tc.want[i] || split.Amount.Currency != "ZAR" {
+				t.Errorf("%s: split %d is %v %s, want %d", tc.name, i, split.Amount.Minor, split.Amount.Currency, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestSplitExpenseErrors(t *testing.T) {
+	bob := 1
+	percent := models.Percent(4000)
+	negative := int64(-1)
+	none := int64(0)
+
+	cases := []struct {
+		name      string
+		splitType string
+		splits    []models.ExpenseSplit
+	}{
+		{name: "no members", splitType: models.SplitEqual},
+		{name: "member twice", splitType: models.SplitEqual, splits: []models.ExpenseSplit{{ContactID: &bob}, {ContactID: &bob}}},
+		{name: "unknown type", splitType: "halves", splits: []models.ExpenseSplit{{}}},
+		{name: "missing percentage", splitType: models.SplitPercentage, splits: []models.ExpenseSplit{{}}},
+		{name: "percentages short of 100", splitType: models.SplitPercentage, splits: []models.ExpenseSplit{{Percentage: &percent}}},
+		{name: "negative shares", splitType: models.SplitShares, splits: []models.ExpenseSplit{{Shares: &negative}}},
+		{name: "no shares", splitType: models.SplitShares, splits: []models.ExpenseSplit{{Shares: &none}}},
+		{name: "exact amounts short", splitType: models.SplitExact, splits: []models.ExpenseSplit{{Amount: models.NewMoney(9999, "ZAR")}}},
+	}
+	for _, tc := range cases {
+		if got, err := splitExpense(models.NewMoney(10000, "ZAR"), tc.splitType, tc.splits); err == nil {
+			t.Errorf("%s: split into %v, want an error", tc.name, got)
+		}
+	}
+}
@@ -2,54 +2,78 @@
 package handlers
 
 import (
-	"database/sql"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TransactionHandler struct {
-	db *sql.DB
+	store store.Store
 }
 
-func NewTransactionHandler(db *sql.DB) *TransactionHandler {
-	return &TransactionHandler{db: db}
+func NewTransactionHandler(s store.Store) *TransactionHandler {
+	return &TransactionHandler{store: s}
 }
 
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	rows, err := h.db.Query(`
-		SELECT t.id, t.debt_id, t.amount, t.transaction_type, t.description, t.created_at
-		FROM transactions t
-		JOIN debts d ON t.debt_id = d.id
-		WHERE d.user_id = ?
-		ORDER BY t.created_at DESC
-	`, userID)
+	filter, err := parseTransactionFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transactions"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID, &transaction.DebtID, &transaction.Amount,
-			&transaction.TransactionType, &transaction.Description, &transaction.CreatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan transaction"})
-			return
-		}
-		transactions = append(transactions, transaction)
+	h.listTransactions(c, userID, filter)
+}
+
+// parseTransactionFilter reads the filters of the transaction list.
+func parseTransactionFilter(c *gin.Context) (store.TransactionFilter, error) {
+	var query models.TransactionListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return store.TransactionFilter{}, err
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	return parseTransactionRanges(c, store.TransactionFilter{
+		DebtID:          query.DebtID,
+		ContactID:       query.ContactID,
+		TransactionType: query.TransactionType,
+		Confirmation:    query.Confirmation,
+	})
+}
+
+// parseTransactionRanges adds the amount and date parameters to filter.
+func parseTransactionRanges(c *gin.Context, filter store.TransactionFilter) (store.TransactionFilter, error) {
+	var err error
+	filter.MinAmount, filter.MaxAmount, err = parseAmountRange(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.CreatedFrom, filter.CreatedBefore, err = parseCreatedRange(c)
+	return filter, err
+}
+
+// listTransactions reads the paging parameters and responds with the page
+// of transactions matching filter.
+func (h *TransactionHandler) listTransactions(c *gin.Context, userID int, filter store.TransactionFilter) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.store.Transactions().List(userID, filter, opts)
+	if err != nil {
+		respondListError(c, err, "Failed to get transactions")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
@@ -60,27 +84,43 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 		return
 	}
 
-	// Check if transaction belongs to user's debt
-	var debtExists int
-	err = h.db.QueryRow(`
-		SELECT d.id FROM transactions t
-		JOIN debts d ON t.debt_id = d.id
-		WHERE t.id = ? AND d.user_id = ?
-	`, transactionID, userID).Scan(&debtExists)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
-	}
+	var proposal *models.DebtChange
+	err = h.store.WithinTx(func(s store.Store) error {
+		// Check if transaction belongs to user's debt
+		transaction, err := s.Transactions().Get(userID, transactionID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusNotFound, "Transaction not found")
+		} else if err != nil {
+			return err
+		}
+
+		// Deleting one side of a shared transaction needs the other user's
+		// approval.
+		if transaction.CounterpartID != nil {
+			debt, err := s.Debts().Get(userID, transaction.DebtID)
+			if err != nil {
+				return err
+			}
+			if debt.Shared {
+				proposal = &models.DebtChange{Kind: "delete_transaction", TransactionID: &transaction.ID}
+				return proposeChange(s, debt, proposal)
+			}
+		}
 
-	_, err = h.db.Exec("DELETE FROM transactions WHERE id = ?", transactionID)
+		if err := s.Transactions().Delete(transactionID); err != nil {
+			return err
+		}
+		return s.Debts().SyncStatus(transaction.DebtID)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		respondError(c, err, "Failed to delete transaction")
 		return
 	}
 
+	if proposal != nil {
+		c.JSON(http.StatusAccepted, proposal)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted successfully"})
 }
 
@@ -93,45 +133,105 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	// Check if debt belongs to user
-	var debtExists int
-	err := h.db.QueryRow(
-		"SELECT id FROM debts WHERE id = ? AND user_id = ?",
-		req.DebtID, userID,
-	).Scan(&debtExists)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Debt not found"})
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
 		return
 	}
 
-	result, err := h.db.Exec(`
-		INSERT INTO transactions (debt_id, amount, transaction_type, description) 
-		VALUES (?, ?, ?, ?)
-	`, req.DebtID, req.Amount, req.TransactionType, req.Description)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
-		return
-	}
+	var response models.CreateTransactionResponse
+	err := h.store.WithinTx(func(s store.Store) error {
+		// Lock the debt first so that concurrent payments are checked against
+		// an up-to-date balance
+		debt, err := s.Debts().Lock(userID, req.DebtID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusBadRequest, "Debt not found")
+		} else if err != nil {
+			return err
+		}
 
-	transactionID, _ := result.LastInsertId()
+		if debt.Status == "removed" {
+			return newRequestError(http.StatusConflict, "Cannot add transactions to a removed debt")
+		}
 
-	var transaction models.Transaction
-	err = h.db.QueryRow(`
-		SELECT id, debt_id, amount, transaction_type, description, created_at 
-		FROM transactions WHERE id = ?
-	`, transactionID).Scan(
-		&transaction.ID, &transaction.DebtID, &transaction.Amount,
-		&transaction.TransactionType, &transaction.Description, &transaction.CreatedAt,
-	)
+		counterpart, shared, err := lockCounterpart(s, debt)
+		if err != nil {
+			return err
+		}
+
+		if err := validateTransactionType(debt.Direction, req.TransactionType); err != nil {
+			return newRequestError(http.StatusBadRequest, err.Error())
+		}
+
+		amount := req.Amount
+		var excess models.Money
+		_, decrease := transactionTypesFor(debt.Direction)
+		remaining := payable(debt)
+		if req.TransactionType == decrease && amount.Cmp(remaining) > 0 {
+			if !debt.Balance.IsPositive() {
+				return newRequestError(http.StatusConflict, "Debt is already settled")
+			}
+			if !remaining.IsPositive() {
+				return newRequestError(http.StatusConflict, "The remaining balance is covered by payments waiting for confirmation")
+			}
+			if req.Overpayment != models.OverpaymentRollOver {
+				return &requestError{status: http.StatusConflict, body: gin.H{
+					"error":   "Payment exceeds the remaining balance",
+					"balance": remaining,
+				}}
+			}
+			excess = amount.Sub(remaining)
+			amount = remaining
+		}
+
+		response.Transaction = models.Transaction{
+			DebtID:          debt.ID,
+			Amount:          amount,
+			TransactionType: req.TransactionType,
+			Description:     &req.Description,
+		}
+		if shared {
+			response.Transaction.Confirmation = "pending"
+			response.Transaction.CreatedBy = &userID
+		}
+		if err := s.Transactions().Create(&response.Transaction); err != nil {
+			return err
+		}
+
+		if err := s.Debts().SyncStatus(debt.ID); err != nil {
+			return err
+		}
+		if shared {
+			if err := mirrorTransaction(s, response.Transaction, debt, counterpart); err != nil {
+				return err
+			}
+		}
+
+		// Roll the excess of an overpayment into a new debt in the opposite
+		// direction: whoever overpaid is now owed the difference.
+		if excess.IsPositive() {
+			description := fmt.Sprintf("Overpayment of debt #%d", debt.ID)
+			rolloverDebt := models.Debt{
+				UserID:         userID,
+				ContactID:      debt.ContactID,
+				OriginalAmount: excess,
+				Currency:       excess.Currency,
+				Direction:      reverseDirection(debt.Direction),
+				Description:    &description,
+			}
+			if err := createDebt(s, &rolloverDebt, *debt.Contact); err != nil {
+				return err
+			}
+			response.RolloverDebt = &rolloverDebt
+		}
+
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get created transaction"})
+		respondError(c, err, "Failed to create transaction")
 		return
 	}
 
-	c.JSON(http.StatusCreated, transaction)
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *TransactionHandler) GetTransaction(c *gin.Context) {
@@ -142,17 +242,8 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 		return
 	}
 
-	var transaction models.Transaction
-	err = h.db.QueryRow(`
-		SELECT t.id, t.debt_id, t.amount, t.transaction_type, t.description, t.created_at
-		FROM transactions t
-		JOIN debts d ON t.debt_id = d.id
-		WHERE t.id = ? AND d.user_id = ?
-	`, transactionID, userID).Scan(
-		&transaction.ID, &transaction.DebtID, &transaction.Amount,
-		&transaction.TransactionType, &transaction.Description, &transaction.CreatedAt,
-	)
-	if err == sql.ErrNoRows {
+	transaction, err := h.store.Transactions().Get(userID, transactionID)
+	if err == store.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	} else if err != nil {
@@ -172,12 +263,8 @@ func (h *TransactionHandler) GetDebtTransactions(c *gin.Context) {
 	}
 
 	// Check if debt belongs to user
-	var debtExists int
-	err = h.db.QueryRow(
-		"SELECT id FROM debts WHERE id = ? AND user_id = ?",
-		debtID, userID,
-	).Scan(&debtExists)
-	if err == sql.ErrNoRows {
+	_, err = h.store.Debts().Get(userID, debtID)
+	if err == store.ErrNotFound {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Debt not found"})
 		return
 	} else if err != nil {
@@ -185,31 +272,21 @@ func (h *TransactionHandler) GetDebtTransactions(c *gin.Context) {
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT id, debt_id, amount, transaction_type, description, created_at
-		FROM transactions 
-		WHERE debt_id = ?
-		ORDER BY created_at DESC
-	`, debtID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transactions"})
+	var query models.TransactionListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID, &transaction.DebtID, &transaction.Amount,
-			&transaction.TransactionType, &transaction.Description, &transaction.CreatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan transaction"})
-			return
-		}
-		transactions = append(transactions, transaction)
+	filter, err := parseTransactionRanges(c, store.TransactionFilter{
+		DebtID:          debtID,
+		TransactionType: query.TransactionType,
+		Confirmation:    query.Confirmation,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
-}
\ No newline at end of file
+	h.listTransactions(c, userID, filter)
+}
@@ -0,0 +1,66 @@
+// internal/handlers/transactions_test.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestDeleteTransactionRestoresBalance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+
+		payment := ts.pay(userID, debt.ID, "100", "paid_back")
+		checkBalance(t, ts.getDebt(userID, debt.ID), "0.00", "settled")
+
+		var transactions models.Page[models.Transaction]
+		ts.expect(ts.do(userID, "GET", fmt.Sprintf("/debts/%d/transactions", debt.ID), nil), http.StatusOK, &transactions)
+		if len(transactions.Data) != 1 || transactions.Data[0].ID != payment.ID {
+			t.Fatalf("debt has transactions %+v, want only the payment", transactions.Data)
+		}
+
+		ts.expect(ts.do(userID, "DELETE", fmt.Sprintf("/transactions/%d", payment.ID), nil), http.StatusOK, nil)
+		checkBalance(t, ts.getDebt(userID, debt.ID), "100.00", "active")
+	})
+}
+
+func TestRollOverOverpayment(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		userID := ts.user("alice")
+		bob := ts.contact(userID, "Bob")
+		debt := ts.debt(userID, bob.ID, "100", "owe_to")
+
+		req := models.CreateTransactionRequest{
+			DebtID: debt.ID, Amount: money(t, "130"), TransactionType: "paid_back", Overpayment: models.OverpaymentRollOver}
+		var created models.CreateTransactionResponse
+		ts.expect(ts.do(userID, "POST", "/transactions", req), http.StatusCreated, &created)
+		if created.RolloverDebt == nil {
+			t.Fatal("no debt was opened for the excess")
+		}
+		checkBalance(t, ts.getDebt(userID, debt.ID), "0.00", "settled")
+		rollover := ts.getDebt(userID, created.RolloverDebt.ID)
+		checkBalance(t, rollover, "30.00", "active")
+		if rollover.Direction != "owe_from" {
+			t.Errorf("the excess is owed %s, want owe_from", rollover.Direction)
+		}
+	})
+}
+
+func TestTransactionErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice, mallory := ts.user("alice"), ts.user("mallory")
+		bob := ts.contact(alice, "Bob")
+		debt := ts.debt(alice, bob.ID, "100", "owe_to")
+		payment := ts.pay(alice, debt.ID, "10", "paid_back")
+
+		ts.expect(ts.do(alice, "GET", "/transactions/first", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(mallory, "GET", fmt.Sprintf("/transactions/%d", payment.ID), nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(mallory, "DELETE", fmt.Sprintf("/transactions/%d", payment.ID), nil), http.StatusNotFound, nil)
+		checkBalance(t, ts.getDebt(alice, debt.ID), "90.00", "active")
+	})
+}
@@ -0,0 +1,392 @@
+// internal/handlers/twofactor.go
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/middleware"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	loginChallengeTTL = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// errInvalidCode is returned for wrong, reused or expired two-factor codes.
+var errInvalidCode = errors.New("invalid two-factor code")
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// GetTwoFactor reports whether two-factor authentication is set up.
+func (h *AuthHandler) GetTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var status models.TwoFactorStatus
+	twoFactor, err := h.store.TwoFactor().Get(userID)
+	if err != nil && err != store.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err == nil {
+		status.Enabled = twoFactor.EnabledAt != nil
+		status.Pending = twoFactor.EnabledAt == nil
+		status.EnabledAt = twoFactor.EnabledAt
+	}
+
+	if status.Enabled {
+		status.RecoveryCodesLeft, err = h.store.TwoFactor().RecoveryCodesLeft(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor starts enrolling an authenticator app. It returns a new
+// secret, which takes effect once a code from it is sent to EnableTwoFactor.
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	var user models.User
+	err = h.store.WithinTx(func(s store.Store) error {
+		if err := h.checkPassword(s, userID, req.Password); err != nil {
+			return err
+		}
+
+		if err := s.TwoFactor().SetSecret(userID, secret); err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Two-factor authentication is already enabled")
+		} else if err != nil {
+			return err
+		}
+
+		user, err = s.Users().Get(userID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(h.TOTPIssuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor turns on two-factor authentication once the user shows a
+// code from the secret they set up, and returns their recovery codes. The
+// codes are stored hashed, so this is the only time they can be seen.
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codes []string
+	err := h.store.WithinTx(func(s store.Store) error {
+		twoFactor, err := s.TwoFactor().Get(userID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Two-factor authentication has not been set up")
+		} else if err != nil {
+			return err
+		}
+		if twoFactor.EnabledAt != nil {
+			return newRequestError(http.StatusConflict, "Two-factor authentication is already enabled")
+		}
+
+		if err := useTOTP(s, twoFactor, req.Code); err == errInvalidCode {
+			return newRequestError(http.StatusBadRequest, "Invalid two-factor code")
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.TwoFactor().Enable(userID); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(s, userID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off, or cancels a setup
+// that was not enabled yet.
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.store.WithinTx(func(s store.Store) error {
+		if err := h.checkPassword(s, userID, req.Password); err != nil {
+			return err
+		}
+
+		twoFactor, err := s.TwoFactor().Get(userID)
+		if err == store.ErrNotFound {
+			return newRequestError(http.StatusConflict, "Two-factor authentication is not enabled")
+		} else if err != nil {
+			return err
+		}
+		if twoFactor.EnabledAt != nil {
+			if err := useCode(s, twoFactor, req.Code); err == errInvalidCode {
+				return newRequestError(http.StatusBadRequest, "Invalid two-factor code")
+			} else if err != nil {
+				return err
+			}
+		}
+
+		return s.TwoFactor().Disable(userID)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codes []string
+	err := h.store.WithinTx(func(s store.Store) error {
+		if err := h.checkPassword(s, userID, req.Password); err != nil {
+			return err
+		}
+
+		twoFactor, err := s.TwoFactor().Get(userID)
+		if err == store.ErrNotFound || (err == nil && twoFactor.EnabledAt == nil) {
+			return newRequestError(http.StatusConflict, "Two-factor authentication is not enabled")
+		} else if err != nil {
+			return err
+		}
+
+		if err := useCode(s, twoFactor, req.Code); err == errInvalidCode {
+			return newRequestError(http.StatusBadRequest, "Invalid two-factor code")
+		} else if err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(s, userID)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to generate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginTwoFactor completes a login that Login answered with a challenge,
+// given a TOTP or recovery code. Wrong codes count towards the lockout of
+// the account just like wrong passwords.
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.ActionTokens.Verify(req.ChallengeToken, auth.PurposeLoginChallenge, fingerprint(h.store, passwordFingerprint))
+	if err == auth.ErrInvalidActionToken {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	user, err := h.store.Users().Get(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if retryAfter, err := h.lockedOut(user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	} else if retryAfter > 0 {
+		middleware.RetryAfter(c, retryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return
+	}
+
+	err = h.store.WithinTx(func(s store.Store) error {
+		twoFactor, err := s.TwoFactor().Get(userID)
+		if err == store.ErrNotFound || (err == nil && twoFactor.EnabledAt == nil) {
+			return errInvalidCode
+		} else if err != nil {
+			return err
+		}
+		return useCode(s, twoFactor, req.Code)
+	})
+	if err == errInvalidCode {
+		if err := h.recordLoginFailure(user.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := h.store.LoginAttempts().Clear(loginKey(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response, err := h.startSession(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// startChallenge answers a correct password on an account with two-factor
+// authentication. The challenge token is tied to the password hash, so a
+// password change invalidates it.
+func (h *AuthHandler) startChallenge(c *gin.Context, user models.User) {
+	token, err := h.ActionTokens.Sign(auth.PurposeLoginChallenge, user.ID, passwordFingerprint(user), loginChallengeTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(loginChallengeTTL.Seconds()),
+	})
+}
+
+// useCode accepts a TOTP code or an unused recovery code and uses it up.
+func useCode(s store.Store, twoFactor models.TwoFactor, code string) error {
+	code = strings.Join(strings.Fields(code), "")
+	if isTOTPCode(code) {
+		return useTOTP(s, twoFactor, code)
+	}
+
+	hashes, err := s.TwoFactor().UnusedRecoveryCodes(twoFactor.UserID)
+	if err != nil {
+		return err
+	}
+	code = normalizeRecoveryCode(code)
+	for id, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) != nil {
+			continue
+		}
+		err := s.TwoFactor().UseRecoveryCode(twoFactor.UserID, id)
+		if err == store.ErrNotFound {
+			return errInvalidCode
+		}
+		return err
+	}
+	return errInvalidCode
+}
+
+// useTOTP accepts a TOTP code unless its time step has been used already.
+func useTOTP(s store.Store, twoFactor models.TwoFactor, code string) error {
+	step, ok := auth.ValidateTOTP(twoFactor.Secret, strings.Join(strings.Fields(code), ""), time.Now())
+	if !ok || step <= twoFactor.LastStep {
+		return errInvalidCode
+	}
+
+	err := s.TwoFactor().UseStep(twoFactor.UserID, step)
+	if err == store.ErrNotFound {
+		return errInvalidCode
+	}
+	return err
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes for the user
+// and returns them in plain text.
+func replaceRecoveryCodes(s store.Store, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10) // 80 bits, 16 characters
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := recoveryCodeEncoding.EncodeToString(b)
+		codes[i] = code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = string(hash)
+	}
+
+	if err := s.TwoFactor().ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignores case and dashes, which people get wrong
+// when typing codes in from paper.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
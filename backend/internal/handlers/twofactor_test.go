@@ -0,0 +1,146 @@
+// internal/handlers/twofactor_test.go
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/models"
+)
+
+var recoveryCodePattern = regexp.MustCompile(`^[a-z2-7]{4}(-[a-z2-7]{4}){3}$`)
+
+// totp returns the code of the secret offset steps from now. Every step
+// is accepted once, so a test that needs several codes takes them from
+// successive steps.
+func totp(t *testing.T, secret string, offset int) string {
+	t.Helper()
+	code, err := auth.GenerateTOTP(secret, time.Now().Add(time.Duration(offset)*30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enableTwoFactor sets up two-factor authentication for a user registered
+// by login and returns the secret and the recovery codes.
+func (ts *testServer) enableTwoFactor(userID int) (string, []string) {
+	ts.t.Helper()
+	var setup models.TwoFactorSetupResponse
+	ts.expect(ts.do(userID, "POST", "/auth/2fa/setup", models.PasswordRequest{Password: "secret1"}), http.StatusOK, &setup)
+
+	var enabled models.RecoveryCodesResponse
+	ts.expect(ts.do(userID, "POST", "/auth/2fa/enable", models.TwoFactorCodeRequest{Code: totp(ts.t, setup.Secret, -1)}), http.StatusOK, &enabled)
+	return setup.Secret, enabled.RecoveryCodes
+}
+
+// challenge logs in with the password and returns the challenge token.
+func (ts *testServer) challenge(email string) string {
+	ts.t.Helper()
+	var challenge models.LoginChallengeResponse
+	ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: email, Password: "secret1"}), http.StatusOK, &challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		ts.t.Fatalf("login answered %+v, want a challenge", challenge)
+	}
+	return challenge.ChallengeToken
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		secret, codes := ts.enableTwoFactor(alice)
+
+		if len(codes) != 10 {
+			t.Fatalf("got %d recovery codes, want 10", len(codes))
+		}
+		for _, code := range codes {
+			if !recoveryCodePattern.MatchString(code) {
+				t.Errorf("recovery code %q is not 16 base32 characters in groups of 4", code)
+			}
+		}
+
+		// A TOTP code completes the login, once.
+		code := models.TwoFactorLoginRequest{ChallengeToken: ts.challenge("alice@example.com"), Code: totp(t, secret, 0)}
+		var session models.LoginResponse
+		ts.expect(ts.do(0, "POST", "/auth/login/2fa", code), http.StatusOK, &session)
+		if session.Token == "" || session.User.ID != alice {
+			t.Errorf("second step answered %+v", session)
+		}
+		ts.expect(ts.do(0, "POST", "/auth/login/2fa", code), http.StatusUnauthorized, nil)
+
+		// So does a recovery code, typed in without dashes and in capitals.
+		recovery := models.TwoFactorLoginRequest{
+			ChallengeToken: ts.challenge("alice@example.com"),
+			Code:           strings.ToUpper(strings.ReplaceAll(codes[3], "-", "")),
+		}
+		ts.expect(ts.do(0, "POST", "/auth/login/2fa", recovery), http.StatusOK, nil)
+		ts.expect(ts.do(0, "POST", "/auth/login/2fa", recovery), http.StatusUnauthorized, nil)
+
+		var status models.TwoFactorStatus
+		ts.expect(ts.do(alice, "GET", "/auth/2fa", nil), http.StatusOK, &status)
+		if !status.Enabled || status.RecoveryCodesLeft != 9 {
+			t.Errorf("status is %+v, want enabled with 9 recovery codes left", status)
+		}
+	})
+}
+
+func TestRegenerateAndDisableTwoFactor(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		_, codes := ts.enableTwoFactor(alice)
+
+		var regenerated models.RecoveryCodesResponse
+		ts.expect(ts.do(alice, "POST", "/auth/2fa/recovery-codes", models.TwoFactorConfirmRequest{Password: "secret1", Code: codes[0]}),
+			http.StatusOK, &regenerated)
+
+		// The old codes are gone with the regeneration.
+		disable := models.TwoFactorConfirmRequest{Password: "secret1", Code: codes[1]}
+		ts.expect(ts.do(alice, "POST", "/auth/2fa/disable", disable), http.StatusBadRequest, nil)
+		disable.Code = regenerated.RecoveryCodes[0]
+		ts.expect(ts.do(alice, "POST", "/auth/2fa/disable", disable), http.StatusOK, nil)
+
+		var session models.LoginResponse
+		ts.expect(ts.do(0, "POST", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "secret1"}), http.StatusOK, &session)
+		if session.Token == "" {
+			t.Errorf("login after disabling two-factor authentication answered %+v", session)
+		}
+	})
+}
+
+func TestTwoFactorErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, ts *testServer) {
+		alice := ts.login("alice@example.com").User.ID
+		bob := ts.login("bob@example.com").User.ID
+		_, codes := ts.enableTwoFactor(bob)
+
+		cases := []struct {
+			name   string
+			userID int
+			path   string
+			body   interface{}
+			status int
+		}{
+			{"set up with the wrong password", alice, "/auth/2fa/setup", models.PasswordRequest{Password: "wrong1"}, http.StatusForbidden},
+			{"enable without a setup", alice, "/auth/2fa/enable", models.TwoFactorCodeRequest{Code: "123456"}, http.StatusConflict},
+			{"set up again once enabled", bob, "/auth/2fa/setup", models.PasswordRequest{Password: "secret1"}, http.StatusConflict},
+			{"regenerate codes when disabled", alice, "/auth/2fa/recovery-codes",
+				models.TwoFactorConfirmRequest{Password: "secret1", Code: "123456"}, http.StatusConflict},
+			{"disable with the wrong password", bob, "/auth/2fa/disable",
+				models.TwoFactorConfirmRequest{Password: "wrong1", Code: codes[0]}, http.StatusForbidden},
+			{"disable with a wrong code", bob, "/auth/2fa/disable",
+				models.TwoFactorConfirmRequest{Password: "secret1", Code: "aaaa-bbbb-cccc-dddd"}, http.StatusBadRequest},
+			{"second step with a forged challenge", 0, "/auth/login/2fa",
+				models.TwoFactorLoginRequest{ChallengeToken: "forged.token", Code: codes[0]}, http.StatusUnauthorized},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				ts := ts.on(t)
+				ts.expect(ts.do(tc.userID, "POST", tc.path, tc.body), tc.status, nil)
+			})
+		}
+	})
+}
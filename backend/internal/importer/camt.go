@@ -0,0 +1,213 @@
+// internal/importer/camt.go
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// camtEntry is an entry (Ntry) of a CAMT.053 statement. Elements are
+// matched by their local names, which are the same in every version of
+// the message.
+type camtEntry struct {
+	Amount     camtAmount `xml:"Amt"`
+	Indicator  string     `xml:"CdtDbtInd"`
+	Status     camtStatus `xml:"Sts"`
+	BookedOn   camtDate   `xml:"BookgDt"`
+	ValueOn    camtDate   `xml:"ValDt"`
+	Reference  string     `xml:"AcctSvcrRef"`
+	Details    []camtTx   `xml:"NtryDtls>TxDtls"`
+	Additional string     `xml:"AddtlNtryInf"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtStatus is a plain code up to version 6 of the message, and a Cd
+// element from version 7.
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+func (s camtStatus) code() string {
+	return strings.TrimSpace(s.Value + s.Code)
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) day() (time.Time, bool) {
+	if d.Date != "" {
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+		return day, err == nil
+	}
+	if d.DateTime != "" && len(strings.TrimSpace(d.DateTime)) >= 10 {
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(d.DateTime)[:10])
+		return day, err == nil
+	}
+	return time.Time{}, false
+}
+
+type camtTx struct {
+	Amount     camtAmount `xml:"Amt"`
+	TxAmount   camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Indicator  string     `xml:"CdtDbtInd"`
+	EndToEndID string     `xml:"Refs>EndToEndId"`
+	Reference  string     `xml:"Refs>AcctSvcrRef"`
+	Debtor     camtParty  `xml:"RltdPties>Dbtr"`
+	Creditor   camtParty  `xml:"RltdPties>Cdtr"`
+	Remittance []string   `xml:"RmtInf>Ustrd"`
+	Additional string     `xml:"AddtlTxInf"`
+}
+
+// camtParty holds the name of a party: directly up to version 7 of the
+// message, and in a Pty element from version 8.
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	return strings.TrimSpace(p.Name + p.PartyName)
+}
+
+// ParseCAMT053 reads the booked entries of the statements of a CAMT.053
+// file. An entry that batches several transactions with their own amounts
+// gives a record for each of them. Pending entries are left out, as they
+// are booked in a later statement.
+func ParseCAMT053(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	var records []Record
+	entries, statements := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("the file is not valid XML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Stmt":
+			statements++
+		case "Ntry":
+			var entry camtEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				return nil, fmt.Errorf("the file is not valid XML: %w", err)
+			}
+			entries++
+			if status := entry.Status.code(); status != "" && status != "BOOK" {
+				continue
+			}
+			for _, record := range entry.records(entries) {
+				if len(records) == MaxRows {
+					return nil, ErrTooManyRows
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	if statements == 0 {
+		return nil, errors.New("the file is not a CAMT.053 statement: it has no Stmt element")
+	}
+	return records, nil
+}
+
+// records turns the entry numbered row into records.
+func (e camtEntry) records(row int) []Record {
+	split := len(e.Details) > 1
+	for _, tx := range e.Details {
+		if tx.amount().Value == "" {
+			split = false
+		}
+	}
+
+	if !split {
+		var tx camtTx
+		if len(e.Details) > 0 {
+			tx = e.Details[0]
+		}
+		return []Record{e.record(row, e.Amount, e.Indicator, tx, firstOf(tx.Reference, e.Reference, tx.EndToEndID))}
+	}
+
+	records := make([]Record, 0, len(e.Details))
+	for _, tx := range e.Details {
+		indicator := tx.Indicator
+		if indicator == "" {
+			indicator = e.Indicator
+		}
+		// The reference of the entry is shared by the transactions in it.
+		records = append(records, e.record(row, tx.amount(), indicator, tx, firstOf(tx.Reference, tx.EndToEndID)))
+	}
+	return records
+}
+
+func (e camtEntry) record(row int, amount camtAmount, indicator string, tx camtTx, reference string) Record {
+	record := Record{
+		Row:       row,
+		Currency:  strings.ToUpper(strings.TrimSpace(amount.Currency)),
+		Reference: reference,
+	}
+	if record.Reference == "NOTPROVIDED" {
+		record.Reference = ""
+	}
+
+	if day, ok := e.BookedOn.day(); ok {
+		record.Date = day
+	} else if day, ok := e.ValueOn.day(); ok {
+		record.Date = day
+	} else {
+		record.fail("the entry has no booking date")
+	}
+
+	minor, err := parseAmount(amount.Value, '.')
+	if err != nil {
+		record.fail("%s", err)
+	}
+	// A reversal is booked in the opposite direction of what it reverses,
+	// so the indicator gives the direction of the money either way.
+	debit := strings.TrimSpace(indicator) == "DBIT"
+	if debit {
+		minor = -minor
+	}
+	record.Amount = minor
+
+	// The other party is the debtor of money received and the creditor of
+	// money paid.
+	if debit {
+		record.Counterparty = tx.Creditor.name()
+	} else {
+		record.Counterparty = tx.Debtor.name()
+	}
+	record.Description = firstOf(strings.TrimSpace(strings.Join(tx.Remittance, " ")), tx.Additional, e.Additional)
+	return record
+}
+
+func (tx camtTx) amount() camtAmount {
+	if strings.TrimSpace(tx.Amount.Value) != "" {
+		return tx.Amount
+	}
+	return tx.TxAmount
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+// internal/importer/camt_test.go
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCAMT053(t *testing.T) {
+	file := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"><BkToCstmrStmt><Stmt>
+<Ntry>
+  <Amt Ccy="EUR">120.50</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+  <BookgDt><Dt>2025-07-01</Dt></BookgDt><AcctSvcrRef>E1</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <RltdPties><Dbtr><Pty><Nm>Me</Nm></Pty></Dbtr><Cdtr><Pty><Nm>Bob</Nm></Pty></Cdtr></RltdPties>
+    <RmtInf><Ustrd>Dinner</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">999.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts>
+  <BookgDt><Dt>2025-07-02</Dt></BookgDt>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">80.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+  <ValDt><DtTm>2025-07-03T10:00:00</DtTm></ValDt><AcctSvcrRef>E3</AcctSvcrRef>
+  <NtryDtls>
+    <TxDtls><Amt Ccy="EUR">30.00</Amt><Refs><EndToEndId>T1</EndToEndId></Refs>
+      <RltdPties><Dbtr><Nm>Carol</Nm></Dbtr></RltdPties></TxDtls>
+    <TxDtls><AmtDtls><TxAmt><Amt Ccy="EUR">50.00</Amt></TxAmt></AmtDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+      <RltdPties><Dbtr><Nm>Dave</Nm></Dbtr></RltdPties></TxDtls>
+  </NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">1.005</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+</Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+	records, err := ParseCAMT053(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, []string{
+		"1 2025-07-01 -12050 EUR Bob E1",
+		"3 2025-07-03 3000 EUR Carol T1",
+		"3 2025-07-03 5000 EUR Dave ",
+		`4 error: the entry has no booking date; amount "1.005" has more than 2 decimals`,
+	})
+	if records[0].Description != "Dinner" {
+		t.Errorf("description is %q, want Dinner", records[0].Description)
+	}
+
+	for _, invalid := range []string{"<Document><BkToCstmrStmt>", "<Document></Document>"} {
+		if _, err := ParseCAMT053(strings.NewReader(invalid)); err == nil {
+			t.Errorf("%q was read as a statement", invalid)
+		}
+	}
+}
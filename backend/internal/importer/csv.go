@@ -0,0 +1,210 @@
+// internal/importer/csv.go
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Columns names the header of the column of each field of a CSV file.
+// Amounts are read signed from Amount, or from Debit (money paid) and
+// Credit (money received), of which a row fills in one.
+type Columns struct {
+	Date         string
+	Amount       string
+	Debit        string
+	Credit       string
+	Currency     string
+	Description  string
+	Counterparty string
+	Reference    string
+	DebtID       string
+}
+
+// CSVOptions sets how a CSV file is read. DateFormat is a Go time layout
+// and defaults to "2006-01-02"; Decimal defaults to '.' and Delimiter to
+// ','.
+type CSVOptions struct {
+	Columns    Columns
+	DateFormat string
+	Decimal    rune
+	Delimiter  rune
+}
+
+// ParseCSV reads a CSV file with a header row. The columns are found by
+// their header, ignoring case and surrounding spaces.
+func ParseCSV(r io.Reader, opts CSVOptions) ([]Record, error) {
+	if opts.DateFormat == "" {
+		opts.DateFormat = "2006-01-02"
+	}
+	if opts.Decimal == 0 {
+		opts.Decimal = '.'
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.Columns.Date == "" {
+		return nil, errors.New("the date column is not mapped")
+	}
+	if opts.Columns.Amount == "" && opts.Columns.Debit == "" && opts.Columns.Credit == "" {
+		return nil, errors.New("the amount column, or the debit and credit columns, are not mapped")
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	} else if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often start UTF-8 files with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// column is the index of a mapped column, or -1 if it is not mapped.
+	var missing []string
+	column := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			missing = append(missing, name)
+			return -1
+		}
+		return i
+	}
+	columns := struct {
+		date, amount, debit, credit, currency, description, counterparty, reference, debtID int
+	}{
+		column(opts.Columns.Date), column(opts.Columns.Amount), column(opts.Columns.Debit),
+		column(opts.Columns.Credit), column(opts.Columns.Currency), column(opts.Columns.Description),
+		column(opts.Columns.Counterparty), column(opts.Columns.Reference), column(opts.Columns.DebtID),
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the file has no column %s", strings.Join(quote(missing), ", "))
+	}
+
+	var records []Record
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			records = append(records, Record{Row: parseErr.StartLine, Errors: []string{parseErr.Err.Error()}})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if blank(fields) {
+			continue
+		}
+		if len(records) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(i int) string {
+			if i < 0 || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+		record := Record{
+			Row:          line,
+			Currency:     strings.ToUpper(field(columns.currency)),
+			Description:  field(columns.description),
+			Counterparty: field(columns.counterparty),
+			Reference:    field(columns.reference),
+			DebtID:       field(columns.debtID),
+		}
+
+		if value := field(columns.date); value == "" {
+			record.fail("the date is missing")
+		} else if date, err := time.Parse(opts.DateFormat, value); err != nil {
+			record.fail("invalid date %q; expected the format %s", value, opts.DateFormat)
+		} else {
+			record.Date = date.UTC()
+		}
+
+		if err := readAmount(&record, field(columns.amount), field(columns.debit), field(columns.credit), opts.Decimal); err != nil {
+			record.fail("%s", err)
+		}
+
+		records = append(records, record)
+	}
+}
+
+// readAmount sets the amount of a record from its amount column, or from
+// its debit and credit columns. Banks often fill in the column that does
+// not apply with zero.
+func readAmount(record *Record, amount, debit, credit string, decimal rune) error {
+	if amount != "" {
+		var err error
+		record.Amount, err = parseAmount(amount, decimal)
+		return err
+	}
+
+	var paid, received int64
+	var err error
+	if debit != "" {
+		if paid, err = parseAmount(debit, decimal); err != nil {
+			return err
+		}
+	}
+	if credit != "" {
+		if received, err = parseAmount(credit, decimal); err != nil {
+			return err
+		}
+	}
+	switch {
+	case paid != 0 && received != 0:
+		return errors.New("both debit and credit are given")
+	case paid != 0:
+		record.Amount = -abs(paid)
+	case received != 0:
+		record.Amount = received
+	case debit == "" && credit == "":
+		return errors.New("the amount is missing")
+	}
+	return nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func blank(fields []string) bool {
+	for _, field := range fields {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func quote(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return quoted
+}
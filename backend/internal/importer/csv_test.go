@@ -0,0 +1,69 @@
+// internal/importer/csv_test.go
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	file := "\ufeffDatum;Betrag;Währung;Name;Referenz\n" +
+		"01.07.2025;-1.234,56;eur;Bob;A1\n" +
+		"\n" +
+		"02.07.2025;50;;Carol;\n" +
+		"2025-07-03;5;EUR;Dave;A3\n" +
+		"04.07.2025;zehn;EUR;Eve;A4\n"
+	records, err := ParseCSV(strings.NewReader(file), CSVOptions{
+		Columns:    Columns{Date: "datum", Amount: " Betrag ", Currency: "Währung", Counterparty: "Name", Reference: "Referenz"},
+		DateFormat: "02.01.2006",
+		Decimal:    ',',
+		Delimiter:  ';',
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, []string{
+		"2 2025-07-01 -123456 EUR Bob A1",
+		"4 2025-07-02 5000  Carol ",
+		`5 error: invalid date "2025-07-03"; expected the format 02.01.2006`,
+		`6 error: invalid amount "zehn"`,
+	})
+}
+
+func TestParseCSVDebitAndCredit(t *testing.T) {
+	file := "date,debit,credit,who\n" +
+		"2025-07-01,12.50,0.00,Bob\n" +
+		"2025-07-02,,30,Bob\n" +
+		"2025-07-03,1,2,Bob\n" +
+		"2025-07-04,,,Bob\n"
+	records, err := ParseCSV(strings.NewReader(file), CSVOptions{Columns: Columns{Date: "date", Debit: "debit", Credit: "credit", Counterparty: "who"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, []string{
+		"2 2025-07-01 -1250  Bob ",
+		"3 2025-07-02 3000  Bob ",
+		"4 error: both debit and credit are given",
+		"5 error: the amount is missing",
+	})
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	columns := Columns{Date: "date", Amount: "amount"}
+	cases := []struct {
+		name string
+		file string
+		opts CSVOptions
+	}{
+		{"empty file", "", CSVOptions{Columns: columns}},
+		{"no date column mapped", "date,amount\n", CSVOptions{Columns: Columns{Amount: "amount"}}},
+		{"no amount column mapped", "date,amount\n", CSVOptions{Columns: Columns{Date: "date"}}},
+		{"missing column", "date,value\n", CSVOptions{Columns: columns}},
+		{"too many rows", "date,amount\n" + strings.Repeat("2025-07-01,1\n", MaxRows+1), CSVOptions{Columns: columns}},
+	}
+	for _, tc := range cases {
+		if records, err := ParseCSV(strings.NewReader(tc.file), tc.opts); err == nil {
+			t.Errorf("%s: read %d records, want an error", tc.name, len(records))
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// internal/importer/importer.go
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats of the files that can be imported. QFX is OFX as Quicken names
+// it, and CAMT053 is the ISO 20022 bank-to-customer statement.
+const (
+	CSV     = "csv"
+	OFX     = "ofx"
+	QFX     = "qfx"
+	CAMT053 = "camt053"
+)
+
+// MaxRows is the most rows a file may have.
+const MaxRows = 5000
+
+// ErrTooManyRows is returned for files of more than MaxRows rows.
+var ErrTooManyRows = fmt.Errorf("the file has more than %d rows", MaxRows)
+
+// Record is one row of an imported file: a payment on a day, with the
+// other party to it. Amount is in minor units, positive for money the user
+// received and negative for money they paid. A row that cannot be read
+// has the reasons in Errors and may lack any of the other fields.
+type Record struct {
+	// Row is the line of a CSV file, or the number of an entry of a
+	// statement counted from 1.
+	Row          int
+	Date         time.Time
+	Amount       int64
+	Currency     string
+	Description  string
+	Counterparty string
+	// Reference identifies the payment at the bank, where the file gives
+	// it, such as the FITID of OFX.
+	Reference string
+	// DebtID is the text of the debt_id column of a CSV file.
+	DebtID string
+	Errors []string
+}
+
+func (r *Record) fail(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// Parse reads the records of a file in one of the formats. Problems with
+// single rows are left in the records; an error is returned only for a
+// file that cannot be read at all.
+func Parse(format string, r io.Reader, opts CSVOptions) ([]Record, error) {
+	switch format {
+	case CSV:
+		return ParseCSV(r, opts)
+	case OFX, QFX:
+		return ParseOFX(r)
+	case CAMT053:
+		return ParseCAMT053(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// DetectFormat guesses the format of a file from its name, and for XML
+// files from the start of its content.
+func DetectFormat(filename string, head []byte) (string, error) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".csv"), strings.HasSuffix(name, ".txt"):
+		return CSV, nil
+	case strings.HasSuffix(name, ".ofx"):
+		return OFX, nil
+	case strings.HasSuffix(name, ".qfx"):
+		return QFX, nil
+	}
+	content := string(head)
+	switch {
+	case strings.Contains(content, "camt.053"):
+		return CAMT053, nil
+	case strings.Contains(content, "OFXHEADER"), strings.Contains(content, "<OFX>"):
+		return OFX, nil
+	}
+	return "", errors.New("cannot tell the format of the file; give format")
+}
+
+// parseAmount reads an amount with the decimal separator decimal. Any
+// other separator is taken for a thousands separator. A leading or
+// trailing minus or parentheses make it negative.
+func parseAmount(s string, decimal rune) (int64, error) {
+	value := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")"):
+		negative, value = true, value[1:len(value)-1]
+	case strings.HasPrefix(value, "-"):
+		negative, value = true, value[1:]
+	case strings.HasSuffix(value, "-"):
+		negative, value = true, value[:len(value)-1]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	var units, cents strings.Builder
+	seenDecimal := false
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case r >= '0' && r <= '9':
+			if seenDecimal {
+				cents.WriteRune(r)
+			} else {
+				units.WriteRune(r)
+			}
+		case r == decimal && !seenDecimal:
+			seenDecimal = true
+		case r == '.' || r == ',' || r == '\'' || r == '\u2019' || r == ' ' || r == '\u00a0' || r == '\u202f':
+			if seenDecimal {
+				return 0, fmt.Errorf("invalid amount %q", s)
+			}
+		default:
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	if units.Len()+cents.Len() == 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if units.Len() > 13 {
+		return 0, fmt.Errorf("amount %q is too large", s)
+	}
+	fraction := strings.TrimRight(cents.String(), "0")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q has more than 2 decimals", s)
+	}
+
+	var minor int64
+	for _, r := range units.String() + (fraction + "00")[:2] {
+		minor = minor*10 + int64(r-'0')
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
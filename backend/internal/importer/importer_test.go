@@ -0,0 +1,96 @@
+// internal/importer/importer_test.go
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe writes records as "row date amount currency counterparty
+// reference", or "row error: ..." for rows that cannot be read.
+func describe(records []Record) []string {
+	described := make([]string, len(records))
+	for i, record := range records {
+		if len(record.Errors) > 0 {
+			described[i] = fmt.Sprintf("%d error: %s", record.Row, strings.Join(record.Errors, "; "))
+			continue
+		}
+		described[i] = fmt.Sprintf("%d %s %d %s %s %s", record.Row, record.Date.Format("2006-01-02"),
+			record.Amount, record.Currency, record.Counterparty, record.Reference)
+	}
+	return described
+}
+
+func checkRecords(t *testing.T, records []Record, want []string) {
+	t.Helper()
+	got := describe(records)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("records are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		text    string
+		decimal rune
+		minor   int64
+		err     bool
+	}{
+		{text: "12", decimal: '.', minor: 1200},
+		{text: "12.3", decimal: '.', minor: 1230},
+		{text: "1,234.56", decimal: '.', minor: 123456},
+		{text: "1.234,56", decimal: ',', minor: 123456},
+		{text: "1 234,5", decimal: ',', minor: 123450},
+		{text: "1’234.50", decimal: '.', minor: 123450},
+		{text: "-0.05", decimal: '.', minor: -5},
+		{text: "0.05-", decimal: '.', minor: -5},
+		{text: "(12.00)", decimal: '.', minor: -1200},
+		{text: "+7", decimal: '.', minor: 700},
+		{text: " 7.10 ", decimal: '.', minor: 710},
+		{text: "1.500", decimal: '.', minor: 150},
+		{text: ",5", decimal: ',', minor: 50},
+		{text: "1.234", decimal: ',', minor: 123400},
+		{text: "1.235", decimal: '.', err: true},
+		{text: "1.2.3", decimal: '.', err: true},
+		{text: "1,00.5", decimal: ',', err: true},
+		{text: "12 EUR", decimal: '.', err: true},
+		{text: "", decimal: '.', err: true},
+		{text: "-", decimal: '.', err: true},
+		{text: "99999999999999", decimal: '.', err: true},
+	}
+	for _, tc := range cases {
+		minor, err := parseAmount(tc.text, tc.decimal)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseAmount(%q, %q) = %d, want an error", tc.text, tc.decimal, minor)
+			}
+			continue
+		}
+		if err != nil || minor != tc.minor {
+			t.Errorf("parseAmount(%q, %q) = %d, %v; want %d", tc.text, tc.decimal, minor, err, tc.minor)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		filename, head, want string
+	}{
+		{"statement.CSV", "", CSV},
+		{"export.txt", "", CSV},
+		{"bank.qfx", "", QFX},
+		{"bank.ofx", "", OFX},
+		{"statement.xml", `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">`, CAMT053},
+		{"statement.xml", "OFXHEADER:100\nDATA:OFXSGML", OFX},
+		{"download", "<?xml version=\"1.0\"?><OFX>", OFX},
+	}
+	for _, tc := range cases {
+		if got, err := DetectFormat(tc.filename, []byte(tc.head)); err != nil || got != tc.want {
+			t.Errorf("DetectFormat(%q) = %q, %v; want %q", tc.filename, got, err, tc.want)
+		}
+	}
+	if format, err := DetectFormat("statement.pdf", []byte("%PDF-1.7")); err == nil {
+		t.Errorf("a PDF was taken for %s", format)
+	}
+}
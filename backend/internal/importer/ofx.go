@@ -0,0 +1,140 @@
+// internal/importer/ofx.go
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var ofxEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ", "&amp;", "&")
+
+// ParseOFX reads the transactions of the bank and credit card statements
+// of an OFX or QFX file. Both OFX 1 (SGML, where elements holding a value
+// need not be closed) and OFX 2 (XML) are read alike, by their tags.
+func ParseOFX(r io.Reader) ([]Record, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := string(content)
+	start := strings.Index(strings.ToUpper(text), "<OFX>")
+	if start < 0 {
+		return nil, errors.New("the file is not OFX: it has no <OFX> element")
+	}
+	text = text[start:]
+
+	var records []Record
+	var currency string
+	var record *Record
+	// payee is set inside a PAYEE aggregate, whose NAME is the payee's,
+	// and converted inside a CURRENCY aggregate, which gives the currency
+	// of the amount when it is not the statement's.
+	payee, converted := false, false
+
+	for len(text) > 0 {
+		open := strings.IndexByte(text, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(text[open:], '>')
+		if end < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(text[open+1 : open+end]))
+		text = text[open+end+1:]
+		value := text
+		if next := strings.IndexByte(text, '<'); next >= 0 {
+			value = text[:next]
+		}
+		value = ofxEntities.Replace(strings.TrimSpace(value))
+
+		switch tag {
+		case "CURDEF":
+			currency = strings.ToUpper(value)
+		case "STMTTRN":
+			finishOFX(record)
+			if len(records) == MaxRows {
+				return nil, ErrTooManyRows
+			}
+			records = append(records, Record{Row: len(records) + 1, Currency: currency})
+			record = &records[len(records)-1]
+		case "/STMTTRN":
+			finishOFX(record)
+			record = nil
+		case "PAYEE":
+			payee = true
+		case "/PAYEE":
+			payee = false
+		case "CURRENCY":
+			converted = true
+		case "/CURRENCY":
+			converted = false
+		}
+		if record == nil || strings.HasPrefix(tag, "/") {
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			date, err := parseOFXDate(value)
+			if err != nil {
+				record.fail("invalid DTPOSTED %q", value)
+			} else {
+				record.Date = date
+			}
+		case "TRNAMT":
+			// OFX amounts have no thousands separators, but some banks
+			// write them with a decimal comma.
+			decimal := '.'
+			if strings.Contains(value, ",") && !strings.Contains(value, ".") {
+				decimal = ','
+			}
+			amount, err := parseAmount(value, decimal)
+			if err != nil {
+				record.fail("%s", err)
+			} else {
+				record.Amount = amount
+			}
+		case "FITID":
+			record.Reference = value
+		case "NAME":
+			if payee || record.Counterparty == "" {
+				record.Counterparty = value
+			}
+		case "MEMO":
+			record.Description = value
+		case "CURSYM":
+			if converted {
+				record.Currency = strings.ToUpper(value)
+			}
+		}
+	}
+	if record != nil {
+		finishOFX(record)
+	}
+	return records, nil
+}
+
+// finishOFX checks that a transaction has what every transaction needs.
+func finishOFX(record *Record) {
+	if record == nil {
+		return
+	}
+	if record.Date.IsZero() && len(record.Errors) == 0 {
+		record.fail("the transaction has no DTPOSTED")
+	}
+	if record.Description == "" {
+		record.Description = record.Counterparty
+	}
+}
+
+// parseOFXDate reads the day of an OFX date such as 20250115 or
+// 20250115120000.000[-5:EST]. Only the day is kept, as the bank gives it.
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.New("too short")
+	}
+	return time.Parse("20060102", value[:8])
+}
@@ -0,0 +1,50 @@
+// internal/importer/ofx_test.go
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOFX(t *testing.T) {
+	// OFX 1 leaves the elements holding a value open.
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>ZAR
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250701120000.000[+2:SAST]<TRNAMT>-120.50<FITID>F1<NAME>Bob &amp; Co<MEMO>Dinner
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20250702<TRNAMT>75,00<FITID>F2<NAME>Carol
+<CURRENCY><CURRATE>1.0<CURSYM>usd</CURRENCY>
+<STMTTRN><TRNTYPE>CREDIT<TRNAMT>5<FITID>F3
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+	records, err := ParseOFX(strings.NewReader(sgml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, []string{
+		"1 2025-07-01 -12050 ZAR Bob & Co F1",
+		"2 2025-07-02 7500 USD Carol F2",
+		"3 error: the transaction has no DTPOSTED",
+	})
+	if records[0].Description != "Dinner" || records[1].Description != "Carol" {
+		t.Errorf("descriptions are %q and %q", records[0].Description, records[1].Description)
+	}
+
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS><CURDEF>EUR</CURDEF>
+<BANKTRANLIST>
+<STMTTRN><DTPOSTED>20250703</DTPOSTED><TRNAMT>-9.99</TRNAMT><FITID>X1</FITID>
+<PAYEE><NAME>Shop</NAME></PAYEE><NAME>Card payment</NAME></STMTTRN>
+</BANKTRANLIST></CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`
+	records, err = ParseOFX(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, []string{"1 2025-07-03 -999 EUR Shop X1"})
+
+	if _, err := ParseOFX(strings.NewReader("date,amount\n")); err == nil {
+		t.Error("a CSV file was read as OFX")
+	}
+}
@@ -0,0 +1,90 @@
+// internal/interest/amortization.go
+package interest
+
+import (
+	"math/big"
+
+	"debt-tracker-backend/internal/recurrence"
+)
+
+// annuityPrecision is the precision, in bits, of the annuity factor.
+const annuityPrecision = 256
+
+// Payment is one payment of an amortization schedule, in minor units.
+// Balance is what is left after it.
+type Payment struct {
+	Payment   int64
+	Interest  int64
+	Principal int64
+	Balance   int64
+}
+
+// PeriodsPerYear is how many payments a year the rule makes, for the
+// periodic rate of an amortization schedule: 365 a year for daily rules,
+// 52 for weekly ones and 12 for monthly ones, times the days of each
+// period given by BYDAY or BYMONTHDAY, over the interval.
+func PeriodsPerYear(rule recurrence.Rule) *big.Rat {
+	perYear := int64(12)
+	switch rule.Freq {
+	case recurrence.Daily:
+		perYear = 365
+	case recurrence.Weekly:
+		perYear = 52 * int64(max(len(rule.ByDay), 1))
+	case recurrence.Monthly:
+		perYear = 12 * int64(max(len(rule.ByMonthDay), 1))
+	}
+	return big.NewRat(perYear, int64(max(rule.Interval, 1)))
+}
+
+// Amortize splits a loan of principal into n equal payments that cover
+// the principal and the interest at rate, the annual rate as a fraction,
+// with perYear payments a year. The periodic rate is rate/perYear. Each
+// payment's interest is rounded to the cent, and the last payment is
+// adjusted to clear the balance exactly.
+func Amortize(principal int64, rate, perYear *big.Rat, n int) []Payment {
+	if n < 1 || principal <= 0 {
+		return nil
+	}
+
+	periodic := new(big.Rat).Quo(rate, perYear)
+	level := levelPayment(principal, periodic, n)
+
+	payments := make([]Payment, n)
+	balance := principal
+	for i := range payments {
+		interest := Round(new(big.Rat).Mul(new(big.Rat).SetInt64(balance), periodic))
+		payment := level
+		if i == n-1 || payment-interest > balance {
+			payment = balance + interest
+		}
+		if i < n-1 && periodic.Sign() == 0 && i < int(principal%int64(n)) {
+			// Without interest, the cents left over go to the first payments.
+			payment++
+		}
+		balance -= payment - interest
+		payments[i] = Payment{Payment: payment, Interest: interest, Principal: payment - interest, Balance: balance}
+	}
+	return payments
+}
+
+// levelPayment is the equal payment that repays principal over n periods
+// at the periodic rate: principal*r / (1 - (1+r)^-n), rounded to the cent.
+func levelPayment(principal int64, periodic *big.Rat, n int) int64 {
+	if periodic.Sign() == 0 {
+		return principal / int64(n)
+	}
+
+	r := new(big.Float).SetPrec(annuityPrecision).SetRat(periodic)
+	growth := new(big.Float).SetPrec(annuityPrecision).SetInt64(1)
+	base := new(big.Float).SetPrec(annuityPrecision).Add(big.NewFloat(1), r)
+	for range n {
+		growth.Mul(growth, base)
+	}
+	// principal*r*(1+r)^n / ((1+r)^n - 1)
+	payment := new(big.Float).SetPrec(annuityPrecision).SetInt64(principal)
+	payment.Mul(payment, r).Mul(payment, growth)
+	payment.Quo(payment, growth.Sub(growth, big.NewFloat(1)))
+
+	exact, _ := payment.Rat(nil)
+	return Round(exact)
+}
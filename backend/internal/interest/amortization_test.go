@@ -0,0 +1,65 @@
+// internal/interest/amortization_test.go
+package interest
+
+import (
+	"math/big"
+	"testing"
+
+	"debt-tracker-backend/internal/recurrence"
+)
+
+func TestAmortize(t *testing.T) {
+	payments := Amortize(100000, big.NewRat(12, 100), big.NewRat(12, 1), 12)
+	if len(payments) != 12 {
+		t.Fatalf("%d payments, want 12", len(payments))
+	}
+	var principal int64
+	for i, payment := range payments {
+		principal += payment.Principal
+		if i < 11 && payment.Payment != 8885 {
+			t.Errorf("payment %d is %d, want 8885", i+1, payment.Payment)
+		}
+		if payment.Payment != payment.Interest+payment.Principal {
+			t.Errorf("payment %d does not add up: %+v", i+1, payment)
+		}
+	}
+	if payments[0].Interest != 1000 {
+		t.Errorf("first payment has %d interest, want 1000", payments[0].Interest)
+	}
+	// The last payment clears the balance exactly.
+	if last := payments[11]; last.Balance != 0 || principal != 100000 {
+		t.Errorf("last payment leaves %d, principal repaid is %d", last.Balance, principal)
+	}
+
+	// Without interest, the leftover cents go to the first payments.
+	var got []int64
+	for _, payment := range Amortize(1000, new(big.Rat), big.NewRat(12, 1), 3) {
+		got = append(got, payment.Payment)
+	}
+	if len(got) != 3 || got[0] != 334 || got[1] != 333 || got[2] != 333 {
+		t.Errorf("payments without interest are %v, want [334 333 333]", got)
+	}
+
+	if payments := Amortize(0, big.NewRat(12, 100), big.NewRat(12, 1), 12); payments != nil {
+		t.Errorf("a loan of nothing has payments %v", payments)
+	}
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	cases := map[string]*big.Rat{
+		"FREQ=DAILY":                         big.NewRat(365, 1),
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH": big.NewRat(52, 1),
+		"FREQ=MONTHLY":                       big.NewRat(12, 1),
+		"FREQ=MONTHLY;INTERVAL=3":            big.NewRat(4, 1),
+		"FREQ=MONTHLY;BYMONTHDAY=1,15":       big.NewRat(24, 1),
+	}
+	for text, want := range cases {
+		rule, err := recurrence.Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := PeriodsPerYear(rule); got.Cmp(want) != 0 {
+			t.Errorf("%s makes %s payments a year, want %s", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// internal/interest/interest.go
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"time"
+
+	"debt-tracker-backend/internal/recurrence"
+)
+
+// Methods of Terms.
+const (
+	Simple   = "simple"
+	Compound = "compound"
+)
+
+// Compounding periods of Terms. Periods follow the calendar in UTC: a
+// monthly period starts on the first of the month, a quarterly one on the
+// first of January, April, July or October.
+const (
+	Daily     = "daily"
+	Monthly   = "monthly"
+	Quarterly = "quarterly"
+	Annually  = "annually"
+)
+
+// Day-count conventions of Terms, which turn a number of days into a
+// fraction of a year.
+const (
+	Actual365    = "actual/365"
+	Actual360    = "actual/360"
+	Thirty360    = "30/360"
+	ActualActual = "actual/actual"
+)
+
+// maxRate is the highest annual rate accepted, in percent.
+const maxRate = 1000
+
+var ratePattern = regexp.MustCompile(`^\d{1,4}(\.\d{1,6})?$`)
+
+// Terms are the interest terms of a loan. Rate is the annual rate as a
+// fraction, so 7.5% is 0.075.
+//
+// Simple interest accrues on the principal only. Repayments settle the
+// interest accrued so far before they reduce the principal. Compound
+// interest accrues on the whole balance and is added to it at the start
+// of every compounding period.
+type Terms struct {
+	Rate        *big.Rat
+	Method      string
+	Compounding string
+	DayCount    string
+}
+
+// ParseRate reads an annual rate given in percent, such as "7.25", and
+// returns it as a fraction.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ratePattern.MatchString(s) || !ok || rate.Sign() <= 0 || rate.Cmp(big.NewRat(maxRate, 1)) > 0 {
+		return nil, fmt.Errorf("invalid interest rate %q; give a percentage above 0 and up to %d", s, maxRate)
+	}
+	return rate.Quo(rate, big.NewRat(100, 1)), nil
+}
+
+// Validate checks the method, compounding period and day-count convention
+// of the terms.
+func (t Terms) Validate() error {
+	if t.Rate == nil || t.Rate.Sign() <= 0 {
+		return errors.New("interest rate must be positive")
+	}
+	switch t.Method {
+	case Simple, Compound:
+	default:
+		return fmt.Errorf("unsupported interest method %q", t.Method)
+	}
+	switch t.Compounding {
+	case Daily, Monthly, Quarterly, Annually:
+	default:
+		return fmt.Errorf("unsupported compounding period %q", t.Compounding)
+	}
+	switch t.DayCount {
+	case Actual365, Actual360, Thirty360, ActualActual:
+	default:
+		return fmt.Errorf("unsupported day count %q", t.DayCount)
+	}
+	return nil
+}
+
+// Event is a change to the balance of a loan on a day, in minor units:
+// positive for more lending, negative for a repayment.
+type Event struct {
+	Day    time.Time
+	Amount int64
+}
+
+// Accrue returns the interest, in minor units, that a loan of opening on
+// the day start accrues by the day to, given the later changes to its
+// balance. Changes take effect on their day; a balance at or below zero
+// accrues nothing. Compound interest is rounded to the cent each time it
+// is added to the balance, and the total is rounded half away from zero.
+func (t Terms) Accrue(opening int64, start time.Time, events []Event, to time.Time) int64 {
+	events = append([]Event(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Day.Before(events[j].Day) })
+
+	// principal and due are the principal and unpaid interest of simple
+	// interest; compound interest keeps the whole balance in principal,
+	// and due is what has accrued since the start of the period.
+	principal := new(big.Rat).SetInt64(opening)
+	due := new(big.Rat)
+	accrued := new(big.Rat)
+	var capitalized int64
+
+	apply := func(event Event) {
+		amount := new(big.Rat).SetInt64(event.Amount)
+		if t.Method == Simple && event.Amount < 0 {
+			// Repayments settle the interest first.
+			payment := amount.Neg(amount)
+			if payment.Cmp(due) <= 0 {
+				due.Sub(due, payment)
+				return
+			}
+			payment.Sub(payment, due)
+			due.SetInt64(0)
+			principal.Sub(principal, payment)
+			return
+		}
+		principal.Add(principal, amount)
+	}
+
+	i := 0
+	day := start
+	for ; i < len(events) && !events[i].Day.After(day); i++ {
+		apply(events[i])
+	}
+	for day.Before(to) {
+		next := to
+		if i < len(events) && events[i].Day.Before(next) {
+			next = events[i].Day
+		}
+		if t.Method == Compound {
+			if boundary := nextPeriod(t.Compounding, day); boundary.Before(next) {
+				next = boundary
+			}
+		}
+
+		if principal.Sign() > 0 {
+			interest := new(big.Rat).Mul(principal, t.Rate)
+			interest.Mul(interest, YearFraction(t.DayCount, day, next))
+			due.Add(due, interest)
+			if t.Method == Simple {
+				accrued.Add(accrued, interest)
+			}
+		}
+		day = next
+
+		if t.Method == Compound && periodStart(t.Compounding, day).Equal(day) {
+			interest := Round(due)
+			principal.Add(principal, new(big.Rat).SetInt64(interest))
+			capitalized += interest
+			due.SetInt64(0)
+		}
+		for ; i < len(events) && !events[i].Day.After(day); i++ {
+			apply(events[i])
+		}
+	}
+
+	if t.Method == Compound {
+		return capitalized + Round(due)
+	}
+	return Round(accrued)
+}
+
+// PeriodStart is the first day of the compounding period that day falls
+// in.
+func (t Terms) PeriodStart(day time.Time) time.Time {
+	return periodStart(t.Compounding, day)
+}
+
+func periodStart(compounding string, day time.Time) time.Time {
+	day = recurrence.Truncate(day)
+	switch compounding {
+	case Monthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case Quarterly:
+		month := day.Month() - (day.Month()-1)%3
+		return time.Date(day.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	case Annually:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextPeriod(compounding string, day time.Time) time.Time {
+	start := periodStart(compounding, day)
+	switch compounding {
+	case Monthly:
+		return start.AddDate(0, 1, 0)
+	case Quarterly:
+		return start.AddDate(0, 3, 0)
+	case Annually:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// YearFraction is the fraction of a year from one day to a later one
+// under a day-count convention. actual/actual is the ISDA convention,
+// which counts the days in each calendar year against the length of that
+// year; 30/360 is the US (bond basis) convention.
+func YearFraction(dayCount string, from, to time.Time) *big.Rat {
+	from, to = recurrence.Truncate(from), recurrence.Truncate(to)
+	if !from.Before(to) {
+		return new(big.Rat)
+	}
+
+	switch dayCount {
+	case Actual360:
+		return big.NewRat(days(from, to), 360)
+	case Thirty360:
+		d1, d2 := from.Day(), to.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 >= 30 {
+			d2 = 30
+		}
+		n := 360*(to.Year()-from.Year()) + 30*int(to.Month()-from.Month()) + d2 - d1
+		return big.NewRat(int64(n), 360)
+	case ActualActual:
+		fraction := new(big.Rat)
+		for from.Before(to) {
+			yearEnd := time.Date(from.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			end := to
+			if yearEnd.Before(end) {
+				end = yearEnd
+			}
+			yearStart := time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+			fraction.Add(fraction, big.NewRat(days(from, end), days(yearStart, yearEnd)))
+			from = end
+		}
+		return fraction
+	default:
+		return big.NewRat(days(from, to), 365)
+	}
+}
+
+func days(from, to time.Time) int64 {
+	return int64(to.Sub(from).Hours() / 24)
+}
+
+// Round rounds to the nearest integer, halves away from zero.
+func Round(value *big.Rat) int64 {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Lsh(remainder.Abs(remainder), 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient.Int64()
+}
@@ -0,0 +1,153 @@
+// internal/interest/interest_test.go
+package interest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/recurrence"
+)
+
+func day(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := recurrence.ParseDay(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("7.25")
+	if err != nil || rate.Cmp(big.NewRat(725, 10000)) != 0 {
+		t.Errorf("ParseRate(7.25) = %v, %v; want 0.0725", rate, err)
+	}
+	for _, invalid := range []string{"", "0", "-1", "1e2", "1/2", "1000.5", "7.1234567"} {
+		if rate, err := ParseRate(invalid); err == nil {
+			t.Errorf("ParseRate(%q) = %v, want an error", invalid, rate)
+		}
+	}
+}
+
+func TestYearFraction(t *testing.T) {
+	cases := []struct {
+		dayCount string
+		from, to string
+		want     *big.Rat
+	}{
+		{Actual365, "2025-01-01", "2025-07-01", big.NewRat(181, 365)},
+		{Actual360, "2025-01-01", "2025-07-01", big.NewRat(181, 360)},
+		{Thirty360, "2025-01-01", "2025-07-01", big.NewRat(180, 360)},
+		{Thirty360, "2025-01-31", "2025-03-01", big.NewRat(31, 360)},
+		{Thirty360, "2025-01-30", "2025-01-31", new(big.Rat)},
+		{Thirty360, "2025-02-28", "2025-03-31", big.NewRat(33, 360)},
+		{ActualActual, "2024-07-01", "2025-07-01", new(big.Rat).Add(big.NewRat(184, 366), big.NewRat(181, 365))},
+		{Actual365, "2025-07-01", "2025-01-01", new(big.Rat)},
+	}
+	for _, tc := range cases {
+		if got := YearFraction(tc.dayCount, day(t, tc.from), day(t, tc.to)); got.Cmp(tc.want) != 0 {
+			t.Errorf("%s from %s to %s is %s, want %s", tc.dayCount, tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	twelve := big.NewRat(12, 100)
+	cases := []struct {
+		name   string
+		terms  Terms
+		start  string
+		events []Event
+		to     string
+		want   int64
+	}{
+		{
+			name:  "simple",
+			terms: Terms{Rate: twelve, Method: Simple, Compounding: Monthly, DayCount: Actual360},
+			start: "2025-01-01", to: "2025-01-31", want: 1000,
+		},
+		{
+			// 500 accrues by the repayment, which settles it and all but 500
+			// of the principal; that 500 accrues 2.50 more, rounded up.
+			name:   "repayments settle interest first",
+			terms:  Terms{Rate: twelve, Method: Simple, Compounding: Monthly, DayCount: Actual360},
+			start:  "2025-01-01",
+			events: []Event{{Day: day(t, "2025-01-16"), Amount: -100000}},
+			to:     "2025-01-31", want: 503,
+		},
+		{
+			name:   "nothing accrues on a balance at zero",
+			terms:  Terms{Rate: twelve, Method: Simple, Compounding: Monthly, DayCount: Actual360},
+			start:  "2025-01-01",
+			events: []Event{{Day: day(t, "2025-01-01"), Amount: -100000}},
+			to:     "2025-01-31", want: 0,
+		},
+		{
+			name:   "more lending",
+			terms:  Terms{Rate: twelve, Method: Simple, Compounding: Monthly, DayCount: Actual360},
+			start:  "2025-01-01",
+			events: []Event{{Day: day(t, "2025-01-16"), Amount: 100000}},
+			to:     "2025-01-31", want: 1500,
+		},
+		{
+			// 1% a month: 1000, then 1010, then 1020.10 rounded to 1020.
+			name:  "compound",
+			terms: Terms{Rate: twelve, Method: Compound, Compounding: Monthly, DayCount: Thirty360},
+			start: "2025-01-01", to: "2025-04-01", want: 3030,
+		},
+		{
+			// Half a month accrues 500, which is added to the balance on
+			// 1 February; the next half month accrues 502.50.
+			name:  "compound from the middle of a period",
+			terms: Terms{Rate: twelve, Method: Compound, Compounding: Monthly, DayCount: Thirty360},
+			start: "2025-01-16", to: "2025-02-16", want: 1003,
+		},
+		{
+			name:  "compound within one period",
+			terms: Terms{Rate: twelve, Method: Compound, Compounding: Annually, DayCount: Thirty360},
+			start: "2025-01-01", to: "2025-04-01", want: 3000,
+		},
+	}
+	for _, tc := range cases {
+		if err := tc.terms.Validate(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := tc.terms.Accrue(100000, day(t, tc.start), tc.events, day(t, tc.to)); got != tc.want {
+			t.Errorf("%s: accrued %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	cases := map[string]string{
+		Daily:     "2025-08-17",
+		Monthly:   "2025-08-01",
+		Quarterly: "2025-07-01",
+		Annually:  "2025-01-01",
+	}
+	for compounding, want := range cases {
+		terms := Terms{Compounding: compounding}
+		if got := recurrence.FormatDay(terms.PeriodStart(day(t, "2025-08-17"))); got != want {
+			t.Errorf("%s period of 2025-08-17 starts on %s, want %s", compounding, got, want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		value *big.Rat
+		want  int64
+	}{
+		{big.NewRat(5, 2), 3},
+		{big.NewRat(-5, 2), -3},
+		{big.NewRat(249, 100), 2},
+		{big.NewRat(-251, 100), -3},
+		{big.NewRat(7, 1), 7},
+	}
+	for _, tc := range cases {
+		if got := Round(tc.value); got != tc.want {
+			t.Errorf("Round(%s) = %d, want %d", tc.value, got, tc.want)
+		}
+	}
+}
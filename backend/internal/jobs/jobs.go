@@ -0,0 +1,27 @@
+// internal/jobs/jobs.go
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs job once immediately and then at every interval until ctx is
+// cancelled. Failures are logged and retried on the next tick.
+func Every(ctx context.Context, name string, interval time.Duration, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(); err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// internal/jobs/login_attempts.go
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"debt-tracker-backend/internal/store"
+)
+
+// PurgeLoginAttempts returns a job that forgets failed logins which no
+// longer count towards a lockout.
+func PurgeLoginAttempts(s store.Store, memory time.Duration) func() error {
+	return func() error {
+		purged, err := s.LoginAttempts().PurgeStale(time.Now().Add(-memory))
+		if err != nil {
+			return err
+		}
+
+		if purged > 0 {
+			log.Printf("Purged %d stale login attempt record(s)", purged)
+		}
+		return nil
+	}
+}
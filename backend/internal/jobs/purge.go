@@ -0,0 +1,30 @@
+// internal/jobs/purge.go
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"debt-tracker-backend/internal/store"
+)
+
+// PurgeRemovedDebts returns a job that permanently deletes debts which
+// have been removed for longer than retention.
+func PurgeRemovedDebts(s store.Store, retention time.Duration) func() error {
+	return func() error {
+		var purged int
+		err := s.WithinTx(func(s store.Store) error {
+			var err error
+			purged, err = s.Debts().PurgeRemoved(time.Now().Add(-retention))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if purged > 0 {
+			log.Printf("Purged %d removed debt(s)", purged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+// internal/jobs/purge_test.go
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+func TestPurgeRemovedDebts(t *testing.T) {
+	st := store.NewMemory()
+	user := models.User{Email: "alice@example.com", PasswordHash: "x", Name: "Alice"}
+	if err := st.Users().Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	contact := models.Contact{UserID: user.ID, Name: "Bob"}
+	if err := st.Contacts().Create(&contact); err != nil {
+		t.Fatal(err)
+	}
+	var debts [2]models.Debt
+	for i := range debts {
+		debts[i] = models.Debt{UserID: user.ID, ContactID: contact.ID, OriginalAmount: models.NewMoney(1000, "ZAR"), Direction: "owe_to"}
+		if err := st.Debts().Create(&debts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kept, removed := debts[0], debts[1]
+	if err := st.Debts().Remove(user.ID, removed.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		retention   time.Duration
+		removedGone bool
+	}{
+		{time.Hour, false},
+		{0, true},
+	}
+	for _, tc := range cases {
+		if err := PurgeRemovedDebts(st, tc.retention)(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.Debts().Get(user.ID, kept.ID); err != nil {
+			t.Errorf("after purging with a retention of %s, the active debt is gone: %v", tc.retention, err)
+		}
+		_, err := st.Debts().Get(user.ID, removed.ID)
+		if gone := err == store.ErrNotFound; gone != tc.removedGone {
+			t.Errorf("after purging with a retention of %s, removed debt gone = %v, want %v", tc.retention, gone, tc.removedGone)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// internal/jobs/rates.go
+package jobs
+
+import (
+	"log"
+
+	"debt-tracker-backend/internal/rates"
+	"debt-tracker-backend/internal/store"
+)
+
+// LoadExchangeRates returns a job that stores the snapshots of a rate
+// provider, replacing any it has stored before for the same days.
+func LoadExchangeRates(s store.Store, provider rates.Provider) func() error {
+	return func() error {
+		snapshots, err := provider.Rates()
+		if err != nil {
+			return err
+		}
+
+		err = s.WithinTx(func(s store.Store) error {
+			return s.ExchangeRates().Save(snapshots)
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Loaded %d exchange rate(s)", len(snapshots))
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+// internal/jobs/reminders.go
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/notify"
+	"debt-tracker-backend/internal/store"
+)
+
+const (
+	// notificationBatch is how many notifications a run sends at most.
+	notificationBatch = 100
+	// maxNotificationAttempts is how often a notification is tried before
+	// it is given up on.
+	maxNotificationAttempts = 5
+)
+
+// QueueReminders returns a job that queues a reminder daysBefore the next
+// payment of a debt falls due, and another once a payment has been overdue
+// for daysAfter days. Each payment is reminded of at most once either way;
+// zero days turns that reminder off.
+func QueueReminders(s store.Store, daysBefore, daysAfter int) func() error {
+	return func() error {
+		debts, err := s.Debts().WithDueDates()
+		if err != nil {
+			return err
+		}
+
+		today := time.Now().UTC().Format(time.DateOnly)
+		queued := 0
+		for _, debt := range debts {
+			if debt.Confirmation == "disputed" {
+				continue
+			}
+
+			var reminders []models.Notification
+			if daysBefore > 0 && debt.NextDueDate != nil && models.DaysBetween(today, *debt.NextDueDate) <= daysBefore {
+				reminders = append(reminders, dueSoonReminder(debt))
+			}
+			if daysAfter > 0 && debt.Overdue && debt.DaysOverdue >= daysAfter {
+				reminders = append(reminders, overdueReminder(debt))
+			}
+
+			for _, reminder := range reminders {
+				added, err := s.Notifications().Enqueue(&reminder)
+				if err != nil {
+					return err
+				}
+				if added {
+					queued++
+				}
+			}
+		}
+
+		if queued > 0 {
+			log.Printf("Queued %d payment reminder(s)", queued)
+		}
+		return nil
+	}
+}
+
+func contactName(debt models.Debt) string {
+	if debt.Contact != nil && debt.Contact.Name != "" {
+		return debt.Contact.Name
+	}
+	return fmt.Sprintf("contact #%d", debt.ContactID)
+}
+
+func debtLabel(debt models.Debt) string {
+	if debt.Description != nil && *debt.Description != "" {
+		return fmt.Sprintf("%q (debt #%d)", *debt.Description, debt.ID)
+	}
+	return fmt.Sprintf("debt #%d", debt.ID)
+}
+
+func dueSoonReminder(debt models.Debt) models.Notification {
+	name := contactName(debt)
+	amount := fmt.Sprintf("%s %s", debt.NextDueAmount, debt.Currency)
+	subject := fmt.Sprintf("%s owes you %s by %s", name, amount, *debt.NextDueDate)
+	if debt.Direction == "owe_to" {
+		subject = fmt.Sprintf("You owe %s %s by %s", name, amount, *debt.NextDueDate)
+	}
+
+	return models.Notification{
+		UserID:  debt.UserID,
+		DebtID:  &debt.ID,
+		Kind:    "due_soon",
+		Key:     fmt.Sprintf("due_soon:debt:%d:%s", debt.ID, *debt.NextDueDate),
+		Subject: subject,
+		Body: fmt.Sprintf("A payment of %s on %s is due on %s. The balance is %s %s.",
+			amount, debtLabel(debt), *debt.NextDueDate, debt.Balance, debt.Currency),
+	}
+}
+
+func overdueReminder(debt models.Debt) models.Notification {
+	name := contactName(debt)
+	amount := fmt.Sprintf("%s %s", debt.OverdueAmount, debt.Currency)
+	subject := fmt.Sprintf("%s is %d day(s) late paying you %s", name, debt.DaysOverdue, amount)
+	if debt.Direction == "owe_to" {
+		subject = fmt.Sprintf("Your payment of %s to %s is %d day(s) late", amount, name, debt.DaysOverdue)
+	}
+
+	return models.Notification{
+		UserID:  debt.UserID,
+		DebtID:  &debt.ID,
+		Kind:    "overdue",
+		Key:     fmt.Sprintf("overdue:debt:%d:%s", debt.ID, *debt.OverdueSince),
+		Subject: subject,
+		Body: fmt.Sprintf("%s of %s has been due since %s. The balance is %s %s.",
+			amount, debtLabel(debt), *debt.OverdueSince, debt.Balance, debt.Currency),
+	}
+}
+
+// SendNotifications returns a job that delivers queued notifications. A
+// notification that fails is tried again on the next run, up to
+// maxNotificationAttempts times.
+func SendNotifications(s store.Store, notifier notify.Notifier) func() error {
+	return func() error {
+		pending, err := s.Notifications().Pending(notificationBatch)
+		if err != nil {
+			return err
+		}
+
+		sent, failed := 0, 0
+		for _, notification := range pending {
+			user, err := s.Users().Get(notification.UserID)
+			if err == nil {
+				err = notifier.Notify(user, notification)
+			}
+			if err != nil {
+				failed++
+				if err := s.Notifications().MarkFailed(notification.ID, err.Error(), maxNotificationAttempts); err != nil && err != store.ErrNotFound {
+					return err
+				}
+				continue
+			}
+
+			sent++
+			if err := s.Notifications().MarkSent(notification.ID, time.Now()); err != nil && err != store.ErrNotFound {
+				return err
+			}
+		}
+
+		if sent > 0 || failed > 0 {
+			log.Printf("Sent %d notification(s), %d failed", sent, failed)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+// internal/jobs/reminders_test.go
+package jobs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+// recorder is a notifier that fails for the users in fail.
+type recorder struct {
+	sent []string
+	fail map[int]bool
+}
+
+func (r *recorder) Notify(user models.User, notification models.Notification) error {
+	if r.fail[user.ID] {
+		return errors.New("mailbox full")
+	}
+	r.sent = append(r.sent, notification.Subject)
+	return nil
+}
+
+func daysFromToday(n int) string {
+	return time.Now().UTC().AddDate(0, 0, n).Format(time.DateOnly)
+}
+
+func TestReminders(t *testing.T) {
+	st := store.NewMemory()
+	var users [2]models.User
+	for i, name := range []string{"alice", "bob"} {
+		users[i] = models.User{Email: name + "@example.com", PasswordHash: "x", Name: name}
+		if err := st.Users().Create(&users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := users[0], users[1]
+
+	openDebt := func(user models.User, direction, dueDate string) {
+		t.Helper()
+		contact := models.Contact{UserID: user.ID, Name: "Carol " + direction + " " + dueDate}
+		if err := st.Contacts().Create(&contact); err != nil {
+			t.Fatal(err)
+		}
+		debt := models.Debt{UserID: user.ID, ContactID: contact.ID, OriginalAmount: models.NewMoney(1000, "ZAR"), Direction: direction, DueDate: &dueDate}
+		if err := st.Debts().Create(&debt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openDebt(alice, "owe_from", daysFromToday(2)) // due soon
+	openDebt(alice, "owe_to", daysFromToday(-3))  // overdue
+	openDebt(alice, "owe_to", daysFromToday(10))  // not yet
+	openDebt(bob, "owe_from", daysFromToday(-1))  // overdue, but not for long
+	openDebt(bob, "owe_from", daysFromToday(0))   // due today
+
+	// Reminders are queued once however often the job runs.
+	for range 2 {
+		if err := QueueReminders(st, 3, 2)(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending, err := st.Notifications().Pending(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, notification := range pending {
+		kinds = append(kinds, notification.Kind)
+	}
+	slices.Sort(kinds)
+	if want := []string{"due_soon", "due_soon", "overdue"}; !slices.Equal(kinds, want) {
+		t.Fatalf("queued reminders are %v, want %v", kinds, want)
+	}
+
+	// Bob's reminder fails until it is given up on; alice's are sent.
+	notifier := &recorder{fail: map[int]bool{bob.ID: true}}
+	for range maxNotificationAttempts {
+		if err := SendNotifications(st, notifier)(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(notifier.sent) != 2 {
+		t.Errorf("sent %v, want alice's 2 reminders", notifier.sent)
+	}
+	if pending, err := st.Notifications().Pending(10); err != nil || len(pending) != 0 {
+		t.Errorf("%d notifications still pending, %v", len(pending), err)
+	}
+}
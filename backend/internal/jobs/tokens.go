@@ -0,0 +1,25 @@
+// internal/jobs/tokens.go
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"debt-tracker-backend/internal/store"
+)
+
+// PurgeExpiredTokens returns a job that deletes expired refresh tokens and
+// revocation entries for access tokens that have expired anyway.
+func PurgeExpiredTokens(s store.Store) func() error {
+	return func() error {
+		purged, err := s.Tokens().PurgeExpired(time.Now())
+		if err != nil {
+			return err
+		}
+
+		if purged > 0 {
+			log.Printf("Purged %d expired token record(s)", purged)
+		}
+		return nil
+	}
+}
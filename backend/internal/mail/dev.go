@@ -0,0 +1,56 @@
+// internal/mail/dev.go
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Log writes messages to the server log instead of sending them.
+type Log struct{}
+
+func NewLog() Log {
+	return Log{}
+}
+
+func (Log) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// File writes each message to its own .eml file in a directory, where
+// tests and developers can pick it up.
+type File struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewFile(dir, from string) (*File, error) {
+	if dir == "" {
+		dir = "mail"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+func (m *File) Send(msg Message) error {
+	m.mu.Lock()
+	m.seq++
+	seq := m.seq
+	m.mu.Unlock()
+
+	name := fmt.Sprintf("%s-%03d-%s.eml", time.Now().UTC().Format("20060102T150405"), seq, unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o644)
+}
@@ -0,0 +1,50 @@
+// internal/mail/mail.go
+package mail
+
+import (
+	"fmt"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email. NewSMTP sends real mail; NewLog and NewFile are
+// stand-ins for local development and tests.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Options selects and configures a Mailer.
+type Options struct {
+	// Driver is "smtp", "file" or "log".
+	Driver string
+	From   string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Dir is where the file driver writes messages.
+	Dir string
+}
+
+func New(opts Options) (Mailer, error) {
+	switch opts.Driver {
+	case "smtp":
+		if opts.SMTPHost == "" || opts.From == "" {
+			return nil, fmt.Errorf("the smtp mailer requires a host and a from address")
+		}
+		return NewSMTP(opts.SMTPHost, opts.SMTPPort, opts.SMTPUsername, opts.SMTPPassword, opts.From), nil
+	case "file":
+		return NewFile(opts.Dir, opts.From)
+	case "log":
+		return NewLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", opts.Driver)
+	}
+}
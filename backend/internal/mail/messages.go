@@ -0,0 +1,84 @@
+// internal/mail/messages.go
+package mail
+
+import "fmt"
+
+func VerifyEmail(to, name, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Please confirm your email address for Debt Tracker by opening this link:
+
+%s
+
+If you did not create an account, you can ignore this email.
+`, name, link),
+	}
+}
+
+func ResetPassword(to, name, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password of your Debt Tracker account. To choose
+a new password, open this link within the next hour:
+
+%s
+
+If it was not you, you can ignore this email; your password is unchanged.
+`, name, link),
+	}
+}
+
+func ConfirmEmailChange(to, name, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+To start using this address for your Debt Tracker account, open this link:
+
+%s
+
+Until then you keep logging in with your current address. If you did not
+ask for this change, you can ignore this email.
+`, name, link),
+	}
+}
+
+func EmailChanged(to, name, newEmail string) Message {
+	return Message{
+		To:      to,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf(`Hi %s,
+
+The email address of your Debt Tracker account was changed to %s.
+
+If you did not make this change, reset your password straight away and
+contact support.
+`, name, newEmail),
+	}
+}
+
+func ContactInvite(to, inviterName, link string) Message {
+	return Message{
+		To:      to,
+		Subject: inviterName + " wants to share debts with you",
+		Body: fmt.Sprintf(`Hi,
+
+%s keeps track of the money you lend each other in Debt Tracker and has
+invited you to link your accounts. Debts between you will then show up in
+both accounts, and changes need the approval of both of you.
+
+To accept, log in or sign up with this email address at:
+
+%s
+
+If you do not know %s, you can ignore this email.
+`, inviterName, link, inviterName),
+	}
+}
@@ -5,55 +5,183 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/auth"
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-func AuthRequired(jwtSecret string) gin.HandlerFunc {
+// RevocationList reports whether an access token, identified by its jti
+// claim, has been revoked.
+type RevocationList interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// APIKeyList looks up API keys by hash and records their use. It is
+// satisfied by store.APIKeyStore.
+type APIKeyList interface {
+	GetByHash(hash string) (models.APIKey, error)
+	Touch(id int, at time.Time) error
+}
+
+// touchInterval limits how often the last use of an API key is written.
+const touchInterval = time.Minute
+
+// AuthRequired accepts only access tokens, which belong to a login session.
+func AuthRequired(keys *auth.Keys, revocations RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		token, ok := bearerToken(c)
+		if !ok {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+		if auth.IsAPIKey(token) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot be used for this endpoint"})
 			c.Abort()
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		if authenticateSession(c, keys, revocations, token) {
+			c.Next()
+		}
+	}
+}
+
+// AuthOrAPIKeyRequired accepts access tokens as well as API keys. Requests
+// made with an API key carry its scopes, which RequireScope checks.
+func AuthOrAPIKeyRequired(keys *auth.Keys, revocations RevocationList, apiKeys APIKeyList) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		if !auth.IsAPIKey(token) {
+			if authenticateSession(c, keys, revocations, token) {
+				c.Next()
+			}
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		key, err := apiKeys.GetByHash(auth.HashAPIKey(token))
+		if err == store.ErrNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userID := int(claims["user_id"].(float64))
-			c.Set("user_id", userID)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		now := time.Now()
+		if key.RevokedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key has been revoked"})
 			c.Abort()
 			return
 		}
+		if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key has expired"})
+			c.Abort()
+			return
+		}
+
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+			if err := apiKeys.Touch(key.ID, now); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("user_id", key.UserID)
+		c.Set("api_key_id", key.ID)
+		c.Set("scopes", key.Scopes)
 
 		c.Next()
 	}
 }
 
+// RequireScope checks that requests made with an API key hold the read
+// scope of resource for GET requests and its write scope otherwise.
+// Session requests are not limited.
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get("scopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		required := resource + ":write"
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			required = resource + ":read"
+		}
+		if !auth.HasScope(scopes.([]string), required) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + required + " scope"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bearerToken reads the token from the Authorization header, responding
+// 401 if there is none.
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return "", false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+		c.Abort()
+		return "", false
+	}
+	return tokenString, true
+}
+
+// authenticateSession verifies an access token and sets the user and
+// session of the request, or responds 401.
+func authenticateSession(c *gin.Context, keys *auth.Keys, revocations RevocationList, tokenString string) bool {
+	claims, err := keys.Verify(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return false
+	}
+
+	revoked, err := revocations.IsRevoked(claims.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		c.Abort()
+		return false
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("session_id", claims.SessionID)
+	return true
+}
+
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Header("Access-Control-Expose-Headers", "Content-Disposition")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -78,4 +206,4 @@ func Logger() gin.HandlerFunc {
 			param.ErrorMessage,
 		)
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,123 @@
+// internal/middleware/ratelimit.go
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter is an in-memory token bucket limiter. Every key gets a bucket
+// that holds up to requests tokens and refills at requests per period; a
+// request takes one token.
+type RateLimiter struct {
+	mu        sync.Mutex
+	capacity  float64
+	rate      float64 // tokens per second
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bursts of requests and, on
+// average, requests per period. It returns nil, which limits nothing, if
+// requests is not positive.
+func NewRateLimiter(requests int, period time.Duration) *RateLimiter {
+	if requests <= 0 || period <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		capacity:  float64(requests),
+		rate:      float64(requests) / period.Seconds(),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns false and how long it takes for the next token to arrive.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep forgets buckets that have had time to refill completely, since a
+// new bucket would be identical. It runs at most once a minute.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.capacity / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests with 429 Too Many Requests and a Retry-After
+// header once the bucket selected by key is empty. A nil limiter lets
+// everything through.
+func RateLimit(limiter *RateLimiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		if ok, retryAfter := limiter.Allow(key(c)); !ok {
+			RetryAfter(c, retryAfter)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RetryAfter sets the Retry-After header, rounding up to whole seconds.
+func RetryAfter(c *gin.Context, d time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+// ByIP keys rate limits by client address.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser keys rate limits by the authenticated user, falling back to the
+// client address. It must run after AuthRequired.
+func ByUser(c *gin.Context) string {
+	if userID := c.GetInt("user_id"); userID != 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return ByIP(c)
+}
@@ -0,0 +1,76 @@
+// internal/middleware/ratelimit_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterAllowsBursts(t *testing.T) {
+	limiter := NewRateLimiter(3, time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("request %d of the burst was limited", i+1)
+		}
+	}
+
+	ok, retryAfter := limiter.Allow("a")
+	if ok {
+		t.Fatal("request past the burst was allowed")
+	}
+	if retryAfter <= 0 || retryAfter > 20*time.Minute {
+		t.Errorf("retry after %s, want up to 20m", retryAfter)
+	}
+
+	// Every key has a bucket of its own.
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Error("request of another key was limited")
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter := NewRateLimiter(1, 50*time.Millisecond)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Fatal("first request was limited")
+	}
+	if ok, _ := limiter.Allow("a"); ok {
+		t.Fatal("second request was allowed at once")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Error("request after the refill was limited")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", RateLimit(NewRateLimiter(1, time.Minute), ByIP), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		return recorder
+	}
+	if status := get().Code; status != http.StatusNoContent {
+		t.Fatalf("first request answered %d", status)
+	}
+	recorder := get()
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request answered %d, want 429", recorder.Code)
+	}
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Retry-After is %q, want 60", retryAfter)
+	}
+
+	// A nil limiter limits nothing.
+	if NewRateLimiter(0, time.Minute) != nil {
+		t.Error("limiter without requests is not nil")
+	}
+}
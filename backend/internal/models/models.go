@@ -3,13 +3,25 @@ package models
 import "time"
 
 type User struct {
-	ID           int       `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Name         string    `json:"name" db:"name"`
-	Phone        *string   `json:"phone" db:"phone"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID           int     `json:"id" db:"id"`
+	Email        string  `json:"email" db:"email"`
+	PasswordHash string  `json:"-" db:"password_hash"`
+	Name         string  `json:"name" db:"name"`
+	Phone        *string `json:"phone" db:"phone"`
+	// EmailVerifiedAt is nil until the user follows the link mailed to
+	// their address.
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	// PendingEmail is an address the user is changing to. It replaces
+	// Email once it has been verified.
+	PendingEmail *string `json:"pending_email,omitempty" db:"pending_email"`
+	// TwoFactorEnabled is true once the user has set up TOTP, after which
+	// logging in also takes a code.
+	TwoFactorEnabled bool `json:"two_factor_enabled" db:"-"`
+	// DefaultCurrency is the ISO 4217 code new debts default to and that
+	// summaries are converted into.
+	DefaultCurrency string    `json:"default_currency" db:"default_currency"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type RegisterRequest struct {
@@ -17,6 +29,8 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 	Name     string `json:"name" binding:"required"`
 	Phone    string `json:"phone"`
+	// DefaultCurrency defaults to DefaultCurrency.
+	DefaultCurrency string `json:"default_currency" binding:"omitempty,iso4217,currency"`
 }
 
 type LoginRequest struct {
@@ -24,20 +38,189 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest is the body of PUT /auth/me, which replaces the
+// profile, and of PATCH /auth/me, which changes only the fields present.
+// Changing the email address requires the current password.
+type UpdateProfileRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1"`
+	Phone    *string `json:"phone"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Password string  `json:"password"`
+	// DefaultCurrency is kept unless given, even by PUT.
+	DefaultCurrency *string `json:"default_currency" binding:"omitempty,iso4217,currency"`
+}
+
+// PasswordRequest confirms a sensitive action with the current password.
+type PasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// AccountExport is the archive of everything stored about a user.
+type AccountExport struct {
+	ExportedAt     time.Time       `json:"exported_at"`
+	User           User            `json:"user"`
+	Contacts       []Contact       `json:"contacts"`
+	Debts          []Debt          `json:"debts"`
+	Transactions   []Transaction   `json:"transactions"`
+	Groups         []Group         `json:"groups"`
+	Expenses       []Expense       `json:"expenses"`
+	Schedules      []Schedule      `json:"schedules"`
+	ImportProfiles []ImportProfile `json:"import_profiles"`
+}
+
+// LoginAttempt tracks consecutive failed logins for an email address.
+type LoginAttempt struct {
+	Email        string     `json:"email" db:"email"`
+	Failures     int        `json:"failures" db:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at" db:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until" db:"locked_until"`
+}
+
+// TwoFactor holds the TOTP secret of a user. It is pending until the user
+// proves they can generate codes by enabling it.
+type TwoFactor struct {
+	UserID    int        `json:"-" db:"user_id"`
+	Secret    string     `json:"-" db:"secret"`
+	EnabledAt *time.Time `json:"enabled_at" db:"enabled_at"`
+	// LastStep is the TOTP time step of the last accepted code. Codes from
+	// that step or earlier are refused, so each code works once.
+	LastStep  int64     `json:"-" db:"last_step"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TwoFactorStatus is the response of GET /auth/2fa.
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Pending           bool       `json:"pending"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetupResponse carries a new TOTP secret, both on its own and as
+// an otpauth:// URI for authenticator apps to scan as a QR code.
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a 6-digit TOTP code or a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorConfirmRequest confirms changes to two-factor authentication
+// with both the password and a code.
+type TwoFactorConfirmRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginChallengeResponse is returned by /auth/login instead of tokens when
+// the account has two-factor authentication enabled. The challenge token
+// is exchanged for tokens at /auth/login/2fa together with a code.
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // seconds until ChallengeToken expires
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// APIKey lets scripts call the API as a user, limited to Scopes. Only
+// a hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse is the only response that includes the key itself.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// TokenRequest carries a token from a link mailed to the user.
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// LoginResponse carries a short-lived access token in Token and the
+// refresh token that exchanges it for a new pair at /auth/refresh.
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until Token expires
+	RefreshToken string `json:"refresh_token"`
+	User         User   `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Only
+// a hash of the token is kept. Tokens are rotated on every use, and all
+// tokens descending from one login share a FamilyID identifying the
+// session.
+type RefreshToken struct {
+	ID              int        `json:"id" db:"id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	FamilyID        string     `json:"family_id" db:"family_id"`
+	TokenHash       string     `json:"-" db:"token_hash"`
+	AccessJTI       string     `json:"-" db:"access_jti"`
+	AccessExpiresAt time.Time  `json:"-" db:"access_expires_at"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt       *time.Time `json:"revoked_at" db:"revoked_at"`
 }
 
 type Contact struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	Name      string    `json:"name" db:"name"`
-	Phone     *string   `json:"phone" db:"phone"`
-	Email     *string   `json:"email" db:"email"`
-	IsActive  bool      `json:"is_active" db:"is_active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID       int     `json:"id" db:"id"`
+	UserID   int     `json:"user_id" db:"user_id"`
+	Name     string  `json:"name" db:"name"`
+	Phone    *string `json:"phone" db:"phone"`
+	Email    *string `json:"email" db:"email"`
+	IsActive bool    `json:"is_active" db:"is_active"`
+	// LinkedUserID is the account of the person this contact stands for,
+	// once they have accepted an invite. Debts with linked contacts are
+	// shared with that account.
+	LinkedUserID *int      `json:"linked_user_id" db:"linked_user_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type CreateContactRequest struct {
@@ -52,52 +235,765 @@ type UpdateContactRequest struct {
 	Email string `json:"email"`
 }
 
+// ContactInvite asks another user to link one of the inviter's contacts to
+// their account. It is addressed to an email address, and only an account
+// that has verified that address can accept it.
+type ContactInvite struct {
+	ID           int        `json:"id" db:"id"`
+	InviterID    int        `json:"inviter_id" db:"inviter_id"`
+	InviterName  string     `json:"inviter_name"`
+	InviterEmail string     `json:"inviter_email"`
+	ContactID    int        `json:"contact_id" db:"contact_id"`
+	Email        string     `json:"email" db:"email"`
+	Status       string     `json:"status" db:"status"` // "pending", "accepted", "declined" or "cancelled"
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RespondedAt  *time.Time `json:"responded_at" db:"responded_at"`
+}
+
+type InviteContactRequest struct {
+	// Email defaults to the email address of the contact.
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+type AcceptInviteRequest struct {
+	// ContactID is the invitee's existing contact for the inviter. A new
+	// contact is created if it is omitted.
+	ContactID int `json:"contact_id" binding:"omitempty,min=1"`
+}
+
+type InviteList struct {
+	Received []ContactInvite `json:"received"`
+	Sent     []ContactInvite `json:"sent"`
+}
+
 type Debt struct {
-	ID          int      `json:"id" db:"id"`
-	UserID      int      `json:"user_id" db:"user_id"`
-	ContactID   int      `json:"contact_id" db:"contact_id"`
-	Amount      float64  `json:"amount" db:"amount"`
-	Direction   string   `json:"direction" db:"direction"` // "owe_to" or "owe_from"
-	Status      string   `json:"status" db:"status"`       // "active", "settled", "removed"
-	Description *string  `json:"description" db:"description"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-	Contact     *Contact `json:"contact,omitempty"`
+	ID        int `json:"id" db:"id"`
+	UserID    int `json:"user_id" db:"user_id"`
+	ContactID int `json:"contact_id" db:"contact_id"`
+	// OriginalAmount is the amount the debt was opened with. PaidAmount and
+	// Balance are derived from the confirmed entries of the transaction
+	// ledger: lent/borrowed entries increase the balance and
+	// paid_back/received_back entries reduce it. PendingAmount is how much
+	// the entries waiting for confirmation would change the balance;
+	// disputed entries do not count.
+	OriginalAmount Money      `json:"original_amount" db:"amount"`
+	PaidAmount     Money      `json:"paid_amount"`
+	Balance        Money      `json:"balance"`
+	PendingAmount  Money      `json:"pending_amount"`
+	Currency       string     `json:"currency" db:"currency"`
+	Direction      string     `json:"direction" db:"direction"` // "owe_to" or "owe_from"
+	Status         string     `json:"status" db:"status"`       // "active", "settled", "removed"
+	Description    *string    `json:"description" db:"description"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	RemovedAt      *time.Time `json:"removed_at,omitempty" db:"removed_at"`
+	// CounterpartID is the mirror of a shared debt in the account of the
+	// linked contact, with the opposite direction.
+	CounterpartID *int `json:"-" db:"counterpart_id"`
+	Shared        bool `json:"shared"`
+	// Confirmation is "confirmed", "pending" or "disputed". Shared debts
+	// are pending until the user who did not create them (CreatedBy)
+	// confirms them; other debts are always confirmed.
+	Confirmation        string  `json:"confirmation" db:"confirmation"`
+	ConfirmationComment *string `json:"confirmation_comment,omitempty" db:"confirmation_comment"`
+	CreatedBy           *int    `json:"created_by,omitempty" db:"created_by"`
+	// ExpenseID is the group expense the debt was split from. Such debts
+	// are recomputed whenever the expense is edited.
+	ExpenseID *int     `json:"expense_id" db:"expense_id"`
+	Contact   *Contact `json:"contact,omitempty"`
+	// DueDate is the day the debt is due. A debt paid in Instalments is
+	// due on the day of the last one.
+	DueDate     *string      `json:"due_date" db:"due_date"`
+	Instalments []Instalment `json:"instalments,omitempty"`
+	// The due status is computed by SetDueStatus. NextDueDate and
+	// NextDueAmount give the next payment not yet covered, and an overdue
+	// debt has OverdueAmount outstanding since OverdueSince.
+	NextDueDate   *string `json:"next_due_date,omitempty"`
+	NextDueAmount *Money  `json:"next_due_amount,omitempty"`
+	Overdue       bool    `json:"overdue"`
+	OverdueSince  *string `json:"overdue_since,omitempty"`
+	OverdueAmount Money   `json:"overdue_amount"`
+	DaysOverdue   int     `json:"days_overdue"`
+	// Interest holds the interest terms of a loan; nil for a debt without
+	// interest.
+	Interest *Interest `json:"interest,omitempty"`
+}
+
+// SetCurrency applies the debt's currency to all of its money fields.
+func (d *Debt) SetCurrency(currency string) {
+	d.Currency = currency
+	d.OriginalAmount.Currency = currency
+	d.PaidAmount.Currency = currency
+	d.Balance.Currency = currency
+	d.PendingAmount.Currency = currency
+	d.OverdueAmount.Currency = currency
+	if d.NextDueAmount != nil {
+		d.NextDueAmount.Currency = currency
+	}
+	for i := range d.Instalments {
+		d.Instalments[i].Amount.Currency = currency
+	}
+}
+
+// SetDueStatus computes whether an active debt is overdue on the day
+// today, an ISO date. Repayments cover the instalments in order, so a
+// debt is overdue once the instalments due before today add up to more
+// than has been paid. A debt without instalments is overdue with its
+// whole balance from the day after its due date.
+func (d *Debt) SetDueStatus(today string) {
+	d.NextDueDate, d.NextDueAmount, d.OverdueSince = nil, nil, nil
+	d.Overdue, d.DaysOverdue = false, 0
+	d.OverdueAmount = NewMoney(0, d.Currency)
+	if d.Status != "active" || d.DueDate == nil || !d.Balance.IsPositive() {
+		return
+	}
+
+	instalments := d.Instalments
+	if len(instalments) == 0 {
+		instalments = []Instalment{{DueDate: *d.DueDate, Amount: d.Balance.Add(d.PaidAmount)}}
+	}
+
+	var due Money
+	for _, instalment := range instalments {
+		due = due.Add(instalment.Amount)
+		outstanding := due.Sub(d.PaidAmount)
+		if !outstanding.IsPositive() {
+			continue
+		}
+		if outstanding.Cmp(d.Balance) > 0 {
+			outstanding = d.Balance
+		}
+
+		if instalment.DueDate >= today {
+			date := instalment.DueDate
+			amount := NewMoney(min(outstanding.Minor, instalment.Amount.Minor), d.Currency)
+			d.NextDueDate, d.NextDueAmount = &date, &amount
+			break
+		}
+		if d.OverdueSince == nil {
+			date := instalment.DueDate
+			d.OverdueSince = &date
+		}
+		d.OverdueAmount = NewMoney(outstanding.Minor, d.Currency)
+	}
+
+	if d.OverdueSince != nil {
+		d.Overdue = true
+		d.DaysOverdue = DaysBetween(*d.OverdueSince, today)
+	}
+}
+
+// DaysBetween counts the days from one ISO date to another.
+func DaysBetween(from, to string) int {
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return 0
+	}
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// Instalment is a part of a debt that is due by a day.
+type Instalment struct {
+	DueDate string `json:"due_date" binding:"required"`
+	Amount  Money  `json:"amount"`
 }
 
 type CreateDebtRequest struct {
-	ContactID   int     `json:"contact_id" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required"`
-	Direction   string  `json:"direction" binding:"required,oneof=owe_to owe_from"`
-	Description string  `json:"description"`
+	ContactID   int    `json:"contact_id" binding:"required"`
+	Amount      Money  `json:"amount"`
+	Direction   string `json:"direction" binding:"required,oneof=owe_to owe_from"`
+	Description string `json:"description"`
+	// Currency is an ISO 4217 code and defaults to the user's default
+	// currency.
+	Currency string `json:"currency" binding:"omitempty,iso4217,currency"`
+	DueRequest
+	Interest *Interest `json:"interest"`
+}
+
+// DueRequest gives when a debt is due: on DueDate, in explicit
+// Instalments, or in equal instalments on the occurrences of
+// InstalmentPlan. At most one of them may be set.
+type DueRequest struct {
+	DueDate        string             `json:"due_date"`
+	Instalments    []Instalment       `json:"instalments" binding:"omitempty,dive"`
+	InstalmentPlan *RecurrenceRequest `json:"instalment_plan"`
+}
+
+// Interest holds the interest terms of a debt. Rate is the annual rate in
+// percent, such as "7.25". Method is "simple" (the default) or
+// "compound". Compounding is how often compound interest is added to the
+// balance and how often AutoPost posts interest: "daily", "monthly" (the
+// default), "quarterly" or "annually". DayCount is "actual/365" (the
+// default), "actual/360", "30/360" or "actual/actual". Interest accrues
+// from StartDate.
+type Interest struct {
+	Rate        string `json:"rate" binding:"required"`
+	Method      string `json:"method" binding:"omitempty,oneof=simple compound"`
+	Compounding string `json:"compounding" binding:"omitempty,oneof=daily monthly quarterly annually"`
+	DayCount    string `json:"day_count" binding:"omitempty,oneof=actual/365 actual/360 30/360 actual/actual"`
+	StartDate   string `json:"start_date"`
+	AutoPost    bool   `json:"auto_post"`
+}
+
+// InterestAccrual is the interest a debt has accrued from the start of
+// its terms to Date. Posted is the part already added to the balance by
+// interest transactions, and Payoff the balance with the rest added.
+type InterestAccrual struct {
+	DebtID    int    `json:"debt_id"`
+	StartDate string `json:"start_date"`
+	Date      string `json:"date"`
+	Accrued   Money  `json:"accrued"`
+	Posted    Money  `json:"posted"`
+	Unposted  Money  `json:"unposted"`
+	Payoff    Money  `json:"payoff"`
+}
+
+// InterestQuery holds the day GET /debts/:id/interest accrues to, today by
+// default.
+type InterestQuery struct {
+	Date string `form:"date"`
+}
+
+// Amortization repays the balance of a debt in equal payments on the
+// occurrences of a rule. The last payment clears what is left.
+type Amortization struct {
+	DebtID        int                   `json:"debt_id"`
+	Principal     Money                 `json:"principal"`
+	Payment       Money                 `json:"payment"`
+	TotalInterest Money                 `json:"total_interest"`
+	Payments      []AmortizationPayment `json:"payments"`
+}
+
+// AmortizationPayment is one payment of an Amortization. Balance is what
+// is left after it.
+type AmortizationPayment struct {
+	Number    int    `json:"number"`
+	DueDate   string `json:"due_date"`
+	Payment   Money  `json:"payment"`
+	Interest  Money  `json:"interest"`
+	Principal Money  `json:"principal"`
+	Balance   Money  `json:"balance"`
 }
 
 type UpdateDebtRequest struct {
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
-	Status      string  `json:"status" binding:"oneof=active settled removed"`
+	Amount      *Money `json:"amount"` // only while the debt has no transactions
+	Description string `json:"description"`
+	// Status removes the debt, or restores a removed one; active and
+	// settled otherwise follow from the balance.
+	Status string `json:"status" binding:"oneof=active settled removed"`
 }
 
+// DebtListQuery holds the debt-specific filters of GET /debts. Paging,
+// sorting and the amount and date ranges are parsed by the handler.
+type DebtListQuery struct {
+	// Status is a comma-separated set of statuses, or "all".
+	Status       string `form:"status"`
+	Direction    string `form:"direction" binding:"omitempty,oneof=owe_to owe_from"`
+	ContactID    int    `form:"contact_id" binding:"omitempty,min=1"`
+	Confirmation string `form:"confirmation" binding:"omitempty,oneof=confirmed pending disputed"`
+	ExpenseID    int    `form:"expense_id" binding:"omitempty,min=1"`
+	Currency     string `form:"currency" binding:"omitempty,iso4217,currency"`
+	HasDueDate   bool   `form:"has_due_date"`
+}
+
+// DebtSummary totals the confirmed balances of the active debts. Pending
+// holds what the entries still waiting for confirmation would add to them;
+// disputed entries count in neither.
+//
+// ByCurrency holds the totals of each currency the user has debts in. The
+// totals above it are converted into Currency, the user's default
+// currency: confirmed amounts at the exchange rates of the day each was
+// recorded, pending amounts at the latest rates. Currencies without the
+// rates to convert them are listed in MissingRates and left out.
 type DebtSummary struct {
-	TotalOwedToOthers     float64 `json:"total_owed_to_others"`
-	TotalOwedFromOthers   float64 `json:"total_owed_from_others"`
-	NetBalance            float64 `json:"net_balance"`
-	ActiveDebtsCount      int     `json:"active_debts_count"`
-	ContactsWithDebts     int     `json:"contacts_with_debts"`
+	Currency            string            `json:"currency"`
+	TotalOwedToOthers   Money             `json:"total_owed_to_others"`
+	TotalOwedFromOthers Money             `json:"total_owed_from_others"`
+	NetBalance          Money             `json:"net_balance"`
+	ActiveDebtsCount    int               `json:"active_debts_count"`
+	ContactsWithDebts   int               `json:"contacts_with_debts"`
+	Pending             PendingSummary    `json:"pending"`
+	DisputedDebtsCount  int               `json:"disputed_debts_count"`
+	ByCurrency          []CurrencySummary `json:"by_currency"`
+	MissingRates        []string          `json:"missing_rates,omitempty"`
+	OverdueDebtsCount   int               `json:"overdue_debts_count"`
+	MaxDaysOverdue      int               `json:"max_days_overdue"`
+}
+
+// CurrencySummary totals the debts in one currency.
+type CurrencySummary struct {
+	Currency            string         `json:"currency"`
+	TotalOwedToOthers   Money          `json:"total_owed_to_others"`
+	TotalOwedFromOthers Money          `json:"total_owed_from_others"`
+	NetBalance          Money          `json:"net_balance"`
+	ActiveDebtsCount    int            `json:"active_debts_count"`
+	Pending             PendingSummary `json:"pending"`
+	DisputedDebtsCount  int            `json:"disputed_debts_count"`
+	Overdue             OverdueSummary `json:"overdue"`
+}
+
+// OverdueSummary totals the overdue amounts of the debts in a currency.
+type OverdueSummary struct {
+	DebtsCount          int   `json:"debts_count"`
+	TotalOwedToOthers   Money `json:"total_owed_to_others"`
+	TotalOwedFromOthers Money `json:"total_owed_from_others"`
+	MaxDaysOverdue      int   `json:"max_days_overdue"`
+}
+
+// LedgerEntry is one confirmed change to the balance of a debt: the amount
+// it was opened with or one of its transactions. Amount is negative for
+// repayments.
+type LedgerEntry struct {
+	DebtID    int
+	Direction string
+	Amount    Money
+	Date      time.Time
+}
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// bought on Date, a day such as "2025-06-30". Rate is a decimal string so
+// that it is stored exactly.
+type ExchangeRate struct {
+	Currency string `json:"currency" db:"currency"`
+	Date     string `json:"date" db:"rate_date"`
+	Rate     string `json:"rate" db:"rate"`
+}
+
+type PendingSummary struct {
+	TotalOwedToOthers   Money `json:"total_owed_to_others"`
+	TotalOwedFromOthers Money `json:"total_owed_from_others"`
+	NetBalance          Money `json:"net_balance"`
+	DebtsCount          int   `json:"debts_count"`
+	TransactionsCount   int   `json:"transactions_count"`
 }
 
 type Transaction struct {
 	ID              int       `json:"id" db:"id"`
 	DebtID          int       `json:"debt_id" db:"debt_id"`
-	Amount          float64   `json:"amount" db:"amount"`
+	Amount          Money     `json:"amount" db:"amount"`
+	Currency        string    `json:"currency" db:"currency"`
 	TransactionType string    `json:"transaction_type" db:"transaction_type"`
 	Description     *string   `json:"description" db:"description"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	// CounterpartID is the mirror of the transaction on the counterpart of
+	// a shared debt.
+	CounterpartID *int `json:"-" db:"counterpart_id"`
+	// Confirmation works as for debts.
+	Confirmation        string  `json:"confirmation" db:"confirmation"`
+	ConfirmationComment *string `json:"confirmation_comment,omitempty" db:"confirmation_comment"`
+	CreatedBy           *int    `json:"created_by,omitempty" db:"created_by"`
+	// Interest marks interest posted to the debt, which counts as an
+	// increase of its balance.
+	Interest bool `json:"interest" db:"interest"`
+}
+
+type ConfirmRequest struct {
+	Comment string `json:"comment"`
+}
+
+type DisputeRequest struct {
+	// Comment tells the other user what is wrong with the entry.
+	Comment string `json:"comment" binding:"required"`
+}
+
+// DebtChange is an edit of a shared debt that waits for the other side to
+// accept it. DebtID and TransactionID refer to the records of the user who
+// proposed the change; accepting it applies the change to both sides.
+type DebtChange struct {
+	ID         int    `json:"id" db:"id"`
+	DebtID     int    `json:"debt_id" db:"debt_id"`
+	ProposedBy int    `json:"proposed_by" db:"proposed_by"`
+	Kind       string `json:"kind" db:"kind"` // "update" or "delete_transaction"
+	// Amount, Description and Status are the new values of an update.
+	// Fields that do not change are nil.
+	Amount        *Money     `json:"amount,omitempty" db:"amount"`
+	Description   *string    `json:"description,omitempty" db:"description"`
+	Status        *string    `json:"status,omitempty" db:"status"`
+	TransactionID *int       `json:"transaction_id,omitempty" db:"transaction_id"`
+	State         string     `json:"state" db:"state"` // "pending", "accepted", "rejected" or "cancelled"
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at" db:"resolved_at"`
+}
+
+// Group is a set of the user's contacts who share expenses.
+type Group struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description" db:"description"`
+	Members     []Contact `json:"members"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ContactIDs  []int  `json:"contact_ids"`
+}
+
+type UpdateGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type GroupMemberRequest struct {
+	ContactID int `json:"contact_id" binding:"required,min=1"`
+}
+
+// Ways of splitting an expense between members.
+const (
+	SplitEqual      = "equal"
+	SplitExact      = "exact"
+	SplitPercentage = "percentage"
+	SplitShares     = "shares"
+)
+
+// Expense is paid by one member of a group and split between members. A
+// nil PaidBy means the user paid. Debts are the debts between the user and
+// the other members that the expense produces.
+type Expense struct {
+	ID          int            `json:"id" db:"id"`
+	GroupID     int            `json:"group_id" db:"group_id"`
+	Description string         `json:"description" db:"description"`
+	Amount      Money          `json:"amount" db:"amount"`
+	Currency    string         `json:"currency" db:"currency"`
+	PaidBy      *int           `json:"paid_by" db:"paid_by"`
+	SplitType   string         `json:"split_type" db:"split_type"` // "equal", "exact", "percentage" or "shares"
+	Splits      []ExpenseSplit `json:"splits"`
+	Debts       []Debt         `json:"debts,omitempty"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// ExpenseSplit is one member's part of an expense. A nil ContactID stands
+// for the user. Amount is the member's share, worked out from Percentage
+// or Shares for those split types.
+type ExpenseSplit struct {
+	ContactID  *int     `json:"contact_id" db:"contact_id"`
+	Amount     Money    `json:"amount" db:"amount"`
+	Percentage *Percent `json:"percentage,omitempty"`
+	Shares     *int64   `json:"shares,omitempty"`
+}
+
+// Weight returns the percentage or number of shares, which are stored in
+// the same column.
+func (s ExpenseSplit) Weight() *int64 {
+	if s.Percentage != nil {
+		weight := int64(*s.Percentage)
+		return &weight
+	}
+	return s.Shares
+}
+
+// SetWeight is the reverse of Weight for an expense with the given split
+// type.
+func (s *ExpenseSplit) SetWeight(splitType string, weight *int64) {
+	s.Percentage, s.Shares = nil, nil
+	if weight == nil {
+		return
+	}
+	switch splitType {
+	case SplitPercentage:
+		percentage := Percent(*weight)
+		s.Percentage = &percentage
+	case SplitShares:
+		shares := *weight
+		s.Shares = &shares
+	}
+}
+
+type ExpenseRequest struct {
+	Description string `json:"description" binding:"required"`
+	Amount      Money  `json:"amount"`
+	// PaidBy is the contact ID of the member who paid, or null if the user
+	// paid.
+	PaidBy    *int   `json:"paid_by"`
+	SplitType string `json:"split_type" binding:"required,oneof=equal exact percentage shares"`
+	// Splits lists the members sharing the expense with their amount,
+	// percentage or shares. An equal split may leave it out to share
+	// between the user and every member.
+	Splits []ExpenseSplit `json:"splits"`
+	// Currency defaults to the user's default currency. It cannot be
+	// changed once the expense exists.
+	Currency string `json:"currency" binding:"omitempty,iso4217,currency"`
+}
+
+// Transfer is one payment of a settlement plan. A nil contact ID stands
+// for the user.
+type Transfer struct {
+	FromContactID *int   `json:"from_contact_id"`
+	ToContactID   *int   `json:"to_contact_id"`
+	Amount        Money  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// SettlementPlan is the shortest list of transfers found that settles the
+// debts in DebtIDs. Transfers may go between two contacts, which settles
+// what one owes the user and the user owes the other. Fingerprint
+// identifies the balances the plan was made for.
+type SettlementPlan struct {
+	GroupID     *int       `json:"group_id"`
+	Transfers   []Transfer `json:"transfers"`
+	DebtIDs     []int      `json:"debt_ids"`
+	Fingerprint string     `json:"fingerprint"`
+}
+
+type SettlementPlanQuery struct {
+	GroupID int `form:"group_id" binding:"omitempty,min=1"`
 }
 
+type SettleRequest struct {
+	GroupID int `json:"group_id" binding:"omitempty,min=1"`
+	// Fingerprint is that of the plan the user reviewed. Settling fails if
+	// the balances have changed since.
+	Fingerprint string `json:"fingerprint"`
+}
+
+type SettleResponse struct {
+	SettlementPlan
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Overpayment policies for repayments that exceed a debt's balance.
+const (
+	OverpaymentReject   = "reject"
+	OverpaymentRollOver = "roll_over"
+)
+
 type CreateTransactionRequest struct {
-	DebtID          int     `json:"debt_id" binding:"required"`
-	Amount          float64 `json:"amount" binding:"required"`
-	TransactionType string  `json:"transaction_type" binding:"required,oneof=lent borrowed paid_back received_back"`
-	Description     string  `json:"description"`
+	DebtID          int    `json:"debt_id" binding:"required"`
+	Amount          Money  `json:"amount"`
+	TransactionType string `json:"transaction_type" binding:"required,oneof=lent borrowed paid_back received_back"`
+	Description     string `json:"description"`
+	// Overpayment is "reject" (the default) or "roll_over", which records
+	// the repayment up to the balance and opens a new debt in the opposite
+	// direction for the excess.
+	Overpayment string `json:"overpayment" binding:"omitempty,oneof=reject roll_over"`
+}
+
+// TransactionListQuery holds the transaction-specific filters of
+// GET /transactions.
+type TransactionListQuery struct {
+	DebtID          int    `form:"debt_id" binding:"omitempty,min=1"`
+	ContactID       int    `form:"contact_id" binding:"omitempty,min=1"`
+	TransactionType string `form:"transaction_type" binding:"omitempty,oneof=lent borrowed paid_back received_back"`
+	Confirmation    string `form:"confirmation" binding:"omitempty,oneof=confirmed pending disputed"`
+}
+
+// ExportQuery holds the options of the exports. Locale sets the decimal
+// and thousands separators of amounts in CSV; Grouping adds the thousands
+// separator, and Delimiter defaults to semicolon for locales that write a
+// decimal comma.
+type ExportQuery struct {
+	Format    string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	Locale    string `form:"locale"`
+	Grouping  bool   `form:"grouping"`
+	Delimiter string `form:"delimiter" binding:"omitempty,oneof=comma semicolon tab"`
+}
+
+type CreateTransactionResponse struct {
+	Transaction
+	RolloverDebt *Debt `json:"rollover_debt,omitempty"`
+}
+
+// Page is the envelope of paginated list responses. NextCursor is null on
+// the last page; Total counts all rows matching the filters.
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int     `json:"total"`
+}
+
+// Schedule repeats a debt or a transaction by a recurrence rule. A debt
+// schedule opens a new debt with ContactID at every occurrence; a
+// transaction schedule records a transaction on DebtID. Days are ISO dates
+// such as "2025-07-01", in UTC.
+type Schedule struct {
+	ID              int     `json:"id" db:"id"`
+	UserID          int     `json:"user_id" db:"user_id"`
+	Kind            string  `json:"kind" db:"kind"` // "debt" or "transaction"
+	ContactID       *int    `json:"contact_id,omitempty" db:"contact_id"`
+	Direction       *string `json:"direction,omitempty" db:"direction"`
+	DebtID          *int    `json:"debt_id,omitempty" db:"debt_id"`
+	TransactionType *string `json:"transaction_type,omitempty" db:"transaction_type"`
+	Amount          Money   `json:"amount" db:"amount"`
+	Currency        string  `json:"currency" db:"currency"`
+	Description     *string `json:"description" db:"description"`
+	RRule           string  `json:"rrule" db:"rrule"`
+	StartDate       string  `json:"start_date" db:"start_date"`
+	// NextDate is the next occurrence still to be created, or nil once the
+	// rule has ended.
+	NextDate  *string   `json:"next_date" db:"next_date"`
+	Status    string    `json:"status" db:"status"` // "active", "paused" or "finished"
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduleOccurrence is one occurrence of a schedule. Only occurrences
+// that were edited or skipped ahead of time, or have been created, are
+// stored; a preview fills in the others as "scheduled" with the amount and
+// description of the schedule.
+type ScheduleOccurrence struct {
+	ScheduleID    int     `json:"schedule_id" db:"schedule_id"`
+	Date          string  `json:"date" db:"occurrence_date"`
+	Status        string  `json:"status" db:"status"` // "scheduled", "edited", "skipped" or "created"
+	Amount        Money   `json:"amount" db:"amount"`
+	Description   *string `json:"description" db:"description"`
+	DebtID        *int    `json:"debt_id,omitempty" db:"debt_id"`
+	TransactionID *int    `json:"transaction_id,omitempty" db:"transaction_id"`
+}
+
+// RecurrenceRequest gives the rule of a schedule either as an RRULE, or as
+// a frequency with an optional interval that ends after Count occurrences
+// or on the day Until.
+type RecurrenceRequest struct {
+	StartDate string `json:"start_date" binding:"required"`
+	RRule     string `json:"rrule"`
+	Frequency string `json:"frequency" binding:"omitempty,oneof=daily weekly monthly"`
+	Interval  int    `json:"interval" binding:"omitempty,min=1"`
+	Count     int    `json:"count" binding:"omitempty,min=1"`
+	Until     string `json:"until"`
+}
+
+// CreateScheduleRequest needs ContactID and Direction for a debt schedule
+// and DebtID and TransactionType for a transaction schedule.
+type CreateScheduleRequest struct {
+	Kind            string `json:"kind" binding:"required,oneof=debt transaction"`
+	ContactID       int    `json:"contact_id" binding:"omitempty,min=1"`
+	Direction       string `json:"direction" binding:"omitempty,oneof=owe_to owe_from"`
+	Currency        string `json:"currency" binding:"omitempty,iso4217,currency"`
+	DebtID          int    `json:"debt_id" binding:"omitempty,min=1"`
+	TransactionType string `json:"transaction_type" binding:"omitempty,oneof=lent borrowed paid_back received_back"`
+	Amount          Money  `json:"amount"`
+	Description     string `json:"description"`
+	RecurrenceRequest
+}
+
+// UpdateScheduleRequest changes the occurrences that have not been
+// created yet.
+type UpdateScheduleRequest struct {
+	Amount      Money  `json:"amount"`
+	Description string `json:"description"`
+	RecurrenceRequest
+}
+
+// OccurrenceListQuery limits a preview of upcoming occurrences, 10 by
+// default.
+type OccurrenceListQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// OccurrenceRequest edits one future occurrence of a schedule. Omitted
+// fields keep the values of the schedule.
+type OccurrenceRequest struct {
+	Amount      *Money  `json:"amount"`
+	Description *string `json:"description"`
+}
+
+// Notification is a message queued for a user, such as a payment reminder.
+// Key identifies what it is about, so that it is queued only once.
+type Notification struct {
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	DebtID    *int       `json:"debt_id,omitempty" db:"debt_id"`
+	Kind      string     `json:"kind" db:"kind"` // "due_soon" or "overdue"
+	Key       string     `json:"-" db:"dedupe_key"`
+	Subject   string     `json:"subject" db:"subject"`
+	Body      string     `json:"body" db:"body"`
+	Status    string     `json:"status" db:"status"` // "pending", "sent" or "failed"
+	Attempts  int        `json:"attempts" db:"attempts"`
+	LastError *string    `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// ImportProfile is a saved mapping for imports of files in one format, so
+// that files from the same bank or spreadsheet can be imported alike.
+type ImportProfile struct {
+	ID        int           `json:"id" db:"id"`
+	UserID    int           `json:"-" db:"user_id"`
+	Name      string        `json:"name" db:"name"`
+	Format    string        `json:"format" db:"format"`
+	Mapping   ImportMapping `json:"mapping" db:"mapping"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type ImportProfileRequest struct {
+	Name    string        `json:"name" binding:"required,max=100"`
+	Format  string        `json:"format" binding:"required,oneof=csv ofx qfx camt053"`
+	Mapping ImportMapping `json:"mapping"`
+}
+
+// ImportMapping says what the rows of an imported file become. Target
+// "debts" opens a debt per row and "transactions" records a transaction
+// per row on a debt of the row's contact, or of the debt_id column. Rows
+// go to the contact ContactID, or to the contact named by the row; when
+// importing debts, CreateContacts adds contacts for names that match none.
+// Currency is used for rows that give none.
+//
+// Columns, DateFormat, Decimal and Delimiter only apply to CSV files.
+type ImportMapping struct {
+	Target         string        `json:"target" binding:"omitempty,oneof=debts transactions"`
+	ContactID      int           `json:"contact_id,omitempty" binding:"omitempty,min=1"`
+	CreateContacts bool          `json:"create_contacts"`
+	Currency       string        `json:"currency,omitempty" binding:"omitempty,iso4217,currency"`
+	Columns        ImportColumns `json:"columns"`
+	// DateFormat is a Go time layout such as "02.01.2006".
+	DateFormat string `json:"date_format,omitempty"`
+	Decimal    string `json:"decimal,omitempty" binding:"omitempty,oneof=. 0x2C"`
+	Delimiter  string `json:"delimiter,omitempty" binding:"omitempty,oneof=comma semicolon tab"`
+}
+
+// ImportColumns names the header of the CSV column of each field. Amounts
+// are given signed in Amount, positive for money received, or unsigned in
+// separate Debit (paid) and Credit (received) columns.
+type ImportColumns struct {
+	Date        string `json:"date,omitempty"`
+	Amount      string `json:"amount,omitempty"`
+	Debit       string `json:"debit,omitempty"`
+	Credit      string `json:"credit,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Description string `json:"description,omitempty"`
+	Contact     string `json:"contact,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	DebtID      string `json:"debt_id,omitempty"`
+}
+
+// ImportRow is the outcome of one row of an import: "new" for a row that
+// is or would be imported, "duplicate" for one already recorded, and
+// "error" for one that cannot be imported, with the reasons in Errors.
+type ImportRow struct {
+	Row             int      `json:"row"`
+	Status          string   `json:"status"`
+	Errors          []string `json:"errors,omitempty"`
+	Date            string   `json:"date,omitempty"`
+	Amount          *Money   `json:"amount,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Contact         string   `json:"contact,omitempty"`
+	Reference       string   `json:"reference,omitempty"`
+	ContactID       *int     `json:"contact_id,omitempty"`
+	NewContact      bool     `json:"new_contact,omitempty"`
+	DebtID          *int     `json:"debt_id,omitempty"`
+	Direction       string   `json:"direction,omitempty"`
+	TransactionType string   `json:"transaction_type,omitempty"`
+	TransactionID   *int     `json:"transaction_id,omitempty"`
+}
+
+// ImportResult reports an import row by row. A dry run reports what an
+// import would do without saving anything.
+type ImportResult struct {
+	DryRun          bool        `json:"dry_run"`
+	Format          string      `json:"format"`
+	Target          string      `json:"target"`
+	New             int         `json:"new"`
+	Duplicates      int         `json:"duplicates"`
+	Errors          int         `json:"errors"`
+	ContactsCreated int         `json:"contacts_created"`
+	Rows            []ImportRow `json:"rows"`
 }
@@ -0,0 +1,88 @@
+// internal/models/models_test.go
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSetDueStatus(t *testing.T) {
+	instalments := []Instalment{
+		{DueDate: "2025-07-01", Amount: NewMoney(3000, "ZAR")},
+		{DueDate: "2025-08-01", Amount: NewMoney(3000, "ZAR")},
+		{DueDate: "2025-09-01", Amount: NewMoney(4000, "ZAR")},
+	}
+	cases := []struct {
+		name        string
+		paid        int64
+		instalments []Instalment
+		today       string
+		// want is the next due date and amount and the overdue status,
+		// with "-" for what is not set.
+		want string
+	}{
+		{name: "due later", today: "2025-08-15", want: "2025-09-01 100.00 -"},
+		{name: "due today", today: "2025-09-01", want: "2025-09-01 100.00 -"},
+		{name: "overdue", paid: 2500, today: "2025-09-11", want: "- - 75.00 since 2025-09-01, 10 days"},
+		{name: "first instalment next", instalments: instalments, today: "2025-06-20", want: "2025-07-01 30.00 -"},
+		{name: "payments cover the first instalment", paid: 3000, instalments: instalments, today: "2025-07-02", want: "2025-08-01 30.00 -"},
+		{name: "paid ahead", paid: 4000, instalments: instalments, today: "2025-07-02", want: "2025-08-01 20.00 -"},
+		{name: "one instalment late", paid: 1000, instalments: instalments, today: "2025-07-03", want: "2025-08-01 30.00 20.00 since 2025-07-01, 2 days"},
+		{name: "two instalments late", paid: 1000, instalments: instalments, today: "2025-08-05", want: "2025-09-01 40.00 50.00 since 2025-07-01, 35 days"},
+		{name: "all late", paid: 3500, instalments: instalments, today: "2025-09-02", want: "- - 65.00 since 2025-08-01, 32 days"},
+	}
+	for _, tc := range cases {
+		dueDate := "2025-09-01"
+		debt := Debt{
+			Status:      "active",
+			Currency:    "ZAR",
+			PaidAmount:  NewMoney(tc.paid, "ZAR"),
+			Balance:     NewMoney(10000-tc.paid, "ZAR"),
+			DueDate:     &dueDate,
+			Instalments: tc.instalments,
+		}
+		debt.SetDueStatus(tc.today)
+
+		next, amount, overdue := "-", "-", "-"
+		if debt.NextDueDate != nil {
+			next, amount = *debt.NextDueDate, debt.NextDueAmount.String()
+		}
+		if debt.Overdue {
+			overdue = fmt.Sprintf("%s since %s, %d days", debt.OverdueAmount, *debt.OverdueSince, debt.DaysOverdue)
+		}
+		if got := next + " " + amount + " " + overdue; got != tc.want {
+			t.Errorf("%s: due status is %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// Settled debts and debts without a due date are never due.
+	dueDate := "2025-07-01"
+	for _, debt := range []Debt{
+		{Status: "settled", Currency: "ZAR", PaidAmount: NewMoney(100, "ZAR"), DueDate: &dueDate},
+		{Status: "active", Currency: "ZAR", Balance: NewMoney(100, "ZAR")},
+	} {
+		debt.SetDueStatus("2025-08-01")
+		if debt.Overdue || debt.NextDueDate != nil {
+			t.Errorf("%s debt with due date %v has a due status", debt.Status, debt.DueDate)
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     int
+	}{
+		{"2025-07-01", "2025-07-01", 0},
+		{"2025-07-01", "2025-07-31", 30},
+		{"2024-02-28", "2024-03-01", 2},
+		{"2025-03-29", "2025-03-31", 2},
+		{"2025-07-10", "2025-07-01", -9},
+		{"2025-07-01", "someday", 0},
+	}
+	for _, tc := range cases {
+		if got := DaysBetween(tc.from, tc.to); got != tc.want {
+			t.Errorf("DaysBetween(%s, %s) = %d, want %d", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
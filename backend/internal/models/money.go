@@ -0,0 +1,237 @@
+// internal/models/money.go
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when no currency is given.
+const DefaultCurrency = "ZAR"
+
+// minorPerMajor is the number of minor units (cents) in one major unit.
+const minorPerMajor = 100
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Money is an exact monetary amount stored as an integer number of minor
+// units (hundredths) together with its ISO 4217 currency code. It is
+// persisted as an INTEGER column and encoded in JSON as a decimal string
+// such as "12.34" so that no precision is lost on either side. Amounts
+// always have two decimal places, so only currencies whose minor unit is a
+// hundredth are supported.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// unsupportedCurrencies are the ISO 4217 codes whose minor unit is not a
+// hundredth: those without decimals, those with three or four, and the
+// funds and metals that have no minor unit at all.
+var unsupportedCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true,
+	"TND": true, "CLF": true, "UYW": true,
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true, "XBD": true,
+	"XDR": true, "XPD": true, "XPT": true, "XSU": true, "XTS": true, "XUA": true,
+	"XXX": true,
+}
+
+// SupportedCurrency reports whether amounts in the ISO 4217 currency can
+// be held as Money, i.e. whether its minor unit is a hundredth.
+func SupportedCurrency(code string) bool {
+	return !unsupportedCurrencies[code]
+}
+
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ParseMoney parses a decimal string with at most two fractional digits,
+// e.g. "12", "12.3", "-0.05".
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && (!hasFrac || frac == "") {
+		return Money{}, ErrInvalidMoney
+	}
+	if len(frac) > 2 || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidMoney
+	}
+
+	var major int64
+	if whole != "" {
+		var err error
+		major, err = strconv.ParseInt(whole, 10, 64)
+		if err != nil || major > (1<<62)/minorPerMajor {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+
+	var minor int64
+	if frac != "" {
+		minor, _ = strconv.ParseInt(frac+strings.Repeat("0", 2-len(frac)), 10, 64)
+	}
+
+	total := major*minorPerMajor + minor
+	if negative {
+		total = -total
+	}
+
+	return Money{Minor: total, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a plain decimal string without the currency.
+func (m Money) String() string {
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorPerMajor, minor%minorPerMajor)
+}
+
+// Add returns m + o. Both amounts are expected to be in the same currency;
+// an empty currency on either side adopts the other.
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.currencyWith(o)}
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.currencyWith(o)}
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+func (m Money) currencyWith(o Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	return o.Currency
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// Cmp compares the amounts of m and o, returning -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts either a decimal string ("12.34") or a bare JSON
+// number (12.34). Numbers are parsed from their literal text so they never
+// pass through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(text, m.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidMoney, text)
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as integer minor units.
+func (m Money) Value() (driver.Value, error) {
+	return m.Minor, nil
+}
+
+// Scan reads integer minor units. The currency is held in a separate
+// column and is left untouched.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		m.Minor = 0
+	case int64:
+		m.Minor = v
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+func (m *Money) scanText(s string) error {
+	minor, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", s, err)
+	}
+	m.Minor = minor
+	return nil
+}
+
+// Percent is a percentage in hundredths of a percent, encoded in JSON as a
+// decimal string such as "33.33" in the same way as Money.
+type Percent int64
+
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return Money{Minor: int64(p)}.MarshalJSON()
+}
+
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	var m Money
+	if err := m.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("invalid percentage: %s", data)
+	}
+	*p = Percent(m.Minor)
+	return nil
+}
@@ -0,0 +1,152 @@
+// internal/models/money_test.go
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		text  string
+		minor int64
+		err   bool
+	}{
+		{text: "12", minor: 1200},
+		{text: "12.3", minor: 1230},
+		{text: "12.34", minor: 1234},
+		{text: "-0.05", minor: -5},
+		{text: "+1.5", minor: 150},
+		{text: ".5", minor: 50},
+		{text: "5.", minor: 500},
+		{text: " 7.10 ", minor: 710},
+		{text: "0", minor: 0},
+		{text: "92233720368547758.07", err: true},
+		{text: "", err: true},
+		{text: ".", err: true},
+		{text: "-", err: true},
+		{text: "1.234", err: true},
+		{text: "1e3", err: true},
+		{text: "1,50", err: true},
+		{text: "--1", err: true},
+		{text: "12.-3", err: true},
+		{text: "abc", err: true},
+	}
+	for _, tc := range cases {
+		money, err := ParseMoney(tc.text, "ZAR")
+		if tc.err {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q) = %v, %v; want ErrInvalidMoney", tc.text, money.Minor, err)
+			}
+			continue
+		}
+		if err != nil || money.Minor != tc.minor || money.Currency != "ZAR" {
+			t.Errorf("ParseMoney(%q) = %v %q, %v; want %d", tc.text, money.Minor, money.Currency, err, tc.minor)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	cases := []struct {
+		minor int64
+		text  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{100, "1.00"},
+		{123456, "1234.56"},
+		{-123456, "-1234.56"},
+	}
+	for _, tc := range cases {
+		if text := NewMoney(tc.minor, "ZAR").String(); text != tc.text {
+			t.Errorf("NewMoney(%d).String() = %q, want %q", tc.minor, text, tc.text)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	cases := []struct {
+		json  string
+		minor int64
+		err   bool
+	}{
+		{json: `"12.34"`, minor: 1234},
+		{json: `12.34`, minor: 1234},
+		{json: `-7`, minor: -700},
+		{json: `null`, minor: 99},
+		{json: `0.1`, minor: 10},
+		{json: `12.345`, err: true},
+		{json: `1e2`, err: true},
+		{json: `"twelve"`, err: true},
+	}
+	for _, tc := range cases {
+		money := NewMoney(99, "ZAR")
+		err := json.Unmarshal([]byte(tc.json), &money)
+		if tc.err {
+			if err == nil {
+				t.Errorf("unmarshalling %s gave %d, want an error", tc.json, money.Minor)
+			}
+			continue
+		}
+		if err != nil || money.Minor != tc.minor {
+			t.Errorf("unmarshalling %s gave %d, %v; want %d", tc.json, money.Minor, err, tc.minor)
+		}
+	}
+
+	encoded, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{NewMoney(-1050, "ZAR")})
+	if err != nil || string(encoded) != `{"amount":"-10.50"}` {
+		t.Errorf("marshalled %s, %v; want {\"amount\":\"-10.50\"}", encoded, err)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	cases := []struct {
+		src   interface{}
+		minor int64
+		err   bool
+	}{
+		{src: int64(1234), minor: 1234},
+		{src: []byte("-50"), minor: -50},
+		{src: " 7 ", minor: 7},
+		{src: nil, minor: 0},
+		{src: "12.34", err: true},
+		{src: 1.5, err: true},
+	}
+	for _, tc := range cases {
+		money := NewMoney(99, "ZAR")
+		err := money.Scan(tc.src)
+		if (err != nil) != tc.err || (!tc.err && money.Minor != tc.minor) {
+			t.Errorf("Scan(%#v) gave %d, %v; want %d", tc.src, money.Minor, err, tc.minor)
+		}
+		if money.Currency != "ZAR" {
+			t.Errorf("Scan(%#v) changed the currency to %q", tc.src, money.Currency)
+		}
+	}
+}
+
+func TestPercentJSON(t *testing.T) {
+	var percent Percent
+	if err := json.Unmarshal([]byte(`"33.33"`), &percent); err != nil || percent != 3333 {
+		t.Errorf("unmarshalling \"33.33\" gave %d, %v; want 3333", percent, err)
+	}
+	if err := json.Unmarshal([]byte(`12.345`), &percent); err == nil {
+		t.Errorf("unmarshalling 12.345 gave %d, want an error", percent)
+	}
+
+	encoded, err := json.Marshal(Percent(5000))
+	if err != nil || string(encoded) != `"50.00"` {
+		t.Errorf("marshalled %s, %v; want \"50.00\"", encoded, err)
+	}
+}
+
+func TestSupportedCurrency(t *testing.T) {
+	for code, want := range map[string]bool{"ZAR": true, "USD": true, "EUR": true, "JPY": false, "KWD": false, "CLF": false, "XAU": false} {
+		if got := SupportedCurrency(code); got != want {
+			t.Errorf("SupportedCurrency(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
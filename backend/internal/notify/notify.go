@@ -0,0 +1,79 @@
+// internal/notify/notify.go
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"debt-tracker-backend/internal/mail"
+	"debt-tracker-backend/internal/models"
+)
+
+// Notifier delivers a notification to the user it is for. NewEmail and
+// NewWebhook deliver for real; NewLog is a stand-in for local development
+// and tests.
+type Notifier interface {
+	Notify(user models.User, notification models.Notification) error
+}
+
+// Options selects and configures a Notifier.
+type Options struct {
+	// Driver is "email", "webhook" or "log".
+	Driver string
+
+	// Mailer sends the messages of the email driver.
+	Mailer mail.Mailer
+
+	// WebhookURL receives the notifications of the webhook driver. With a
+	// WebhookSecret, each request is signed.
+	WebhookURL    string
+	WebhookSecret string
+}
+
+func New(opts Options) (Notifier, error) {
+	switch opts.Driver {
+	case "email":
+		if opts.Mailer == nil {
+			return nil, fmt.Errorf("the email notifier requires a mailer")
+		}
+		return NewEmail(opts.Mailer), nil
+	case "webhook":
+		if opts.WebhookURL == "" {
+			return nil, fmt.Errorf("the webhook notifier requires a URL")
+		}
+		return NewWebhook(opts.WebhookURL, opts.WebhookSecret), nil
+	case "log":
+		return NewLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", opts.Driver)
+	}
+}
+
+// Email sends notifications to the user's email address.
+type Email struct {
+	mailer mail.Mailer
+}
+
+func NewEmail(mailer mail.Mailer) Email {
+	return Email{mailer: mailer}
+}
+
+func (n Email) Notify(user models.User, notification models.Notification) error {
+	return n.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: notification.Subject,
+		Body:    notification.Body,
+	})
+}
+
+// Log writes notifications to the server log instead of delivering them.
+type Log struct{}
+
+func NewLog() Log {
+	return Log{}
+}
+
+func (Log) Notify(user models.User, notification models.Notification) error {
+	log.Printf("Notification %d for user %d: %s\n%s", notification.ID, user.ID, notification.Subject, notification.Body)
+	return nil
+}
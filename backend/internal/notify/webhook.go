@@ -0,0 +1,77 @@
+// internal/notify/webhook.go
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// Webhook posts each notification as JSON to a URL. With a secret, the
+// X-Signature header carries the hex HMAC-SHA256 of the body, so that the
+// receiver can check where it came from.
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type webhookPayload struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	DebtID    *int      `json:"debt_id,omitempty"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (n *Webhook) Notify(user models.User, notification models.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:        notification.ID,
+		Kind:      notification.Kind,
+		UserID:    user.ID,
+		Email:     user.Email,
+		DebtID:    notification.DebtID,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+		CreatedAt: notification.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
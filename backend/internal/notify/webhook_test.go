@@ -0,0 +1,67 @@
+// internal/notify/webhook_test.go
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"debt-tracker-backend/internal/models"
+)
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Header.Get("X-Signature") != want {
+			t.Errorf("signature is %q, want %q", r.Header.Get("X-Signature"), want)
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL, "secret")
+	user := models.User{ID: 7, Email: "alice@example.com"}
+	debtID := 3
+	notification := models.Notification{ID: 1, UserID: 7, DebtID: &debtID, Kind: "overdue", Subject: "Late", Body: "Pay up"}
+	if err := webhook.Notify(user, notification); err != nil {
+		t.Fatal(err)
+	}
+	if got.Email != user.Email || got.Kind != "overdue" || got.DebtID == nil || *got.DebtID != debtID {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	status = http.StatusBadGateway
+	if err := webhook.Notify(user, notification); err == nil {
+		t.Error("a failed webhook reported success")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, opts := range []Options{
+		{Driver: "email"},
+		{Driver: "webhook"},
+		{Driver: "sms"},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", opts)
+		}
+	}
+	if _, err := New(Options{Driver: "log"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,84 @@
+// internal/rates/file.go
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// File reads snapshots from a local JSON file of the form
+//
+//	{
+//	  "base": "USD",
+//	  "rates": {
+//	    "2025-06-30": {"ZAR": "17.75", "EUR": "0.853"}
+//	  }
+//	}
+//
+// where each rate is how many units of the currency one unit of the base
+// bought that day. The base currency is added at a rate of 1. The file is
+// read again on every call, so it can be updated while the server runs.
+type File struct {
+	path string
+}
+
+func NewFile(path string) File {
+	return File{path: path}
+}
+
+type rateFile struct {
+	Base  string                       `json:"base"`
+	Rates map[string]map[string]string `json:"rates"`
+}
+
+func (f File) Rates() ([]models.ExchangeRate, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	if !currencyCode.MatchString(file.Base) {
+		return nil, fmt.Errorf("%s: invalid base currency %q", f.path, file.Base)
+	}
+
+	var rates []models.ExchangeRate
+	for day, quotes := range file.Rates {
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q", f.path, day)
+		}
+
+		rates = append(rates, models.ExchangeRate{Currency: file.Base, Date: day, Rate: "1"})
+		for currency, rate := range quotes {
+			if currency == file.Base {
+				continue
+			}
+			if !currencyCode.MatchString(currency) {
+				return nil, fmt.Errorf("%s: invalid currency %q on %s", f.path, currency, day)
+			}
+			if _, err := ParseRate(rate); err != nil {
+				return nil, fmt.Errorf("%s: %w for %s on %s", f.path, err, currency, day)
+			}
+			rates = append(rates, models.ExchangeRate{Currency: currency, Date: day, Rate: rate})
+		}
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Date != rates[j].Date {
+			return rates[i].Date < rates[j].Date
+		}
+		return rates[i].Currency < rates[j].Currency
+	})
+	return rates, nil
+}
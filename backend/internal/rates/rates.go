@@ -0,0 +1,109 @@
+// internal/rates/rates.go
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+// ErrNoRate is returned when a currency has no rate on or before the day
+// of a conversion.
+var ErrNoRate = errors.New("no exchange rate")
+
+// Provider supplies exchange rate snapshots, which the load job stores.
+// NewFile reads them from a local file; any other source only needs to
+// implement Rates.
+type Provider interface {
+	// Rates returns the snapshots the provider has, every one of them
+	// quoted against the same base currency.
+	Rates() ([]models.ExchangeRate, error)
+}
+
+// Day is the day a rate snapshot applies to for a point in time.
+func Day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// ParseRate parses the decimal string of a rate, which must be positive.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", s)
+	}
+	return rate, nil
+}
+
+// Converter converts money between currencies with the stored snapshots.
+// It caches the rates it looks up, so it is meant to serve one request.
+type Converter struct {
+	rates store.ExchangeRateStore
+	cache map[[2]string]*big.Rat
+}
+
+func NewConverter(rates store.ExchangeRateStore) *Converter {
+	return &Converter{rates: rates, cache: make(map[[2]string]*big.Rat)}
+}
+
+// Convert converts the amount into the currency at the latest rates on or
+// before the day of at, rounding half away from zero to the cent. It
+// returns ErrNoRate if either currency has no such rate.
+func (c *Converter) Convert(amount models.Money, currency string, at time.Time) (models.Money, error) {
+	if amount.Currency == currency {
+		return amount, nil
+	}
+
+	day := Day(at)
+	from, err := c.rate(amount.Currency, day)
+	if err != nil {
+		return models.Money{}, err
+	}
+	to, err := c.rate(currency, day)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	value := new(big.Rat).SetInt64(amount.Minor)
+	value.Mul(value, to).Quo(value, from)
+	minor, err := round(value)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return models.NewMoney(minor, currency), nil
+}
+
+func (c *Converter) rate(currency, day string) (*big.Rat, error) {
+	key := [2]string{currency, day}
+	if rate, ok := c.cache[key]; ok {
+		return rate, nil
+	}
+
+	stored, err := c.rates.Get(currency, day)
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("%w for %s on %s", ErrNoRate, currency, day)
+	} else if err != nil {
+		return nil, err
+	}
+	rate, err := ParseRate(stored.Rate)
+	if err != nil {
+		return nil, err
+	}
+	c.cache[key] = rate
+	return rate, nil
+}
+
+// round rounds to the nearest integer, halves away from zero.
+func round(value *big.Rat) (int64, error) {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Lsh(remainder.Abs(remainder), 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return 0, errors.New("converted amount is out of range")
+	}
+	return quotient.Int64(), nil
+}
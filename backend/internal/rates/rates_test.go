@@ -0,0 +1,107 @@
+// internal/rates/rates_test.go
+package rates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+	"debt-tracker-backend/internal/store"
+)
+
+func TestConvert(t *testing.T) {
+	st := store.NewMemory()
+	err := st.ExchangeRates().Save([]models.ExchangeRate{
+		{Currency: "USD", Date: "2025-06-02", Rate: "1"},
+		{Currency: "ZAR", Date: "2025-06-02", Rate: "18"},
+		{Currency: "EUR", Date: "2025-06-02", Rate: "0.9"},
+		{Currency: "USD", Date: "2025-06-30", Rate: "1"},
+		{Currency: "ZAR", Date: "2025-06-30", Rate: "17.75"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	june := func(day int) time.Time { return time.Date(2025, 6, day, 12, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		minor    int64
+		from, to string
+		at       time.Time
+		want     int64
+		err      error
+	}{
+		{minor: 1800, from: "ZAR", to: "USD", at: june(2), want: 100},
+		{minor: 1800, from: "ZAR", to: "USD", at: june(15), want: 100},
+		{minor: 1775, from: "ZAR", to: "USD", at: june(30), want: 100},
+		{minor: 100, from: "USD", to: "ZAR", at: june(30), want: 1775},
+		{minor: 1000, from: "EUR", to: "ZAR", at: june(2), want: 20000},
+		// A rand cent is 1/18 of a US cent, so 9 of them are half a cent,
+		// which rounds away from zero.
+		{minor: 1, from: "ZAR", to: "USD", at: june(2), want: 0},
+		{minor: 9, from: "ZAR", to: "USD", at: june(2), want: 1},
+		{minor: -9, from: "ZAR", to: "USD", at: june(2), want: -1},
+		// The euro has no rate on the 30th and uses that of the 2nd.
+		{minor: 1000, from: "EUR", to: "USD", at: june(30), want: 1111},
+		{minor: 100, from: "GBP", to: "ZAR", at: june(30), err: ErrNoRate},
+		{minor: 100, from: "ZAR", to: "USD", at: june(1), err: ErrNoRate},
+		{minor: 100, from: "GBP", to: "GBP", at: june(1), want: 100},
+	}
+	for _, tc := range cases {
+		converter := NewConverter(st.ExchangeRates())
+		got, err := converter.Convert(models.NewMoney(tc.minor, tc.from), tc.to, tc.at)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("converting %d %s to %s gave %v, %v; want %v", tc.minor, tc.from, tc.to, got, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || got.Minor != tc.want || got.Currency != tc.to {
+			t.Errorf("converting %d %s to %s gave %d %s, %v; want %d", tc.minor, tc.from, tc.to, got.Minor, got.Currency, err, tc.want)
+		}
+	}
+}
+
+func TestFileRates(t *testing.T) {
+	write := func(content string) File {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "rates.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return NewFile(path)
+	}
+
+	rates, err := write(`{"base": "USD", "rates": {"2025-06-30": {"ZAR": "17.75", "EUR": "0.853"}}}`).Rates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ExchangeRate{
+		{Currency: "EUR", Date: "2025-06-30", Rate: "0.853"},
+		{Currency: "USD", Date: "2025-06-30", Rate: "1"},
+		{Currency: "ZAR", Date: "2025-06-30", Rate: "17.75"},
+	}
+	if len(rates) != len(want) {
+		t.Fatalf("rates are %v, want %v", rates, want)
+	}
+	for i := range want {
+		if rates[i] != want[i] {
+			t.Errorf("rate %d is %v, want %v", i, rates[i], want[i])
+		}
+	}
+
+	for _, content := range []string{
+		`{"base": "usd", "rates": {}}`,
+		`{"base": "USD", "rates": {"30 June": {"ZAR": "17.75"}}}`,
+		`{"base": "USD", "rates": {"2025-06-30": {"RAND": "17.75"}}}`,
+		`{"base": "USD", "rates": {"2025-06-30": {"ZAR": "0"}}}`,
+		`{"base": "USD", "rates": {"2025-06-30": {"ZAR": "a lot"}}}`,
+		`not json`,
+	} {
+		if rates, err := write(content).Rates(); err == nil {
+			t.Errorf("reading %s gave %v, want an error", content, rates)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// internal/recurrence/recurrence.go
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a Rule.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// maxIdlePeriods bounds how many periods in a row may pass without an
+// occurrence before a rule is taken to have none left, as with
+// BYMONTHDAY=31 every twelve months from February.
+const maxIdlePeriods = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Rule is the subset of the iCalendar RRULE (RFC 5545) that schedules
+// use: a FREQ of DAILY, WEEKLY or MONTHLY with an INTERVAL, BYDAY for
+// weekly rules, BYMONTHDAY for monthly ones, and an end given by COUNT or
+// UNTIL. Occurrences are whole days, counted from a start day that is
+// always the first of them when it matches the rule. As in RFC 5545, a
+// monthly rule skips months without the day, so the last day of every
+// month is BYMONTHDAY=-1 rather than 31.
+type Rule struct {
+	Freq     string
+	Interval int
+	// ByDay defaults to the weekday of the start day.
+	ByDay []time.Weekday
+	// ByMonthDay defaults to the day of the month of the start day.
+	// Negative days count back from the end of the month.
+	ByMonthDay []int
+	// Count ends the rule after that many occurrences and Until after
+	// that day; zero values mean no end.
+	Count int
+	Until time.Time
+}
+
+// Parse reads a rule such as "FREQ=MONTHLY;BYMONTHDAY=1;COUNT=12". An
+// "RRULE:" prefix is allowed.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return rule, errors.New("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return rule, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			// A time of day is allowed but only the day counts.
+			day, _, _ := strings.Cut(value, "T")
+			until, err := time.Parse("20060102", day)
+			if err != nil {
+				return rule, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := weekdays[code]
+				if !ok {
+					return rule, fmt.Errorf("unsupported BYDAY %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(item)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", item)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return rule, errors.New("only WKST=MO is supported")
+			}
+		default:
+			return rule, fmt.Errorf("unsupported recurrence rule part %q", name)
+		}
+	}
+
+	return rule, rule.Validate()
+}
+
+// Validate checks that the rule stays within the supported subset.
+func (r Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return errors.New("recurrence rule needs a FREQ")
+	default:
+		return fmt.Errorf("unsupported FREQ %q; use DAILY, WEEKLY or MONTHLY", r.Freq)
+	}
+	if r.Interval < 1 {
+		return errors.New("INTERVAL must be at least 1")
+	}
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("a recurrence rule cannot have both COUNT and UNTIL")
+	}
+	return nil
+}
+
+// String formats the rule in RRULE syntax, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, weekday := range r.ByDay {
+			codes[i] = strings.ToUpper(weekday.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterate calls fn with each occurrence of the rule from the start day in
+// order, until fn returns false or the rule ends.
+func (r Rule) Iterate(start time.Time, fn func(day time.Time) bool) {
+	start = Truncate(start)
+	count := 0
+	idle := 0
+	for period := 0; idle < maxIdlePeriods; period++ {
+		days := r.period(start, period)
+		idle++
+		for _, day := range days {
+			if day.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && day.After(r.Until) {
+				return
+			}
+			idle = 0
+			count++
+			if !fn(day) || (r.Count > 0 && count >= r.Count) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the days of the rule in the given period after the one
+// holding the start day, in order.
+func (r Rule) period(start time.Time, period int) []time.Time {
+	switch r.Freq {
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, period*r.Interval)}
+
+	case Weekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		// Weeks start on Monday.
+		monday := start.AddDate(0, 0, -mondayOffset(start.Weekday())+7*period*r.Interval)
+		days := make([]time.Time, 0, len(byDay))
+		for _, weekday := range byDay {
+			days = append(days, monday.AddDate(0, 0, mondayOffset(weekday)))
+		}
+		return sorted(days)
+
+	default:
+		byMonthDay := r.ByMonthDay
+		if len(byMonthDay) == 0 {
+			byMonthDay = []int{start.Day()}
+		}
+		first := time.Date(start.Year(), start.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		length := first.AddDate(0, 1, -1).Day()
+		days := make([]time.Time, 0, len(byMonthDay))
+		for _, day := range byMonthDay {
+			if day < 0 {
+				day += length + 1
+			}
+			if day >= 1 && day <= length {
+				days = append(days, first.AddDate(0, 0, day-1))
+			}
+		}
+		return sorted(days)
+	}
+}
+
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// sorted orders the days and drops duplicates, such as BYMONTHDAY=31,-1
+// in a month of 31 days.
+func sorted(days []time.Time) []time.Time {
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	unique := days[:0]
+	for _, day := range days {
+		if len(unique) == 0 || !unique[len(unique)-1].Equal(day) {
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
+
+// Next returns the first occurrence on or after the day, and false if
+// there is none.
+func (r Rule) Next(start, day time.Time) (time.Time, bool) {
+	day = Truncate(day)
+	var next time.Time
+	found := false
+	r.Iterate(start, func(occurrence time.Time) bool {
+		if occurrence.Before(day) {
+			return true
+		}
+		next, found = occurrence, true
+		return false
+	})
+	return next, found
+}
+
+// Upcoming returns at most limit occurrences on or after the day.
+func (r Rule) Upcoming(start, day time.Time, limit int) []time.Time {
+	day = Truncate(day)
+	var upcoming []time.Time
+	if limit <= 0 {
+		return upcoming
+	}
+	r.Iterate(start, func(occurrence time.Time) bool {
+		if !occurrence.Before(day) {
+			upcoming = append(upcoming, occurrence)
+		}
+		return len(upcoming) < limit
+	})
+	return upcoming
+}
+
+// Includes reports whether the day is an occurrence of the rule.
+func (r Rule) Includes(start, day time.Time) bool {
+	next, ok := r.Next(start, day)
+	return ok && next.Equal(Truncate(day))
+}
+
+// Truncate returns the UTC day of t as midnight.
+func Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDay reads a day such as "2025-07-01".
+func ParseDay(s string) (time.Time, error) {
+	return time.Parse(time.DateOnly, s)
+}
+
+// FormatDay formats a day as "2025-07-01".
+func FormatDay(day time.Time) string {
+	return day.Format(time.DateOnly)
+}
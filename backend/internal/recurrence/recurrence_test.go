@@ -0,0 +1,150 @@
+// internal/recurrence/recurrence_test.go
+package recurrence
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func day(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := ParseDay(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4"},
+		{"freq=monthly;bymonthday=-1;until=20251231T000000Z", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20251231"},
+		{"FREQ=MONTHLY;INTERVAL=1;WKST=MO", "FREQ=MONTHLY"},
+	}
+	for _, tc := range cases {
+		rule, err := Parse(tc.rule)
+		if err != nil || rule.String() != tc.want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", tc.rule, rule, err, tc.want)
+		}
+	}
+
+	for _, invalid := range []string{
+		"",
+		"FREQ",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;BYHOUR=9",
+	} {
+		if rule, err := Parse(invalid); err == nil {
+			t.Errorf("Parse(%q) = %q, want an error", invalid, rule)
+		}
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		start string
+		from  string
+		limit int
+		want  []string
+	}{
+		{
+			name: "last day of the month", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: "2025-01-15", limit: 4,
+			want: []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"},
+		},
+		{
+			name: "months without the day are skipped", rule: "FREQ=MONTHLY", start: "2025-01-31", limit: 4,
+			want: []string{"2025-01-31", "2025-03-31", "2025-05-31", "2025-07-31"},
+		},
+		{
+			name: "leap days", rule: "FREQ=MONTHLY;INTERVAL=12", start: "2024-02-29", limit: 2,
+			want: []string{"2024-02-29", "2028-02-29"},
+		},
+		{
+			name: "days that never come", rule: "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30", start: "2025-02-01", limit: 2,
+		},
+		{
+			name: "weekdays after the start", rule: "FREQ=WEEKLY;BYDAY=MO,FR", start: "2025-07-02", limit: 4,
+			want: []string{"2025-07-04", "2025-07-07", "2025-07-11", "2025-07-14"},
+		},
+		{
+			name: "every other week", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", start: "2025-07-01", limit: 3,
+			want: []string{"2025-07-01", "2025-07-15", "2025-07-29"},
+		},
+		{
+			name: "count", rule: "FREQ=DAILY;INTERVAL=3;COUNT=3", start: "2025-07-01", limit: 10,
+			want: []string{"2025-07-01", "2025-07-04", "2025-07-07"},
+		},
+		{
+			name: "count from a start that does not match", rule: "FREQ=WEEKLY;BYDAY=MO;COUNT=2", start: "2025-07-02", limit: 10,
+			want: []string{"2025-07-07", "2025-07-14"},
+		},
+		{
+			name: "count includes past occurrences", rule: "FREQ=MONTHLY;COUNT=3", start: "2025-01-10", from: "2025-02-15", limit: 10,
+			want: []string{"2025-03-10"},
+		},
+		{
+			name: "until is inclusive", rule: "FREQ=WEEKLY;UNTIL=20250715", start: "2025-07-01", limit: 10,
+			want: []string{"2025-07-01", "2025-07-08", "2025-07-15"},
+		},
+	}
+	for _, tc := range cases {
+		rule, err := Parse(tc.rule)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		from := tc.from
+		if from == "" {
+			from = tc.start
+		}
+		var got []string
+		for _, occurrence := range rule.Upcoming(day(t, tc.start), day(t, from), tc.limit) {
+			got = append(got, FormatDay(occurrence))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: occurrences are %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	rule, err := Parse("FREQ=MONTHLY;BYMONTHDAY=-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := day(t, "2025-01-31")
+	for s, want := range map[string]bool{
+		"2025-01-31": true,
+		"2025-02-28": true,
+		"2025-02-27": false,
+		"2024-12-31": false,
+	} {
+		// A time of day does not matter.
+		at := day(t, s).Add(15 * time.Hour)
+		if got := rule.Includes(start, at); got != want {
+			t.Errorf("Includes(%s) = %v, want %v", s, got, want)
+		}
+	}
+
+	if next, ok := rule.Next(start, day(t, "2025-02-01")); !ok || FormatDay(next) != "2025-02-28" {
+		t.Errorf("Next after 1 February is %s, %v; want 2025-02-28", FormatDay(next), ok)
+	}
+}
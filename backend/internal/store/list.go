@@ -0,0 +1,315 @@
+// internal/store/list.go
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ErrInvalidQuery is wrapped by errors about unknown sort fields and
+// malformed cursors.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// ListOptions selects one page of a list. Pages are keyed by cursors
+// rather than offsets, so rows added while paging do not shift the pages
+// that follow.
+type ListOptions struct {
+	Limit int
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Sort names the field to order by; empty uses the list's default.
+	Sort string
+	// Order is "asc" or "desc". It defaults to ascending when Sort is set.
+	Order string
+}
+
+type ContactFilter struct {
+	IncludeInactive bool
+	CreatedFrom     *time.Time
+	CreatedBefore   *time.Time
+}
+
+type DebtFilter struct {
+	// Statuses defaults to just "active".
+	Statuses      []string
+	Direction     string
+	ContactID     int
+	Confirmation  string
+	ExpenseID     int
+	GroupID       int // debts split from the expenses of a group
+	Currency      string
+	HasDueDate    bool
+	MinAmount     *models.Money
+	MaxAmount     *models.Money
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+}
+
+type TransactionFilter struct {
+	DebtID          int
+	ContactID       int
+	TransactionType string
+	Confirmation    string
+	MinAmount       *models.Money
+	MaxAmount       *models.Money
+	CreatedFrom     *time.Time
+	CreatedBefore   *time.Time
+}
+
+type sortKind int
+
+const (
+	sortText sortKind = iota
+	sortTime
+	sortMoney
+)
+
+// sortField is a field a list can be ordered by. expr is the SQL
+// expression it corresponds to.
+type sortField struct {
+	expr string
+	kind sortKind
+}
+
+var contactSorts = map[string]sortField{
+	"name":       {expr: "name", kind: sortText},
+	"created_at": {expr: "created_at", kind: sortTime},
+}
+
+var debtSorts = map[string]sortField{
+	"created_at": {expr: "d.created_at", kind: sortTime},
+	"updated_at": {expr: "d.updated_at", kind: sortTime},
+	"amount":     {expr: "d.amount", kind: sortMoney},
+	"balance":    {expr: "(" + debtBalanceExpr + ")", kind: sortMoney},
+}
+
+var transactionSorts = map[string]sortField{
+	"created_at": {expr: "t.created_at", kind: sortTime},
+	"amount":     {expr: "t.amount", kind: sortMoney},
+}
+
+func contactKey(contact models.Contact, field string) (interface{}, int) {
+	if field == "name" {
+		return contact.Name, contact.ID
+	}
+	return contact.CreatedAt, contact.ID
+}
+
+func debtKey(debt models.Debt, field string) (interface{}, int) {
+	switch field {
+	case "updated_at":
+		return debt.UpdatedAt, debt.ID
+	case "amount":
+		return debt.OriginalAmount.Minor, debt.ID
+	case "balance":
+		return debt.Balance.Minor, debt.ID
+	}
+	return debt.CreatedAt, debt.ID
+}
+
+func transactionKey(transaction models.Transaction, field string) (interface{}, int) {
+	if field == "amount" {
+		return transaction.Amount.Minor, transaction.ID
+	}
+	return transaction.CreatedAt, transaction.ID
+}
+
+type sortSpec struct {
+	field string
+	desc  bool
+}
+
+func (s sortSpec) String() string {
+	if s.desc {
+		return s.field + ":desc"
+	}
+	return s.field + ":asc"
+}
+
+func resolveSort(fields map[string]sortField, defaultSort sortSpec, opts ListOptions) (sortSpec, error) {
+	if opts.Sort == "" && opts.Order == "" {
+		return defaultSort, nil
+	}
+
+	spec := sortSpec{field: opts.Sort}
+	if spec.field == "" {
+		spec.field = defaultSort.field
+	}
+	if _, ok := fields[spec.field]; !ok {
+		return spec, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, spec.field)
+	}
+
+	switch opts.Order {
+	case "", "asc":
+	case "desc":
+		spec.desc = true
+	default:
+		return spec, fmt.Errorf("%w: order must be \"asc\" or \"desc\"", ErrInvalidQuery)
+	}
+	return spec, nil
+}
+
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// All follows the cursors of a list method and returns the items of every
+// page, for callers that need the complete set.
+func All[T any](list func(opts ListOptions) (models.Page[T], error)) ([]T, error) {
+	items := []T{}
+	err := Each(ListOptions{}, list, func(page []T) error {
+		items = append(items, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Each follows the cursors of a list method from the first page in the
+// sort of opts, and calls fn with the items of each page, so that callers
+// such as exports never hold more than one page. fn is called at least
+// once, with no items if nothing matches.
+func Each[T any](opts ListOptions, list func(opts ListOptions) (models.Page[T], error), fn func(page []T) error) error {
+	opts.Limit, opts.Cursor = MaxLimit, ""
+	for {
+		page, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(page.Data); err != nil {
+			return err
+		}
+		if page.NextCursor == nil {
+			return nil
+		}
+		opts.Cursor = *page.NextCursor
+	}
+}
+
+// cursor identifies the last row of a page by its sort value and ID. It
+// records the sort it was issued for so it cannot be replayed against a
+// different ordering.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(spec sortSpec, value string, id int) *string {
+	data, _ := json.Marshal(cursor{Sort: spec.String(), Value: value, ID: id})
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return &encoded
+}
+
+func decodeCursor(encoded string, spec sortSpec) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.Sort != spec.String() {
+		return c, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidQuery)
+	}
+	return c, nil
+}
+
+// sqlTime formats a time the way CURRENT_TIMESTAMP stores it, so that
+// comparisons also work against SQLite's text timestamps.
+func sqlTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
+// creationTime is the value to insert into created_at: the time a record
+// was created if the caller gave one, as imports do, or NULL for now.
+func creationTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return sqlTime(t)
+}
+
+// sortValue converts a cursor value back into a value comparable with the
+// sort field.
+func sortValue(kind sortKind, value string) (interface{}, error) {
+	switch kind {
+	case sortMoney:
+		minor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		return minor, nil
+	case sortTime:
+		t, err := time.Parse("2006-01-02 15:04:05.999999", value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		return t, nil
+	default:
+		return value, nil
+	}
+}
+
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
+
+func formatSortValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return sqlTime(v)
+	default:
+		return v.(string)
+	}
+}
+
+// sqlSortValue returns a cursor value as a query argument. Times are passed
+// as text for the same reason as in sqlTime.
+func sqlSortValue(kind sortKind, value string) (interface{}, error) {
+	v, err := sortValue(kind, value)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := v.(time.Time); ok {
+		return sqlTime(t), nil
+	}
+	return v, nil
+}
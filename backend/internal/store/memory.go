@@ -0,0 +1,2264 @@
+// internal/store/memory.go
+package store
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// Memory is an in-memory Store for tests. It mirrors the behaviour of the
+// SQL store, including derived balances, but keeps nothing on disk.
+// Transactions hold an exclusive lock for their whole duration and are
+// rolled back by restoring a snapshot.
+type Memory struct {
+	*memoryData
+	inTx bool
+}
+
+var _ Store = (*Memory)(nil)
+
+type memoryData struct {
+	mu           sync.Mutex
+	nextID       int
+	users        map[int]models.User
+	contacts     map[int]models.Contact
+	debts        map[int]models.Debt
+	transactions map[int]models.Transaction
+	refresh      map[int]models.RefreshToken
+	revoked      map[string]time.Time // access token ID to expiry
+	attempts     map[string]models.LoginAttempt
+	twoFactor    map[int]models.TwoFactor // by user ID
+	recovery     map[int]recoveryCode
+	apiKeys      map[int]models.APIKey
+	invites      map[int]models.ContactInvite
+	changes      map[int]models.DebtChange
+	groups       map[int]models.Group
+	members      map[groupMember]time.Time // when the contact joined
+	expenses     map[int]models.Expense
+	rates        map[rateDay]models.ExchangeRate
+	schedules    map[int]models.Schedule
+	occurrences  map[occurrenceDay]models.ScheduleOccurrence
+	outbox       map[int]models.Notification // queued notifications
+	profiles     map[int]models.ImportProfile
+}
+
+type groupMember struct {
+	groupID   int
+	contactID int
+}
+
+type rateDay struct {
+	currency string
+	day      string
+}
+
+type occurrenceDay struct {
+	scheduleID int
+	day        string
+}
+
+type recoveryCode struct {
+	userID int
+	hash   string
+	used   bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{memoryData: &memoryData{
+		users:        make(map[int]models.User),
+		contacts:     make(map[int]models.Contact),
+		debts:        make(map[int]models.Debt),
+		transactions: make(map[int]models.Transaction),
+		refresh:      make(map[int]models.RefreshToken),
+		revoked:      make(map[string]time.Time),
+		attempts:     make(map[string]models.LoginAttempt),
+		twoFactor:    make(map[int]models.TwoFactor),
+		recovery:     make(map[int]recoveryCode),
+		apiKeys:      make(map[int]models.APIKey),
+		invites:      make(map[int]models.ContactInvite),
+		changes:      make(map[int]models.DebtChange),
+		groups:       make(map[int]models.Group),
+		members:      make(map[groupMember]time.Time),
+		expenses:     make(map[int]models.Expense),
+		rates:        make(map[rateDay]models.ExchangeRate),
+		schedules:    make(map[int]models.Schedule),
+		occurrences:  make(map[occurrenceDay]models.ScheduleOccurrence),
+		outbox:       make(map[int]models.Notification),
+		profiles:     make(map[int]models.ImportProfile),
+	}}
+}
+
+func (m *Memory) Users() UserStore                   { return memoryUsers{m} }
+func (m *Memory) Contacts() ContactStore             { return memoryContacts{m} }
+func (m *Memory) Debts() DebtStore                   { return memoryDebts{m} }
+func (m *Memory) Transactions() TransactionStore     { return memoryTransactions{m} }
+func (m *Memory) Tokens() TokenStore                 { return memoryTokens{m} }
+func (m *Memory) LoginAttempts() LoginAttemptStore   { return memoryLoginAttempts{m} }
+func (m *Memory) TwoFactor() TwoFactorStore          { return memoryTwoFactor{m} }
+func (m *Memory) APIKeys() APIKeyStore               { return memoryAPIKeys{m} }
+func (m *Memory) Invites() InviteStore               { return memoryInvites{m} }
+func (m *Memory) DebtChanges() DebtChangeStore       { return memoryDebtChanges{m} }
+func (m *Memory) Groups() GroupStore                 { return memoryGroups{m} }
+func (m *Memory) Expenses() ExpenseStore             { return memoryExpenses{m} }
+func (m *Memory) ExchangeRates() ExchangeRateStore   { return memoryExchangeRates{m} }
+func (m *Memory) Schedules() ScheduleStore           { return memorySchedules{m} }
+func (m *Memory) Notifications() NotificationStore   { return memoryNotifications{m} }
+func (m *Memory) ImportProfiles() ImportProfileStore { return memoryImportProfiles{m} }
+
+func (m *Memory) WithinTx(fn func(Store) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.snapshot()
+	if err := fn(&Memory{memoryData: m.memoryData, inTx: true}); err != nil {
+		m.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// lock takes the store lock unless it is already held by the transaction
+// the call is part of.
+func (m *Memory) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (d *memoryData) id() int {
+	d.nextID++
+	return d.nextID
+}
+
+func (d *memoryData) snapshot() *memoryData {
+	snapshot := &memoryData{
+		nextID:       d.nextID,
+		users:        make(map[int]models.User, len(d.users)),
+		contacts:     make(map[int]models.Contact, len(d.contacts)),
+		debts:        make(map[int]models.Debt, len(d.debts)),
+		transactions: make(map[int]models.Transaction, len(d.transactions)),
+		refresh:      make(map[int]models.RefreshToken, len(d.refresh)),
+		revoked:      make(map[string]time.Time, len(d.revoked)),
+		attempts:     make(map[string]models.LoginAttempt, len(d.attempts)),
+		twoFactor:    make(map[int]models.TwoFactor, len(d.twoFactor)),
+		recovery:     make(map[int]recoveryCode, len(d.recovery)),
+		apiKeys:      make(map[int]models.APIKey, len(d.apiKeys)),
+		invites:      make(map[int]models.ContactInvite, len(d.invites)),
+		changes:      make(map[int]models.DebtChange, len(d.changes)),
+		groups:       make(map[int]models.Group, len(d.groups)),
+		members:      make(map[groupMember]time.Time, len(d.members)),
+		expenses:     make(map[int]models.Expense, len(d.expenses)),
+		rates:        make(map[rateDay]models.ExchangeRate, len(d.rates)),
+		schedules:    make(map[int]models.Schedule, len(d.schedules)),
+		occurrences:  make(map[occurrenceDay]models.ScheduleOccurrence, len(d.occurrences)),
+		outbox:       make(map[int]models.Notification, len(d.outbox)),
+		profiles:     make(map[int]models.ImportProfile, len(d.profiles)),
+	}
+	for id, user := range d.users {
+		snapshot.users[id] = user
+	}
+	for id, contact := range d.contacts {
+		snapshot.contacts[id] = contact
+	}
+	for id, debt := range d.debts {
+		snapshot.debts[id] = debt
+	}
+	for id, transaction := range d.transactions {
+		snapshot.transactions[id] = transaction
+	}
+	for id, token := range d.refresh {
+		snapshot.refresh[id] = token
+	}
+	for jti, expiresAt := range d.revoked {
+		snapshot.revoked[jti] = expiresAt
+	}
+	for email, attempt := range d.attempts {
+		snapshot.attempts[email] = attempt
+	}
+	for userID, twoFactor := range d.twoFactor {
+		snapshot.twoFactor[userID] = twoFactor
+	}
+	for id, code := range d.recovery {
+		snapshot.recovery[id] = code
+	}
+	for id, key := range d.apiKeys {
+		snapshot.apiKeys[id] = key
+	}
+	for id, invite := range d.invites {
+		snapshot.invites[id] = invite
+	}
+	for id, change := range d.changes {
+		snapshot.changes[id] = change
+	}
+	for id, group := range d.groups {
+		snapshot.groups[id] = group
+	}
+	for member, joinedAt := range d.members {
+		snapshot.members[member] = joinedAt
+	}
+	// Splits are never modified in place, so sharing them is safe.
+	for id, expense := range d.expenses {
+		snapshot.expenses[id] = expense
+	}
+	for key, rate := range d.rates {
+		snapshot.rates[key] = rate
+	}
+	for id, schedule := range d.schedules {
+		snapshot.schedules[id] = schedule
+	}
+	for key, occurrence := range d.occurrences {
+		snapshot.occurrences[key] = occurrence
+	}
+	for id, notification := range d.outbox {
+		snapshot.outbox[id] = notification
+	}
+	for id, profile := range d.profiles {
+		snapshot.profiles[id] = profile
+	}
+	return snapshot
+}
+
+func (d *memoryData) restore(snapshot *memoryData) {
+	d.nextID = snapshot.nextID
+	d.users = snapshot.users
+	d.contacts = snapshot.contacts
+	d.debts = snapshot.debts
+	d.transactions = snapshot.transactions
+	d.refresh = snapshot.refresh
+	d.revoked = snapshot.revoked
+	d.attempts = snapshot.attempts
+	d.twoFactor = snapshot.twoFactor
+	d.recovery = snapshot.recovery
+	d.apiKeys = snapshot.apiKeys
+	d.invites = snapshot.invites
+	d.changes = snapshot.changes
+	d.groups = snapshot.groups
+	d.members = snapshot.members
+	d.expenses = snapshot.expenses
+	d.rates = snapshot.rates
+	d.schedules = snapshot.schedules
+	d.occurrences = snapshot.occurrences
+	d.outbox = snapshot.outbox
+	d.profiles = snapshot.profiles
+}
+
+// user fills in the fields the SQL store derives from other tables.
+func (d *memoryData) user(user models.User) models.User {
+	twoFactor, ok := d.twoFactor[user.ID]
+	user.TwoFactorEnabled = ok && twoFactor.EnabledAt != nil
+	return user
+}
+
+// deleteDebt deletes a debt with its transactions, proposed changes,
+// notifications and the schedules of transactions on it.
+func (d *memoryData) deleteDebt(id int) {
+	for transactionID, transaction := range d.transactions {
+		if transaction.DebtID == id {
+			delete(d.transactions, transactionID)
+		}
+	}
+	for changeID, change := range d.changes {
+		if change.DebtID == id {
+			delete(d.changes, changeID)
+		}
+	}
+	for scheduleID, schedule := range d.schedules {
+		if schedule.DebtID != nil && *schedule.DebtID == id {
+			d.deleteSchedule(scheduleID)
+		}
+	}
+	for notificationID, notification := range d.outbox {
+		if notification.DebtID != nil && *notification.DebtID == id {
+			delete(d.outbox, notificationID)
+		}
+	}
+	delete(d.debts, id)
+}
+
+// deleteSchedule deletes a schedule with its occurrences.
+func (d *memoryData) deleteSchedule(id int) {
+	for key := range d.occurrences {
+		if key.scheduleID == id {
+			delete(d.occurrences, key)
+		}
+	}
+	delete(d.schedules, id)
+}
+
+// deleteExpense deletes an expense and unlinks the debts split from it.
+func (d *memoryData) deleteExpense(id int) {
+	for debtID, debt := range d.debts {
+		if debt.ExpenseID != nil && *debt.ExpenseID == id {
+			debt.ExpenseID = nil
+			d.debts[debtID] = debt
+		}
+	}
+	delete(d.expenses, id)
+}
+
+// deleteGroup deletes a group with its members and expenses.
+func (d *memoryData) deleteGroup(id int) {
+	for expenseID, expense := range d.expenses {
+		if expense.GroupID == id {
+			d.deleteExpense(expenseID)
+		}
+	}
+	for member := range d.members {
+		if member.groupID == id {
+			delete(d.members, member)
+		}
+	}
+	delete(d.groups, id)
+}
+
+// now is truncated to the precision of PostgreSQL timestamps, which is
+// also the precision cursors carry.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// createdAt is the creation time of a new record: t if the caller gave
+// one, as the SQL store keeps it, or now.
+func createdAt(t time.Time) time.Time {
+	if t.IsZero() {
+		return now()
+	}
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func inCreatedRange(createdAt time.Time, from, before *time.Time) bool {
+	return (from == nil || !createdAt.Before(*from)) && (before == nil || createdAt.Before(*before))
+}
+
+func inAmountRange(amount models.Money, min, max *models.Money) bool {
+	return (min == nil || amount.Minor >= min.Minor) && (max == nil || amount.Minor <= max.Minor)
+}
+
+// memoryPage sorts items and cuts out the page requested by opts, the same
+// way sqlPage does in SQL.
+func memoryPage[T any](items []T, sorts map[string]sortField, defaultSort sortSpec, opts ListOptions, key func(T, string) (interface{}, int)) (models.Page[T], error) {
+	page := models.Page[T]{Data: []T{}, Total: len(items)}
+
+	spec, err := resolveSort(sorts, defaultSort, opts)
+	if err != nil {
+		return page, err
+	}
+
+	compare := func(a, b T) int {
+		aValue, aID := key(a, spec.field)
+		bValue, bID := key(b, spec.field)
+		result := compareSortValues(aValue, bValue)
+		if result == 0 {
+			result = aID - bID
+		}
+		if spec.desc {
+			return -result
+		}
+		return result
+	}
+	sort.Slice(items, func(i, j int) bool { return compare(items[i], items[j]) < 0 })
+
+	start := 0
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, spec)
+		if err != nil {
+			return page, err
+		}
+		value, err := sortValue(sorts[spec.field].kind, c.Value)
+		if err != nil {
+			return page, err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			itemValue, itemID := key(items[i], spec.field)
+			result := compareSortValues(itemValue, value)
+			if result == 0 {
+				result = itemID - c.ID
+			}
+			if spec.desc {
+				result = -result
+			}
+			return result > 0
+		})
+	}
+
+	limit := pageLimit(opts.Limit)
+	end := start + limit
+	if end >= len(items) {
+		end = len(items)
+	} else {
+		value, id := key(items[end-1], spec.field)
+		page.NextCursor = encodeCursor(spec, formatSortValue(value), id)
+	}
+	page.Data = append(page.Data, items[start:end]...)
+	return page, nil
+}
+
+type memoryUsers struct {
+	m *Memory
+}
+
+func (s memoryUsers) Create(user *models.User) error {
+	defer s.m.lock()()
+
+	user.ID = s.m.id()
+	if user.DefaultCurrency == "" {
+		user.DefaultCurrency = models.DefaultCurrency
+	}
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+	s.m.users[user.ID] = *user
+	return nil
+}
+
+func (s memoryUsers) Get(id int) (models.User, error) {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	user.PasswordHash = ""
+	return s.m.user(user), nil
+}
+
+func (s memoryUsers) GetWithPassword(id int) (models.User, error) {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return s.m.user(user), nil
+}
+
+func (s memoryUsers) GetByEmail(email string) (models.User, error) {
+	defer s.m.lock()()
+
+	for _, user := range s.m.users {
+		if user.Email == email {
+			return s.m.user(user), nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (s memoryUsers) SetPassword(id int, passwordHash string) error {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = now()
+	s.m.users[id] = user
+	return nil
+}
+
+func (s memoryUsers) MarkEmailVerified(id int) error {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok || user.EmailVerifiedAt != nil {
+		return ErrNotFound
+	}
+	user.UpdatedAt = now()
+	user.EmailVerifiedAt = &user.UpdatedAt
+	s.m.users[id] = user
+	return nil
+}
+
+func (s memoryUsers) Update(user *models.User) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Name = user.Name
+	stored.Phone = user.Phone
+	stored.DefaultCurrency = user.DefaultCurrency
+	stored.UpdatedAt = now()
+	s.m.users[user.ID] = stored
+
+	*user = s.m.user(stored)
+	user.PasswordHash = ""
+	return nil
+}
+
+func (s memoryUsers) SetPendingEmail(id int, email *string) error {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.PendingEmail = email
+	user.UpdatedAt = now()
+	s.m.users[id] = user
+	return nil
+}
+
+func (s memoryUsers) ConfirmPendingEmail(id int) error {
+	defer s.m.lock()()
+
+	user, ok := s.m.users[id]
+	if !ok || user.PendingEmail == nil {
+		return ErrNotFound
+	}
+	user.Email = *user.PendingEmail
+	user.PendingEmail = nil
+	user.UpdatedAt = now()
+	user.EmailVerifiedAt = &user.UpdatedAt
+	s.m.users[id] = user
+	return nil
+}
+
+// Delete mirrors the cascading foreign keys of the SQL schema.
+func (s memoryUsers) Delete(id int) error {
+	defer s.m.lock()()
+
+	if _, ok := s.m.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.users, id)
+
+	for contactID, contact := range s.m.contacts {
+		if contact.UserID == id {
+			delete(s.m.contacts, contactID)
+		} else if contact.LinkedUserID != nil && *contact.LinkedUserID == id {
+			contact.LinkedUserID = nil
+			s.m.contacts[contactID] = contact
+		}
+	}
+	for debtID, debt := range s.m.debts {
+		if debt.UserID == id {
+			s.m.deleteDebt(debtID)
+		}
+	}
+	// Entries shared with the user are now the other users' own.
+	for debtID, debt := range s.m.debts {
+		if debt.CounterpartID != nil {
+			if _, ok := s.m.debts[*debt.CounterpartID]; !ok {
+				debt.Confirmation = "confirmed"
+				s.m.debts[debtID] = debt
+			}
+		}
+	}
+	for transactionID, transaction := range s.m.transactions {
+		if transaction.CounterpartID != nil {
+			if _, ok := s.m.transactions[*transaction.CounterpartID]; !ok {
+				transaction.Confirmation = "confirmed"
+				s.m.transactions[transactionID] = transaction
+			}
+		}
+	}
+	for groupID, group := range s.m.groups {
+		if group.UserID == id {
+			s.m.deleteGroup(groupID)
+		}
+	}
+	for scheduleID, schedule := range s.m.schedules {
+		if schedule.UserID == id {
+			s.m.deleteSchedule(scheduleID)
+		}
+	}
+	for notificationID, notification := range s.m.outbox {
+		if notification.UserID == id {
+			delete(s.m.outbox, notificationID)
+		}
+	}
+	for profileID, profile := range s.m.profiles {
+		if profile.UserID == id {
+			delete(s.m.profiles, profileID)
+		}
+	}
+	for inviteID, invite := range s.m.invites {
+		if invite.InviterID == id {
+			delete(s.m.invites, inviteID)
+		}
+	}
+	for tokenID, token := range s.m.refresh {
+		if token.UserID == id {
+			delete(s.m.refresh, tokenID)
+		}
+	}
+	delete(s.m.twoFactor, id)
+	for codeID, code := range s.m.recovery {
+		if code.userID == id {
+			delete(s.m.recovery, codeID)
+		}
+	}
+	for keyID, key := range s.m.apiKeys {
+		if key.UserID == id {
+			delete(s.m.apiKeys, keyID)
+		}
+	}
+	return nil
+}
+
+type memoryContacts struct {
+	m *Memory
+}
+
+func (s memoryContacts) List(userID int, filter ContactFilter, opts ListOptions) (models.Page[models.Contact], error) {
+	defer s.m.lock()()
+
+	var contacts []models.Contact
+	for _, contact := range s.m.contacts {
+		if contact.UserID == userID && (contact.IsActive || filter.IncludeInactive) &&
+			inCreatedRange(contact.CreatedAt, filter.CreatedFrom, filter.CreatedBefore) {
+			contacts = append(contacts, contact)
+		}
+	}
+	return memoryPage(contacts, contactSorts, sortSpec{field: "name"}, opts, contactKey)
+}
+
+func (s memoryContacts) Get(userID, id int) (models.Contact, error) {
+	defer s.m.lock()()
+
+	contact, ok := s.m.contacts[id]
+	if !ok || contact.UserID != userID {
+		return models.Contact{}, ErrNotFound
+	}
+	return contact, nil
+}
+
+func (s memoryContacts) Create(contact *models.Contact) error {
+	defer s.m.lock()()
+
+	contact.ID = s.m.id()
+	contact.IsActive = true
+	contact.CreatedAt = now()
+	contact.UpdatedAt = contact.CreatedAt
+	s.m.contacts[contact.ID] = *contact
+	return nil
+}
+
+func (s memoryContacts) Update(contact *models.Contact) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.contacts[contact.ID]
+	if !ok || stored.UserID != contact.UserID {
+		return ErrNotFound
+	}
+	stored.Name = contact.Name
+	stored.Phone = contact.Phone
+	stored.Email = contact.Email
+	stored.UpdatedAt = now()
+	s.m.contacts[stored.ID] = stored
+	*contact = stored
+	return nil
+}
+
+func (s memoryContacts) Deactivate(userID, id int) error {
+	defer s.m.lock()()
+
+	contact, ok := s.m.contacts[id]
+	if !ok || contact.UserID != userID {
+		return ErrNotFound
+	}
+	contact.IsActive = false
+	contact.UpdatedAt = now()
+	s.m.contacts[id] = contact
+	return nil
+}
+
+func (s memoryContacts) Link(userID, id, linkedUserID int) error {
+	defer s.m.lock()()
+
+	contact, ok := s.m.contacts[id]
+	if !ok || contact.UserID != userID || contact.LinkedUserID != nil {
+		return ErrNotFound
+	}
+	contact.LinkedUserID = &linkedUserID
+	contact.UpdatedAt = now()
+	s.m.contacts[id] = contact
+	return nil
+}
+
+func (s memoryContacts) GetLinked(userID, linkedUserID int) (models.Contact, error) {
+	defer s.m.lock()()
+
+	for _, contact := range s.m.contacts {
+		if contact.UserID == userID && contact.LinkedUserID != nil && *contact.LinkedUserID == linkedUserID {
+			return contact, nil
+		}
+	}
+	return models.Contact{}, ErrNotFound
+}
+
+type memoryDebts struct {
+	m *Memory
+}
+
+// ledger fills in the derived fields of a stored debt.
+func (s memoryDebts) ledger(debt models.Debt) models.Debt {
+	debt.PaidAmount = models.Money{}
+	debt.Balance = debt.OriginalAmount
+	debt.PendingAmount = models.Money{}
+	for _, transaction := range s.m.transactions {
+		if transaction.DebtID != debt.ID {
+			continue
+		}
+		amount := transaction.Amount
+		repayment := transaction.TransactionType != "lent" && transaction.TransactionType != "borrowed"
+		if repayment {
+			amount = amount.Neg()
+		}
+		switch transaction.Confirmation {
+		case "confirmed":
+			debt.Balance = debt.Balance.Add(amount)
+			if repayment {
+				debt.PaidAmount = debt.PaidAmount.Add(transaction.Amount)
+			}
+		case "pending":
+			debt.PendingAmount = debt.PendingAmount.Add(amount)
+		}
+	}
+	debt.Instalments = slices.Clone(debt.Instalments)
+	debt.SetDueStatus(today())
+	debt.SetCurrency(debt.Currency)
+
+	if debt.CounterpartID != nil {
+		if _, ok := s.m.debts[*debt.CounterpartID]; !ok {
+			debt.CounterpartID = nil
+		}
+	}
+	debt.Shared = debt.CounterpartID != nil
+
+	if contact, ok := s.m.contacts[debt.ContactID]; ok {
+		debt.Contact = &models.Contact{
+			ID: contact.ID, Name: contact.Name, Phone: contact.Phone, Email: contact.Email,
+			LinkedUserID: contact.LinkedUserID,
+		}
+	}
+	return debt
+}
+
+func (s memoryDebts) find(userID, id int) (models.Debt, bool) {
+	debt, ok := s.m.debts[id]
+	if !ok || debt.UserID != userID {
+		return models.Debt{}, false
+	}
+	return debt, true
+}
+
+func (s memoryDebts) List(userID int, filter DebtFilter, opts ListOptions) (models.Page[models.Debt], error) {
+	defer s.m.lock()()
+
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{"active"}
+	}
+
+	var debts []models.Debt
+	for _, debt := range s.m.debts {
+		if debt.UserID != userID || !slices.Contains(statuses, debt.Status) ||
+			(filter.Direction != "" && debt.Direction != filter.Direction) ||
+			(filter.ContactID != 0 && debt.ContactID != filter.ContactID) ||
+			(filter.Confirmation != "" && debt.Confirmation != filter.Confirmation) ||
+			(filter.ExpenseID != 0 && (debt.ExpenseID == nil || *debt.ExpenseID != filter.ExpenseID)) ||
+			(filter.GroupID != 0 && (debt.ExpenseID == nil || s.m.expenses[*debt.ExpenseID].GroupID != filter.GroupID)) ||
+			(filter.Currency != "" && debt.Currency != filter.Currency) ||
+			(filter.HasDueDate && debt.DueDate == nil) ||
+			!inAmountRange(debt.OriginalAmount, filter.MinAmount, filter.MaxAmount) ||
+			!inCreatedRange(debt.CreatedAt, filter.CreatedFrom, filter.CreatedBefore) {
+			continue
+		}
+		debts = append(debts, s.ledger(debt))
+	}
+	return memoryPage(debts, debtSorts, sortSpec{field: "created_at", desc: true}, opts, debtKey)
+}
+
+func (s memoryDebts) Get(userID, id int) (models.Debt, error) {
+	defer s.m.lock()()
+
+	debt, ok := s.find(userID, id)
+	if !ok {
+		return models.Debt{}, ErrNotFound
+	}
+	return s.ledger(debt), nil
+}
+
+// Lock needs no extra work: transactions already run exclusively.
+func (s memoryDebts) Lock(userID, id int) (models.Debt, error) {
+	return s.Get(userID, id)
+}
+
+func (s memoryDebts) Create(debt *models.Debt) error {
+	defer s.m.lock()()
+
+	if debt.Currency == "" {
+		debt.Currency = models.DefaultCurrency
+	}
+	if debt.Confirmation == "" {
+		debt.Confirmation = "confirmed"
+	}
+	debt.ID = s.m.id()
+	debt.Status = "active"
+	debt.Instalments = slices.Clone(debt.Instalments)
+	debt.Interest = cloneInterest(debt.Interest)
+	debt.CreatedAt = createdAt(debt.CreatedAt)
+	debt.UpdatedAt = debt.CreatedAt
+	s.m.debts[debt.ID] = *debt
+	*debt = s.ledger(*debt)
+	return nil
+}
+
+func (s memoryDebts) Update(debt *models.Debt) error {
+	defer s.m.lock()()
+
+	stored, ok := s.find(debt.UserID, debt.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	stored.OriginalAmount = debt.OriginalAmount
+	stored.Description = debt.Description
+	stored.Status = debt.Status
+	stored.UpdatedAt = now()
+	if stored.Status != "removed" {
+		stored.RemovedAt = nil
+	} else if stored.RemovedAt == nil {
+		stored.RemovedAt = &stored.UpdatedAt
+	}
+	s.m.debts[stored.ID] = stored
+	*debt = s.ledger(stored)
+	return nil
+}
+
+func (s memoryDebts) Remove(userID, id int) error {
+	defer s.m.lock()()
+
+	debt, ok := s.find(userID, id)
+	if !ok {
+		return ErrNotFound
+	}
+	debt.Status = "removed"
+	debt.UpdatedAt = now()
+	if debt.RemovedAt == nil {
+		debt.RemovedAt = &debt.UpdatedAt
+	}
+	s.m.debts[id] = debt
+	return nil
+}
+
+func (s memoryDebts) Restore(userID, id int) error {
+	defer s.m.lock()()
+
+	debt, ok := s.find(userID, id)
+	if !ok || debt.Status != "removed" {
+		return ErrNotFound
+	}
+	debt.Status = "active"
+	debt.RemovedAt = nil
+	debt.UpdatedAt = now()
+	s.m.debts[id] = debt
+	return nil
+}
+
+func (s memoryDebts) PurgeRemoved(before time.Time) (int, error) {
+	defer s.m.lock()()
+
+	purged := 0
+	for id, debt := range s.m.debts {
+		if debt.Status != "removed" || debt.RemovedAt == nil || !debt.RemovedAt.Before(before) {
+			continue
+		}
+		s.m.deleteDebt(id)
+		purged++
+	}
+	return purged, nil
+}
+
+func (s memoryDebts) TransactionCount(id int) (int, error) {
+	defer s.m.lock()()
+
+	count := 0
+	for _, transaction := range s.m.transactions {
+		if transaction.DebtID == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s memoryDebts) SyncStatus(id int) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if debt.Status != "active" && debt.Status != "settled" {
+		return nil
+	}
+
+	status := "active"
+	if !s.ledger(debt).Balance.IsPositive() {
+		status = "settled"
+	}
+	if debt.Status != status {
+		debt.Status = status
+		debt.UpdatedAt = now()
+		s.m.debts[id] = debt
+	}
+	return nil
+}
+
+func (s memoryDebts) Summary(userID int) (models.DebtSummary, error) {
+	defer s.m.lock()()
+
+	summary := models.DebtSummary{ByCurrency: []models.CurrencySummary{}}
+	byCurrency := make(map[string]*models.CurrencySummary)
+	contacts := make(map[int]bool)
+	for _, debt := range s.m.debts {
+		if debt.UserID != userID {
+			continue
+		}
+		if debt.Status == "active" {
+			contacts[debt.ContactID] = true
+		}
+
+		confirmed, change, pendingTransactions := s.split(debt)
+		open := s.open(debt)
+		disputed := debt.Status != "removed" && debt.Confirmation == "disputed"
+		if !open && !disputed {
+			continue
+		}
+
+		totals := byCurrency[debt.Currency]
+		if totals == nil {
+			totals = &models.CurrencySummary{Currency: debt.Currency}
+			totals.TotalOwedToOthers.Currency = debt.Currency
+			totals.TotalOwedFromOthers.Currency = debt.Currency
+			totals.Pending.TotalOwedToOthers.Currency = debt.Currency
+			totals.Pending.TotalOwedFromOthers.Currency = debt.Currency
+			byCurrency[debt.Currency] = totals
+		}
+		pending := &totals.Pending
+		if debt.Status == "active" {
+			totals.ActiveDebtsCount++
+		}
+		if disputed {
+			totals.DisputedDebtsCount++
+		}
+		if !open {
+			continue
+		}
+
+		owedTo, pendingOwedTo := &totals.TotalOwedFromOthers, &pending.TotalOwedFromOthers
+		if debt.Direction == "owe_to" {
+			owedTo, pendingOwedTo = &totals.TotalOwedToOthers, &pending.TotalOwedToOthers
+		}
+		switch debt.Confirmation {
+		case "confirmed":
+			*owedTo = owedTo.Add(confirmed)
+			*pendingOwedTo = pendingOwedTo.Add(change)
+			pending.TransactionsCount += pendingTransactions
+		case "pending":
+			*pendingOwedTo = pendingOwedTo.Add(confirmed).Add(change)
+			pending.DebtsCount++
+		}
+	}
+
+	for _, totals := range byCurrency {
+		totals.NetBalance = totals.TotalOwedFromOthers.Sub(totals.TotalOwedToOthers)
+		totals.Pending.NetBalance = totals.Pending.TotalOwedFromOthers.Sub(totals.Pending.TotalOwedToOthers)
+		summary.ByCurrency = append(summary.ByCurrency, *totals)
+
+		summary.ActiveDebtsCount += totals.ActiveDebtsCount
+		summary.Pending.DebtsCount += totals.Pending.DebtsCount
+		summary.Pending.TransactionsCount += totals.Pending.TransactionsCount
+		summary.DisputedDebtsCount += totals.DisputedDebtsCount
+	}
+	sort.Slice(summary.ByCurrency, func(i, j int) bool {
+		return summary.ByCurrency[i].Currency < summary.ByCurrency[j].Currency
+	})
+	summary.ContactsWithDebts = len(contacts)
+	return summary, nil
+}
+
+// split divides the balance of the debt as the SQL store does: what its
+// confirmed entries add up to, what its pending transactions would change,
+// and how many of those there are.
+func (s memoryDebts) split(debt models.Debt) (confirmed, change models.Money, pendingTransactions int) {
+	confirmed = debt.OriginalAmount
+	for _, transaction := range s.m.transactions {
+		if transaction.DebtID != debt.ID {
+			continue
+		}
+		switch transaction.Confirmation {
+		case "confirmed":
+			confirmed = confirmed.Add(signedAmount(transaction))
+		case "pending":
+			change = change.Add(signedAmount(transaction))
+			pendingTransactions++
+		}
+	}
+	return confirmed, change, pendingTransactions
+}
+
+// open reports whether the summary counts the debt: it is active, or
+// settled with pending entries in its ledger.
+func (s memoryDebts) open(debt models.Debt) bool {
+	if debt.Status == "active" {
+		return true
+	}
+	if debt.Status != "settled" {
+		return false
+	}
+	for _, transaction := range s.m.transactions {
+		if transaction.DebtID == debt.ID && transaction.Confirmation == "pending" {
+			return true
+		}
+	}
+	return false
+}
+
+// signedAmount is how much the transaction changes the balance of its debt.
+func signedAmount(transaction models.Transaction) models.Money {
+	if transaction.TransactionType != "lent" && transaction.TransactionType != "borrowed" {
+		return transaction.Amount.Neg()
+	}
+	return transaction.Amount
+}
+
+func (s memoryDebts) Ledger(userID int) ([]models.LedgerEntry, error) {
+	defer s.m.lock()()
+
+	var debts []models.Debt
+	for _, debt := range s.m.debts {
+		if debt.UserID == userID && debt.Confirmation == "confirmed" && s.open(debt) {
+			debts = append(debts, debt)
+		}
+	}
+	sort.Slice(debts, func(i, j int) bool { return debts[i].ID < debts[j].ID })
+
+	entries := []models.LedgerEntry{}
+	for _, debt := range debts {
+		var transactions []models.Transaction
+		for _, transaction := range s.m.transactions {
+			if transaction.DebtID == debt.ID && transaction.Confirmation == "confirmed" {
+				transactions = append(transactions, transaction)
+			}
+		}
+		sort.Slice(transactions, func(i, j int) bool {
+			if !transactions[i].CreatedAt.Equal(transactions[j].CreatedAt) {
+				return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+			}
+			return transactions[i].ID < transactions[j].ID
+		})
+
+		entry := models.LedgerEntry{DebtID: debt.ID, Direction: debt.Direction}
+		entry.Amount = models.NewMoney(debt.OriginalAmount.Minor, debt.Currency)
+		entry.Date = debt.CreatedAt
+		entries = append(entries, entry)
+		for _, transaction := range transactions {
+			entry.Amount = models.NewMoney(signedAmount(transaction).Minor, debt.Currency)
+			entry.Date = transaction.CreatedAt
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s memoryDebts) SetConfirmation(id int, confirmation string, comment *string) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	debt.Confirmation = confirmation
+	debt.ConfirmationComment = comment
+	debt.UpdatedAt = now()
+	s.m.debts[id] = debt
+	return nil
+}
+
+func (s memoryDebts) SetDueDate(id int, dueDate *string, instalments []models.Instalment) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	debt.DueDate = dueDate
+	debt.Instalments = slices.Clone(instalments)
+	debt.UpdatedAt = now()
+	s.m.debts[id] = debt
+	return nil
+}
+
+func (s memoryDebts) SetInterest(id int, interest *models.Interest) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	debt.Interest = cloneInterest(interest)
+	debt.UpdatedAt = now()
+	s.m.debts[id] = debt
+	return nil
+}
+
+func cloneInterest(interest *models.Interest) *models.Interest {
+	if interest == nil {
+		return nil
+	}
+	clone := *interest
+	return &clone
+}
+
+func (s memoryDebts) WithDueDates() ([]models.Debt, error) {
+	return s.active(func(debt models.Debt) bool { return debt.DueDate != nil })
+}
+
+func (s memoryDebts) WithInterest() ([]models.Debt, error) {
+	return s.active(func(debt models.Debt) bool { return debt.Interest != nil && debt.Interest.AutoPost })
+}
+
+// active returns the active debts of all users that match, oldest first.
+func (s memoryDebts) active(match func(debt models.Debt) bool) ([]models.Debt, error) {
+	defer s.m.lock()()
+
+	debts := []models.Debt{}
+	for _, debt := range s.m.debts {
+		if debt.Status == "active" && match(debt) {
+			debts = append(debts, s.ledger(debt))
+		}
+	}
+	sort.Slice(debts, func(i, j int) bool {
+		if !debts[i].CreatedAt.Equal(debts[j].CreatedAt) {
+			return debts[i].CreatedAt.Before(debts[j].CreatedAt)
+		}
+		return debts[i].ID < debts[j].ID
+	})
+	return debts, nil
+}
+
+func (s memoryDebts) Link(id, counterpartID int) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[id]
+	counterpart, found := s.m.debts[counterpartID]
+	if !ok || !found {
+		return ErrNotFound
+	}
+	debt.CounterpartID = &counterpart.ID
+	counterpart.CounterpartID = &debt.ID
+	s.m.debts[id] = debt
+	s.m.debts[counterpartID] = counterpart
+	return nil
+}
+
+type memoryTransactions struct {
+	m *Memory
+}
+
+func (s memoryTransactions) List(userID int, filter TransactionFilter, opts ListOptions) (models.Page[models.Transaction], error) {
+	defer s.m.lock()()
+
+	var transactions []models.Transaction
+	for _, transaction := range s.m.transactions {
+		debt := s.m.debts[transaction.DebtID]
+		if debt.UserID != userID ||
+			(filter.DebtID != 0 && transaction.DebtID != filter.DebtID) ||
+			(filter.ContactID != 0 && debt.ContactID != filter.ContactID) ||
+			(filter.TransactionType != "" && transaction.TransactionType != filter.TransactionType) ||
+			(filter.Confirmation != "" && transaction.Confirmation != filter.Confirmation) ||
+			!inAmountRange(transaction.Amount, filter.MinAmount, filter.MaxAmount) ||
+			!inCreatedRange(transaction.CreatedAt, filter.CreatedFrom, filter.CreatedBefore) {
+			continue
+		}
+		transactions = append(transactions, s.withCurrency(transaction))
+	}
+	return memoryPage(transactions, transactionSorts, sortSpec{field: "created_at", desc: true}, opts, transactionKey)
+}
+
+func (s memoryTransactions) Get(userID, id int) (models.Transaction, error) {
+	defer s.m.lock()()
+
+	transaction, ok := s.m.transactions[id]
+	if !ok || s.m.debts[transaction.DebtID].UserID != userID {
+		return models.Transaction{}, ErrNotFound
+	}
+	return s.withCurrency(transaction), nil
+}
+
+// withCurrency also drops a counterpart that no longer exists, as the SQL
+// store does.
+func (s memoryTransactions) withCurrency(transaction models.Transaction) models.Transaction {
+	currency := s.m.debts[transaction.DebtID].Currency
+	transaction.Currency = currency
+	transaction.Amount.Currency = currency
+	if transaction.CounterpartID != nil {
+		if _, ok := s.m.transactions[*transaction.CounterpartID]; !ok {
+			transaction.CounterpartID = nil
+		}
+	}
+	return transaction
+}
+
+func (s memoryTransactions) Create(transaction *models.Transaction) error {
+	defer s.m.lock()()
+
+	debt, ok := s.m.debts[transaction.DebtID]
+	if !ok {
+		return ErrNotFound
+	}
+	if transaction.Confirmation == "" {
+		transaction.Confirmation = "confirmed"
+	}
+	transaction.ID = s.m.id()
+	transaction.Currency = debt.Currency
+	transaction.Amount.Currency = debt.Currency
+	transaction.CreatedAt = createdAt(transaction.CreatedAt)
+	s.m.transactions[transaction.ID] = *transaction
+	return nil
+}
+
+func (s memoryTransactions) Delete(id int) error {
+	defer s.m.lock()()
+
+	if _, ok := s.m.transactions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.transactions, id)
+	return nil
+}
+
+func (s memoryTransactions) SetConfirmation(id int, confirmation string, comment *string) error {
+	defer s.m.lock()()
+
+	transaction, ok := s.m.transactions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	transaction.Confirmation = confirmation
+	transaction.ConfirmationComment = comment
+	s.m.transactions[id] = transaction
+	return nil
+}
+
+func (s memoryTransactions) Link(id, counterpartID int) error {
+	defer s.m.lock()()
+
+	transaction, ok := s.m.transactions[id]
+	counterpart, found := s.m.transactions[counterpartID]
+	if !ok || !found {
+		return ErrNotFound
+	}
+	transaction.CounterpartID = &counterpart.ID
+	counterpart.CounterpartID = &transaction.ID
+	s.m.transactions[id] = transaction
+	s.m.transactions[counterpartID] = counterpart
+	return nil
+}
+
+type memoryTokens struct {
+	m *Memory
+}
+
+func (s memoryTokens) CreateRefresh(token *models.RefreshToken) error {
+	defer s.m.lock()()
+
+	token.ID = s.m.id()
+	token.CreatedAt = now()
+	token.RevokedAt = nil
+	s.m.refresh[token.ID] = *token
+	return nil
+}
+
+func (s memoryTokens) GetRefresh(hash string) (models.RefreshToken, error) {
+	defer s.m.lock()()
+
+	for _, token := range s.m.refresh {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (s memoryTokens) RevokeRefresh(id int) error {
+	defer s.m.lock()()
+
+	if s.revoke(func(token models.RefreshToken) bool { return token.ID == id }) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s memoryTokens) RevokeFamily(userID int, familyID string) error {
+	defer s.m.lock()()
+
+	s.revoke(func(token models.RefreshToken) bool {
+		return token.UserID == userID && token.FamilyID == familyID
+	})
+	return nil
+}
+
+func (s memoryTokens) RevokeUser(userID int) (int, error) {
+	defer s.m.lock()()
+
+	return s.revoke(func(token models.RefreshToken) bool { return token.UserID == userID }), nil
+}
+
+func (s memoryTokens) revoke(match func(models.RefreshToken) bool) int {
+	revokedAt := now()
+	revoked := 0
+	for id, token := range s.m.refresh {
+		if token.RevokedAt != nil || !match(token) {
+			continue
+		}
+		if _, ok := s.m.revoked[token.AccessJTI]; !ok {
+			s.m.revoked[token.AccessJTI] = token.AccessExpiresAt
+		}
+		token.RevokedAt = &revokedAt
+		s.m.refresh[id] = token
+		revoked++
+	}
+	return revoked
+}
+
+func (s memoryTokens) IsRevoked(jti string) (bool, error) {
+	defer s.m.lock()()
+
+	_, ok := s.m.revoked[jti]
+	return ok, nil
+}
+
+func (s memoryTokens) PurgeExpired(before time.Time) (int, error) {
+	defer s.m.lock()()
+
+	purged := 0
+	for id, token := range s.m.refresh {
+		if token.ExpiresAt.Before(before) {
+			delete(s.m.refresh, id)
+			purged++
+		}
+	}
+	for jti, expiresAt := range s.m.revoked {
+		if expiresAt.Before(before) {
+			delete(s.m.revoked, jti)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+type memoryLoginAttempts struct {
+	m *Memory
+}
+
+func (s memoryLoginAttempts) Get(email string) (models.LoginAttempt, error) {
+	defer s.m.lock()()
+
+	attempt, ok := s.m.attempts[email]
+	if !ok {
+		return models.LoginAttempt{}, ErrNotFound
+	}
+	return attempt, nil
+}
+
+func (s memoryLoginAttempts) RecordFailure(email string, at, resetBefore time.Time) (int, error) {
+	defer s.m.lock()()
+
+	attempt, ok := s.m.attempts[email]
+	if !ok || attempt.LastFailedAt.Before(resetBefore) {
+		attempt = models.LoginAttempt{Email: email, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = at
+	s.m.attempts[email] = attempt
+	return attempt.Failures, nil
+}
+
+func (s memoryLoginAttempts) LockUntil(email string, until time.Time) error {
+	defer s.m.lock()()
+
+	attempt, ok := s.m.attempts[email]
+	if !ok {
+		return ErrNotFound
+	}
+	attempt.LockedUntil = &until
+	s.m.attempts[email] = attempt
+	return nil
+}
+
+func (s memoryLoginAttempts) Clear(email string) error {
+	defer s.m.lock()()
+
+	delete(s.m.attempts, email)
+	return nil
+}
+
+func (s memoryLoginAttempts) PurgeStale(before time.Time) (int, error) {
+	defer s.m.lock()()
+
+	purged := 0
+	for email, attempt := range s.m.attempts {
+		if attempt.LastFailedAt.Before(before) && (attempt.LockedUntil == nil || attempt.LockedUntil.Before(before)) {
+			delete(s.m.attempts, email)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+type memoryTwoFactor struct {
+	m *Memory
+}
+
+func (s memoryTwoFactor) Get(userID int) (models.TwoFactor, error) {
+	defer s.m.lock()()
+
+	twoFactor, ok := s.m.twoFactor[userID]
+	if !ok {
+		return models.TwoFactor{}, ErrNotFound
+	}
+	return twoFactor, nil
+}
+
+func (s memoryTwoFactor) SetSecret(userID int, secret string) error {
+	defer s.m.lock()()
+
+	if twoFactor, ok := s.m.twoFactor[userID]; ok && twoFactor.EnabledAt != nil {
+		return ErrNotFound
+	}
+	s.m.twoFactor[userID] = models.TwoFactor{UserID: userID, Secret: secret, CreatedAt: now()}
+	return nil
+}
+
+func (s memoryTwoFactor) Enable(userID int) error {
+	defer s.m.lock()()
+
+	twoFactor, ok := s.m.twoFactor[userID]
+	if !ok || twoFactor.EnabledAt != nil {
+		return ErrNotFound
+	}
+	enabledAt := now()
+	twoFactor.EnabledAt = &enabledAt
+	s.m.twoFactor[userID] = twoFactor
+	return nil
+}
+
+func (s memoryTwoFactor) UseStep(userID int, step int64) error {
+	defer s.m.lock()()
+
+	twoFactor, ok := s.m.twoFactor[userID]
+	if !ok || twoFactor.LastStep >= step {
+		return ErrNotFound
+	}
+	twoFactor.LastStep = step
+	s.m.twoFactor[userID] = twoFactor
+	return nil
+}
+
+func (s memoryTwoFactor) Disable(userID int) error {
+	defer s.m.lock()()
+
+	if _, ok := s.m.twoFactor[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.twoFactor, userID)
+	s.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (s memoryTwoFactor) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	defer s.m.lock()()
+
+	s.deleteRecoveryCodes(userID)
+	for _, hash := range hashes {
+		s.m.recovery[s.m.id()] = recoveryCode{userID: userID, hash: hash}
+	}
+	return nil
+}
+
+func (s memoryTwoFactor) deleteRecoveryCodes(userID int) {
+	for id, code := range s.m.recovery {
+		if code.userID == userID {
+			delete(s.m.recovery, id)
+		}
+	}
+}
+
+func (s memoryTwoFactor) UnusedRecoveryCodes(userID int) (map[int]string, error) {
+	defer s.m.lock()()
+
+	hashes := make(map[int]string)
+	for id, code := range s.m.recovery {
+		if code.userID == userID && !code.used {
+			hashes[id] = code.hash
+		}
+	}
+	return hashes, nil
+}
+
+func (s memoryTwoFactor) UseRecoveryCode(userID, id int) error {
+	defer s.m.lock()()
+
+	code, ok := s.m.recovery[id]
+	if !ok || code.userID != userID || code.used {
+		return ErrNotFound
+	}
+	code.used = true
+	s.m.recovery[id] = code
+	return nil
+}
+
+func (s memoryTwoFactor) RecoveryCodesLeft(userID int) (int, error) {
+	defer s.m.lock()()
+
+	left := 0
+	for _, code := range s.m.recovery {
+		if code.userID == userID && !code.used {
+			left++
+		}
+	}
+	return left, nil
+}
+
+type memoryAPIKeys struct {
+	m *Memory
+}
+
+func (s memoryAPIKeys) Create(key *models.APIKey) error {
+	defer s.m.lock()()
+
+	key.ID = s.m.id()
+	key.CreatedAt = now()
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+	s.m.apiKeys[key.ID] = *key
+	return nil
+}
+
+func (s memoryAPIKeys) List(userID int) ([]models.APIKey, error) {
+	defer s.m.lock()()
+
+	keys := []models.APIKey{}
+	for _, key := range s.m.apiKeys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (s memoryAPIKeys) GetByHash(hash string) (models.APIKey, error) {
+	defer s.m.lock()()
+
+	for _, key := range s.m.apiKeys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (s memoryAPIKeys) Revoke(userID, id int) error {
+	defer s.m.lock()()
+
+	key, ok := s.m.apiKeys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return ErrNotFound
+	}
+	revokedAt := now()
+	key.RevokedAt = &revokedAt
+	s.m.apiKeys[id] = key
+	return nil
+}
+
+func (s memoryAPIKeys) Touch(id int, at time.Time) error {
+	defer s.m.lock()()
+
+	key, ok := s.m.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	s.m.apiKeys[id] = key
+	return nil
+}
+
+type memoryInvites struct {
+	m *Memory
+}
+
+// withInviter fills in the inviter details the SQL store joins in.
+func (s memoryInvites) withInviter(invite models.ContactInvite) models.ContactInvite {
+	inviter := s.m.users[invite.InviterID]
+	invite.InviterName = inviter.Name
+	invite.InviterEmail = inviter.Email
+	return invite
+}
+
+func (s memoryInvites) Create(invite *models.ContactInvite) error {
+	defer s.m.lock()()
+
+	invite.ID = s.m.id()
+	invite.Status = "pending"
+	invite.CreatedAt = now()
+	invite.RespondedAt = nil
+	s.m.invites[invite.ID] = *invite
+	*invite = s.withInviter(*invite)
+	return nil
+}
+
+func (s memoryInvites) Get(id int) (models.ContactInvite, error) {
+	defer s.m.lock()()
+
+	invite, ok := s.m.invites[id]
+	if !ok {
+		return models.ContactInvite{}, ErrNotFound
+	}
+	return s.withInviter(invite), nil
+}
+
+func (s memoryInvites) ListReceived(email string) ([]models.ContactInvite, error) {
+	return s.list(func(invite models.ContactInvite) bool { return strings.EqualFold(invite.Email, email) })
+}
+
+func (s memoryInvites) ListSent(userID int) ([]models.ContactInvite, error) {
+	return s.list(func(invite models.ContactInvite) bool { return invite.InviterID == userID })
+}
+
+func (s memoryInvites) list(match func(models.ContactInvite) bool) ([]models.ContactInvite, error) {
+	defer s.m.lock()()
+
+	invites := []models.ContactInvite{}
+	for _, invite := range s.m.invites {
+		if invite.Status == "pending" && match(invite) {
+			invites = append(invites, s.withInviter(invite))
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].ID > invites[j].ID })
+	return invites, nil
+}
+
+func (s memoryInvites) Resolve(id int, status string) error {
+	defer s.m.lock()()
+
+	invite, ok := s.m.invites[id]
+	if !ok || invite.Status != "pending" {
+		return ErrNotFound
+	}
+	respondedAt := now()
+	invite.Status = status
+	invite.RespondedAt = &respondedAt
+	s.m.invites[id] = invite
+	return nil
+}
+
+type memoryDebtChanges struct {
+	m *Memory
+}
+
+func (s memoryDebtChanges) withCurrency(change models.DebtChange) models.DebtChange {
+	if change.Amount != nil {
+		amount := *change.Amount
+		amount.Currency = s.m.debts[change.DebtID].Currency
+		change.Amount = &amount
+	}
+	return change
+}
+
+func (s memoryDebtChanges) Create(change *models.DebtChange) error {
+	defer s.m.lock()()
+
+	if _, ok := s.m.debts[change.DebtID]; !ok {
+		return ErrNotFound
+	}
+	change.ID = s.m.id()
+	change.State = "pending"
+	change.CreatedAt = now()
+	change.ResolvedAt = nil
+	s.m.changes[change.ID] = *change
+	*change = s.withCurrency(*change)
+	return nil
+}
+
+func (s memoryDebtChanges) Get(id int) (models.DebtChange, error) {
+	defer s.m.lock()()
+
+	change, ok := s.m.changes[id]
+	if !ok {
+		return models.DebtChange{}, ErrNotFound
+	}
+	return s.withCurrency(change), nil
+}
+
+func (s memoryDebtChanges) List(debtIDs ...int) ([]models.DebtChange, error) {
+	defer s.m.lock()()
+
+	changes := []models.DebtChange{}
+	for _, change := range s.m.changes {
+		if slices.Contains(debtIDs, change.DebtID) {
+			changes = append(changes, s.withCurrency(change))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID > changes[j].ID })
+	return changes, nil
+}
+
+func (s memoryDebtChanges) Resolve(id int, state string) error {
+	defer s.m.lock()()
+
+	change, ok := s.m.changes[id]
+	if !ok || change.State != "pending" {
+		return ErrNotFound
+	}
+	resolvedAt := now()
+	change.State = state
+	change.ResolvedAt = &resolvedAt
+	s.m.changes[id] = change
+	return nil
+}
+
+type memoryGroups struct {
+	m *Memory
+}
+
+// withMembers fills in the members the SQL store joins in.
+func (s memoryGroups) withMembers(group models.Group) models.Group {
+	group.Members = []models.Contact{}
+	for member := range s.m.members {
+		if member.groupID == group.ID {
+			group.Members = append(group.Members, s.m.contacts[member.contactID])
+		}
+	}
+	sort.Slice(group.Members, func(i, j int) bool {
+		if group.Members[i].Name != group.Members[j].Name {
+			return group.Members[i].Name < group.Members[j].Name
+		}
+		return group.Members[i].ID < group.Members[j].ID
+	})
+	return group
+}
+
+func (s memoryGroups) List(userID int) ([]models.Group, error) {
+	defer s.m.lock()()
+
+	groups := []models.Group{}
+	for _, group := range s.m.groups {
+		if group.UserID == userID {
+			groups = append(groups, s.withMembers(group))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups, nil
+}
+
+func (s memoryGroups) Get(userID, id int) (models.Group, error) {
+	defer s.m.lock()()
+
+	group, ok := s.m.groups[id]
+	if !ok || group.UserID != userID {
+		return models.Group{}, ErrNotFound
+	}
+	return s.withMembers(group), nil
+}
+
+func (s memoryGroups) Create(group *models.Group) error {
+	defer s.m.lock()()
+
+	group.ID = s.m.id()
+	group.Members = nil
+	group.CreatedAt = now()
+	group.UpdatedAt = group.CreatedAt
+	s.m.groups[group.ID] = *group
+	*group = s.withMembers(*group)
+	return nil
+}
+
+func (s memoryGroups) Update(group *models.Group) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.groups[group.ID]
+	if !ok || stored.UserID != group.UserID {
+		return ErrNotFound
+	}
+	stored.Name = group.Name
+	stored.Description = group.Description
+	stored.UpdatedAt = now()
+	s.m.groups[stored.ID] = stored
+	*group = s.withMembers(stored)
+	return nil
+}
+
+func (s memoryGroups) Delete(userID, id int) error {
+	defer s.m.lock()()
+
+	group, ok := s.m.groups[id]
+	if !ok || group.UserID != userID {
+		return ErrNotFound
+	}
+	s.m.deleteGroup(id)
+	return nil
+}
+
+func (s memoryGroups) AddMember(groupID, contactID int) error {
+	defer s.m.lock()()
+
+	member := groupMember{groupID: groupID, contactID: contactID}
+	if _, ok := s.m.members[member]; ok {
+		return ErrNotFound
+	}
+	if _, ok := s.m.groups[groupID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.m.contacts[contactID]; !ok {
+		return ErrNotFound
+	}
+	s.m.members[member] = now()
+	return nil
+}
+
+func (s memoryGroups) RemoveMember(groupID, contactID int) error {
+	defer s.m.lock()()
+
+	member := groupMember{groupID: groupID, contactID: contactID}
+	if _, ok := s.m.members[member]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.members, member)
+	return nil
+}
+
+type memoryExpenses struct {
+	m *Memory
+}
+
+// withCurrency applies the expense's currency to its amounts. The splits
+// are copied so that callers cannot change the stored ones.
+func (s memoryExpenses) withCurrency(expense models.Expense) models.Expense {
+	expense.Amount.Currency = expense.Currency
+	splits := make([]models.ExpenseSplit, len(expense.Splits))
+	for i, split := range expense.Splits {
+		split.Amount.Currency = expense.Currency
+		split.SetWeight(expense.SplitType, split.Weight())
+		splits[i] = split
+	}
+	expense.Splits = splits
+	return expense
+}
+
+func (s memoryExpenses) List(groupID int) ([]models.Expense, error) {
+	defer s.m.lock()()
+
+	expenses := []models.Expense{}
+	for _, expense := range s.m.expenses {
+		if expense.GroupID == groupID {
+			expenses = append(expenses, s.withCurrency(expense))
+		}
+	}
+	sort.Slice(expenses, func(i, j int) bool { return expenses[i].ID > expenses[j].ID })
+	return expenses, nil
+}
+
+func (s memoryExpenses) Get(groupID, id int) (models.Expense, error) {
+	defer s.m.lock()()
+
+	expense, ok := s.m.expenses[id]
+	if !ok || expense.GroupID != groupID {
+		return models.Expense{}, ErrNotFound
+	}
+	return s.withCurrency(expense), nil
+}
+
+func (s memoryExpenses) Create(expense *models.Expense) error {
+	defer s.m.lock()()
+
+	if _, ok := s.m.groups[expense.GroupID]; !ok {
+		return ErrNotFound
+	}
+	if expense.Currency == "" {
+		expense.Currency = models.DefaultCurrency
+	}
+	expense.ID = s.m.id()
+	expense.Debts = nil
+	expense.CreatedAt = now()
+	expense.UpdatedAt = expense.CreatedAt
+	*expense = s.withCurrency(*expense)
+	s.m.expenses[expense.ID] = s.withCurrency(*expense)
+	return nil
+}
+
+func (s memoryExpenses) Update(expense *models.Expense) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.expenses[expense.ID]
+	if !ok || stored.GroupID != expense.GroupID {
+		return ErrNotFound
+	}
+	stored.Description = expense.Description
+	stored.Amount = expense.Amount
+	stored.PaidBy = expense.PaidBy
+	stored.SplitType = expense.SplitType
+	stored.Splits = expense.Splits
+	stored.UpdatedAt = now()
+	stored = s.withCurrency(stored)
+	s.m.expenses[stored.ID] = stored
+	*expense = s.withCurrency(stored)
+	return nil
+}
+
+func (s memoryExpenses) Delete(groupID, id int) error {
+	defer s.m.lock()()
+
+	expense, ok := s.m.expenses[id]
+	if !ok || expense.GroupID != groupID {
+		return ErrNotFound
+	}
+	s.m.deleteExpense(id)
+	return nil
+}
+
+type memoryExchangeRates struct {
+	m *Memory
+}
+
+func (s memoryExchangeRates) Save(rates []models.ExchangeRate) error {
+	defer s.m.lock()()
+
+	for _, rate := range rates {
+		s.m.rates[rateDay{rate.Currency, rate.Date}] = rate
+	}
+	return nil
+}
+
+func (s memoryExchangeRates) Get(currency, day string) (models.ExchangeRate, error) {
+	defer s.m.lock()()
+
+	var latest models.ExchangeRate
+	for key, rate := range s.m.rates {
+		if key.currency == currency && key.day <= day && key.day > latest.Date {
+			latest = rate
+		}
+	}
+	if latest.Date == "" {
+		return latest, ErrNotFound
+	}
+	return latest, nil
+}
+
+type memorySchedules struct {
+	m *Memory
+}
+
+// byCreation orders schedules oldest first, as the SQL store does.
+func byCreation(schedules []models.Schedule) []models.Schedule {
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+func (s memorySchedules) List(userID int) ([]models.Schedule, error) {
+	defer s.m.lock()()
+
+	schedules := []models.Schedule{}
+	for _, schedule := range s.m.schedules {
+		if schedule.UserID == userID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return byCreation(schedules), nil
+}
+
+func (s memorySchedules) Get(userID, id int) (models.Schedule, error) {
+	defer s.m.lock()()
+
+	schedule, ok := s.m.schedules[id]
+	if !ok || schedule.UserID != userID {
+		return models.Schedule{}, ErrNotFound
+	}
+	return schedule, nil
+}
+
+func (s memorySchedules) Create(schedule *models.Schedule) error {
+	defer s.m.lock()()
+
+	schedule.ID = s.m.id()
+	if schedule.Currency == "" {
+		schedule.Currency = models.DefaultCurrency
+	}
+	schedule.Amount.Currency = schedule.Currency
+	schedule.Status = "active"
+	schedule.CreatedAt = now()
+	schedule.UpdatedAt = schedule.CreatedAt
+	s.m.schedules[schedule.ID] = *schedule
+	return nil
+}
+
+func (s memorySchedules) Update(schedule *models.Schedule) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.schedules[schedule.ID]
+	if !ok || stored.UserID != schedule.UserID {
+		return ErrNotFound
+	}
+	stored.Amount = models.NewMoney(schedule.Amount.Minor, stored.Currency)
+	stored.Description = schedule.Description
+	stored.RRule = schedule.RRule
+	stored.StartDate = schedule.StartDate
+	stored.NextDate = schedule.NextDate
+	stored.Status = schedule.Status
+	stored.UpdatedAt = now()
+	s.m.schedules[schedule.ID] = stored
+	*schedule = stored
+	return nil
+}
+
+func (s memorySchedules) Delete(userID, id int) error {
+	defer s.m.lock()()
+
+	schedule, ok := s.m.schedules[id]
+	if !ok || schedule.UserID != userID {
+		return ErrNotFound
+	}
+	s.m.deleteSchedule(id)
+	return nil
+}
+
+func (s memorySchedules) Due(day string) ([]models.Schedule, error) {
+	defer s.m.lock()()
+
+	schedules := []models.Schedule{}
+	for _, schedule := range s.m.schedules {
+		if schedule.Status == "active" && schedule.NextDate != nil && *schedule.NextDate <= day {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return byCreation(schedules), nil
+}
+
+func (s memorySchedules) Advance(id int, from string, to *string) error {
+	defer s.m.lock()()
+
+	schedule, ok := s.m.schedules[id]
+	if !ok || schedule.NextDate == nil || *schedule.NextDate != from {
+		return ErrNotFound
+	}
+	schedule.NextDate = to
+	if to == nil {
+		schedule.Status = "finished"
+	}
+	schedule.UpdatedAt = now()
+	s.m.schedules[id] = schedule
+	return nil
+}
+
+func (s memorySchedules) Occurrences(scheduleID int) ([]models.ScheduleOccurrence, error) {
+	defer s.m.lock()()
+
+	occurrences := []models.ScheduleOccurrence{}
+	for key, occurrence := range s.m.occurrences {
+		if key.scheduleID == scheduleID {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Date < occurrences[j].Date })
+	return occurrences, nil
+}
+
+func (s memorySchedules) GetOccurrence(scheduleID int, day string) (models.ScheduleOccurrence, error) {
+	defer s.m.lock()()
+
+	occurrence, ok := s.m.occurrences[occurrenceDay{scheduleID, day}]
+	if !ok {
+		return models.ScheduleOccurrence{}, ErrNotFound
+	}
+	return occurrence, nil
+}
+
+func (s memorySchedules) SaveOccurrence(occurrence *models.ScheduleOccurrence) error {
+	defer s.m.lock()()
+
+	schedule, ok := s.m.schedules[occurrence.ScheduleID]
+	if !ok {
+		return ErrNotFound
+	}
+	occurrence.Amount.Currency = schedule.Currency
+	s.m.occurrences[occurrenceDay{occurrence.ScheduleID, occurrence.Date}] = *occurrence
+	return nil
+}
+
+func (s memorySchedules) DeleteOccurrence(scheduleID int, day string) error {
+	defer s.m.lock()()
+
+	key := occurrenceDay{scheduleID, day}
+	if _, ok := s.m.occurrences[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.m.occurrences, key)
+	return nil
+}
+
+type memoryNotifications struct {
+	m *Memory
+}
+
+func (s memoryNotifications) Enqueue(notification *models.Notification) (bool, error) {
+	defer s.m.lock()()
+
+	for _, queued := range s.m.outbox {
+		if queued.Key == notification.Key {
+			return false, nil
+		}
+	}
+	notification.ID = s.m.id()
+	notification.Status = "pending"
+	notification.Attempts = 0
+	notification.LastError = nil
+	notification.CreatedAt = now()
+	notification.SentAt = nil
+	s.m.outbox[notification.ID] = *notification
+	return true, nil
+}
+
+func (s memoryNotifications) Pending(limit int) ([]models.Notification, error) {
+	defer s.m.lock()()
+
+	notifications := []models.Notification{}
+	for _, notification := range s.m.outbox {
+		if notification.Status == "pending" {
+			notifications = append(notifications, notification)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (s memoryNotifications) MarkSent(id int, at time.Time) error {
+	defer s.m.lock()()
+
+	notification, ok := s.m.outbox[id]
+	if !ok || notification.Status != "pending" {
+		return ErrNotFound
+	}
+	sentAt := at.UTC().Truncate(time.Microsecond)
+	notification.Status = "sent"
+	notification.SentAt = &sentAt
+	notification.Attempts++
+	s.m.outbox[id] = notification
+	return nil
+}
+
+func (s memoryNotifications) MarkFailed(id int, message string, maxAttempts int) error {
+	defer s.m.lock()()
+
+	notification, ok := s.m.outbox[id]
+	if !ok || notification.Status != "pending" {
+		return ErrNotFound
+	}
+	notification.Attempts++
+	notification.LastError = &message
+	if notification.Attempts >= maxAttempts {
+		notification.Status = "failed"
+	}
+	s.m.outbox[id] = notification
+	return nil
+}
+
+type memoryImportProfiles struct {
+	m *Memory
+}
+
+func (s memoryImportProfiles) List(userID int) ([]models.ImportProfile, error) {
+	defer s.m.lock()()
+
+	profiles := []models.ImportProfile{}
+	for _, profile := range s.m.profiles {
+		if profile.UserID == userID {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Name != profiles[j].Name {
+			return profiles[i].Name < profiles[j].Name
+		}
+		return profiles[i].ID < profiles[j].ID
+	})
+	return profiles, nil
+}
+
+func (s memoryImportProfiles) Get(userID, id int) (models.ImportProfile, error) {
+	defer s.m.lock()()
+
+	profile, ok := s.m.profiles[id]
+	if !ok || profile.UserID != userID {
+		return models.ImportProfile{}, ErrNotFound
+	}
+	return profile, nil
+}
+
+func (s memoryImportProfiles) Create(profile *models.ImportProfile) error {
+	defer s.m.lock()()
+
+	profile.ID = s.m.id()
+	profile.CreatedAt = now()
+	profile.UpdatedAt = profile.CreatedAt
+	s.m.profiles[profile.ID] = *profile
+	return nil
+}
+
+func (s memoryImportProfiles) Update(profile *models.ImportProfile) error {
+	defer s.m.lock()()
+
+	stored, ok := s.m.profiles[profile.ID]
+	if !ok || stored.UserID != profile.UserID {
+		return ErrNotFound
+	}
+	stored.Name = profile.Name
+	stored.Format = profile.Format
+	stored.Mapping = profile.Mapping
+	stored.UpdatedAt = now()
+	s.m.profiles[stored.ID] = stored
+	*profile = stored
+	return nil
+}
+
+func (s memoryImportProfiles) Delete(userID, id int) error {
+	defer s.m.lock()()
+
+	profile, ok := s.m.profiles[id]
+	if !ok || profile.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.m.profiles, id)
+	return nil
+}
@@ -0,0 +1,89 @@
+// internal/store/sql.go
+package store
+
+import (
+	"database/sql"
+
+	"debt-tracker-backend/internal/database"
+)
+
+// querier is satisfied by both *database.DB and *database.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// SQLStore keeps its records in the SQLite or PostgreSQL database.
+type SQLStore struct {
+	db *database.DB
+	q  querier
+	tx bool
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewSQL(db *database.DB) *SQLStore {
+	return &SQLStore{db: db, q: db}
+}
+
+func (s *SQLStore) Users() UserStore                   { return sqlUsers{s.q} }
+func (s *SQLStore) Contacts() ContactStore             { return sqlContacts{s.q} }
+func (s *SQLStore) Debts() DebtStore                   { return sqlDebts{s.q} }
+func (s *SQLStore) Transactions() TransactionStore     { return sqlTransactions{s.q} }
+func (s *SQLStore) Tokens() TokenStore                 { return sqlTokens{s.q} }
+func (s *SQLStore) LoginAttempts() LoginAttemptStore   { return sqlLoginAttempts{s.q} }
+func (s *SQLStore) TwoFactor() TwoFactorStore          { return sqlTwoFactor{s.q} }
+func (s *SQLStore) APIKeys() APIKeyStore               { return sqlAPIKeys{s.q} }
+func (s *SQLStore) Invites() InviteStore               { return sqlInvites{s.q} }
+func (s *SQLStore) DebtChanges() DebtChangeStore       { return sqlDebtChanges{s.q} }
+func (s *SQLStore) Groups() GroupStore                 { return sqlGroups{s.q} }
+func (s *SQLStore) Expenses() ExpenseStore             { return sqlExpenses{s.q} }
+func (s *SQLStore) ExchangeRates() ExchangeRateStore   { return sqlExchangeRates{s.q} }
+func (s *SQLStore) Schedules() ScheduleStore           { return sqlSchedules{s.q} }
+func (s *SQLStore) Notifications() NotificationStore   { return sqlNotifications{s.q} }
+func (s *SQLStore) ImportProfiles() ImportProfileStore { return sqlImportProfiles{s.q} }
+
+// WithinTx runs fn in a database transaction. Nested calls join the
+// transaction that is already open.
+func (s *SQLStore) WithinTx(fn func(Store) error) error {
+	if s.tx {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLStore{db: s.db, q: tx, tx: true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// notFound translates sql.ErrNoRows into ErrNotFound.
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// requireAffected returns ErrNotFound if an update matched no rows.
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// internal/store/sql_api_keys.go
+package store
+
+import (
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at, revoked_at"
+
+type sqlAPIKeys struct {
+	q querier
+}
+
+// Scopes are stored space-separated, as in OAuth.
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes,
+		&key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &key.RevokedAt)
+	key.Scopes = strings.Fields(scopes)
+	return key, err
+}
+
+func (s sqlAPIKeys) Create(key *models.APIKey) error {
+	var expiresAt interface{}
+	if key.ExpiresAt != nil {
+		expiresAt = sqlTime(*key.ExpiresAt)
+	}
+
+	var keyID int
+	err := s.q.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+	`, key.UserID, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, " "), expiresAt,
+	).Scan(&keyID)
+	if err != nil {
+		return err
+	}
+
+	created, err := scanAPIKey(s.q.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", keyID))
+	if err != nil {
+		return err
+	}
+	*key = created
+	return nil
+}
+
+func (s sqlAPIKeys) List(userID int) ([]models.APIKey, error) {
+	rows, err := s.q.Query(
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = ? AND revoked_at IS NULL ORDER BY created_at DESC, id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s sqlAPIKeys) GetByHash(hash string) (models.APIKey, error) {
+	key, err := scanAPIKey(s.q.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?", hash))
+	return key, notFound(err)
+}
+
+func (s sqlAPIKeys) Revoke(userID, id int) error {
+	result, err := s.q.Exec(
+		"UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlAPIKeys) Touch(id int, at time.Time) error {
+	_, err := s.q.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", sqlTime(at), id)
+	return err
+}
@@ -0,0 +1,118 @@
+// internal/store/sql_contacts.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const contactColumns = `id, user_id, name, phone, email, is_active, linked_user_id, created_at, updated_at`
+
+type sqlContacts struct {
+	q querier
+}
+
+func scanContact(row scanner) (models.Contact, error) {
+	var contact models.Contact
+	err := row.Scan(
+		&contact.ID, &contact.UserID, &contact.Name, &contact.Phone,
+		&contact.Email, &contact.IsActive, &contact.LinkedUserID, &contact.CreatedAt, &contact.UpdatedAt,
+	)
+	return contact, err
+}
+
+func (s sqlContacts) List(userID int, filter ContactFilter, opts ListOptions) (models.Page[models.Contact], error) {
+	list := sqlList{
+		columns:     contactColumns,
+		tables:      "contacts",
+		idColumn:    "id",
+		sorts:       contactSorts,
+		defaultSort: sortSpec{field: "name"},
+	}
+	list.filter("user_id = ?", userID)
+	if !filter.IncludeInactive {
+		list.filter("is_active = TRUE")
+	}
+	list.filterCreated("created_at", filter.CreatedFrom, filter.CreatedBefore)
+
+	return sqlPage(s.q, list, opts, scanContact, contactKey)
+}
+
+func (s sqlContacts) Get(userID, id int) (models.Contact, error) {
+	contact, err := scanContact(s.q.QueryRow(`
+		SELECT `+contactColumns+`
+		FROM contacts WHERE id = ? AND user_id = ?
+	`, id, userID))
+	return contact, notFound(err)
+}
+
+func (s sqlContacts) Create(contact *models.Contact) error {
+	var contactID int
+	err := s.q.QueryRow(`
+		INSERT INTO contacts (user_id, name, phone, email)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`, contact.UserID, contact.Name, contact.Phone, contact.Email).Scan(&contactID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(contact.UserID, contactID)
+	if err != nil {
+		return err
+	}
+	*contact = created
+	return nil
+}
+
+func (s sqlContacts) Update(contact *models.Contact) error {
+	result, err := s.q.Exec(`
+		UPDATE contacts
+		SET name = ?, phone = ?, email = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, contact.Name, contact.Phone, contact.Email, contact.ID, contact.UserID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(contact.UserID, contact.ID)
+	if err != nil {
+		return err
+	}
+	*contact = updated
+	return nil
+}
+
+func (s sqlContacts) Deactivate(userID, id int) error {
+	result, err := s.q.Exec(`
+		UPDATE contacts
+		SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlContacts) Link(userID, id, linkedUserID int) error {
+	result, err := s.q.Exec(`
+		UPDATE contacts
+		SET linked_user_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND linked_user_id IS NULL
+	`, linkedUserID, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlContacts) GetLinked(userID, linkedUserID int) (models.Contact, error) {
+	contact, err := scanContact(s.q.QueryRow(`
+		SELECT `+contactColumns+`
+		FROM contacts WHERE user_id = ? AND linked_user_id = ?
+	`, userID, linkedUserID))
+	return contact, notFound(err)
+}
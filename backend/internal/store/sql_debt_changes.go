@@ -0,0 +1,100 @@
+// internal/store/sql_debt_changes.go
+package store
+
+import (
+	"strings"
+
+	"debt-tracker-backend/internal/models"
+)
+
+const debtChangeColumns = `ch.id, ch.debt_id, ch.proposed_by, ch.kind, ch.amount, d.currency, ch.description, ch.status,
+		       ch.transaction_id, ch.state, ch.created_at, ch.resolved_at`
+
+const debtChangeTables = `debt_changes ch
+		JOIN debts d ON ch.debt_id = d.id`
+
+type sqlDebtChanges struct {
+	q querier
+}
+
+func scanDebtChange(row scanner) (models.DebtChange, error) {
+	var change models.DebtChange
+	var currency string
+	err := row.Scan(
+		&change.ID, &change.DebtID, &change.ProposedBy, &change.Kind, &change.Amount, &currency,
+		&change.Description, &change.Status, &change.TransactionID, &change.State,
+		&change.CreatedAt, &change.ResolvedAt,
+	)
+	if change.Amount != nil {
+		change.Amount.Currency = currency
+	}
+	return change, err
+}
+
+func (s sqlDebtChanges) Create(change *models.DebtChange) error {
+	var changeID int
+	err := s.q.QueryRow(`
+		INSERT INTO debt_changes (debt_id, proposed_by, kind, amount, description, status, transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, change.DebtID, change.ProposedBy, change.Kind, change.Amount, change.Description,
+		change.Status, change.TransactionID).Scan(&changeID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(changeID)
+	if err != nil {
+		return err
+	}
+	*change = created
+	return nil
+}
+
+func (s sqlDebtChanges) Get(id int) (models.DebtChange, error) {
+	change, err := scanDebtChange(s.q.QueryRow("SELECT "+debtChangeColumns+" FROM "+debtChangeTables+" WHERE ch.id = ?", id))
+	return change, notFound(err)
+}
+
+func (s sqlDebtChanges) List(debtIDs ...int) ([]models.DebtChange, error) {
+	changes := []models.DebtChange{}
+	if len(debtIDs) == 0 {
+		return changes, nil
+	}
+
+	args := make([]interface{}, len(debtIDs))
+	for i, id := range debtIDs {
+		args[i] = id
+	}
+	rows, err := s.q.Query(`
+		SELECT `+debtChangeColumns+`
+		FROM `+debtChangeTables+`
+		WHERE ch.debt_id IN (?`+strings.Repeat(", ?", len(debtIDs)-1)+`)
+		ORDER BY ch.created_at DESC, ch.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change, err := scanDebtChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (s sqlDebtChanges) Resolve(id int, state string) error {
+	result, err := s.q.Exec(`
+		UPDATE debt_changes
+		SET state = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND state = 'pending'
+	`, state, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,597 @@
+// internal/store/sql_debts.go
+package store
+
+import (
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// debtBalanceExpr computes the remaining balance of the debt aliased as d
+// from its confirmed transactions. Pending transactions only count towards
+// debtPendingExpr, and disputed ones not at all.
+const debtBalanceExpr = `d.amount + COALESCE((SELECT SUM(CASE WHEN t.transaction_type IN ('lent', 'borrowed') THEN t.amount ELSE -t.amount END)
+		                 FROM transactions t WHERE t.debt_id = d.id AND t.confirmation = 'confirmed'), 0)`
+
+// debtPendingExpr sums how much the pending transactions of the debt
+// aliased as d would change its balance.
+var debtPendingExpr = debtChangeExpr("pending")
+
+// debtChangeExpr sums how much the transactions of the debt aliased as d
+// with the given confirmation change its balance.
+func debtChangeExpr(confirmation string) string {
+	return `COALESCE((SELECT SUM(CASE WHEN t.transaction_type IN ('lent', 'borrowed') THEN t.amount ELSE -t.amount END)
+		                 FROM transactions t WHERE t.debt_id = d.id AND t.confirmation = '` + confirmation + `'), 0)`
+}
+
+// debtOpenExpr is 1 for the debts aliased as d that the summary counts:
+// active ones, and settled ones whose ledger still has pending entries.
+const debtOpenExpr = `CASE WHEN d.status = 'active' OR (d.status = 'settled' AND EXISTS (
+		                 SELECT 1 FROM transactions t WHERE t.debt_id = d.id AND t.confirmation = 'pending'
+		             )) THEN 1 ELSE 0 END`
+
+// debtPaidExpr sums the confirmed repayments recorded against the debt
+// aliased as d.
+const debtPaidExpr = `COALESCE((SELECT SUM(t.amount) FROM transactions t
+		                 WHERE t.debt_id = d.id AND t.transaction_type IN ('paid_back', 'received_back')
+		                 AND t.confirmation = 'confirmed'), 0)`
+
+// debtColumns selects a debt with its contact and ledger totals, in the
+// order expected by scanDebt. The counterpart is looked up so that a debt
+// whose counterpart was deleted with its owner reads as unshared.
+var debtColumns = `d.id, d.user_id, d.contact_id, d.amount, d.currency, d.direction, d.status,
+		       d.description, d.created_at, d.updated_at, d.removed_at,
+		       (SELECT cd.id FROM debts cd WHERE cd.id = d.counterpart_id),
+		       d.confirmation, d.confirmation_comment, d.created_by, d.expense_id, d.due_date,
+		       d.interest_rate, d.interest_method, d.interest_compounding, d.interest_day_count,
+		       d.interest_start, d.interest_auto_post,
+		       c.id, c.name, c.phone, c.email, c.linked_user_id,
+		       ` + debtPaidExpr + `,
+		       ` + debtBalanceExpr + `,
+		       ` + debtPendingExpr
+
+const debtTables = `debts d
+		JOIN contacts c ON d.contact_id = c.id`
+
+type sqlDebts struct {
+	q querier
+}
+
+func scanDebt(row scanner) (models.Debt, error) {
+	var debt models.Debt
+	var contact models.Contact
+	var rate, method, compounding, dayCount, start *string
+	var autoPost bool
+	err := row.Scan(
+		&debt.ID, &debt.UserID, &debt.ContactID, &debt.OriginalAmount, &debt.Currency, &debt.Direction,
+		&debt.Status, &debt.Description, &debt.CreatedAt, &debt.UpdatedAt, &debt.RemovedAt,
+		&debt.CounterpartID, &debt.Confirmation, &debt.ConfirmationComment, &debt.CreatedBy, &debt.ExpenseID, &debt.DueDate,
+		&rate, &method, &compounding, &dayCount, &start, &autoPost,
+		&contact.ID, &contact.Name, &contact.Phone, &contact.Email, &contact.LinkedUserID,
+		&debt.PaidAmount, &debt.Balance, &debt.PendingAmount,
+	)
+	if err != nil {
+		return debt, err
+	}
+
+	debt.SetCurrency(debt.Currency)
+	debt.Shared = debt.CounterpartID != nil
+	debt.Contact = &contact
+	if rate != nil && method != nil && compounding != nil && dayCount != nil && start != nil {
+		debt.Interest = &models.Interest{
+			Rate:        *rate,
+			Method:      *method,
+			Compounding: *compounding,
+			DayCount:    *dayCount,
+			StartDate:   *start,
+			AutoPost:    autoPost,
+		}
+	}
+	return debt, nil
+}
+
+// interestValues are the values of the interest columns of a debt with
+// the given terms, in the order of debtColumns.
+func interestValues(interest *models.Interest) []interface{} {
+	if interest == nil {
+		return []interface{}{nil, nil, nil, nil, nil, false}
+	}
+	return []interface{}{interest.Rate, interest.Method, interest.Compounding, interest.DayCount,
+		interest.StartDate, interest.AutoPost}
+}
+
+// today is the day, in UTC, that due statuses are computed for.
+func today() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// instalmentBatch is how many debts withDue loads the instalments of per
+// query, to stay well within the bind parameter limits of both databases
+// when it is given the debts of all users.
+const instalmentBatch = 500
+
+// withDue loads the instalments of the debts and computes their due
+// status.
+func (s sqlDebts) withDue(debts []models.Debt) error {
+	var ids []interface{}
+	for _, debt := range debts {
+		if debt.DueDate != nil {
+			ids = append(ids, debt.ID)
+		}
+	}
+
+	instalments := make(map[int][]models.Instalment)
+	for len(ids) > 0 {
+		batch := ids[:min(len(ids), instalmentBatch)]
+		ids = ids[len(batch):]
+		if err := s.loadInstalments(batch, instalments); err != nil {
+			return err
+		}
+	}
+
+	day := today()
+	for i := range debts {
+		debts[i].Instalments = instalments[debts[i].ID]
+		debts[i].SetDueStatus(day)
+		debts[i].SetCurrency(debts[i].Currency)
+	}
+	return nil
+}
+
+// loadInstalments adds the instalments of the debts to instalments, by
+// debt ID.
+func (s sqlDebts) loadInstalments(ids []interface{}, instalments map[int][]models.Instalment) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := s.q.Query(`
+		SELECT debt_id, due_date, amount FROM debt_instalments
+		WHERE debt_id IN (`+placeholders+`)
+		ORDER BY debt_id, due_date
+	`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var debtID int
+		var instalment models.Instalment
+		if err := rows.Scan(&debtID, &instalment.DueDate, &instalment.Amount); err != nil {
+			return err
+		}
+		instalments[debtID] = append(instalments[debtID], instalment)
+	}
+	return rows.Err()
+}
+
+// saveInstalments replaces the instalments of the debt.
+func (s sqlDebts) saveInstalments(id int, instalments []models.Instalment) error {
+	if _, err := s.q.Exec("DELETE FROM debt_instalments WHERE debt_id = ?", id); err != nil {
+		return err
+	}
+	for _, instalment := range instalments {
+		_, err := s.q.Exec(`
+			INSERT INTO debt_instalments (debt_id, due_date, amount) VALUES (?, ?, ?)
+		`, id, instalment.DueDate, instalment.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlDebts) List(userID int, filter DebtFilter, opts ListOptions) (models.Page[models.Debt], error) {
+	list := sqlList{
+		columns:     debtColumns,
+		tables:      debtTables,
+		idColumn:    "d.id",
+		sorts:       debtSorts,
+		defaultSort: sortSpec{field: "created_at", desc: true},
+	}
+
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{"active"}
+	}
+	list.filter("d.user_id = ?", userID)
+	list.filterIn("d.status", statuses)
+	if filter.Direction != "" {
+		list.filter("d.direction = ?", filter.Direction)
+	}
+	if filter.ContactID != 0 {
+		list.filter("d.contact_id = ?", filter.ContactID)
+	}
+	if filter.Confirmation != "" {
+		list.filter("d.confirmation = ?", filter.Confirmation)
+	}
+	if filter.ExpenseID != 0 {
+		list.filter("d.expense_id = ?", filter.ExpenseID)
+	}
+	if filter.GroupID != 0 {
+		list.filter("d.expense_id IN (SELECT e.id FROM expenses e WHERE e.group_id = ?)", filter.GroupID)
+	}
+	if filter.Currency != "" {
+		list.filter("d.currency = ?", filter.Currency)
+	}
+	if filter.HasDueDate {
+		list.filter("d.due_date IS NOT NULL")
+	}
+	list.filterAmount("d.amount", filter.MinAmount, filter.MaxAmount)
+	list.filterCreated("d.created_at", filter.CreatedFrom, filter.CreatedBefore)
+
+	page, err := sqlPage(s.q, list, opts, scanDebt, debtKey)
+	if err != nil {
+		return page, err
+	}
+	return page, s.withDue(page.Data)
+}
+
+func (s sqlDebts) Get(userID, id int) (models.Debt, error) {
+	debt, err := scanDebt(s.q.QueryRow(`
+		SELECT `+debtColumns+`
+		FROM `+debtTables+`
+		WHERE d.id = ? AND d.user_id = ?
+	`, id, userID))
+	if err != nil {
+		return debt, notFound(err)
+	}
+
+	debts := []models.Debt{debt}
+	err = s.withDue(debts)
+	return debts[0], err
+}
+
+// Lock touches the debt row before reading it: SQLite then holds the
+// database write lock and PostgreSQL the row lock until commit.
+func (s sqlDebts) Lock(userID, id int) (models.Debt, error) {
+	result, err := s.q.Exec(
+		"UPDATE debts SET updated_at = updated_at WHERE id = ? AND user_id = ?",
+		id, userID,
+	)
+	if err != nil {
+		return models.Debt{}, err
+	}
+	if err := requireAffected(result); err != nil {
+		return models.Debt{}, err
+	}
+
+	return s.Get(userID, id)
+}
+
+func (s sqlDebts) Create(debt *models.Debt) error {
+	currency := debt.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	confirmation := debt.Confirmation
+	if confirmation == "" {
+		confirmation = "confirmed"
+	}
+
+	createdAt := creationTime(debt.CreatedAt)
+	args := []interface{}{debt.UserID, debt.ContactID, debt.OriginalAmount, currency, debt.Direction, debt.Description,
+		confirmation, debt.CreatedBy, debt.ExpenseID, debt.DueDate, createdAt, createdAt}
+	var debtID int
+	err := s.q.QueryRow(`
+		INSERT INTO debts (user_id, contact_id, amount, currency, direction, description, confirmation, created_by, expense_id, due_date,
+		                   created_at, updated_at,
+		                   interest_rate, interest_method, interest_compounding, interest_day_count, interest_start, interest_auto_post)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), COALESCE(?, CURRENT_TIMESTAMP), ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, append(args, interestValues(debt.Interest)...)...).Scan(&debtID)
+	if err != nil {
+		return err
+	}
+	if err := s.saveInstalments(debtID, debt.Instalments); err != nil {
+		return err
+	}
+
+	created, err := s.Get(debt.UserID, debtID)
+	if err != nil {
+		return err
+	}
+	*debt = created
+	return nil
+}
+
+func (s sqlDebts) Update(debt *models.Debt) error {
+	result, err := s.q.Exec(`
+		UPDATE debts
+		SET amount = ?, description = ?, status = ?, updated_at = CURRENT_TIMESTAMP,
+		    removed_at = CASE WHEN ? = 'removed' THEN COALESCE(removed_at, CURRENT_TIMESTAMP) END
+		WHERE id = ? AND user_id = ?
+	`, debt.OriginalAmount, debt.Description, debt.Status, debt.Status, debt.ID, debt.UserID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(debt.UserID, debt.ID)
+	if err != nil {
+		return err
+	}
+	*debt = updated
+	return nil
+}
+
+func (s sqlDebts) Remove(userID, id int) error {
+	result, err := s.q.Exec(`
+		UPDATE debts
+		SET status = 'removed', updated_at = CURRENT_TIMESTAMP,
+		    removed_at = COALESCE(removed_at, CURRENT_TIMESTAMP)
+		WHERE id = ? AND user_id = ?
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlDebts) Restore(userID, id int) error {
+	result, err := s.q.Exec(`
+		UPDATE debts
+		SET status = 'active', removed_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND status = 'removed'
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlDebts) PurgeRemoved(before time.Time) (int, error) {
+	const purgeable = `SELECT id FROM debts WHERE status = 'removed' AND removed_at < ?`
+	cutoff := sqlTime(before)
+
+	if _, err := s.q.Exec("DELETE FROM transactions WHERE debt_id IN ("+purgeable+")", cutoff); err != nil {
+		return 0, err
+	}
+
+	result, err := s.q.Exec("DELETE FROM debts WHERE id IN ("+purgeable+")", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
+
+func (s sqlDebts) TransactionCount(id int) (int, error) {
+	var count int
+	err := s.q.QueryRow("SELECT COUNT(*) FROM transactions WHERE debt_id = ?", id).Scan(&count)
+	return count, err
+}
+
+func (s sqlDebts) SyncStatus(id int) error {
+	var balance models.Money
+	err := s.q.QueryRow(`
+		SELECT `+debtBalanceExpr+`
+		FROM debts d WHERE d.id = ?
+	`, id).Scan(&balance)
+	if err != nil {
+		return notFound(err)
+	}
+
+	status := "active"
+	if !balance.IsPositive() {
+		status = "settled"
+	}
+
+	_, err = s.q.Exec(`
+		UPDATE debts
+		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('active', 'settled') AND status <> ?
+	`, status, id, status)
+	return err
+}
+
+// Summary splits each open debt into its confirmed balance and the change
+// its pending entries would make: a pending debt is pending as a whole, a
+// confirmed one only in its pending transactions. A debt settled by a
+// payment that is not confirmed yet is still open.
+func (s sqlDebts) Summary(userID int) (models.DebtSummary, error) {
+	summary := models.DebtSummary{ByCurrency: []models.CurrencySummary{}}
+
+	rows, err := s.q.Query(`
+		SELECT
+			currency,
+			COALESCE(SUM(CASE WHEN direction = 'owe_to' AND is_open = 1 AND confirmation = 'confirmed' THEN confirmed ELSE 0 END), 0) as total_owed_to,
+			COALESCE(SUM(CASE WHEN direction = 'owe_from' AND is_open = 1 AND confirmation = 'confirmed' THEN confirmed ELSE 0 END), 0) as total_owed_from,
+			COALESCE(SUM(CASE WHEN direction = 'owe_to' AND is_open = 1 THEN
+				CASE confirmation WHEN 'confirmed' THEN pending_change WHEN 'pending' THEN confirmed + pending_change ELSE 0 END
+			ELSE 0 END), 0) as pending_owed_to,
+			COALESCE(SUM(CASE WHEN direction = 'owe_from' AND is_open = 1 THEN
+				CASE confirmation WHEN 'confirmed' THEN pending_change WHEN 'pending' THEN confirmed + pending_change ELSE 0 END
+			ELSE 0 END), 0) as pending_owed_from,
+			COUNT(CASE WHEN status = 'active' THEN 1 END) as active_count,
+			COUNT(CASE WHEN is_open = 1 AND confirmation = 'pending' THEN 1 END) as pending_count,
+			COALESCE(SUM(CASE WHEN is_open = 1 AND confirmation = 'confirmed' THEN pending_transactions ELSE 0 END), 0) as pending_transactions,
+			COUNT(CASE WHEN status <> 'removed' AND confirmation = 'disputed' THEN 1 END) as disputed_count
+		FROM (
+			SELECT d.direction, d.status, d.currency, d.confirmation,
+			       d.amount + `+debtChangeExpr("confirmed")+` AS confirmed,
+			       `+debtChangeExpr("pending")+` AS pending_change,
+			       (SELECT COUNT(*) FROM transactions t WHERE t.debt_id = d.id AND t.confirmation = 'pending') AS pending_transactions,
+			       `+debtOpenExpr+` AS is_open
+			FROM debts d
+			WHERE d.user_id = ?
+		) balances
+		WHERE is_open = 1 OR (status <> 'removed' AND confirmation = 'disputed')
+		GROUP BY currency
+		ORDER BY currency
+	`, userID)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var totals models.CurrencySummary
+		pending := &totals.Pending
+		err := rows.Scan(&totals.Currency, &totals.TotalOwedToOthers, &totals.TotalOwedFromOthers,
+			&pending.TotalOwedToOthers, &pending.TotalOwedFromOthers, &totals.ActiveDebtsCount,
+			&pending.DebtsCount, &pending.TransactionsCount, &totals.DisputedDebtsCount)
+		if err != nil {
+			return summary, err
+		}
+
+		totals.TotalOwedToOthers.Currency = totals.Currency
+		totals.TotalOwedFromOthers.Currency = totals.Currency
+		totals.NetBalance = totals.TotalOwedFromOthers.Sub(totals.TotalOwedToOthers)
+		pending.TotalOwedToOthers.Currency = totals.Currency
+		pending.TotalOwedFromOthers.Currency = totals.Currency
+		pending.NetBalance = pending.TotalOwedFromOthers.Sub(pending.TotalOwedToOthers)
+		summary.ByCurrency = append(summary.ByCurrency, totals)
+
+		summary.ActiveDebtsCount += totals.ActiveDebtsCount
+		summary.Pending.DebtsCount += pending.DebtsCount
+		summary.Pending.TransactionsCount += pending.TransactionsCount
+		summary.DisputedDebtsCount += totals.DisputedDebtsCount
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+
+	err = s.q.QueryRow(
+		"SELECT COUNT(DISTINCT contact_id) FROM debts WHERE user_id = ? AND status = 'active'", userID,
+	).Scan(&summary.ContactsWithDebts)
+	return summary, err
+}
+
+func (s sqlDebts) Ledger(userID int) ([]models.LedgerEntry, error) {
+	rows, err := s.q.Query(`
+		SELECT d.id, d.direction, d.currency, d.amount, d.created_at,
+		       t.transaction_type, t.amount, t.created_at
+		FROM debts d
+		LEFT JOIN transactions t ON t.debt_id = d.id AND t.confirmation = 'confirmed'
+		WHERE d.user_id = ? AND d.confirmation = 'confirmed' AND `+debtOpenExpr+` = 1
+		ORDER BY d.id, t.created_at, t.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LedgerEntry{}
+	lastDebt := 0
+	for rows.Next() {
+		var debt models.LedgerEntry
+		var transactionType *string
+		var amount models.Money
+		var createdAt *time.Time
+		err := rows.Scan(&debt.DebtID, &debt.Direction, &debt.Amount.Currency, &debt.Amount, &debt.Date,
+			&transactionType, &amount, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		// The amount the debt was opened with comes first.
+		if debt.DebtID != lastDebt {
+			entries = append(entries, debt)
+			lastDebt = debt.DebtID
+		}
+		if transactionType == nil {
+			continue
+		}
+
+		amount.Currency = debt.Amount.Currency
+		if *transactionType != "lent" && *transactionType != "borrowed" {
+			amount = amount.Neg()
+		}
+		entries = append(entries, models.LedgerEntry{
+			DebtID:    debt.DebtID,
+			Direction: debt.Direction,
+			Amount:    amount,
+			Date:      *createdAt,
+		})
+	}
+	return entries, rows.Err()
+}
+
+func (s sqlDebts) SetDueDate(id int, dueDate *string, instalments []models.Instalment) error {
+	result, err := s.q.Exec(`
+		UPDATE debts SET due_date = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, dueDate, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+	return s.saveInstalments(id, instalments)
+}
+
+func (s sqlDebts) SetInterest(id int, interest *models.Interest) error {
+	result, err := s.q.Exec(`
+		UPDATE debts
+		SET interest_rate = ?, interest_method = ?, interest_compounding = ?, interest_day_count = ?,
+		    interest_start = ?, interest_auto_post = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, append(interestValues(interest), id)...)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlDebts) WithDueDates() ([]models.Debt, error) {
+	return s.active("d.due_date IS NOT NULL")
+}
+
+func (s sqlDebts) WithInterest() ([]models.Debt, error) {
+	return s.active("d.interest_rate IS NOT NULL AND d.interest_auto_post = ?", true)
+}
+
+// active returns the active debts of all users that match the condition,
+// oldest first.
+func (s sqlDebts) active(condition string, args ...interface{}) ([]models.Debt, error) {
+	rows, err := s.q.Query(`
+		SELECT `+debtColumns+`
+		FROM `+debtTables+`
+		WHERE d.status = 'active' AND `+condition+`
+		ORDER BY d.created_at, d.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	debts := []models.Debt{}
+	for rows.Next() {
+		debt, err := scanDebt(rows)
+		if err != nil {
+			return nil, err
+		}
+		debts = append(debts, debt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return debts, s.withDue(debts)
+}
+
+func (s sqlDebts) Link(id, counterpartID int) error {
+	for _, pair := range [][2]int{{id, counterpartID}, {counterpartID, id}} {
+		result, err := s.q.Exec("UPDATE debts SET counterpart_id = ? WHERE id = ?", pair[1], pair[0])
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlDebts) SetConfirmation(id int, confirmation string, comment *string) error {
+	result, err := s.q.Exec(`
+		UPDATE debts
+		SET confirmation = ?, confirmation_comment = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, confirmation, comment, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
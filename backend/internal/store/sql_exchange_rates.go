@@ -0,0 +1,34 @@
+// internal/store/sql_exchange_rates.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+type sqlExchangeRates struct {
+	q querier
+}
+
+func (s sqlExchangeRates) Save(rates []models.ExchangeRate) error {
+	for _, rate := range rates {
+		_, err := s.q.Exec(`
+			INSERT INTO exchange_rates (currency, rate_date, rate) VALUES (?, ?, ?)
+			ON CONFLICT (currency, rate_date) DO UPDATE SET rate = excluded.rate
+		`, rate.Currency, rate.Date, rate.Rate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlExchangeRates) Get(currency, day string) (models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := s.q.QueryRow(`
+		SELECT currency, rate_date, rate FROM exchange_rates
+		WHERE currency = ? AND rate_date <= ?
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, currency, day).Scan(&rate.Currency, &rate.Date, &rate.Rate)
+	return rate, notFound(err)
+}
@@ -0,0 +1,171 @@
+// internal/store/sql_expenses.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const expenseColumns = `id, group_id, description, amount, currency, paid_by, split_type, created_at, updated_at`
+
+type sqlExpenses struct {
+	q querier
+}
+
+func scanExpense(row scanner) (models.Expense, error) {
+	var expense models.Expense
+	err := row.Scan(
+		&expense.ID, &expense.GroupID, &expense.Description, &expense.Amount, &expense.Currency,
+		&expense.PaidBy, &expense.SplitType, &expense.CreatedAt, &expense.UpdatedAt,
+	)
+	if err != nil {
+		return expense, err
+	}
+
+	expense.Amount.Currency = expense.Currency
+	return expense, nil
+}
+
+// withSplits loads the splits of the expense in the order they were given.
+func (s sqlExpenses) withSplits(expense models.Expense) (models.Expense, error) {
+	rows, err := s.q.Query(`
+		SELECT contact_id, amount, weight
+		FROM expense_splits WHERE expense_id = ?
+		ORDER BY id
+	`, expense.ID)
+	if err != nil {
+		return expense, err
+	}
+	defer rows.Close()
+
+	expense.Splits = []models.ExpenseSplit{}
+	for rows.Next() {
+		var split models.ExpenseSplit
+		var weight *int64
+		if err := rows.Scan(&split.ContactID, &split.Amount, &weight); err != nil {
+			return expense, err
+		}
+		split.Amount.Currency = expense.Currency
+		split.SetWeight(expense.SplitType, weight)
+		expense.Splits = append(expense.Splits, split)
+	}
+	return expense, rows.Err()
+}
+
+func (s sqlExpenses) insertSplits(expense models.Expense) error {
+	for _, split := range expense.Splits {
+		_, err := s.q.Exec(`
+			INSERT INTO expense_splits (expense_id, contact_id, amount, weight)
+			VALUES (?, ?, ?, ?)
+		`, expense.ID, split.ContactID, split.Amount, split.Weight())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlExpenses) List(groupID int) ([]models.Expense, error) {
+	rows, err := s.q.Query(`
+		SELECT `+expenseColumns+`
+		FROM expenses WHERE group_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := []models.Expense{}
+	for rows.Next() {
+		expense, err := scanExpense(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range expenses {
+		if expenses[i], err = s.withSplits(expenses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return expenses, nil
+}
+
+func (s sqlExpenses) Get(groupID, id int) (models.Expense, error) {
+	expense, err := scanExpense(s.q.QueryRow(`
+		SELECT `+expenseColumns+`
+		FROM expenses WHERE id = ? AND group_id = ?
+	`, id, groupID))
+	if err != nil {
+		return expense, notFound(err)
+	}
+	return s.withSplits(expense)
+}
+
+func (s sqlExpenses) Create(expense *models.Expense) error {
+	currency := expense.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	err := s.q.QueryRow(`
+		INSERT INTO expenses (group_id, description, amount, currency, paid_by, split_type)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, expense.GroupID, expense.Description, expense.Amount, currency, expense.PaidBy,
+		expense.SplitType).Scan(&expense.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.insertSplits(*expense); err != nil {
+		return err
+	}
+
+	created, err := s.Get(expense.GroupID, expense.ID)
+	if err != nil {
+		return err
+	}
+	*expense = created
+	return nil
+}
+
+func (s sqlExpenses) Update(expense *models.Expense) error {
+	result, err := s.q.Exec(`
+		UPDATE expenses
+		SET description = ?, amount = ?, paid_by = ?, split_type = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND group_id = ?
+	`, expense.Description, expense.Amount, expense.PaidBy, expense.SplitType, expense.ID, expense.GroupID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	if _, err := s.q.Exec("DELETE FROM expense_splits WHERE expense_id = ?", expense.ID); err != nil {
+		return err
+	}
+	if err := s.insertSplits(*expense); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(expense.GroupID, expense.ID)
+	if err != nil {
+		return err
+	}
+	*expense = updated
+	return nil
+}
+
+func (s sqlExpenses) Delete(groupID, id int) error {
+	result, err := s.q.Exec("DELETE FROM expenses WHERE id = ? AND group_id = ?", id, groupID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,156 @@
+// internal/store/sql_groups.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const groupColumns = `id, user_id, name, description, created_at, updated_at`
+
+type sqlGroups struct {
+	q querier
+}
+
+func scanGroup(row scanner) (models.Group, error) {
+	var group models.Group
+	err := row.Scan(&group.ID, &group.UserID, &group.Name, &group.Description, &group.CreatedAt, &group.UpdatedAt)
+	return group, err
+}
+
+// members loads the contacts in the group, ordered by name.
+func (s sqlGroups) members(groupID int) ([]models.Contact, error) {
+	rows, err := s.q.Query(`
+		SELECT c.id, c.user_id, c.name, c.phone, c.email, c.is_active, c.linked_user_id, c.created_at, c.updated_at
+		FROM group_members gm
+		JOIN contacts c ON gm.contact_id = c.id
+		WHERE gm.group_id = ?
+		ORDER BY c.name, c.id
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.Contact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, contact)
+	}
+	return members, rows.Err()
+}
+
+func (s sqlGroups) List(userID int) ([]models.Group, error) {
+	rows, err := s.q.Query(`
+		SELECT `+groupColumns+`
+		FROM expense_groups WHERE user_id = ?
+		ORDER BY name, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []models.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		if groups[i].Members, err = s.members(groups[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+func (s sqlGroups) Get(userID, id int) (models.Group, error) {
+	group, err := scanGroup(s.q.QueryRow(`
+		SELECT `+groupColumns+`
+		FROM expense_groups WHERE id = ? AND user_id = ?
+	`, id, userID))
+	if err != nil {
+		return group, notFound(err)
+	}
+
+	group.Members, err = s.members(id)
+	return group, err
+}
+
+func (s sqlGroups) Create(group *models.Group) error {
+	var groupID int
+	err := s.q.QueryRow(`
+		INSERT INTO expense_groups (user_id, name, description)
+		VALUES (?, ?, ?)
+		RETURNING id
+	`, group.UserID, group.Name, group.Description).Scan(&groupID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(group.UserID, groupID)
+	if err != nil {
+		return err
+	}
+	*group = created
+	return nil
+}
+
+func (s sqlGroups) Update(group *models.Group) error {
+	result, err := s.q.Exec(`
+		UPDATE expense_groups
+		SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, group.Name, group.Description, group.ID, group.UserID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(group.UserID, group.ID)
+	if err != nil {
+		return err
+	}
+	*group = updated
+	return nil
+}
+
+func (s sqlGroups) Delete(userID, id int) error {
+	result, err := s.q.Exec("DELETE FROM expense_groups WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlGroups) AddMember(groupID, contactID int) error {
+	result, err := s.q.Exec(`
+		INSERT INTO group_members (group_id, contact_id)
+		VALUES (?, ?)
+		ON CONFLICT (group_id, contact_id) DO NOTHING
+	`, groupID, contactID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlGroups) RemoveMember(groupID, contactID int) error {
+	result, err := s.q.Exec("DELETE FROM group_members WHERE group_id = ? AND contact_id = ?", groupID, contactID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,112 @@
+// internal/store/sql_import_profiles.go
+package store
+
+import (
+	"encoding/json"
+
+	"debt-tracker-backend/internal/models"
+)
+
+const importProfileColumns = "id, user_id, name, format, mapping, created_at, updated_at"
+
+type sqlImportProfiles struct {
+	q querier
+}
+
+// Mappings are stored as JSON.
+func scanImportProfile(row scanner) (models.ImportProfile, error) {
+	var profile models.ImportProfile
+	var mapping string
+	err := row.Scan(&profile.ID, &profile.UserID, &profile.Name, &profile.Format, &mapping,
+		&profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		return profile, err
+	}
+	return profile, json.Unmarshal([]byte(mapping), &profile.Mapping)
+}
+
+func (s sqlImportProfiles) List(userID int) ([]models.ImportProfile, error) {
+	rows, err := s.q.Query(
+		"SELECT "+importProfileColumns+" FROM import_profiles WHERE user_id = ? ORDER BY name, id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.ImportProfile{}
+	for rows.Next() {
+		profile, err := scanImportProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+func (s sqlImportProfiles) Get(userID, id int) (models.ImportProfile, error) {
+	profile, err := scanImportProfile(s.q.QueryRow(
+		"SELECT "+importProfileColumns+" FROM import_profiles WHERE id = ? AND user_id = ?",
+		id, userID,
+	))
+	return profile, notFound(err)
+}
+
+func (s sqlImportProfiles) Create(profile *models.ImportProfile) error {
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return err
+	}
+
+	var profileID int
+	err = s.q.QueryRow(`
+		INSERT INTO import_profiles (user_id, name, format, mapping)
+		VALUES (?, ?, ?, ?) RETURNING id
+	`, profile.UserID, profile.Name, profile.Format, string(mapping)).Scan(&profileID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(profile.UserID, profileID)
+	if err != nil {
+		return err
+	}
+	*profile = created
+	return nil
+}
+
+func (s sqlImportProfiles) Update(profile *models.ImportProfile) error {
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.q.Exec(`
+		UPDATE import_profiles
+		SET name = ?, format = ?, mapping = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, profile.Name, profile.Format, string(mapping), profile.ID, profile.UserID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(profile.UserID, profile.ID)
+	if err != nil {
+		return err
+	}
+	*profile = updated
+	return nil
+}
+
+func (s sqlImportProfiles) Delete(userID, id int) error {
+	result, err := s.q.Exec("DELETE FROM import_profiles WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,91 @@
+// internal/store/sql_invites.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const inviteColumns = `i.id, i.inviter_id, u.name, u.email, i.contact_id, i.email, i.status, i.created_at, i.responded_at`
+
+const inviteTables = `contact_invites i
+		JOIN users u ON i.inviter_id = u.id`
+
+type sqlInvites struct {
+	q querier
+}
+
+func scanInvite(row scanner) (models.ContactInvite, error) {
+	var invite models.ContactInvite
+	err := row.Scan(
+		&invite.ID, &invite.InviterID, &invite.InviterName, &invite.InviterEmail, &invite.ContactID,
+		&invite.Email, &invite.Status, &invite.CreatedAt, &invite.RespondedAt,
+	)
+	return invite, err
+}
+
+func (s sqlInvites) Create(invite *models.ContactInvite) error {
+	var inviteID int
+	err := s.q.QueryRow(`
+		INSERT INTO contact_invites (inviter_id, contact_id, email)
+		VALUES (?, ?, ?)
+		RETURNING id
+	`, invite.InviterID, invite.ContactID, invite.Email).Scan(&inviteID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(inviteID)
+	if err != nil {
+		return err
+	}
+	*invite = created
+	return nil
+}
+
+func (s sqlInvites) Get(id int) (models.ContactInvite, error) {
+	invite, err := scanInvite(s.q.QueryRow("SELECT "+inviteColumns+" FROM "+inviteTables+" WHERE i.id = ?", id))
+	return invite, notFound(err)
+}
+
+func (s sqlInvites) ListReceived(email string) ([]models.ContactInvite, error) {
+	return s.list("LOWER(i.email) = LOWER(?)", email)
+}
+
+func (s sqlInvites) ListSent(userID int) ([]models.ContactInvite, error) {
+	return s.list("i.inviter_id = ?", userID)
+}
+
+func (s sqlInvites) list(where string, arg interface{}) ([]models.ContactInvite, error) {
+	rows, err := s.q.Query(`
+		SELECT `+inviteColumns+`
+		FROM `+inviteTables+`
+		WHERE `+where+` AND i.status = 'pending'
+		ORDER BY i.created_at DESC, i.id DESC
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.ContactInvite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+func (s sqlInvites) Resolve(id int, status string) error {
+	result, err := s.q.Exec(`
+		UPDATE contact_invites
+		SET status = ?, responded_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'pending'
+	`, status, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,134 @@
+// internal/store/sql_list.go
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// sqlList builds a filtered, keyset-paginated SELECT.
+type sqlList struct {
+	columns     string
+	tables      string
+	idColumn    string
+	sorts       map[string]sortField
+	defaultSort sortSpec
+	where       []string
+	args        []interface{}
+}
+
+func (l *sqlList) filter(condition string, args ...interface{}) {
+	l.where = append(l.where, condition)
+	l.args = append(l.args, args...)
+}
+
+func (l *sqlList) whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// sqlPage runs the list and returns the requested page. key returns the
+// sort value and ID of a row, from which the next cursor is built.
+func sqlPage[T any](q querier, l sqlList, opts ListOptions, scan func(scanner) (T, error), key func(T, string) (interface{}, int)) (models.Page[T], error) {
+	page := models.Page[T]{Data: []T{}}
+
+	spec, err := resolveSort(l.sorts, l.defaultSort, opts)
+	if err != nil {
+		return page, err
+	}
+	field := l.sorts[spec.field]
+
+	err = q.QueryRow("SELECT COUNT(*) FROM "+l.tables+" "+l.whereClause(l.where), l.args...).Scan(&page.Total)
+	if err != nil {
+		return page, err
+	}
+
+	op, direction := ">", "ASC"
+	if spec.desc {
+		op, direction = "<", "DESC"
+	}
+
+	where := append([]string{}, l.where...)
+	args := append([]interface{}{}, l.args...)
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, spec)
+		if err != nil {
+			return page, err
+		}
+		value, err := sqlSortValue(field.kind, c.Value)
+		if err != nil {
+			return page, err
+		}
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))",
+			field.expr, op, field.expr, l.idColumn, op))
+		args = append(args, value, value, c.ID)
+	}
+
+	limit := pageLimit(opts.Limit)
+	args = append(args, limit+1)
+
+	rows, err := q.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY %s %s, %s %s
+		LIMIT ?
+	`, l.columns, l.tables, l.whereClause(where), field.expr, direction, l.idColumn, direction), args...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return page, err
+		}
+		page.Data = append(page.Data, item)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		value, id := key(page.Data[limit-1], spec.field)
+		page.NextCursor = encodeCursor(spec, formatSortValue(value), id)
+	}
+	return page, nil
+}
+
+// filterIn restricts expr to one of values.
+func (l *sqlList) filterIn(expr string, values []string) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	l.filter(expr+" IN ("+placeholders+")", args...)
+}
+
+// filterCreated restricts expr to the half-open range [from, before).
+func (l *sqlList) filterCreated(expr string, from, before *time.Time) {
+	if from != nil {
+		l.filter(expr+" >= ?", sqlTime(*from))
+	}
+	if before != nil {
+		l.filter(expr+" < ?", sqlTime(*before))
+	}
+}
+
+// filterAmount restricts expr to the closed range [min, max].
+func (l *sqlList) filterAmount(expr string, min, max *models.Money) {
+	if min != nil {
+		l.filter(expr+" >= ?", min.Minor)
+	}
+	if max != nil {
+		l.filter(expr+" <= ?", max.Minor)
+	}
+}
@@ -0,0 +1,61 @@
+// internal/store/sql_login_attempts.go
+package store
+
+import (
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+type sqlLoginAttempts struct {
+	q querier
+}
+
+func (s sqlLoginAttempts) Get(email string) (models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := s.q.QueryRow(
+		"SELECT email, failures, last_failed_at, locked_until FROM login_attempts WHERE email = ?",
+		email,
+	).Scan(&attempt.Email, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	return attempt, notFound(err)
+}
+
+// RecordFailure increments the counter in a single statement so that
+// concurrent failures are all counted.
+func (s sqlLoginAttempts) RecordFailure(email string, at, resetBefore time.Time) (int, error) {
+	var failures int
+	err := s.q.QueryRow(`
+		INSERT INTO login_attempts (email, failures, last_failed_at) VALUES (?, 1, ?)
+		ON CONFLICT (email) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failed_at = excluded.last_failed_at
+		RETURNING failures
+	`, email, sqlTime(at), sqlTime(resetBefore)).Scan(&failures)
+	return failures, err
+}
+
+func (s sqlLoginAttempts) LockUntil(email string, until time.Time) error {
+	result, err := s.q.Exec("UPDATE login_attempts SET locked_until = ? WHERE email = ?", sqlTime(until), email)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlLoginAttempts) Clear(email string) error {
+	_, err := s.q.Exec("DELETE FROM login_attempts WHERE email = ?", email)
+	return err
+}
+
+func (s sqlLoginAttempts) PurgeStale(before time.Time) (int, error) {
+	cutoff := sqlTime(before)
+	result, err := s.q.Exec(
+		"DELETE FROM login_attempts WHERE last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)",
+		cutoff, cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
@@ -0,0 +1,97 @@
+// internal/store/sql_notifications.go
+package store
+
+import (
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+const notificationColumns = `id, user_id, debt_id, kind, dedupe_key, subject, body, status, attempts, last_error,
+		       created_at, sent_at`
+
+type sqlNotifications struct {
+	q querier
+}
+
+func scanNotification(row scanner) (models.Notification, error) {
+	var notification models.Notification
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &notification.DebtID, &notification.Kind, &notification.Key,
+		&notification.Subject, &notification.Body, &notification.Status, &notification.Attempts,
+		&notification.LastError, &notification.CreatedAt, &notification.SentAt,
+	)
+	return notification, err
+}
+
+func (s sqlNotifications) Enqueue(notification *models.Notification) (bool, error) {
+	var notificationID int
+	err := s.q.QueryRow(`
+		INSERT INTO notifications (user_id, debt_id, kind, dedupe_key, subject, body)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (dedupe_key) DO NOTHING
+		RETURNING id
+	`, notification.UserID, notification.DebtID, notification.Kind, notification.Key,
+		notification.Subject, notification.Body).Scan(&notificationID)
+	if err := notFound(err); err == ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	queued, err := scanNotification(s.q.QueryRow(`
+		SELECT `+notificationColumns+` FROM notifications WHERE id = ?
+	`, notificationID))
+	if err != nil {
+		return false, err
+	}
+	*notification = queued
+	return true, nil
+}
+
+func (s sqlNotifications) Pending(limit int) ([]models.Notification, error) {
+	rows, err := s.q.Query(`
+		SELECT `+notificationColumns+`
+		FROM notifications WHERE status = 'pending'
+		ORDER BY id
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func (s sqlNotifications) MarkSent(id int, at time.Time) error {
+	result, err := s.q.Exec(`
+		UPDATE notifications SET status = 'sent', sent_at = ?, attempts = attempts + 1
+		WHERE id = ? AND status = 'pending'
+	`, sqlTime(at), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlNotifications) MarkFailed(id int, message string, maxAttempts int) error {
+	result, err := s.q.Exec(`
+		UPDATE notifications
+		SET attempts = attempts + 1, last_error = ?,
+		    status = CASE WHEN attempts + 1 >= ? THEN 'failed' ELSE 'pending' END
+		WHERE id = ? AND status = 'pending'
+	`, message, maxAttempts, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,219 @@
+// internal/store/sql_schedules.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const scheduleColumns = `id, user_id, kind, contact_id, direction, debt_id, transaction_type, amount, currency,
+		       description, rrule, start_date, next_date, status, created_at, updated_at`
+
+const occurrenceColumns = `o.schedule_id, o.occurrence_date, o.status, o.amount, s.currency, o.description,
+		       o.debt_id, o.transaction_id`
+
+type sqlSchedules struct {
+	q querier
+}
+
+func scanSchedule(row scanner) (models.Schedule, error) {
+	var schedule models.Schedule
+	err := row.Scan(
+		&schedule.ID, &schedule.UserID, &schedule.Kind, &schedule.ContactID, &schedule.Direction,
+		&schedule.DebtID, &schedule.TransactionType, &schedule.Amount, &schedule.Currency,
+		&schedule.Description, &schedule.RRule, &schedule.StartDate, &schedule.NextDate,
+		&schedule.Status, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	schedule.Amount.Currency = schedule.Currency
+	return schedule, err
+}
+
+func scanOccurrence(row scanner) (models.ScheduleOccurrence, error) {
+	var occurrence models.ScheduleOccurrence
+	err := row.Scan(
+		&occurrence.ScheduleID, &occurrence.Date, &occurrence.Status, &occurrence.Amount,
+		&occurrence.Amount.Currency, &occurrence.Description, &occurrence.DebtID, &occurrence.TransactionID,
+	)
+	return occurrence, err
+}
+
+func (s sqlSchedules) list(query string, args ...interface{}) ([]models.Schedule, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (s sqlSchedules) List(userID int) ([]models.Schedule, error) {
+	return s.list(`
+		SELECT `+scheduleColumns+`
+		FROM schedules WHERE user_id = ?
+		ORDER BY created_at, id
+	`, userID)
+}
+
+func (s sqlSchedules) Get(userID, id int) (models.Schedule, error) {
+	schedule, err := scanSchedule(s.q.QueryRow(`
+		SELECT `+scheduleColumns+`
+		FROM schedules WHERE id = ? AND user_id = ?
+	`, id, userID))
+	return schedule, notFound(err)
+}
+
+func (s sqlSchedules) Create(schedule *models.Schedule) error {
+	currency := schedule.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	var scheduleID int
+	err := s.q.QueryRow(`
+		INSERT INTO schedules (user_id, kind, contact_id, direction, debt_id, transaction_type, amount, currency,
+		                       description, rrule, start_date, next_date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, schedule.UserID, schedule.Kind, schedule.ContactID, schedule.Direction, schedule.DebtID,
+		schedule.TransactionType, schedule.Amount, currency, schedule.Description, schedule.RRule,
+		schedule.StartDate, schedule.NextDate).Scan(&scheduleID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(schedule.UserID, scheduleID)
+	if err != nil {
+		return err
+	}
+	*schedule = created
+	return nil
+}
+
+func (s sqlSchedules) Update(schedule *models.Schedule) error {
+	result, err := s.q.Exec(`
+		UPDATE schedules
+		SET amount = ?, description = ?, rrule = ?, start_date = ?, next_date = ?, status = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, schedule.Amount, schedule.Description, schedule.RRule, schedule.StartDate, schedule.NextDate,
+		schedule.Status, schedule.ID, schedule.UserID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(schedule.UserID, schedule.ID)
+	if err != nil {
+		return err
+	}
+	*schedule = updated
+	return nil
+}
+
+func (s sqlSchedules) Delete(userID, id int) error {
+	result, err := s.q.Exec("DELETE FROM schedules WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlSchedules) Due(day string) ([]models.Schedule, error) {
+	return s.list(`
+		SELECT `+scheduleColumns+`
+		FROM schedules WHERE status = 'active' AND next_date <= ?
+		ORDER BY created_at, id
+	`, day)
+}
+
+func (s sqlSchedules) Advance(id int, from string, to *string) error {
+	query := `UPDATE schedules SET next_date = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND next_date = ?`
+	if to == nil {
+		query = `UPDATE schedules SET next_date = ?, status = 'finished', updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND next_date = ?`
+	}
+	result, err := s.q.Exec(query, to, id, from)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlSchedules) Occurrences(scheduleID int) ([]models.ScheduleOccurrence, error) {
+	rows, err := s.q.Query(`
+		SELECT `+occurrenceColumns+`
+		FROM schedule_occurrences o
+		JOIN schedules s ON o.schedule_id = s.id
+		WHERE o.schedule_id = ?
+		ORDER BY o.occurrence_date
+	`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := []models.ScheduleOccurrence{}
+	for rows.Next() {
+		occurrence, err := scanOccurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, rows.Err()
+}
+
+func (s sqlSchedules) GetOccurrence(scheduleID int, day string) (models.ScheduleOccurrence, error) {
+	occurrence, err := scanOccurrence(s.q.QueryRow(`
+		SELECT `+occurrenceColumns+`
+		FROM schedule_occurrences o
+		JOIN schedules s ON o.schedule_id = s.id
+		WHERE o.schedule_id = ? AND o.occurrence_date = ?
+	`, scheduleID, day))
+	return occurrence, notFound(err)
+}
+
+func (s sqlSchedules) SaveOccurrence(occurrence *models.ScheduleOccurrence) error {
+	_, err := s.q.Exec(`
+		INSERT INTO schedule_occurrences (schedule_id, occurrence_date, status, amount, description, debt_id, transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (schedule_id, occurrence_date) DO UPDATE SET
+			status = excluded.status,
+			amount = excluded.amount,
+			description = excluded.description,
+			debt_id = excluded.debt_id,
+			transaction_id = excluded.transaction_id
+	`, occurrence.ScheduleID, occurrence.Date, occurrence.Status, occurrence.Amount, occurrence.Description,
+		occurrence.DebtID, occurrence.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	saved, err := s.GetOccurrence(occurrence.ScheduleID, occurrence.Date)
+	if err != nil {
+		return err
+	}
+	*occurrence = saved
+	return nil
+}
+
+func (s sqlSchedules) DeleteOccurrence(scheduleID int, day string) error {
+	result, err := s.q.Exec(`
+		DELETE FROM schedule_occurrences WHERE schedule_id = ? AND occurrence_date = ?
+	`, scheduleID, day)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
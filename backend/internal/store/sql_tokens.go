@@ -0,0 +1,109 @@
+// internal/store/sql_tokens.go
+package store
+
+import (
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+type sqlTokens struct {
+	q querier
+}
+
+func (s sqlTokens) CreateRefresh(token *models.RefreshToken) error {
+	var tokenID int
+	err := s.q.QueryRow(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+	`, token.UserID, token.FamilyID, token.TokenHash, token.AccessJTI,
+		sqlTime(token.AccessExpiresAt), sqlTime(token.ExpiresAt),
+	).Scan(&tokenID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.get("id = ?", tokenID)
+	if err != nil {
+		return err
+	}
+	*token = created
+	return nil
+}
+
+func (s sqlTokens) GetRefresh(hash string) (models.RefreshToken, error) {
+	return s.get("token_hash = ?", hash)
+}
+
+func (s sqlTokens) get(where string, args ...interface{}) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := s.q.QueryRow(`
+		SELECT id, user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, created_at, revoked_at
+		FROM refresh_tokens WHERE `+where, args...,
+	).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.AccessJTI,
+		&token.AccessExpiresAt, &token.ExpiresAt, &token.CreatedAt, &token.RevokedAt)
+	return token, notFound(err)
+}
+
+func (s sqlTokens) RevokeRefresh(id int) error {
+	revoked, err := s.revoke("id = ?", id)
+	if err == nil && revoked == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s sqlTokens) RevokeFamily(userID int, familyID string) error {
+	_, err := s.revoke("user_id = ? AND family_id = ?", userID, familyID)
+	return err
+}
+
+func (s sqlTokens) RevokeUser(userID int) (int, error) {
+	return s.revoke("user_id = ?", userID)
+}
+
+// revoke puts the access tokens of the matching live refresh tokens on the
+// revocation list, then revokes the refresh tokens themselves.
+func (s sqlTokens) revoke(where string, args ...interface{}) (int, error) {
+	where = "revoked_at IS NULL AND " + where
+
+	_, err := s.q.Exec(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		SELECT access_jti, user_id, access_expires_at FROM refresh_tokens WHERE `+where+`
+		ON CONFLICT (jti) DO NOTHING
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := s.q.Exec("UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := result.RowsAffected()
+	return int(revoked), err
+}
+
+func (s sqlTokens) IsRevoked(jti string) (bool, error) {
+	var count int
+	err := s.q.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?", jti).Scan(&count)
+	return count > 0, err
+}
+
+func (s sqlTokens) PurgeExpired(before time.Time) (int, error) {
+	cutoff := sqlTime(before)
+
+	purged := 0
+	for _, table := range []string{"refresh_tokens", "revoked_tokens"} {
+		result, err := s.q.Exec("DELETE FROM "+table+" WHERE expires_at < ?", cutoff)
+		if err != nil {
+			return purged, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		purged += int(deleted)
+	}
+	return purged, nil
+}
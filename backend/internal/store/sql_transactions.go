@@ -0,0 +1,126 @@
+// internal/store/sql_transactions.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const transactionColumns = `t.id, t.debt_id, t.amount, d.currency, t.transaction_type, t.description, t.created_at,
+		       (SELECT ct.id FROM transactions ct WHERE ct.id = t.counterpart_id),
+		       t.confirmation, t.confirmation_comment, t.created_by, t.interest`
+
+const transactionTables = `transactions t
+		JOIN debts d ON t.debt_id = d.id`
+
+type sqlTransactions struct {
+	q querier
+}
+
+func scanTransaction(row scanner) (models.Transaction, error) {
+	var transaction models.Transaction
+	err := row.Scan(
+		&transaction.ID, &transaction.DebtID, &transaction.Amount, &transaction.Currency,
+		&transaction.TransactionType, &transaction.Description, &transaction.CreatedAt,
+		&transaction.CounterpartID, &transaction.Confirmation, &transaction.ConfirmationComment,
+		&transaction.CreatedBy, &transaction.Interest,
+	)
+	transaction.Amount.Currency = transaction.Currency
+	return transaction, err
+}
+
+func (s sqlTransactions) List(userID int, filter TransactionFilter, opts ListOptions) (models.Page[models.Transaction], error) {
+	list := sqlList{
+		columns:     transactionColumns,
+		tables:      transactionTables,
+		idColumn:    "t.id",
+		sorts:       transactionSorts,
+		defaultSort: sortSpec{field: "created_at", desc: true},
+	}
+	list.filter("d.user_id = ?", userID)
+	if filter.DebtID != 0 {
+		list.filter("t.debt_id = ?", filter.DebtID)
+	}
+	if filter.ContactID != 0 {
+		list.filter("d.contact_id = ?", filter.ContactID)
+	}
+	if filter.TransactionType != "" {
+		list.filter("t.transaction_type = ?", filter.TransactionType)
+	}
+	if filter.Confirmation != "" {
+		list.filter("t.confirmation = ?", filter.Confirmation)
+	}
+	list.filterAmount("t.amount", filter.MinAmount, filter.MaxAmount)
+	list.filterCreated("t.created_at", filter.CreatedFrom, filter.CreatedBefore)
+
+	return sqlPage(s.q, list, opts, scanTransaction, transactionKey)
+}
+
+func (s sqlTransactions) Get(userID, id int) (models.Transaction, error) {
+	transaction, err := scanTransaction(s.q.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM `+transactionTables+`
+		WHERE t.id = ? AND d.user_id = ?
+	`, id, userID))
+	return transaction, notFound(err)
+}
+
+func (s sqlTransactions) Create(transaction *models.Transaction) error {
+	confirmation := transaction.Confirmation
+	if confirmation == "" {
+		confirmation = "confirmed"
+	}
+
+	var transactionID int
+	err := s.q.QueryRow(`
+		INSERT INTO transactions (debt_id, amount, transaction_type, description, confirmation, created_by, interest, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))
+		RETURNING id
+	`, transaction.DebtID, transaction.Amount, transaction.TransactionType, transaction.Description,
+		confirmation, transaction.CreatedBy, transaction.Interest, creationTime(transaction.CreatedAt)).Scan(&transactionID)
+	if err != nil {
+		return err
+	}
+
+	created, err := scanTransaction(s.q.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM `+transactionTables+`
+		WHERE t.id = ?
+	`, transactionID))
+	if err != nil {
+		return err
+	}
+	*transaction = created
+	return nil
+}
+
+func (s sqlTransactions) Delete(id int) error {
+	result, err := s.q.Exec("DELETE FROM transactions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTransactions) Link(id, counterpartID int) error {
+	for _, pair := range [][2]int{{id, counterpartID}, {counterpartID, id}} {
+		result, err := s.q.Exec("UPDATE transactions SET counterpart_id = ? WHERE id = ?", pair[1], pair[0])
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlTransactions) SetConfirmation(id int, confirmation string, comment *string) error {
+	result, err := s.q.Exec(
+		"UPDATE transactions SET confirmation = ?, confirmation_comment = ? WHERE id = ?",
+		confirmation, comment, id,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
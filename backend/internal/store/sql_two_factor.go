@@ -0,0 +1,119 @@
+// internal/store/sql_two_factor.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+type sqlTwoFactor struct {
+	q querier
+}
+
+func (s sqlTwoFactor) Get(userID int) (models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := s.q.QueryRow(
+		"SELECT user_id, secret, enabled_at, last_step, created_at FROM two_factor WHERE user_id = ?",
+		userID,
+	).Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.EnabledAt, &twoFactor.LastStep, &twoFactor.CreatedAt)
+	return twoFactor, notFound(err)
+}
+
+func (s sqlTwoFactor) SetSecret(userID int, secret string) error {
+	result, err := s.q.Exec(`
+		INSERT INTO two_factor (user_id, secret) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = excluded.secret,
+			last_step = 0,
+			created_at = CURRENT_TIMESTAMP
+		WHERE two_factor.enabled_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTwoFactor) Enable(userID int) error {
+	result, err := s.q.Exec(
+		"UPDATE two_factor SET enabled_at = CURRENT_TIMESTAMP WHERE user_id = ? AND enabled_at IS NULL",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTwoFactor) UseStep(userID int, step int64) error {
+	result, err := s.q.Exec(
+		"UPDATE two_factor SET last_step = ? WHERE user_id = ? AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTwoFactor) Disable(userID int) error {
+	if _, err := s.q.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	result, err := s.q.Exec("DELETE FROM two_factor WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTwoFactor) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	if _, err := s.q.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		_, err := s.q.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqlTwoFactor) UnusedRecoveryCodes(userID int) (map[int]string, error) {
+	rows, err := s.q.Query("SELECT id, code_hash FROM recovery_codes WHERE user_id = ? AND used_at IS NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
+
+func (s sqlTwoFactor) UseRecoveryCode(userID, id int) error {
+	result, err := s.q.Exec(
+		"UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND used_at IS NULL",
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlTwoFactor) RecoveryCodesLeft(userID int) (int, error) {
+	var left int
+	err := s.q.QueryRow(
+		"SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL",
+		userID,
+	).Scan(&left)
+	return left, err
+}
@@ -0,0 +1,155 @@
+// internal/store/sql_users.go
+package store
+
+import (
+	"debt-tracker-backend/internal/models"
+)
+
+const userColumns = "id, email, name, phone, email_verified_at, pending_email, default_currency, created_at, updated_at, " +
+	"EXISTS (SELECT 1 FROM two_factor WHERE two_factor.user_id = users.id AND two_factor.enabled_at IS NOT NULL)"
+
+type sqlUsers struct {
+	q querier
+}
+
+func scanUser(row scanner, extra ...interface{}) (models.User, error) {
+	var user models.User
+	err := row.Scan(append([]interface{}{
+		&user.ID, &user.Email, &user.Name, &user.Phone, &user.EmailVerifiedAt,
+		&user.PendingEmail, &user.DefaultCurrency, &user.CreatedAt, &user.UpdatedAt, &user.TwoFactorEnabled,
+	}, extra...)...)
+	return user, err
+}
+
+func (s sqlUsers) Create(user *models.User) error {
+	currency := user.DefaultCurrency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	var userID int
+	err := s.q.QueryRow(
+		"INSERT INTO users (email, password_hash, name, phone, default_currency) VALUES (?, ?, ?, ?, ?) RETURNING id",
+		user.Email, user.PasswordHash, user.Name, user.Phone, currency,
+	).Scan(&userID)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.Get(userID)
+	if err != nil {
+		return err
+	}
+	created.PasswordHash = user.PasswordHash
+	*user = created
+	return nil
+}
+
+func (s sqlUsers) Get(id int) (models.User, error) {
+	user, err := scanUser(s.q.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+	return user, notFound(err)
+}
+
+func (s sqlUsers) GetWithPassword(id int) (models.User, error) {
+	return s.getWithPassword("id = ?", id)
+}
+
+func (s sqlUsers) GetByEmail(email string) (models.User, error) {
+	return s.getWithPassword("email = ?", email)
+}
+
+func (s sqlUsers) getWithPassword(where string, arg interface{}) (models.User, error) {
+	var passwordHash string
+	user, err := scanUser(s.q.QueryRow("SELECT "+userColumns+", password_hash FROM users WHERE "+where, arg), &passwordHash)
+	user.PasswordHash = passwordHash
+	return user, notFound(err)
+}
+
+func (s sqlUsers) Update(user *models.User) error {
+	result, err := s.q.Exec(
+		"UPDATE users SET name = ?, phone = ?, default_currency = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		user.Name, user.Phone, user.DefaultCurrency, user.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	updated, err := s.Get(user.ID)
+	if err != nil {
+		return err
+	}
+	*user = updated
+	return nil
+}
+
+func (s sqlUsers) SetPassword(id int, passwordHash string) error {
+	result, err := s.q.Exec(
+		"UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		passwordHash, id,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlUsers) MarkEmailVerified(id int) error {
+	result, err := s.q.Exec(
+		"UPDATE users SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND email_verified_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlUsers) SetPendingEmail(id int, email *string) error {
+	result, err := s.q.Exec(
+		"UPDATE users SET pending_email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		email, id,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (s sqlUsers) ConfirmPendingEmail(id int) error {
+	result, err := s.q.Exec(`
+		UPDATE users
+		SET email = pending_email, pending_email = NULL,
+		    email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND pending_email IS NOT NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// Delete relies on cascading foreign keys, except for links from other
+// users' contacts, which have none. Entries shared with the user become
+// the other users' own, so nobody is left to confirm them.
+func (s sqlUsers) Delete(id int) error {
+	for _, query := range []string{
+		"UPDATE contacts SET linked_user_id = NULL WHERE linked_user_id = ?",
+		`UPDATE debts SET confirmation = 'confirmed'
+		 WHERE counterpart_id IN (SELECT d.id FROM debts d WHERE d.user_id = ?)`,
+		`UPDATE transactions SET confirmation = 'confirmed'
+		 WHERE counterpart_id IN (SELECT t.id FROM transactions t JOIN debts d ON t.debt_id = d.id WHERE d.user_id = ?)`,
+	} {
+		if _, err := s.q.Exec(query, id); err != nil {
+			return err
+		}
+	}
+
+	result, err := s.q.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
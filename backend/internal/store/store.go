@@ -0,0 +1,358 @@
+// internal/store/store.go
+package store
+
+import (
+	"errors"
+	"time"
+
+	"debt-tracker-backend/internal/models"
+)
+
+// ErrNotFound is returned when a record does not exist or does not belong
+// to the requesting user.
+var ErrNotFound = errors.New("record not found")
+
+// Store gives access to the repositories of the application. The SQL
+// implementation is returned by NewSQL and an in-memory one by NewMemory.
+type Store interface {
+	Users() UserStore
+	Contacts() ContactStore
+	Debts() DebtStore
+	Transactions() TransactionStore
+	Tokens() TokenStore
+	LoginAttempts() LoginAttemptStore
+	TwoFactor() TwoFactorStore
+	APIKeys() APIKeyStore
+	Invites() InviteStore
+	DebtChanges() DebtChangeStore
+	Groups() GroupStore
+	Expenses() ExpenseStore
+	ExchangeRates() ExchangeRateStore
+	Schedules() ScheduleStore
+	Notifications() NotificationStore
+	ImportProfiles() ImportProfileStore
+
+	// WithinTx runs fn against a Store whose changes are committed together
+	// when fn returns nil and rolled back when it returns an error.
+	WithinTx(fn func(Store) error) error
+}
+
+type UserStore interface {
+	// Create inserts the user and fills in its ID and timestamps.
+	Create(user *models.User) error
+	Get(id int) (models.User, error)
+	// GetWithPassword and GetByEmail also load the password hash.
+	GetWithPassword(id int) (models.User, error)
+	GetByEmail(email string) (models.User, error)
+	SetPassword(id int, passwordHash string) error
+	// Update saves the name, phone and default currency of the user.
+	Update(user *models.User) error
+	// MarkEmailVerified records that the user has verified their email
+	// address. It returns ErrNotFound if it is already verified.
+	MarkEmailVerified(id int) error
+	// SetPendingEmail records the address the user is changing to, or
+	// cancels the change when email is nil.
+	SetPendingEmail(id int, email *string) error
+	// ConfirmPendingEmail makes the pending address the verified email of
+	// the user. It returns ErrNotFound if no change is pending.
+	ConfirmPendingEmail(id int) error
+	// Delete removes the user together with all of their data.
+	Delete(id int) error
+}
+
+type ContactStore interface {
+	// List returns a page of the user's active contacts, by default
+	// ordered by name. Contacts can be sorted by name or created_at.
+	// Deactivated contacts are included only if the filter asks for them.
+	List(userID int, filter ContactFilter, opts ListOptions) (models.Page[models.Contact], error)
+	Get(userID, id int) (models.Contact, error)
+	Create(contact *models.Contact) error
+	// Update saves the name, phone and email of the contact.
+	Update(contact *models.Contact) error
+	Deactivate(userID, id int) error
+	// Link connects the contact to the account of the person it stands
+	// for. It returns ErrNotFound if the contact is already linked.
+	Link(userID, id, linkedUserID int) error
+	// GetLinked returns the user's contact that is linked to the account
+	// linkedUserID.
+	GetLinked(userID, linkedUserID int) (models.Contact, error)
+}
+
+// Debts are returned with their instalments and their due status as of
+// today (UTC); see models.Debt.SetDueStatus.
+type DebtStore interface {
+	// List returns a page of the user's debts, newest first by default.
+	// Debts can be sorted by created_at, updated_at, amount or balance.
+	List(userID int, filter DebtFilter, opts ListOptions) (models.Page[models.Debt], error)
+	Get(userID, id int) (models.Debt, error)
+	// Lock loads the debt under a write lock held until the surrounding
+	// transaction ends, so concurrent payments see an up-to-date balance.
+	// It must be the first statement of the transaction.
+	Lock(userID, id int) (models.Debt, error)
+	// Create inserts the debt and fills in its ID and derived fields. It is
+	// created now unless CreatedAt is set.
+	Create(debt *models.Debt) error
+	// Update saves the original amount, description and status of the debt.
+	Update(debt *models.Debt) error
+	// Remove soft-deletes the debt. It stays visible with status "removed"
+	// until it is restored or purged.
+	Remove(userID, id int) error
+	// Restore brings back a removed debt. It returns ErrNotFound if the
+	// debt is not removed; callers should SyncStatus afterwards.
+	Restore(userID, id int) error
+	// PurgeRemoved permanently deletes debts removed before the given time,
+	// together with their transactions, and returns how many were deleted.
+	PurgeRemoved(before time.Time) (int, error)
+	TransactionCount(id int) (int, error)
+	// SyncStatus marks an active debt as settled once its balance reaches
+	// zero, and reopens a settled debt whose balance is positive again.
+	// Removed debts are left alone.
+	SyncStatus(id int) error
+	// Summary fills in the totals of each currency and the counts of the
+	// summary. The totals of the summary itself are left to the caller to
+	// convert.
+	Summary(userID int) (models.DebtSummary, error)
+	// Ledger returns the confirmed entries of the open, confirmed debts
+	// that Summary totals, ordered by debt and date.
+	Ledger(userID int) ([]models.LedgerEntry, error)
+	// SetDueDate replaces the due date and instalments of the debt.
+	SetDueDate(id int, dueDate *string, instalments []models.Instalment) error
+	// WithDueDates returns the active debts of all users that have a due
+	// date, oldest first.
+	WithDueDates() ([]models.Debt, error)
+	// SetInterest replaces the interest terms of the debt; nil removes them.
+	SetInterest(id int, interest *models.Interest) error
+	// WithInterest returns the active debts of all users whose interest is
+	// posted automatically, oldest first.
+	WithInterest() ([]models.Debt, error)
+	// Link makes two debts of different users each other's counterpart.
+	Link(id, counterpartID int) error
+	// SetConfirmation records whether the other user agrees with the debt,
+	// with their comment.
+	SetConfirmation(id int, confirmation string, comment *string) error
+}
+
+type TransactionStore interface {
+	// List returns a page of the transactions on the user's debts, newest
+	// first by default. Transactions can be sorted by created_at or amount.
+	List(userID int, filter TransactionFilter, opts ListOptions) (models.Page[models.Transaction], error)
+	Get(userID, id int) (models.Transaction, error)
+	// Create inserts the transaction and fills in its ID. It is created now
+	// unless CreatedAt is set.
+	Create(transaction *models.Transaction) error
+	Delete(id int) error
+	// Link makes two transactions each other's counterpart.
+	Link(id, counterpartID int) error
+	SetConfirmation(id int, confirmation string, comment *string) error
+}
+
+type TokenStore interface {
+	// CreateRefresh inserts the refresh token and fills in its ID and
+	// creation time.
+	CreateRefresh(token *models.RefreshToken) error
+	// GetRefresh looks a refresh token up by its hash, including revoked
+	// and expired tokens.
+	GetRefresh(hash string) (models.RefreshToken, error)
+	// RevokeRefresh revokes a refresh token and the access token issued
+	// with it. It returns ErrNotFound if the token is already revoked.
+	RevokeRefresh(id int) error
+	// RevokeFamily revokes the live tokens of one of the user's sessions.
+	RevokeFamily(userID int, familyID string) error
+	// RevokeUser revokes the live tokens of all the user's sessions and
+	// returns how many sessions were ended.
+	RevokeUser(userID int) (int, error)
+	// IsRevoked reports whether the access token with the given ID is on
+	// the revocation list.
+	IsRevoked(jti string) (bool, error)
+	// PurgeExpired deletes refresh tokens and revocation entries that
+	// expired before the given time and returns how many were deleted.
+	PurgeExpired(before time.Time) (int, error)
+}
+
+type LoginAttemptStore interface {
+	// Get returns ErrNotFound if no failures are recorded for the address.
+	Get(email string) (models.LoginAttempt, error)
+	// RecordFailure counts a failed login at the given time and returns the
+	// number of consecutive failures. Failures last recorded before
+	// resetBefore are forgotten first.
+	RecordFailure(email string, at, resetBefore time.Time) (int, error)
+	LockUntil(email string, until time.Time) error
+	// Clear forgets the failures of the address after a successful login.
+	Clear(email string) error
+	// PurgeStale deletes records last updated before the given time whose
+	// lock has expired, and returns how many were deleted.
+	PurgeStale(before time.Time) (int, error)
+}
+
+type TwoFactorStore interface {
+	// Get returns ErrNotFound if the user has not started setting up
+	// two-factor authentication.
+	Get(userID int) (models.TwoFactor, error)
+	// SetSecret starts a setup with a new secret, replacing an earlier one
+	// that was never enabled. It returns ErrNotFound if two-factor
+	// authentication is already enabled.
+	SetSecret(userID int, secret string) error
+	// Enable turns on the pending setup. It returns ErrNotFound if there is
+	// none.
+	Enable(userID int) error
+	// UseStep records that a code from the given time step was accepted.
+	// It returns ErrNotFound if that step or a later one was used already,
+	// so that concurrent logins cannot share a code.
+	UseStep(userID int, step int64) error
+	// Disable deletes the secret and the recovery codes of the user.
+	Disable(userID int) error
+	// ReplaceRecoveryCodes swaps the user's recovery codes for new ones,
+	// given as hashes.
+	ReplaceRecoveryCodes(userID int, hashes []string) error
+	// UnusedRecoveryCodes returns the hashes of the user's unused recovery
+	// codes by ID.
+	UnusedRecoveryCodes(userID int) (map[int]string, error)
+	// UseRecoveryCode marks an unused code as used. It returns ErrNotFound
+	// if the code has been used already.
+	UseRecoveryCode(userID, id int) error
+	// RecoveryCodesLeft counts the user's unused recovery codes.
+	RecoveryCodesLeft(userID int) (int, error)
+}
+
+type APIKeyStore interface {
+	// Create inserts the key and fills in its ID and creation time.
+	Create(key *models.APIKey) error
+	// List returns the user's keys that have not been revoked, newest
+	// first.
+	List(userID int) ([]models.APIKey, error)
+	// GetByHash looks a key up by its hash, including revoked and expired
+	// keys.
+	GetByHash(hash string) (models.APIKey, error)
+	// Revoke returns ErrNotFound if the user has no such key or it is
+	// already revoked.
+	Revoke(userID, id int) error
+	// Touch records when the key was last used.
+	Touch(id int, at time.Time) error
+}
+
+type InviteStore interface {
+	// Create inserts the invite and fills in its ID, status and creation
+	// time.
+	Create(invite *models.ContactInvite) error
+	Get(id int) (models.ContactInvite, error)
+	// ListReceived returns the pending invites addressed to the email
+	// address, newest first.
+	ListReceived(email string) ([]models.ContactInvite, error)
+	// ListSent returns the user's pending invites, newest first.
+	ListSent(userID int) ([]models.ContactInvite, error)
+	// Resolve closes a pending invite with the given status. It returns
+	// ErrNotFound if the invite is not pending.
+	Resolve(id int, status string) error
+}
+
+type DebtChangeStore interface {
+	// Create inserts the change and fills in its ID, state and creation
+	// time.
+	Create(change *models.DebtChange) error
+	Get(id int) (models.DebtChange, error)
+	// List returns the changes proposed for any of the debts, newest first.
+	List(debtIDs ...int) ([]models.DebtChange, error)
+	// Resolve closes a pending change with the given state. It returns
+	// ErrNotFound if the change is not pending.
+	Resolve(id int, state string) error
+}
+
+type GroupStore interface {
+	// List returns the user's groups with their members, ordered by name.
+	List(userID int) ([]models.Group, error)
+	Get(userID, id int) (models.Group, error)
+	// Create inserts the group and fills in its ID and timestamps. Members
+	// are added separately.
+	Create(group *models.Group) error
+	// Update saves the name and description of the group.
+	Update(group *models.Group) error
+	// Delete removes the group with its expenses. The debts split from the
+	// expenses are kept as ordinary debts.
+	Delete(userID, id int) error
+	// AddMember returns ErrNotFound if the contact is already a member.
+	AddMember(groupID, contactID int) error
+	RemoveMember(groupID, contactID int) error
+}
+
+type ExpenseStore interface {
+	// List returns the expenses of the group with their splits, newest
+	// first.
+	List(groupID int) ([]models.Expense, error)
+	Get(groupID, id int) (models.Expense, error)
+	// Create inserts the expense with its splits and fills in its ID and
+	// timestamps.
+	Create(expense *models.Expense) error
+	// Update saves the expense and replaces its splits.
+	Update(expense *models.Expense) error
+	// Delete removes the expense. Its debts are kept as ordinary debts.
+	Delete(groupID, id int) error
+}
+
+// ExchangeRateStore keeps daily snapshots of exchange rates, all quoted
+// against the same base currency.
+type ExchangeRateStore interface {
+	// Save records the rates, replacing those of the same currency and day.
+	Save(rates []models.ExchangeRate) error
+	// Get returns the latest rate of the currency on or before the day, or
+	// ErrNotFound if there is none.
+	Get(currency, day string) (models.ExchangeRate, error)
+}
+
+// ScheduleStore keeps recurring schedules and the occurrences of them that
+// were edited, skipped or created. Days are ISO dates.
+type ScheduleStore interface {
+	// List returns the user's schedules, oldest first.
+	List(userID int) ([]models.Schedule, error)
+	Get(userID, id int) (models.Schedule, error)
+	// Create inserts the schedule and fills in its ID, status and
+	// timestamps.
+	Create(schedule *models.Schedule) error
+	// Update saves the amount, description, rule, start date, next date and
+	// status of the schedule.
+	Update(schedule *models.Schedule) error
+	// Delete removes the schedule with its occurrences. Debts and
+	// transactions it created are kept.
+	Delete(userID, id int) error
+	// Due returns the active schedules whose next occurrence is on or
+	// before the day, oldest first.
+	Due(day string) ([]models.Schedule, error)
+	// Advance moves the next occurrence of the schedule from one day to
+	// another, or finishes the schedule when to is nil. It returns
+	// ErrNotFound if the next occurrence is no longer from, so that two
+	// runs cannot both create the same occurrences.
+	Advance(id int, from string, to *string) error
+	// Occurrences returns the stored occurrences of the schedule, by date.
+	Occurrences(scheduleID int) ([]models.ScheduleOccurrence, error)
+	GetOccurrence(scheduleID int, day string) (models.ScheduleOccurrence, error)
+	// SaveOccurrence records the occurrence, replacing the one stored for
+	// the same day.
+	SaveOccurrence(occurrence *models.ScheduleOccurrence) error
+	DeleteOccurrence(scheduleID int, day string) error
+}
+
+// NotificationStore is the queue of notifications waiting to be sent.
+type NotificationStore interface {
+	// Enqueue inserts the notification and fills in its ID, status and
+	// creation time. It reports false, and leaves the notification as it
+	// is, if one with the same key was queued before.
+	Enqueue(notification *models.Notification) (bool, error)
+	// Pending returns at most limit notifications waiting to be sent,
+	// oldest first.
+	Pending(limit int) ([]models.Notification, error)
+	MarkSent(id int, at time.Time) error
+	// MarkFailed records a failed attempt to send the notification, and
+	// gives up on it after maxAttempts.
+	MarkFailed(id int, message string, maxAttempts int) error
+}
+
+// ImportProfileStore keeps the saved mappings of imports.
+type ImportProfileStore interface {
+	// List returns the user's profiles, ordered by name.
+	List(userID int) ([]models.ImportProfile, error)
+	Get(userID, id int) (models.ImportProfile, error)
+	// Create inserts the profile and fills in its ID and timestamps.
+	Create(profile *models.ImportProfile) error
+	// Update saves the name, format and mapping of the profile.
+	Update(profile *models.ImportProfile) error
+	Delete(userID, id int) error
+}